@@ -42,6 +42,13 @@ type Affine struct {
 var (
 	_ Aligner = SW{}
 	_ Aligner = NW{}
+	_ Aligner = SWBanded{}
+	_ Aligner = NWBanded{}
+	_ Aligner = SWAffineBanded{}
+	_ Aligner = NWAffineBanded{}
+	_ Aligner = Hirschberg{}
+	_ Aligner = MyersMiller{}
+	_ Aligner = SWAffineStriped{}
 )
 
 const (
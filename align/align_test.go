@@ -5,10 +5,15 @@
 package align
 
 import (
+	"fmt"
+	"math"
+	"math/rand"
 	"strings"
 	"testing"
 
+	"github.com/biogo/biogo/align/matrix"
 	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
 	"github.com/biogo/biogo/io/seqio/fasta"
 	"github.com/biogo/biogo/seq/linear"
 	"gopkg.in/check.v1"
@@ -23,6 +28,858 @@ var _ = check.Suite(&S{})
 
 func (s *S) TestWarning(c *check.C) { c.Log("\nFIXME: Tests only in example tests.\n") }
 
+// bandedPair returns a pair of related random sequences of length about n. The
+// query has a deletion and a later insertion relative to the reference, so
+// the optimal alignment path leaves the main diagonals.
+func bandedPair(n, indel int, qual bool) (ref, query AlphabetSlicer) {
+	rnd := rand.New(rand.NewSource(1))
+	const bases = "acgt"
+	r := make([]byte, n)
+	for i := range r {
+		r[i] = bases[rnd.Intn(len(bases))]
+	}
+	ins := make([]byte, indel)
+	for i := range ins {
+		ins[i] = bases[rnd.Intn(len(bases))]
+	}
+	q := append(append([]byte(nil), r[:n/4]...), r[n/4+indel:3*n/4]...)
+	q = append(append(q, ins...), r[3*n/4:]...)
+	for i := 10; i < len(q); i += 17 {
+		q[i] = bases[(strings.IndexByte(bases, q[i])+1)%len(bases)]
+	}
+
+	if !qual {
+		ref = linear.NewSeq("ref", alphabet.BytesToLetters(r), alphabet.DNAgapped)
+		query = linear.NewSeq("query", alphabet.BytesToLetters(q), alphabet.DNAgapped)
+		return ref, query
+	}
+	return bandedQSeq("ref", r), bandedQSeq("query", q)
+}
+
+// bandedQSeq returns a quality sequence with the letters in b.
+func bandedQSeq(id string, b []byte) *linear.QSeq {
+	ql := make([]alphabet.QLetter, len(b))
+	for i, l := range b {
+		ql[i] = alphabet.QLetter{L: alphabet.Letter(l), Q: 40}
+	}
+	return linear.NewQSeq(id, ql, alphabet.DNAgapped, alphabet.Sanger)
+}
+
+var (
+	bandedMatrix = Linear{
+		{0, -5, -5, -5, -5},
+		{-5, 10, -3, -1, -4},
+		{-5, -3, 9, -5, 0},
+		{-5, -1, -5, 7, -3},
+		{-5, -4, 0, -3, 8},
+	}
+	bandedAffine = Linear{
+		{0, -1, -1, -1, -1},
+		{-1, 2, -1, -1, -1},
+		{-1, -1, 2, -1, -1},
+		{-1, -1, -1, 2, -1},
+		{-1, -1, -1, -1, 2},
+	}
+)
+
+func (s *S) TestBanded(c *check.C) {
+	for _, qual := range []bool{false, true} {
+		ref, query := bandedPair(200, 12, qual)
+		for _, t := range []struct {
+			name   string
+			full   Aligner
+			banded func(band, max int) Aligner
+		}{
+			{
+				name: "NW",
+				full: NW(bandedMatrix),
+				banded: func(band, max int) Aligner {
+					return NWBanded{Matrix: bandedMatrix, Band: band, MaxBand: max}
+				},
+			},
+			{
+				name: "SW",
+				full: SW(bandedMatrix),
+				banded: func(band, max int) Aligner {
+					return SWBanded{Matrix: bandedMatrix, Band: band, MaxBand: max}
+				},
+			},
+			{
+				name: "NWAffine",
+				full: NWAffine{Matrix: bandedAffine, GapOpen: -5},
+				banded: func(band, max int) Aligner {
+					return NWAffineBanded{Matrix: bandedAffine, GapOpen: -5, Band: band, MaxBand: max}
+				},
+			},
+			{
+				name: "SWAffine",
+				full: SWAffine{Matrix: bandedAffine, GapOpen: -5},
+				banded: func(band, max int) Aligner {
+					return SWAffineBanded{Matrix: bandedAffine, GapOpen: -5, Band: band, MaxBand: max}
+				},
+			},
+		} {
+			want, err := t.full.Align(ref, query)
+			c.Assert(err, check.Equals, nil)
+
+			// A band covering the whole table gives the full alignment.
+			got, err := t.banded(1000, 0).Align(ref, query)
+			c.Assert(err, check.Equals, nil)
+			c.Check(fmt.Sprint(got), check.Equals, fmt.Sprint(want), check.Commentf("%s qual=%t", t.name, qual))
+
+			// A band covering the indels gives the full alignment.
+			got, err = t.banded(12, 0).Align(ref, query)
+			c.Assert(err, check.Equals, nil)
+			c.Check(fmt.Sprint(got), check.Equals, fmt.Sprint(want), check.Commentf("%s qual=%t", t.name, qual))
+
+			// A band limited by MaxBand cannot contain the alignment path.
+			got, err = t.banded(1, 3).Align(ref, query)
+			c.Assert(err, check.Equals, nil)
+			c.Check(fmt.Sprint(got), check.Not(check.Equals), fmt.Sprint(want), check.Commentf("%s qual=%t", t.name, qual))
+		}
+	}
+}
+
+// The best path within a band may stay off the band edges while a better
+// path lies outside the band.
+func (s *S) TestBandedOffEdge(c *check.C) {
+	ref := linear.NewSeq("ref", alphabet.BytesToLetters([]byte("ctaacaccagttttgaccgttgcttcgagggctctctgcctgtattctca")), alphabet.DNAgapped)
+	query := linear.NewSeq("query", alphabet.BytesToLetters([]byte("ctaacaccattttgaccgtcttcgacggggctctctgcctgtattctca")), alphabet.DNAgapped)
+	score := func(a Aligner) int {
+		f, err := a.Align(ref, query)
+		c.Assert(err, check.Equals, nil)
+		var s int
+		for _, fp := range f {
+			s += fp.(*featPair).score
+		}
+		return s
+	}
+	c.Check(score(NW(bandedMatrix)), check.Equals, 374)
+	c.Check(score(NWBanded{Matrix: bandedMatrix, Band: 0}), check.Equals, 356)
+}
+
+// A band covering the whole table reproduces the unbanded aligners.
+func (s *S) TestBandedFullRandom(c *check.C) {
+	rnd := rand.New(rand.NewSource(1))
+	type test struct {
+		m       Linear
+		gapOpen int
+		r, q    []byte
+	}
+	tests := []test{
+		{
+			m: Linear{
+				{0, -2, -1, -2, -1},
+				{-2, 7, 2, 0, -4},
+				{-1, 2, 2, -2, 0},
+				{-2, 0, -2, 7, 2},
+				{-1, -4, 0, 2, 10},
+			},
+			gapOpen: -1,
+			r:       []byte("atattaatatta"),
+			q:       []byte("atattttaataaa"),
+		},
+	}
+	for k := 0; k < 500; k++ {
+		tests = append(tests, test{
+			m:       randomMatrix(rnd),
+			gapOpen: -rnd.Intn(6),
+			r:       randomDNA(rnd, 40),
+			q:       randomDNA(rnd, 40),
+		})
+	}
+	for _, t := range tests {
+		ref := linear.NewSeq("ref", alphabet.BytesToLetters(t.r), alphabet.DNAgapped)
+		query := linear.NewSeq("query", alphabet.BytesToLetters(t.q), alphabet.DNAgapped)
+		for _, a := range []struct {
+			name         string
+			full, banded Aligner
+		}{
+			{name: "NW", full: NW(t.m), banded: NWBanded{Matrix: t.m, Band: 1000}},
+			{name: "SW", full: SW(t.m), banded: SWBanded{Matrix: t.m, Band: 1000}},
+			{
+				name:   "NWAffine",
+				full:   NWAffine{Matrix: t.m, GapOpen: t.gapOpen},
+				banded: NWAffineBanded{Matrix: t.m, GapOpen: t.gapOpen, Band: 1000},
+			},
+			{
+				name:   "SWAffine",
+				full:   SWAffine{Matrix: t.m, GapOpen: t.gapOpen},
+				banded: SWAffineBanded{Matrix: t.m, GapOpen: t.gapOpen, Band: 1000},
+			},
+		} {
+			want, err := a.full.Align(ref, query)
+			c.Assert(err, check.Equals, nil)
+			got, err := a.banded.Align(ref, query)
+			c.Assert(err, check.Equals, nil)
+			c.Check(fmt.Sprint(got), check.Equals, fmt.Sprint(want),
+				check.Commentf("%s matrix=%v gapOpen=%d r=%s q=%s", a.name, t.m, t.gapOpen, t.r, t.q))
+		}
+	}
+}
+
+func (s *S) TestBand(c *check.C) {
+	for _, t := range []struct {
+		r, c, w int
+		lo, hi  int
+		full    bool
+	}{
+		{r: 11, c: 11, w: 2, lo: -2, hi: 2},
+		{r: 11, c: 16, w: 2, lo: -2, hi: 7},
+		{r: 16, c: 11, w: 2, lo: -7, hi: 2},
+		{r: 4, c: 6, w: 10, lo: -3, hi: 5, full: true},
+	} {
+		b := newBand(t.r, t.c, t.w)
+		c.Check(b.lo, check.Equals, t.lo)
+		c.Check(b.hi, check.Equals, t.hi)
+		c.Check(b.full(), check.Equals, t.full)
+		for i := 0; i < t.r; i++ {
+			lo, hi := b.cols(i)
+			c.Check(lo <= hi, check.Equals, true)
+			c.Check(b.index(i, lo) >= i*b.width(), check.Equals, true)
+			c.Check(b.index(i, hi) < (i+1)*b.width(), check.Equals, true)
+		}
+	}
+}
+
+func (s *S) TestWiden(c *check.C) {
+	for _, t := range []struct {
+		w, max int
+		reach  int
+		want   []int
+	}{
+		{w: 1, max: 0, reach: 5, want: []int{1, 3, 7}},
+		{w: 1, max: 5, reach: 10, want: []int{1, 3, 5}},
+		{w: 8, max: 5, reach: 10, want: []int{5}},
+		{w: 0, max: 0, reach: 1000, want: []int{0, 1, 3, 7, 15, 20}}, // Last band is clipped to the table.
+	} {
+		var got []int
+		widen(t.w, t.max, 21, 31, func(b band) ([]feat.Pair, bool, error) {
+			w := b.hi - (b.c - b.r)
+			got = append(got, w)
+			return nil, w < t.reach, nil
+		})
+		c.Check(got, check.DeepEquals, t.want)
+	}
+}
+
+func (s *S) TestHirschberg(c *check.C) {
+	rnd := rand.New(rand.NewSource(1))
+	const bases = "acgt"
+	mutate := func(b []byte) []byte {
+		var m []byte
+		for _, l := range b {
+			switch rnd.Intn(20) {
+			case 0:
+				m = append(m, bases[rnd.Intn(len(bases))])
+			case 1:
+			case 2:
+				m = append(m, l, bases[rnd.Intn(len(bases))])
+			default:
+				m = append(m, l)
+			}
+		}
+		return m
+	}
+	for _, n := range []int{1, 5, 16, 17, 40, 100, 300} {
+		for k := 0; k < 5; k++ {
+			r := make([]byte, n+rnd.Intn(n))
+			for i := range r {
+				r[i] = bases[rnd.Intn(len(bases))]
+			}
+			q := mutate(r)
+			if k == 0 {
+				q = q[:len(q)/2]
+			}
+			if len(q) == 0 {
+				// NWAffine does not handle empty sequences.
+				continue
+			}
+			for _, qual := range []bool{false, true} {
+				var ref, query AlphabetSlicer
+				if qual {
+					ref, query = bandedQSeq("ref", r), bandedQSeq("query", q)
+				} else {
+					ref = linear.NewSeq("ref", alphabet.BytesToLetters(r), alphabet.DNAgapped)
+					query = linear.NewSeq("query", alphabet.BytesToLetters(q), alphabet.DNAgapped)
+				}
+				for _, t := range []struct {
+					name       string
+					full, thin Aligner
+				}{
+					{name: "NW", full: NW(bandedMatrix), thin: Hirschberg(bandedMatrix)},
+					{name: "NW", full: NW(bandedAffine), thin: Hirschberg(bandedAffine)},
+					{
+						name: "NWAffine",
+						full: NWAffine{Matrix: bandedAffine, GapOpen: -5},
+						thin: MyersMiller{Matrix: bandedAffine, GapOpen: -5},
+					},
+					{
+						name: "NWAffine",
+						full: NWAffine{Matrix: bandedMatrix, GapOpen: -10},
+						thin: MyersMiller{Matrix: bandedMatrix, GapOpen: -10},
+					},
+				} {
+					want, err := t.full.Align(ref, query)
+					c.Assert(err, check.Equals, nil)
+					got, err := t.thin.Align(ref, query)
+					c.Assert(err, check.Equals, nil)
+					c.Check(fmt.Sprint(got), check.Equals, fmt.Sprint(want), check.Commentf("%s n=%d qual=%t", t.name, n, qual))
+				}
+			}
+		}
+	}
+}
+
+func (s *S) TestSWAffineStriped(c *check.C) {
+	rnd := rand.New(rand.NewSource(1))
+	random := func(n int, bases string) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = bases[rnd.Intn(len(bases))]
+		}
+		return b
+	}
+	for _, t := range []struct {
+		n     int
+		bases string
+	}{
+		{n: 1, bases: "acgt"},
+		{n: 7, bases: "acgt"},
+		{n: 8, bases: "acgt"},
+		{n: 9, bases: "acgt"},
+		{n: 50, bases: "acgt"},
+		{n: 50, bases: "at"},
+		{n: 200, bases: "acgt"},
+		{n: 200, bases: "a"},
+	} {
+		q := random(t.n, t.bases)
+		query := linear.NewSeq("query", alphabet.BytesToLetters(q), alphabet.DNAgapped)
+		for _, a := range []SWAffine{
+			{Matrix: bandedAffine, GapOpen: -5},
+			{Matrix: bandedMatrix, GapOpen: -10},
+		} {
+			p, err := SWAffineStriped(a).Profile(query)
+			c.Assert(err, check.Equals, nil)
+			for k := 0; k < 5; k++ {
+				r := random(t.n/2+rnd.Intn(2*t.n), t.bases)
+				ref := linear.NewSeq("ref", alphabet.BytesToLetters(r), alphabet.DNAgapped)
+				want, err := a.Align(ref, query)
+				c.Assert(err, check.Equals, nil)
+				got, err := SWAffineStriped(a).Align(ref, query)
+				c.Assert(err, check.Equals, nil)
+				c.Check(fmt.Sprint(got), check.Equals, fmt.Sprint(want), check.Commentf("n=%d bases=%q", t.n, t.bases))
+
+				var score int
+				for _, fp := range want {
+					score += fp.(*featPair).score
+				}
+				end := want[len(want)-1].Features()
+				gotScore, rEnd, qEnd, err := p.Score(ref)
+				c.Assert(err, check.Equals, nil)
+				c.Check(gotScore, check.Equals, score)
+				c.Check(rEnd, check.Equals, end[0].End())
+				c.Check(qEnd, check.Equals, end[1].End())
+
+				qref, qquery := bandedQSeq("ref", r), bandedQSeq("query", q)
+				got, err = SWAffineStriped(a).Align(qref, qquery)
+				c.Assert(err, check.Equals, nil)
+				c.Check(fmt.Sprint(got), check.Equals, fmt.Sprint(want), check.Commentf("n=%d bases=%q qual", t.n, t.bases))
+			}
+		}
+	}
+}
+
+// randomMatrix returns a random symmetric DNA scoring matrix with
+// non-positive gap scores and positive scores for matches.
+func randomMatrix(rnd *rand.Rand) Linear {
+	m := make(Linear, 5)
+	for i := range m {
+		m[i] = make([]int, 5)
+	}
+	for i := range m {
+		for j := i; j < len(m); j++ {
+			var v int
+			switch {
+			case i == 0 && j == 0:
+			case i == 0:
+				v = -rnd.Intn(4)
+			case i == j:
+				v = 1 + rnd.Intn(10)
+			default:
+				v = rnd.Intn(10) - 6
+			}
+			m[i][j], m[j][i] = v, v
+		}
+	}
+	return m
+}
+
+// randomDNA returns a random DNA sequence with between 1 and n bases.
+func randomDNA(rnd *rand.Rand, n int) []byte {
+	const bases = "acgt"
+	b := make([]byte, 1+rnd.Intn(n))
+	for i := range b {
+		b[i] = bases[rnd.Intn(len(bases))]
+	}
+	return b
+}
+
+func (s *S) TestSWAffineStripedRandom(c *check.C) {
+	rnd := rand.New(rand.NewSource(1))
+	type test struct {
+		m       Linear
+		gapOpen int
+		r, q    []byte
+	}
+	tests := []test{
+		{
+			m: Linear{
+				{0, -3, -1, -2, -2},
+				{-3, 4, -4, -1, -5},
+				{-1, -4, 7, -5, -2},
+				{-2, -1, -5, 8, -6},
+				{-2, -5, -2, -6, 8},
+			},
+			gapOpen: -1,
+			r:       []byte("catacaaatctatataaacgcgatacttcg"),
+			q:       []byte("ccgagtgagcgggtgaaggtcctggttacgccctggaga"),
+		},
+	}
+	for k := 0; k < 500; k++ {
+		tests = append(tests, test{
+			m:       randomMatrix(rnd),
+			gapOpen: -rnd.Intn(6),
+			r:       randomDNA(rnd, 40),
+			q:       randomDNA(rnd, 40),
+		})
+	}
+	for _, t := range tests {
+		a := SWAffine{Matrix: t.m, GapOpen: t.gapOpen}
+		for _, qual := range []bool{false, true} {
+			var ref, query AlphabetSlicer
+			if qual {
+				ref, query = bandedQSeq("ref", t.r), bandedQSeq("query", t.q)
+			} else {
+				ref = linear.NewSeq("ref", alphabet.BytesToLetters(t.r), alphabet.DNAgapped)
+				query = linear.NewSeq("query", alphabet.BytesToLetters(t.q), alphabet.DNAgapped)
+			}
+			want, err := a.Align(ref, query)
+			c.Assert(err, check.Equals, nil)
+			got, err := SWAffineStriped(a).Align(ref, query)
+			c.Assert(err, check.Equals, nil)
+			c.Check(fmt.Sprint(got), check.Equals, fmt.Sprint(want),
+				check.Commentf("matrix=%v gapOpen=%d r=%s q=%s", t.m, t.gapOpen, t.r, t.q))
+		}
+	}
+}
+
+func (s *S) TestStripedProfileFallback(c *check.C) {
+	for _, t := range []struct {
+		name   string
+		a      SWAffine
+		r, q   string
+		packed bool
+		lanes  bool
+	}{
+		{
+			name:   "lanes",
+			a:      SWAffine{Matrix: bandedAffine, GapOpen: -5},
+			r:      "acgtacgtttgacgtacgtaaacgt",
+			q:      "tacgttgacgtagtaaa",
+			packed: true,
+			lanes:  true,
+		},
+		{
+			name: "overflow",
+			a: SWAffine{
+				Matrix: Linear{
+					{0, -1, -1, -1, -1},
+					{-1, 1000, -1000, -1000, -1000},
+					{-1, -1000, 1000, -1000, -1000},
+					{-1, -1000, -1000, 1000, -1000},
+					{-1, -1000, -1000, -1000, 1000},
+				},
+				GapOpen: -5,
+			},
+			r:      "ttttacgtacgtacgtacgtacgtacgtacgtacgtacgtacgtacgtacgtacgtacgttttt",
+			q:      "gggacgtacgtacgtacgtacgtacgtacgtacgtacgtacgtacgtacgtacgtacgtggg",
+			packed: true,
+		},
+		{
+			name: "positive gap open",
+			a:    SWAffine{Matrix: bandedAffine, GapOpen: 5},
+			r:    "acgtacgtttgacgtacgtaaacgt",
+			q:    "tacgttgacgtagtaaa",
+		},
+		{
+			name: "gapped reference",
+			a: SWAffine{
+				Matrix: Linear{
+					{2, -1, -1, -1, -1},
+					{-1, 2, -1, -1, -1},
+					{-1, -1, 2, -1, -1},
+					{-1, -1, -1, 2, -1},
+					{-1, -1, -1, -1, 0},
+				},
+				GapOpen: -5,
+			},
+			r: "acgtacg--ttgacgtacgtaaacgt",
+			q: "tacgttgacgtagtaaa",
+		},
+	} {
+		ref := linear.NewSeq("ref", alphabet.BytesToLetters([]byte(t.r)), alphabet.DNAgapped)
+		query := linear.NewSeq("query", alphabet.BytesToLetters([]byte(t.q)), alphabet.DNAgapped)
+		p, err := SWAffineStriped(t.a).Profile(query)
+		c.Assert(err, check.Equals, nil)
+		rSeq, _, err := indexLetters(ref.Seq, nil, alphabet.DNAgapped.LetterIndex())
+		c.Assert(err, check.Equals, nil)
+		packed := p.packed(rSeq)
+		c.Check(packed, check.Equals, t.packed, check.Commentf("%s", t.name))
+		if packed {
+			_, _, _, ok := p.scoreLanes(rSeq)
+			c.Check(ok, check.Equals, t.lanes, check.Commentf("%s", t.name))
+		}
+
+		want, err := t.a.Align(ref, query)
+		c.Assert(err, check.Equals, nil)
+		var score int
+		for _, fp := range want {
+			score += fp.(*featPair).score
+		}
+		end := want[len(want)-1].Features()
+		gotScore, rEnd, qEnd, err := p.Score(ref)
+		c.Assert(err, check.Equals, nil)
+		c.Check(gotScore, check.Equals, score, check.Commentf("%s", t.name))
+		c.Check(rEnd, check.Equals, end[0].End(), check.Commentf("%s", t.name))
+		c.Check(qEnd, check.Equals, end[1].End(), check.Commentf("%s", t.name))
+	}
+}
+
+func (s *S) TestSummarize(c *check.C) {
+	m := Linear{
+		{0, -1, -1, -1, -1},
+		{-1, 1, -1, -1, -1},
+		{-1, -1, 1, 1, -1},
+		{-1, -1, 1, 1, -1},
+		{-1, -1, -1, -1, 1},
+	}
+	seg := func(as, ae, bs, be, score int) feat.Pair {
+		return &featPair{
+			a:     feature{start: as, end: ae},
+			b:     feature{start: bs, end: be},
+			score: score,
+		}
+	}
+	for _, t := range []struct {
+		ref, query string
+		aln        []feat.Pair
+
+		want          Summary
+		cigar, extend string
+	}{
+		{
+			ref:   "acgtacgtacgt",
+			query: "acctggacgtgt",
+			aln: []feat.Pair{
+				seg(0, 4, 0, 4, 4),
+				seg(4, 4, 4, 6, -2),
+				seg(4, 8, 6, 10, 4),
+				seg(8, 10, 10, 10, -2),
+				seg(10, 12, 10, 12, 2),
+			},
+			want: Summary{
+				Length:        14,
+				Identities:    9,
+				Positives:     10,
+				Mismatches:    1,
+				Gaps:          4,
+				GapOpens:      2,
+				GapExtensions: 2,
+				Score:         6,
+			},
+			cigar:  "4M2I4M2D2M",
+			extend: "2=1X1=2I4=2D2=",
+		},
+		{
+			ref:   "aattcc",
+			query: "aaggcc",
+			aln: []feat.Pair{
+				seg(0, 2, 0, 2, 2),
+				seg(2, 4, 2, 2, -2),
+				seg(4, 4, 2, 4, -2),
+				seg(4, 6, 4, 6, 2),
+			},
+			want: Summary{
+				Length:        8,
+				Identities:    4,
+				Positives:     4,
+				Gaps:          4,
+				GapOpens:      2,
+				GapExtensions: 2,
+			},
+			cigar:  "2M2D2I2M",
+			extend: "2=2D2I2=",
+		},
+		{
+			ref:   "acgt",
+			query: "cg",
+			aln:   []feat.Pair{seg(1, 3, 0, 2, 2)},
+			want: Summary{
+				Length:     2,
+				Identities: 2,
+				Positives:  2,
+				Score:      2,
+			},
+			cigar:  "2M",
+			extend: "2=",
+		},
+	} {
+		ref := linear.NewSeq("ref", alphabet.BytesToLetters([]byte(t.ref)), alphabet.DNAgapped)
+		query := linear.NewSeq("query", alphabet.BytesToLetters([]byte(t.query)), alphabet.DNAgapped)
+		got, err := Summarize(ref, query, t.aln, m)
+		c.Assert(err, check.Equals, nil)
+		c.Check(got.Cigar(), check.Equals, t.cigar)
+		c.Check(got.ExtendedCigar(), check.Equals, t.extend)
+		got.ops = nil
+		c.Check(got, check.DeepEquals, t.want)
+		c.Check(got.Identity(), check.Equals, float64(t.want.Identities)/float64(t.want.Length))
+		c.Check(got.Similarity(), check.Equals, float64(t.want.Positives)/float64(t.want.Length))
+	}
+
+	ref := linear.NewSeq("ref", alphabet.BytesToLetters([]byte("acgt")), alphabet.DNAgapped)
+	query := linear.NewSeq("query", alphabet.BytesToLetters([]byte("acgt")), alphabet.DNAgapped)
+	_, err := Summarize(ref, query, []feat.Pair{seg(0, 5, 0, 5, 5)}, m)
+	c.Check(err, check.NotNil)
+	_, err = Summarize(ref, query, []feat.Pair{seg(0, 3, 0, 2, 1)}, m)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestKarlinAltschul(c *check.C) {
+	dna := func(match, mismatch int) Linear {
+		m := Linear{{0, -1, -1, -1, -1}}
+		for i := 1; i < 5; i++ {
+			row := []int{-1, mismatch, mismatch, mismatch, mismatch}
+			row[i] = match
+			m = append(m, row)
+		}
+		return m
+	}
+
+	// Robinson and Robinson amino acid frequencies.
+	robinson := make([]float64, alphabet.Protein.Len())
+	for l, f := range map[byte]float64{
+		'a': 0.07805, 'r': 0.05129, 'n': 0.04487, 'd': 0.05364, 'c': 0.01925,
+		'q': 0.04264, 'e': 0.06295, 'g': 0.07377, 'h': 0.02199, 'i': 0.05142,
+		'l': 0.09019, 'k': 0.05744, 'm': 0.02243, 'f': 0.03856, 'p': 0.05203,
+		's': 0.07120, 't': 0.05841, 'w': 0.01330, 'y': 0.03216, 'v': 0.06441,
+	} {
+		robinson[alphabet.Protein.IndexOf(alphabet.Letter(l))] = f
+	}
+
+	for _, t := range []struct {
+		m     Linear
+		alpha alphabet.Alphabet
+		freqs []float64
+
+		want KarlinAltschul
+		tol  float64
+	}{
+		{
+			// The values for a +1/-1 scoring scheme with uniform
+			// frequencies are known exactly.
+			m:     dna(1, -1),
+			alpha: alphabet.DNAgapped,
+			freqs: []float64{0, 1, 1, 1, 1},
+			want:  KarlinAltschul{Lambda: math.Log(3), K: 1. / 3, H: math.Log(3) / 2},
+			tol:   1e-12,
+		},
+		{
+			// Values from the NCBI BLAST blastn parameter tables.
+			m:     dna(1, -3),
+			alpha: alphabet.DNAgapped,
+			freqs: []float64{0, 0.25, 0.25, 0.25, 0.25},
+			want:  KarlinAltschul{Lambda: 1.374, K: 0.711, H: 1.31},
+			tol:   5e-3,
+		},
+		{
+			// Values from the NCBI BLAST blastp parameter tables.
+			m:     matrix.BLOSUM62,
+			alpha: alphabet.Protein,
+			freqs: robinson,
+			want:  KarlinAltschul{Lambda: 0.3176, K: 0.134, H: 0.4012},
+			tol:   1e-3,
+		},
+	} {
+		ka, err := NewKarlinAltschul(t.m, t.alpha, t.freqs)
+		c.Assert(err, check.Equals, nil)
+		c.Check(math.Abs(ka.Lambda-t.want.Lambda) < t.tol, check.Equals, true, check.Commentf("got lambda=%v want %v", ka.Lambda, t.want.Lambda))
+		c.Check(math.Abs(ka.K-t.want.K) < t.tol, check.Equals, true, check.Commentf("got K=%v want %v", ka.K, t.want.K))
+		c.Check(math.Abs(ka.H-t.want.H) < t.tol, check.Equals, true, check.Commentf("got H=%v want %v", ka.H, t.want.H))
+	}
+
+	ka := KarlinAltschul{Lambda: math.Log(3), K: 1. / 3}
+	c.Check(math.Abs(ka.BitScore(4)-5*math.Log2(3)) < 1e-12, check.Equals, true)
+	c.Check(math.Abs(ka.EValue(4, 100, 1000)-1e5/243) < 1e-9, check.Equals, true)
+
+	for _, t := range []struct {
+		m     Linear
+		freqs []float64
+		err   error
+	}{
+		{m: dna(1, -1), freqs: []float64{0, 1, 1, 1}, err: ErrBadComposition},
+		{m: dna(1, -1), freqs: []float64{0, 1, -1, 1, 1}, err: ErrBadComposition},
+		{m: dna(1, -1), freqs: []float64{1, 0, 0, 0, 0}, err: ErrBadComposition},
+		{m: dna(-1, -1), freqs: []float64{0, 1, 1, 1, 1}, err: ErrNoPositiveScore},
+		{m: dna(3, -1), freqs: []float64{0, 1, 1, 1, 1}, err: ErrNonNegativeExpected},
+	} {
+		_, err := NewKarlinAltschul(t.m, alphabet.DNAgapped, t.freqs)
+		c.Check(err, check.Equals, t.err)
+	}
+}
+
+func (s *S) TestAlignN(c *check.C) {
+	lin := SW{
+		{0, -1, -1, -1, -1},
+		{-1, 2, -1, -1, -1},
+		{-1, -1, 2, -1, -1},
+		{-1, -1, -1, 2, -1},
+		{-1, -1, -1, -1, 2},
+	}
+	aff := SWAffine{Matrix: Linear(lin), GapOpen: -3}
+	alignN := []struct {
+		name  string
+		align Aligner
+		n     func(ref, query AlphabetSlicer, n int) ([][]feat.Pair, error)
+	}{
+		{name: "SW", align: lin, n: lin.AlignN},
+		{name: "SWAffine", align: aff, n: aff.AlignN},
+	}
+
+	// The first alignment is the best alignment and later alignments
+	// do not decrease in score or use a table cell used by an earlier
+	// alignment.
+	for _, qual := range []bool{false, true} {
+		for _, n := range []int{20, 40, 100} {
+			ref, query := bandedPair(n, 3, qual)
+			for _, a := range alignN {
+				want, err := a.align.Align(ref, query)
+				c.Assert(err, check.Equals, nil)
+				got, err := a.n(ref, query, -1)
+				c.Assert(err, check.Equals, nil)
+				c.Assert(len(got) > 0, check.Equals, true)
+				c.Check(fmt.Sprint(got[0]), check.Equals, fmt.Sprint(want), check.Commentf("%s n=%d", a.name, n))
+
+				used := make(map[[2]int]bool)
+				last := 0
+				for k, aln := range got {
+					score := 0
+					for _, fp := range aln {
+						score += fp.(*featPair).score
+						fc := fp.Features()
+						for i, j := fc[0].Start(), fc[1].Start(); i < fc[0].End() || j < fc[1].End(); {
+							if fc[0].Len() != 0 {
+								i++
+							}
+							if fc[1].Len() != 0 {
+								j++
+							}
+							c.Check(used[[2]int{i, j}], check.Equals, false, check.Commentf("%s n=%d alignment %d reuses (%d,%d)", a.name, n, k, i, j))
+							used[[2]int{i, j}] = true
+						}
+					}
+					c.Check(score > 0, check.Equals, true)
+					if k != 0 {
+						c.Check(score <= last, check.Equals, true, check.Commentf("%s n=%d alignment %d", a.name, n, k))
+					}
+					last = score
+				}
+
+				k := 2
+				if len(got) < k {
+					k = len(got)
+				}
+				top, err := a.n(ref, query, 2)
+				c.Assert(err, check.Equals, nil)
+				c.Check(fmt.Sprint(top), check.Equals, fmt.Sprint(got[:k]))
+			}
+		}
+	}
+
+	// All copies of a repeated domain are found.
+	rnd := rand.New(rand.NewSource(1))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = "acgt"[rnd.Intn(4)]
+		}
+		return b
+	}
+	domain := random(30)
+	var r []byte
+	for i := 0; i < 3; i++ {
+		r = append(r, random(40)...)
+		r = append(r, domain...)
+	}
+	r = append(r, random(40)...)
+	ref := linear.NewSeq("ref", alphabet.BytesToLetters(r), alphabet.DNAgapped)
+	query := linear.NewSeq("query", alphabet.BytesToLetters(domain), alphabet.DNAgapped)
+	for _, a := range alignN {
+		got, err := a.n(ref, query, 3)
+		c.Assert(err, check.Equals, nil)
+		c.Assert(len(got), check.Equals, 3)
+		starts := make(map[int]bool)
+		for _, aln := range got {
+			c.Check(len(aln), check.Equals, 1)
+			fc := aln[0].Features()
+			c.Check(fc[1].Start(), check.Equals, 0)
+			c.Check(fc[1].End(), check.Equals, len(domain))
+			starts[fc[0].Start()] = true
+		}
+		c.Check(starts, check.DeepEquals, map[int]bool{40: true, 110: true, 180: true}, check.Commentf("%s", a.name))
+	}
+}
+
+func (s *S) TestAlignNRandom(c *check.C) {
+	rnd := rand.New(rand.NewSource(1))
+	total := func(aln []feat.Pair) int {
+		var score int
+		for _, fp := range aln {
+			score += fp.(*featPair).score
+		}
+		return score
+	}
+	for k := 0; k < 500; k++ {
+		m := randomMatrix(rnd)
+		lin := SW(m)
+		aff := SWAffine{Matrix: m, GapOpen: -rnd.Intn(6)}
+		r, q := randomDNA(rnd, 60), randomDNA(rnd, 60)
+		ref := linear.NewSeq("ref", alphabet.BytesToLetters(r), alphabet.DNAgapped)
+		query := linear.NewSeq("query", alphabet.BytesToLetters(q), alphabet.DNAgapped)
+		for _, a := range []struct {
+			name  string
+			align Aligner
+			n     func(ref, query AlphabetSlicer, n int) ([][]feat.Pair, error)
+		}{
+			{name: "SW", align: lin, n: lin.AlignN},
+			{name: "SWAffine", align: aff, n: aff.AlignN},
+		} {
+			want, err := a.align.Align(ref, query)
+			c.Assert(err, check.Equals, nil)
+			got, err := a.n(ref, query, -1)
+			c.Assert(err, check.Equals, nil)
+			if len(got) == 0 {
+				continue
+			}
+			comment := check.Commentf("%s matrix=%v r=%s q=%s", a.name, m, r, q)
+			c.Check(total(got[0]) >= total(want), check.Equals, true, comment)
+			for i := 1; i < len(got); i++ {
+				c.Check(total(got[i]) <= total(got[i-1]), check.Equals, true, comment)
+			}
+		}
+	}
+}
+
 func BenchmarkSWAlign(b *testing.B) {
 	t := &linear.Seq{}
 	t.Alpha = alphabet.DNAgapped
@@ -110,3 +967,152 @@ func BenchmarkNWAffineAlign(b *testing.B) {
 		needle.Align(nwsa, nwsb)
 	}
 }
+
+func BenchmarkHirschbergAlign(b *testing.B) {
+	t := &linear.Seq{}
+	t.Alpha = alphabet.DNAgapped
+	r := fasta.NewReader(strings.NewReader(crspFa), t)
+	nwsa, _ := r.Read()
+	nwsb, _ := r.Read()
+
+	needle := Hirschberg{
+		{10, -3, -1, -4, -5},
+		{-3, 9, -5, 0, -5},
+		{-1, -5, 7, -3, -5},
+		{-4, 0, -3, 8, -5},
+		{-4, -4, -4, -4, 0},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		needle.Align(nwsa, nwsb)
+	}
+}
+
+func BenchmarkMyersMillerAlign(b *testing.B) {
+	t := &linear.Seq{}
+	t.Alpha = alphabet.DNAgapped
+	r := fasta.NewReader(strings.NewReader(crspFa), t)
+	nwsa, _ := r.Read()
+	nwsb, _ := r.Read()
+
+	needle := MyersMiller{
+		Matrix: Linear{
+			{10, -3, -1, -4, -5},
+			{-3, 9, -5, 0, -5},
+			{-1, -5, 7, -3, -5},
+			{-4, 0, -3, 8, -5},
+			{-4, -4, -4, -4, 0},
+		},
+		GapOpen: -10,
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		needle.Align(nwsa, nwsb)
+	}
+}
+
+func BenchmarkSWAffineStripedScore(b *testing.B) {
+	t := &linear.Seq{}
+	t.Alpha = alphabet.DNAgapped
+	r := fasta.NewReader(strings.NewReader(crspFa), t)
+	swsa, _ := r.Read()
+	swsb, _ := r.Read()
+
+	smith := SWAffineStriped{
+		Matrix: Linear{
+			{2, -1, -1, -1, -1},
+			{-1, 2, -1, -1, -1},
+			{-1, -1, 2, -1, -1},
+			{-1, -1, -1, 2, -1},
+			{-1, -1, -1, -1, 0},
+		},
+		GapOpen: -5,
+	}
+	p, _ := smith.Profile(swsb)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Score(swsa)
+	}
+}
+
+func BenchmarkSWAffineStripedAlign(b *testing.B) {
+	t := &linear.Seq{}
+	t.Alpha = alphabet.DNAgapped
+	r := fasta.NewReader(strings.NewReader(crspFa), t)
+	swsa, _ := r.Read()
+	swsb, _ := r.Read()
+
+	smith := SWAffineStriped{
+		Matrix: Linear{
+			{2, -1, -1, -1, -1},
+			{-1, 2, -1, -1, -1},
+			{-1, -1, 2, -1, -1},
+			{-1, -1, -1, 2, -1},
+			{-1, -1, -1, -1, 0},
+		},
+		GapOpen: -5,
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		smith.Align(swsa, swsb)
+	}
+}
+
+// shortReads returns a 100 base query and 150 base reference windows from crspFa.
+func shortReads() (query *linear.Seq, refs []*linear.Seq) {
+	t := &linear.Seq{}
+	t.Alpha = alphabet.DNAgapped
+	r := fasta.NewReader(strings.NewReader(crspFa), t)
+	swsa, _ := r.Read()
+	swsb, _ := r.Read()
+
+	ref := swsa.(*linear.Seq).Seq
+	query = linear.NewSeq("query", swsb.(*linear.Seq).Seq[500:600], alphabet.DNAgapped)
+	for i := 0; i+150 <= len(ref); i += 50 {
+		refs = append(refs, linear.NewSeq("ref", ref[i:i+150], alphabet.DNAgapped))
+	}
+	return query, refs
+}
+
+func BenchmarkSWAffineShortAlign(b *testing.B) {
+	query, refs := shortReads()
+	smith := SWAffine{
+		Matrix: Linear{
+			{2, -1, -1, -1, -1},
+			{-1, 2, -1, -1, -1},
+			{-1, -1, 2, -1, -1},
+			{-1, -1, -1, 2, -1},
+			{-1, -1, -1, -1, 0},
+		},
+		GapOpen: -5,
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ref := range refs {
+			smith.Align(ref, query)
+		}
+	}
+}
+
+func BenchmarkSWAffineStripedShortScore(b *testing.B) {
+	query, refs := shortReads()
+	smith := SWAffineStriped{
+		Matrix: Linear{
+			{2, -1, -1, -1, -1},
+			{-1, 2, -1, -1, -1},
+			{-1, -1, 2, -1, -1},
+			{-1, -1, -1, 2, -1},
+			{-1, -1, -1, -1, 0},
+		},
+		GapOpen: -5,
+	}
+	p, _ := smith.Profile(query)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ref := range refs {
+			p.Score(ref)
+		}
+	}
+}
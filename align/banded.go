@@ -0,0 +1,92 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package align
+
+import (
+	"github.com/biogo/biogo/feat"
+)
+
+// band describes a diagonal band of a dynamic programming table with r rows
+// and c columns. Cells (i, j) with lo <= j-i <= hi are within the band. Band
+// cells are stored row-major with hi-lo+1 elements per row, so the diagonal,
+// up and left neighbours of the cell at p are at p-w, p-w+1 and p-1 where w
+// is the width of the band.
+type band struct {
+	lo, hi int
+	r, c   int
+}
+
+// newBand returns a band extending w diagonals either side of the diagonals
+// between the main diagonal and the diagonal ending at the last cell of an
+// r×c table. The band is clipped to the table.
+func newBand(r, c, w int) band {
+	b := band{lo: -w, hi: w, r: r, c: c}
+	if d := c - r; d < 0 {
+		b.lo += d
+	} else {
+		b.hi += d
+	}
+	if b.lo < -(r - 1) {
+		b.lo = -(r - 1)
+	}
+	if b.hi > c-1 {
+		b.hi = c - 1
+	}
+	return b
+}
+
+// width returns the number of diagonals in the band.
+func (b band) width() int { return b.hi - b.lo + 1 }
+
+// index returns the table index of cell (i, j).
+func (b band) index(i, j int) int { return i*b.width() + j - i - b.lo }
+
+// cols returns the first and last columns of row i within the band.
+func (b band) cols(i int) (lo, hi int) {
+	lo, hi = i+b.lo, i+b.hi
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > b.c-1 {
+		hi = b.c - 1
+	}
+	return lo, hi
+}
+
+// full returns whether the band covers the whole table.
+func (b band) full() bool { return b.lo == -(b.r-1) && b.hi == b.c-1 }
+
+// atEdge returns whether cell (i, j) lies on an edge diagonal of the band that
+// is not also a corner of the table, and so may have been constrained by the
+// band.
+func (b band) atEdge(i, j int) bool {
+	d := j - i
+	return (d == b.lo && d != -(b.r-1)) || (d == b.hi && d != b.c-1)
+}
+
+// widen calls align with bands of increasing width, starting with w, until the
+// returned alignment path does not reach the edge of the band, the band covers
+// the whole r×c table or the band width reaches max. If max is less than one,
+// the band width is not limited. A path that does not reach the band edge is
+// not guaranteed to be the optimal path through the whole table.
+func widen(w, max, r, c int, align func(band) ([]feat.Pair, bool, error)) ([]feat.Pair, error) {
+	if w < 0 {
+		w = 0
+	}
+	if max > 0 && w > max {
+		w = max
+	}
+	for {
+		b := newBand(r, c, w)
+		aln, edge, err := align(b)
+		if err != nil || !edge || b.full() || (max > 0 && w >= max) {
+			return aln, err
+		}
+		w = 2*w + 1
+		if max > 0 && w > max {
+			w = max
+		}
+	}
+}
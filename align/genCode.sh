@@ -125,3 +125,83 @@ cat < nw_affine_type.got \
 | gofmt -r 'rSeq[i] -> rSeq[i].L' \
 | gofmt -r 'qSeq[i] -> qSeq[i].L' \
 >> nw_affine_qletters.go
+
+echo -e $WARNING\
+> nw_banded_letters.go
+cat < nw_banded_type.got \
+| gofmt -r 'alignType -> alignLetters' \
+| gofmt -r 'Type -> alphabet.Letters' \
+>> nw_banded_letters.go
+
+echo -e $WARNING\
+> nw_banded_qletters.go
+cat < nw_banded_type.got \
+| gofmt -r 'alignType -> alignQLetters' \
+| gofmt -r 'Type -> alphabet.QLetters' \
+| gofmt -r 'rSeq[i] -> rSeq[i].L' \
+| gofmt -r 'qSeq[i] -> qSeq[i].L' \
+>> nw_banded_qletters.go
+
+echo -e $WARNING\
+> sw_banded_letters.go
+cat < sw_banded_type.got \
+| gofmt -r 'alignType -> alignLetters' \
+| gofmt -r 'Type -> alphabet.Letters' \
+>> sw_banded_letters.go
+
+echo -e $WARNING\
+> sw_banded_qletters.go
+cat < sw_banded_type.got \
+| gofmt -r 'alignType -> alignQLetters' \
+| gofmt -r 'Type -> alphabet.QLetters' \
+| gofmt -r 'rSeq[i] -> rSeq[i].L' \
+| gofmt -r 'qSeq[i] -> qSeq[i].L' \
+>> sw_banded_qletters.go
+
+echo -e $WARNING\
+> nw_affine_banded_letters.go
+cat < nw_affine_banded_type.got \
+| gofmt -r 'alignType -> alignLetters' \
+| gofmt -r 'Type -> alphabet.Letters' \
+>> nw_affine_banded_letters.go
+
+echo -e $WARNING\
+> nw_affine_banded_qletters.go
+cat < nw_affine_banded_type.got \
+| gofmt -r 'alignType -> alignQLetters' \
+| gofmt -r 'Type -> alphabet.QLetters' \
+| gofmt -r 'rSeq[i] -> rSeq[i].L' \
+| gofmt -r 'qSeq[i] -> qSeq[i].L' \
+>> nw_affine_banded_qletters.go
+
+echo -e $WARNING\
+> sw_affine_banded_letters.go
+cat < sw_affine_banded_type.got \
+| gofmt -r 'alignType -> alignLetters' \
+| gofmt -r 'Type -> alphabet.Letters' \
+>> sw_affine_banded_letters.go
+
+echo -e $WARNING\
+> sw_affine_banded_qletters.go
+cat < sw_affine_banded_type.got \
+| gofmt -r 'alignType -> alignQLetters' \
+| gofmt -r 'Type -> alphabet.QLetters' \
+| gofmt -r 'rSeq[i] -> rSeq[i].L' \
+| gofmt -r 'qSeq[i] -> qSeq[i].L' \
+>> sw_affine_banded_qletters.go
+
+echo -e $WARNING\
+> hirschberg_letters.go
+cat < hirschberg_type.got \
+| gofmt -r 'indexType -> indexLetters' \
+| gofmt -r 'Type -> alphabet.Letters' \
+>> hirschberg_letters.go
+
+echo -e $WARNING\
+> hirschberg_qletters.go
+cat < hirschberg_type.got \
+| gofmt -r 'indexType -> indexQLetters' \
+| gofmt -r 'Type -> alphabet.QLetters' \
+| gofmt -r 'rSeq[i] -> rSeq[i].L' \
+| gofmt -r 'qSeq[i] -> qSeq[i].L' \
+>> hirschberg_qletters.go
@@ -0,0 +1,318 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package align
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+
+	"fmt"
+)
+
+// Hirschberg is the linear gap penalty Needleman-Wunsch aligner type using a Hirschberg-style
+// divide and conquer strategy to align in linear space. Hirschberg returns the same alignments
+// as NW for the same scoring matrix.
+type Hirschberg Linear
+
+// Align aligns two sequences using the Needleman-Wunsch algorithm in O(n+m) space. It returns an
+// alignment description or an error if the scoring matrix is not square, or the sequence data types
+// or alphabets do not match.
+func (a Hirschberg) Align(reference, query AlphabetSlicer) ([]feat.Pair, error) {
+	alpha := reference.Alphabet()
+	if alpha == nil {
+		return nil, ErrNoAlphabet
+	}
+	if alpha != query.Alphabet() {
+		return nil, ErrMismatchedAlphabets
+	}
+	if alpha.IndexOf(alpha.Gap()) != 0 {
+		return nil, ErrNotGappedAlphabet
+	}
+	la, err := flatten(Linear(a), alpha)
+	if err != nil {
+		return nil, err
+	}
+	var rSeq, qSeq []int
+	switch r := reference.Slice().(type) {
+	case alphabet.Letters:
+		q, ok := query.Slice().(alphabet.Letters)
+		if !ok {
+			return nil, ErrMismatchedTypes
+		}
+		rSeq, qSeq, err = indexLetters(r, q, alpha.LetterIndex())
+	case alphabet.QLetters:
+		q, ok := query.Slice().(alphabet.QLetters)
+		if !ok {
+			return nil, ErrMismatchedTypes
+		}
+		rSeq, qSeq, err = indexQLetters(r, q, alpha.LetterIndex())
+	default:
+		return nil, ErrTypeNotHandled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := &nwTable{let: len(a), la: la, rSeq: rSeq, qSeq: qSeq}
+	r, c := len(rSeq)+1, len(qSeq)+1
+	top := make([]int, c)
+	for j := 1; j < c; j++ {
+		top[j] = top[j-1] + la[qSeq[j-1]]
+	}
+	side := make([]int, r-1)
+	for i := range side {
+		side[i] = la[rSeq[i]*t.let]
+		if i != 0 {
+			side[i] += side[i-1]
+		}
+	}
+
+	end, steps := divideTrace(t, block{i0: 1, i1: r, j0: 1, j1: c}, top, side, cell{i: r - 1, j: c - 1}, nil)
+	var tail int
+	if end.i == 0 {
+		tail = top[end.j]
+	} else {
+		tail = side[end.i-1]
+	}
+	return stepPairs(steps, r-1, c-1, tail), nil
+}
+
+// flatten returns the scoring matrix m as a flat slice after checking that it is square and large
+// enough for alpha.
+func flatten(m Linear, alpha alphabet.Alphabet) ([]int, error) {
+	let := len(m)
+	if let < alpha.Len() {
+		return nil, ErrMatrixWrongSize{Size: let, Len: alpha.Len()}
+	}
+	la := make([]int, 0, let*let)
+	for _, row := range m {
+		if len(row) != let {
+			return nil, ErrMatrixNotSquare
+		}
+		la = append(la, row...)
+	}
+	return la, nil
+}
+
+// minDivide is the smallest block dimension that divideTrace will subdivide. Blocks with a
+// dimension at or below minDivide are traced back using a complete table for the block.
+const minDivide = 16
+
+// block is a rectangular region of a dynamic programming table holding rows [i0, i1)
+// and columns [j0, j1).
+type block struct {
+	i0, i1 int
+	j0, j1 int
+}
+
+// split returns the first row and column of the lower right quadrant of b.
+func (b block) split() (mi, mj int) { return (b.i0 + b.i1) / 2, (b.j0 + b.j1) / 2 }
+
+// contains returns whether p is within b.
+func (b block) contains(p cell) bool {
+	return b.i0 <= p.i && p.i < b.i1 && b.j0 <= p.j && p.j < b.j1
+}
+
+// cell is a position in a dynamic programming table and the score layer being traced.
+type cell struct {
+	i, j  int
+	layer int
+}
+
+// step is a single traceback move and its contribution to the alignment score.
+type step struct {
+	dir   int
+	score int
+}
+
+// dcTable is a dynamic programming table that can be calculated for a block given the scores
+// of the row above the block and the column to its left. Each table cell holds cells() scores,
+// so a boundary spanning n cells has n*cells() elements.
+type dcTable interface {
+	// cells returns the number of scores held for each table cell.
+	cells() int
+
+	// fill calculates the block b from its top and left boundaries, storing the scores
+	// of row mi-1 from column b.j0-1 in row and of column mj-1 from row b.i0-1 in col.
+	// Cells in rows from mi and columns from mj need not be calculated.
+	fill(b block, mi, mj int, top, side, row, col []int)
+
+	// trace follows the traceback from p until it leaves the block b, appending
+	// the moves made to steps.
+	trace(b block, top, side []int, p cell, steps []step) (cell, []step)
+}
+
+// divideTrace follows the traceback from p until it leaves the block b, appending the moves made
+// to steps. Large blocks are divided into quadrants, keeping only the scores on the quadrant
+// boundaries, and the traceback is followed through each quadrant it passes through. Since a
+// traceback never visits more than three quadrants, the time taken is proportional to the area
+// of the block and the space used is proportional to its perimeter.
+func divideTrace(t dcTable, b block, top, side []int, p cell, steps []step) (cell, []step) {
+	h, w := b.i1-b.i0, b.j1-b.j0
+	if h <= minDivide || w <= minDivide {
+		return t.trace(b, top, side, p, steps)
+	}
+
+	k := t.cells()
+	mi, mj := b.split()
+	row := make([]int, (w+1)*k)
+	col := make([]int, (h+1)*k)
+	t.fill(b, mi, mj, top, side, row, col)
+
+	for b.contains(p) {
+		var (
+			q           block
+			qTop, qSide []int
+		)
+		switch {
+		case p.i < mi && p.j < mj:
+			q = block{i0: b.i0, i1: mi, j0: b.j0, j1: mj}
+			qTop, qSide = top[:(mj-b.j0+1)*k], side[:(mi-b.i0)*k]
+		case p.i < mi:
+			q = block{i0: b.i0, i1: mi, j0: mj, j1: b.j1}
+			qTop, qSide = top[(mj-b.j0)*k:], col[k:(mi-b.i0+1)*k]
+		case p.j < mj:
+			q = block{i0: mi, i1: b.i1, j0: b.j0, j1: mj}
+			qTop, qSide = row[:(mj-b.j0+1)*k], side[(mi-b.i0)*k:]
+		default:
+			q = block{i0: mi, i1: b.i1, j0: mj, j1: b.j1}
+			qTop, qSide = row[(mj-b.j0)*k:], col[(mi-b.i0+1)*k:]
+		}
+		p, steps = divideTrace(t, q, qTop, qSide, p, steps)
+	}
+	return p, steps
+}
+
+// stepPairs returns the alignment described by the traceback steps taken from (i, j), grouping
+// runs of moves in the same direction in the same way as the full table aligners. The score of
+// any leading gap between the table origin and the end of the traceback is given by tail.
+func stepPairs(steps []step, i, j int, tail int) []feat.Pair {
+	var aln []feat.Pair
+	score, last := 0, diag
+	maxI, maxJ := i, j
+	for k, s := range steps {
+		if k != 0 && s.dir != last {
+			aln = append(aln, &featPair{
+				a:     feature{start: i, end: maxI},
+				b:     feature{start: j, end: maxJ},
+				score: score,
+			})
+			maxI, maxJ = i, j
+			score = 0
+		}
+		score += s.score
+		switch s.dir {
+		case diag:
+			i--
+			j--
+		case up:
+			i--
+		case left:
+			j--
+		}
+		last = s.dir
+	}
+
+	aln = append(aln, &featPair{
+		a:     feature{start: i, end: maxI},
+		b:     feature{start: j, end: maxJ},
+		score: score,
+	})
+	if i != j {
+		aln = append(aln, &featPair{
+			a:     feature{start: 0, end: i},
+			b:     feature{start: 0, end: j},
+			score: tail,
+		})
+	}
+
+	for i, j := 0, len(aln)-1; i < j; i, j = i+1, j-1 {
+		aln[i], aln[j] = aln[j], aln[i]
+	}
+
+	return aln
+}
+
+// nwTable is the linear gap penalty Needleman-Wunsch dynamic programming table.
+type nwTable struct {
+	let int
+	la  []int
+
+	rSeq, qSeq []int
+}
+
+func (t *nwTable) cells() int { return 1 }
+
+func (t *nwTable) fill(b block, mi, mj int, top, side, row, col []int) {
+	let, la := t.let, t.la
+	prev := append([]int(nil), top...)
+	curr := make([]int, len(top))
+	col[0] = top[mj-b.j0]
+	for i := b.i0; i < b.i1; i++ {
+		end := b.j1
+		if i >= mi {
+			end = mj
+		}
+		rVal := t.rSeq[i-1]
+		curr[0] = side[i-b.i0]
+		for j := b.j0; j < end; j++ {
+			qVal := t.qSeq[j-1]
+			p := j - b.j0 + 1
+			curr[p] = max3(
+				prev[p-1]+la[rVal*let+qVal],
+				prev[p]+la[rVal*let],
+				curr[p-1]+la[qVal],
+			)
+		}
+		if i == mi-1 {
+			copy(row, curr)
+		}
+		col[i-b.i0+1] = curr[mj-b.j0]
+		prev, curr = curr, prev
+	}
+}
+
+func (t *nwTable) trace(b block, top, side []int, p cell, steps []step) (cell, []step) {
+	let, la := t.let, t.la
+	c := b.j1 - b.j0 + 1
+	table := make([]int, (b.i1-b.i0+1)*c)
+	copy(table, top)
+	for i := b.i0; i < b.i1; i++ {
+		rVal := t.rSeq[i-1]
+		row := (i - b.i0 + 1) * c
+		table[row] = side[i-b.i0]
+		for j := b.j0; j < b.j1; j++ {
+			qVal := t.qSeq[j-1]
+			p := row + j - b.j0 + 1
+			table[p] = max3(
+				table[p-c-1]+la[rVal*let+qVal],
+				table[p-c]+la[rVal*let],
+				table[p-1]+la[qVal],
+			)
+		}
+	}
+
+	for b.contains(p) {
+		var (
+			rVal = t.rSeq[p.i-1]
+			qVal = t.qSeq[p.j-1]
+		)
+		switch i := (p.i-b.i0+1)*c + p.j - b.j0 + 1; table[i] {
+		case table[i-c-1] + la[rVal*let+qVal]:
+			steps = append(steps, step{dir: diag, score: table[i] - table[i-c-1]})
+			p.i--
+			p.j--
+		case table[i-c] + la[rVal*let]:
+			steps = append(steps, step{dir: up, score: table[i] - table[i-c]})
+			p.i--
+		case table[i-1] + la[qVal]:
+			steps = append(steps, step{dir: left, score: table[i] - table[i-1]})
+			p.j--
+		default:
+			panic(fmt.Sprintf("align: hirschberg internal error: no path at row: %d col:%d\n", p.i, p.j))
+		}
+	}
+	return p, steps
+}
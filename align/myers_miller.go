@@ -0,0 +1,232 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package align
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+
+	"fmt"
+)
+
+// MyersMiller is the affine gap penalty Needleman-Wunsch aligner type using the Myers and Miller
+// extension of the Hirschberg divide and conquer strategy to align in linear space. MyersMiller
+// returns the same alignments as NWAffine for the same scoring matrix and gap open penalty.
+type MyersMiller Affine
+
+// Align aligns two sequences using the Needleman-Wunsch algorithm in O(n+m) space. It returns an
+// alignment description or an error if the scoring matrix is not square, or the sequence data types
+// or alphabets do not match.
+func (a MyersMiller) Align(reference, query AlphabetSlicer) ([]feat.Pair, error) {
+	alpha := reference.Alphabet()
+	if alpha == nil {
+		return nil, ErrNoAlphabet
+	}
+	if alpha != query.Alphabet() {
+		return nil, ErrMismatchedAlphabets
+	}
+	if alpha.IndexOf(alpha.Gap()) != 0 {
+		return nil, ErrNotGappedAlphabet
+	}
+	la, err := flatten(a.Matrix, alpha)
+	if err != nil {
+		return nil, err
+	}
+	var rSeq, qSeq []int
+	switch r := reference.Slice().(type) {
+	case alphabet.Letters:
+		q, ok := query.Slice().(alphabet.Letters)
+		if !ok {
+			return nil, ErrMismatchedTypes
+		}
+		rSeq, qSeq, err = indexLetters(r, q, alpha.LetterIndex())
+	case alphabet.QLetters:
+		q, ok := query.Slice().(alphabet.QLetters)
+		if !ok {
+			return nil, ErrMismatchedTypes
+		}
+		rSeq, qSeq, err = indexQLetters(r, q, alpha.LetterIndex())
+	default:
+		return nil, ErrTypeNotHandled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := &nwAffineTable{let: len(a.Matrix), la: la, gapOpen: a.GapOpen, rSeq: rSeq, qSeq: qSeq}
+	r, c := len(rSeq)+1, len(qSeq)+1
+	top := make([]int, c*3)
+	copy(top, []int{diag: 0, up: minInt, left: minInt})
+	for j := 1; j < c; j++ {
+		p := j * 3
+		top[p+diag] = minInt
+		top[p+up] = minInt
+		if j == 1 {
+			top[p+left] = a.GapOpen + la[qSeq[0]]
+		} else {
+			top[p+left] = top[p-3+left] + la[qSeq[j-1]]
+		}
+	}
+	side := make([]int, (r-1)*3)
+	for i := 0; i < r-1; i++ {
+		p := i * 3
+		side[p+diag] = minInt
+		if i == 0 {
+			side[p+up] = a.GapOpen + la[rSeq[0]*t.let]
+		} else {
+			side[p+up] = side[p-3+up] + la[rSeq[i]*t.let]
+		}
+		side[p+left] = minInt
+	}
+
+	// Start the traceback from the best scoring layer of the last cell.
+	var last []int
+	switch {
+	case r == 1:
+		last = top[(c-1)*3:]
+	case c == 1:
+		last = side[(r-2)*3:]
+	default:
+		row := make([]int, c*3)
+		t.fill(block{i0: 1, i1: r, j0: 1, j1: c}, r, c, top, side, row, make([]int, r*3))
+		last = row[(c-1)*3:]
+	}
+	var layer int
+	best := last[0]
+	for l, s := range last[1:3] {
+		if s > best {
+			best, layer = s, l+1
+		}
+	}
+
+	end, steps := divideTrace(t, block{i0: 1, i1: r, j0: 1, j1: c}, top, side, cell{i: r - 1, j: c - 1, layer: layer}, nil)
+	dir := diag
+	if len(steps) != 0 {
+		dir = steps[len(steps)-1].dir
+	}
+	var tail int
+	if end.i == 0 {
+		tail = top[end.j*3+dir]
+	} else {
+		tail = side[(end.i-1)*3+dir]
+	}
+	return stepPairs(steps, r-1, c-1, tail), nil
+}
+
+// nwAffineTable is the affine gap penalty Needleman-Wunsch dynamic programming table.
+// Each cell holds the diag, up and left layer scores.
+type nwAffineTable struct {
+	let     int
+	la      []int
+	gapOpen int
+
+	rSeq, qSeq []int
+}
+
+func (t *nwAffineTable) cells() int { return 3 }
+
+// score calculates the cell at p in table from its diagonal, up and left neighbours at
+// d, u and l.
+func (t *nwAffineTable) score(table []int, p, d, u, l, rVal, qVal int) {
+	let, la := t.let, t.la
+	table[p+diag] = max3(table[d+diag], table[d+up], table[d+left]) + la[rVal*let+qVal]
+	table[p+up] = max2(
+		add(table[u+diag], t.gapOpen+la[rVal*let]),
+		add(table[u+up], la[rVal*let]),
+	)
+	table[p+left] = max2(
+		add(table[l+diag], t.gapOpen+la[qVal]),
+		add(table[l+left], la[qVal]),
+	)
+}
+
+func (t *nwAffineTable) fill(b block, mi, mj int, top, side, row, col []int) {
+	// The previous and current rows are held together so that
+	// score can address neighbours in either.
+	n := len(top)
+	rows := make([]int, 2*n)
+	prev, curr := 0, n
+	copy(rows[prev:], top)
+	copy(col, top[(mj-b.j0)*3:(mj-b.j0+1)*3])
+	for i := b.i0; i < b.i1; i++ {
+		end := b.j1
+		if i >= mi {
+			end = mj
+		}
+		rVal := t.rSeq[i-1]
+		copy(rows[curr:curr+3], side[(i-b.i0)*3:])
+		for j := b.j0; j < end; j++ {
+			o := (j - b.j0) * 3
+			t.score(rows, curr+o+3, prev+o, prev+o+3, curr+o, rVal, t.qSeq[j-1])
+		}
+		if i == mi-1 {
+			copy(row, rows[curr:curr+n])
+		}
+		copy(col[(i-b.i0+1)*3:], rows[curr+(mj-b.j0)*3:curr+(mj-b.j0+1)*3])
+		prev, curr = curr, prev
+	}
+}
+
+func (t *nwAffineTable) trace(b block, top, side []int, p cell, steps []step) (cell, []step) {
+	let, la := t.let, t.la
+	c := (b.j1 - b.j0 + 1) * 3
+	table := make([]int, (b.i1-b.i0+1)*c)
+	copy(table, top)
+	for i := b.i0; i < b.i1; i++ {
+		rVal := t.rSeq[i-1]
+		row := (i - b.i0 + 1) * c
+		copy(table[row:row+3], side[(i-b.i0)*3:])
+		for j := b.j0; j < b.j1; j++ {
+			p := row + (j-b.j0+1)*3
+			t.score(table, p, p-c-3, p-c, p-3, rVal, t.qSeq[j-1])
+		}
+	}
+
+	for b.contains(p) {
+		var (
+			rVal = t.rSeq[p.i-1]
+			qVal = t.qSeq[p.j-1]
+		)
+		i := (p.i-b.i0+1)*c + (p.j-b.j0+1)*3
+		d, u, l := i-c-3, i-c, i-3
+		s := table[i+p.layer]
+		switch s {
+		case table[u+up] + la[rVal*let]:
+			steps = append(steps, step{dir: up, score: s - table[u+up]})
+			p.i--
+			p.layer = up
+		case table[l+left] + la[qVal]:
+			steps = append(steps, step{dir: left, score: s - table[l+left]})
+			p.j--
+			p.layer = left
+		case table[u+diag] + t.gapOpen + la[rVal*let]:
+			steps = append(steps, step{dir: up, score: s - table[u+diag]})
+			p.i--
+			p.layer = diag
+		case table[l+diag] + t.gapOpen + la[qVal]:
+			steps = append(steps, step{dir: left, score: s - table[l+diag]})
+			p.j--
+			p.layer = diag
+		case table[d+up] + la[rVal*let+qVal]:
+			steps = append(steps, step{dir: diag, score: s - table[d+up]})
+			p.i--
+			p.j--
+			p.layer = up
+		case table[d+left] + la[rVal*let+qVal]:
+			steps = append(steps, step{dir: diag, score: s - table[d+left]})
+			p.i--
+			p.j--
+			p.layer = left
+		case table[d+diag] + la[rVal*let+qVal]:
+			steps = append(steps, step{dir: diag, score: s - table[d+diag]})
+			p.i--
+			p.j--
+			p.layer = diag
+		default:
+			panic(fmt.Sprintf("align: myers miller internal error: no path at row: %d col:%d layer:%s\n", p.i, p.j, "mul"[p.layer:p.layer+1]))
+		}
+	}
+	return p, steps
+}
@@ -0,0 +1,61 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package align
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+)
+
+// NWAffineBanded is the affine gap penalty banded Needleman-Wunsch aligner type.
+// Only cells of the dynamic programming table within Band diagonals of the
+// diagonals joining the ends of the sequences are considered. If the best
+// path within the band reaches the edge of the band, the band is widened and
+// the alignment is repeated until the path lies within the band or the band is
+// MaxBand wide. A MaxBand of zero places no limit on the band width. The
+// returned alignment is the best path within the final band; it may differ
+// from the alignment returned by NWAffine when a better path lies outside the band.
+type NWAffineBanded struct {
+	Matrix  Linear
+	GapOpen int
+	Band    int
+	MaxBand int
+}
+
+// Align aligns two sequences using the banded Needleman-Wunsch algorithm. It returns an alignment
+// description or an error if the scoring matrix is not square, or the sequence data types or alphabets
+// do not match.
+func (a NWAffineBanded) Align(reference, query AlphabetSlicer) ([]feat.Pair, error) {
+	alpha := reference.Alphabet()
+	if alpha == nil {
+		return nil, ErrNoAlphabet
+	}
+	if alpha != query.Alphabet() {
+		return nil, ErrMismatchedAlphabets
+	}
+	if alpha.IndexOf(alpha.Gap()) != 0 {
+		return nil, ErrNotGappedAlphabet
+	}
+	switch rSeq := reference.Slice().(type) {
+	case alphabet.Letters:
+		qSeq, ok := query.Slice().(alphabet.Letters)
+		if !ok {
+			return nil, ErrMismatchedTypes
+		}
+		return widen(a.Band, a.MaxBand, rSeq.Len()+1, qSeq.Len()+1, func(b band) ([]feat.Pair, bool, error) {
+			return a.alignLetters(rSeq, qSeq, alpha, b)
+		})
+	case alphabet.QLetters:
+		qSeq, ok := query.Slice().(alphabet.QLetters)
+		if !ok {
+			return nil, ErrMismatchedTypes
+		}
+		return widen(a.Band, a.MaxBand, rSeq.Len()+1, qSeq.Len()+1, func(b band) ([]feat.Pair, bool, error) {
+			return a.alignQLetters(rSeq, qSeq, alpha, b)
+		})
+	default:
+		return nil, ErrTypeNotHandled
+	}
+}
@@ -0,0 +1,414 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package align
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+var (
+	ErrBadComposition      = errors.New("align: invalid background composition")
+	ErrNoPositiveScore     = errors.New("align: scoring matrix has no positive score")
+	ErrNonNegativeExpected = errors.New("align: expected score is not negative")
+)
+
+// scorer is a feat.Pair that holds an alignment score.
+type scorer interface {
+	Score() int
+}
+
+// cigarOp is a run of alignment columns of the same type.
+type cigarOp struct {
+	op byte
+	n  int
+}
+
+// Summary holds summary statistics for an alignment.
+type Summary struct {
+	Length        int // number of alignment columns
+	Identities    int // number of columns with identical letters
+	Positives     int // number of columns with a positive substitution score
+	Mismatches    int // number of columns with non-identical letters
+	Gaps          int // number of gap columns
+	GapOpens      int // number of gaps
+	GapExtensions int // number of gap columns following the first column of each gap
+	Score         int // sum of the alignment segment scores
+
+	ops []cigarOp
+}
+
+// Summarize returns summary statistics for the alignment f of reference and query, using the
+// scoring matrix m to determine similarity. The alignment segment scores are summed into the
+// Score field of the returned Summary when f holds feat.Pairs with a Score() int method, as is
+// the case for alignments returned by the aligners in this package. Summarize returns an error
+// if the scoring matrix is not square, the sequence data types or alphabets do not match, or the
+// alignment is not consistent with the sequences.
+func Summarize(reference, query AlphabetSlicer, f []feat.Pair, m Linear) (Summary, error) {
+	alpha := reference.Alphabet()
+	if alpha == nil {
+		return Summary{}, ErrNoAlphabet
+	}
+	if alpha != query.Alphabet() {
+		return Summary{}, ErrMismatchedAlphabets
+	}
+	la, err := flatten(m, alpha)
+	if err != nil {
+		return Summary{}, err
+	}
+	var rSeq, qSeq []int
+	switch r := reference.Slice().(type) {
+	case alphabet.Letters:
+		q, ok := query.Slice().(alphabet.Letters)
+		if !ok {
+			return Summary{}, ErrMismatchedTypes
+		}
+		rSeq, qSeq, err = indexLetters(r, q, alpha.LetterIndex())
+	case alphabet.QLetters:
+		q, ok := query.Slice().(alphabet.QLetters)
+		if !ok {
+			return Summary{}, ErrMismatchedTypes
+		}
+		rSeq, qSeq, err = indexQLetters(r, q, alpha.LetterIndex())
+	default:
+		return Summary{}, ErrTypeNotHandled
+	}
+	if err != nil {
+		return Summary{}, err
+	}
+
+	var (
+		s    Summary
+		let  = len(m)
+		last = byte(0)
+	)
+	for _, fp := range f {
+		if sc, ok := fp.(scorer); ok {
+			s.Score += sc.Score()
+		}
+		fc := fp.Features()
+		a, b := fc[0], fc[1]
+		if a.Start() < 0 || a.End() > len(rSeq) || b.Start() < 0 || b.End() > len(qSeq) {
+			return Summary{}, fmt.Errorf("align: alignment segment %v out of sequence range", fp)
+		}
+		switch {
+		case a.Len() == 0 && b.Len() == 0:
+			continue
+		case a.Len() == 0:
+			s.addGap('I', b.Len(), last)
+			last = 'I'
+		case b.Len() == 0:
+			s.addGap('D', a.Len(), last)
+			last = 'D'
+		case a.Len() != b.Len():
+			return Summary{}, fmt.Errorf("align: alignment segment %v has mismatched lengths", fp)
+		default:
+			for k := 0; k < a.Len(); k++ {
+				rVal, qVal := rSeq[a.Start()+k], qSeq[b.Start()+k]
+				if la[rVal*let+qVal] > 0 {
+					s.Positives++
+				}
+				if rVal == qVal {
+					s.Identities++
+					s.add('=', 1)
+				} else {
+					s.Mismatches++
+					s.add('X', 1)
+				}
+			}
+			s.Length += a.Len()
+			last = 'M'
+		}
+	}
+	return s, nil
+}
+
+// addGap adds a gap of n columns of type op following a segment of type last.
+func (s *Summary) addGap(op byte, n int, last byte) {
+	if op != last {
+		s.GapOpens++
+		s.GapExtensions += n - 1
+	} else {
+		s.GapExtensions += n
+	}
+	s.Gaps += n
+	s.Length += n
+	s.add(op, n)
+}
+
+// add appends n columns of type op to the extended CIGAR operations.
+func (s *Summary) add(op byte, n int) {
+	if len(s.ops) != 0 && s.ops[len(s.ops)-1].op == op {
+		s.ops[len(s.ops)-1].n += n
+		return
+	}
+	s.ops = append(s.ops, cigarOp{op: op, n: n})
+}
+
+// Identity returns the fraction of alignment columns with identical letters.
+func (s Summary) Identity() float64 {
+	if s.Length == 0 {
+		return 0
+	}
+	return float64(s.Identities) / float64(s.Length)
+}
+
+// Similarity returns the fraction of alignment columns with a positive substitution score.
+func (s Summary) Similarity() float64 {
+	if s.Length == 0 {
+		return 0
+	}
+	return float64(s.Positives) / float64(s.Length)
+}
+
+// Cigar returns the CIGAR string describing the aligned region of the query relative to the
+// reference using the M, I and D operations. Unaligned ends of the sequences are not described.
+func (s Summary) Cigar() string {
+	var (
+		buf bytes.Buffer
+		n   int
+	)
+	for i, o := range s.ops {
+		if o.op == '=' || o.op == 'X' {
+			n += o.n
+			if i+1 < len(s.ops) && (s.ops[i+1].op == '=' || s.ops[i+1].op == 'X') {
+				continue
+			}
+			o = cigarOp{op: 'M', n: n}
+			n = 0
+		}
+		buf.WriteString(strconv.Itoa(o.n))
+		buf.WriteByte(o.op)
+	}
+	return buf.String()
+}
+
+// ExtendedCigar returns the extended CIGAR string describing the aligned region of the query
+// relative to the reference using the =, X, I and D operations. Unaligned ends of the sequences
+// are not described.
+func (s Summary) ExtendedCigar() string {
+	var buf bytes.Buffer
+	for _, o := range s.ops {
+		buf.WriteString(strconv.Itoa(o.n))
+		buf.WriteByte(o.op)
+	}
+	return buf.String()
+}
+
+// KarlinAltschul holds the Karlin-Altschul statistical parameters of a scoring scheme.
+//
+// Reference:
+//
+// Karlin S, Altschul SF. Methods for assessing the statistical significance of molecular
+// sequence features by using general scoring schemes. PNAS 87(6):2264-2268 (1990).
+type KarlinAltschul struct {
+	Lambda float64 // scale of the scoring scheme
+	K      float64 // search space correction
+	H      float64 // relative entropy of the target and background frequencies in nats
+}
+
+// maxKIter is the maximum number of terms summed when estimating K.
+const maxKIter = 1000
+
+// NewKarlinAltschul returns the Karlin-Altschul parameters for ungapped local alignment with the
+// scoring matrix m given the background letter frequencies in freqs. The frequency of the letter
+// with index i in alpha is held in freqs[i]; the frequency of the gap letter is ignored and the
+// remaining frequencies are normalised to sum to one. NewKarlinAltschul returns an error if the
+// matrix is not valid for alpha, the frequencies are not valid, or the scoring matrix does not
+// have a positive score and a negative expected score.
+//
+// The parameters of gapped alignments cannot be calculated analytically. Empirically determined
+// parameters for gapped alignment may be used by constructing a KarlinAltschul value directly.
+func NewKarlinAltschul(m Linear, alpha alphabet.Alphabet, freqs []float64) (KarlinAltschul, error) {
+	if alpha.IndexOf(alpha.Gap()) != 0 {
+		return KarlinAltschul{}, ErrNotGappedAlphabet
+	}
+	la, err := flatten(m, alpha)
+	if err != nil {
+		return KarlinAltschul{}, err
+	}
+	if len(freqs) != alpha.Len() {
+		return KarlinAltschul{}, ErrBadComposition
+	}
+	var sum float64
+	for _, f := range freqs[1:] {
+		if f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+			return KarlinAltschul{}, ErrBadComposition
+		}
+		sum += f
+	}
+	if sum <= 0 {
+		return KarlinAltschul{}, ErrBadComposition
+	}
+
+	// Find the range of scores with non-zero probability.
+	let := len(m)
+	low, high := 0, 0
+	for i := 1; i < alpha.Len(); i++ {
+		for j := 1; j < alpha.Len(); j++ {
+			if freqs[i] == 0 || freqs[j] == 0 {
+				continue
+			}
+			if s := la[i*let+j]; s < low {
+				low = s
+			} else if s > high {
+				high = s
+			}
+		}
+	}
+	if high <= 0 {
+		return KarlinAltschul{}, ErrNoPositiveScore
+	}
+
+	// Calculate the score probabilities.
+	p := make([]float64, high-low+1)
+	for i := 1; i < alpha.Len(); i++ {
+		for j := 1; j < alpha.Len(); j++ {
+			if freqs[i] == 0 || freqs[j] == 0 {
+				continue
+			}
+			p[la[i*let+j]-low] += freqs[i] * freqs[j] / (sum * sum)
+		}
+	}
+	var (
+		mean float64
+		d    int
+	)
+	for k, v := range p {
+		if v == 0 {
+			continue
+		}
+		s := k + low
+		mean += float64(s) * v
+		d = gcd(d, s)
+	}
+	if mean >= 0 {
+		return KarlinAltschul{}, ErrNonNegativeExpected
+	}
+
+	lambda := karlinLambda(p, low)
+	var h float64
+	for k, v := range p {
+		s := float64(k + low)
+		h += s * v * math.Exp(lambda*s)
+	}
+	h *= lambda
+
+	return KarlinAltschul{Lambda: lambda, K: karlinK(p, low, d, lambda, h), H: h}, nil
+}
+
+// gcd returns the greatest common divisor of the absolute values of a and b.
+func gcd(a, b int) int {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// karlinLambda returns the unique positive lambda satisfying sum(p[s]*exp(lambda*s)) = 1 for the
+// score probabilities p starting at the score low.
+func karlinLambda(p []float64, low int) float64 {
+	phi := func(lambda float64) float64 {
+		var sum float64
+		for k, v := range p {
+			sum += v * math.Exp(lambda*float64(k+low))
+		}
+		return sum - 1
+	}
+
+	// phi is convex with phi(0) = 0 and a negative slope
+	// at zero, so lambda is bracketed by the smallest
+	// doubling of hi with a positive phi.
+	lo, hi := 0.0, 0.5
+	for phi(hi) <= 0 {
+		lo, hi = hi, hi*2
+	}
+	for i := 0; i < 100 && hi-lo > 1e-15*hi; i++ {
+		mid := (lo + hi) / 2
+		if phi(mid) <= 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// karlinK returns the Karlin-Altschul K parameter for the score probabilities p starting at the
+// score low, where the scores have the greatest common divisor d, and lambda and h are the scale
+// and relative entropy of the scoring scheme.
+func karlinK(p []float64, low, d int, lambda, h float64) float64 {
+	// Scores are held in units of d to reduce the
+	// size of the convolved score distributions.
+	var (
+		lo = low / d
+		hi = (len(p) - 1 + low) / d
+		ld = lambda * float64(d)
+
+		step = make([]float64, hi-lo+1)
+	)
+	for k, v := range p {
+		if v != 0 {
+			step[(k+low)/d-lo] += v
+		}
+	}
+
+	// sigma is the sum over k of the expectation of exp(lambda*S_k) where
+	// S_k is negative plus the probability that S_k is not negative, each
+	// divided by k, where S_k is the sum of k independent scores.
+	var sigma float64
+	dist := []float64{1}
+	for k := 1; k <= maxKIter; k++ {
+		next := make([]float64, len(dist)+len(step)-1)
+		for i, a := range dist {
+			if a == 0 {
+				continue
+			}
+			for j, b := range step {
+				next[i+j] += a * b
+			}
+		}
+		dist = next
+
+		var term float64
+		for i, v := range dist {
+			if s := i + k*lo; s < 0 {
+				term += v * math.Exp(ld*float64(s))
+			} else {
+				term += v
+			}
+		}
+		term /= float64(k)
+		sigma += term
+		if term < 1e-15*sigma {
+			break
+		}
+	}
+
+	return ld * math.Exp(-2*sigma) / (h * -math.Expm1(-ld))
+}
+
+// BitScore returns the normalised bit score of an alignment with the given raw score.
+func (ka KarlinAltschul) BitScore(score int) float64 {
+	return (ka.Lambda*float64(score) - math.Log(ka.K)) / math.Ln2
+}
+
+// EValue returns the expected number of distinct alignments with at least the given raw score
+// when aligning sequences of lengths m and n.
+func (ka KarlinAltschul) EValue(score, m, n int) float64 {
+	return ka.K * float64(m) * float64(n) * math.Exp(-ka.Lambda*float64(score))
+}
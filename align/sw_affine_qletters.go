@@ -162,6 +162,7 @@ func (a SWAffine) alignQLetters(rSeq, qSeq alphabet.QLetters, alpha alphabet.Alp
 	var aln []feat.Pair
 	score, last, layer := 0, diag, diag
 	i, j := maxI, maxJ
+	end := i*c + j
 loop:
 	for i > 0 && j > 0 {
 		var (
@@ -172,7 +173,7 @@ loop:
 		case 0:
 			break loop
 		case table[p-c][up] + la[rVal*let]:
-			if last != up && p != len(table)-1 {
+			if last != up && p != end {
 				aln = append(aln, &featPair{
 					a:     feature{start: i, end: maxI},
 					b:     feature{start: j, end: maxJ},
@@ -186,7 +187,7 @@ loop:
 			layer = up
 			last = up
 		case table[p-1][left] + la[qVal]:
-			if last != left && p != len(table)-1 {
+			if last != left && p != end {
 				aln = append(aln, &featPair{
 					a:     feature{start: i, end: maxI},
 					b:     feature{start: j, end: maxJ},
@@ -200,7 +201,7 @@ loop:
 			layer = left
 			last = left
 		case table[p-c][diag] + a.GapOpen + la[rVal*let]:
-			if last != up && p != len(table)-1 {
+			if last != up && p != end {
 				aln = append(aln, &featPair{
 					a:     feature{start: i, end: maxI},
 					b:     feature{start: j, end: maxJ},
@@ -214,7 +215,7 @@ loop:
 			layer = diag
 			last = up
 		case table[p-1][diag] + a.GapOpen + la[qVal]:
-			if last != left && p != len(table)-1 {
+			if last != left && p != end {
 				aln = append(aln, &featPair{
 					a:     feature{start: i, end: maxI},
 					b:     feature{start: j, end: maxJ},
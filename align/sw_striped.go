@@ -0,0 +1,447 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package align
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+)
+
+// SWAffineStriped is the affine gap penalty Smith-Waterman aligner type using Farrar's striped
+// query profile algorithm to find the end of the best local alignment. SWAffineStriped uses the
+// same scoring scheme as SWAffine and returns the same alignments. When only the score and end
+// coordinates of the alignment are required, a StripedProfile should be used.
+//
+// Reference:
+//
+// Farrar M. Striped Smith-Waterman speeds database searches six times over other SIMD
+// implementations. Bioinformatics 23(2):156-161 (2007).
+type SWAffineStriped Affine
+
+// Align aligns two sequences using the striped Smith-Waterman algorithm. It returns an alignment
+// description or an error if the scoring matrix is not square, or the sequence data types or alphabets
+// do not match.
+//
+// Only the part of the dynamic programming table preceding the end of the best alignment is held
+// during the traceback.
+func (a SWAffineStriped) Align(reference, query AlphabetSlicer) ([]feat.Pair, error) {
+	alpha := reference.Alphabet()
+	if alpha == nil {
+		return nil, ErrNoAlphabet
+	}
+	if alpha != query.Alphabet() {
+		return nil, ErrMismatchedAlphabets
+	}
+	p, err := a.Profile(query)
+	if err != nil {
+		return nil, err
+	}
+	_, i, j, err := p.Score(reference)
+	if err != nil {
+		return nil, err
+	}
+	switch rSeq := reference.Slice().(type) {
+	case alphabet.Letters:
+		qSeq, ok := query.Slice().(alphabet.Letters)
+		if !ok {
+			return nil, ErrMismatchedTypes
+		}
+		return SWAffine(a).alignLetters(rSeq[:i], qSeq[:j], alpha)
+	case alphabet.QLetters:
+		qSeq, ok := query.Slice().(alphabet.QLetters)
+		if !ok {
+			return nil, ErrMismatchedTypes
+		}
+		return SWAffine(a).alignQLetters(rSeq[:i], qSeq[:j], alpha)
+	default:
+		return nil, ErrTypeNotHandled
+	}
+}
+
+// The striped profile packs four 16 bit lanes into each uint64 so that the
+// recurrences can be evaluated for four query positions at once using
+// SIMD-within-a-register arithmetic. Values held in lanes are non-negative
+// and less than laneHigh; the high bit of each lane is used as a borrow
+// guard by the lane operations below and to detect overflow.
+const (
+	lanes    = 4
+	laneBits = 16
+	laneMax  = 1<<(laneBits-1) - 1
+
+	laneOnes = 0x0001000100010001
+	laneHigh = 0x8000800080008000
+	laneAll  = laneMax * laneOnes
+)
+
+// inLane returns whether v can be held in a lane.
+func inLane(v int) bool { return 0 <= v && v <= laneMax }
+
+// broadcast returns v in each lane.
+func broadcast(v int) uint64 { return uint64(v) * laneOnes }
+
+// geLanes returns a mask with laneMax in each lane where a >= b and zero elsewhere.
+func geLanes(a, b uint64) uint64 {
+	t := ((a | laneHigh) - b) & laneHigh
+	return t - t>>(laneBits-1)
+}
+
+// subLanes returns the lane-wise difference of a and b, saturating at zero.
+func subLanes(a, b uint64) uint64 {
+	d := (a | laneHigh) - b
+	t := d & laneHigh
+	return d & (t - t>>(laneBits-1))
+}
+
+// maxLanes returns the lane-wise maximum of a and b.
+func maxLanes(a, b uint64) uint64 {
+	return b + subLanes(a, b)
+}
+
+// StripedProfile is a striped query profile for affine gap Smith-Waterman alignment of a single
+// query sequence against many reference sequences.
+type StripedProfile struct {
+	alpha   alphabet.Alphabet
+	let     int
+	la      []int
+	gapOpen int
+
+	query []int
+	n     int // length of the query
+	seg   int // number of vectors in each row
+
+	// score holds the striped biased match scores for
+	// each letter of the alphabet against the query, and
+	// open and ext hold the striped gap open and extension
+	// costs for the query. rowOpen and rowExt hold the
+	// gap open and extension costs for each letter of
+	// the alphabet in the reference, and rowOK records
+	// whether those costs can be held in lanes. valid
+	// masks lanes holding positions within the query.
+	// score is nil if the query scores and gap costs
+	// cannot be held in lanes.
+	score           []uint64
+	open, ext       []uint64
+	rowOpen, rowExt []uint64
+	rowOK           []bool
+	valid           []uint64
+	bias            uint64
+}
+
+// Profile returns a StripedProfile for the query sequence using the receiver's scoring scheme.
+// It returns an error if the scoring matrix is not square, or the query alphabet or data type is
+// not handled.
+func (a SWAffineStriped) Profile(query AlphabetSlicer) (*StripedProfile, error) {
+	alpha := query.Alphabet()
+	if alpha == nil {
+		return nil, ErrNoAlphabet
+	}
+	if alpha.IndexOf(alpha.Gap()) != 0 {
+		return nil, ErrNotGappedAlphabet
+	}
+	la, err := flatten(a.Matrix, alpha)
+	if err != nil {
+		return nil, err
+	}
+	var qSeq []int
+	switch q := query.Slice().(type) {
+	case alphabet.Letters:
+		_, qSeq, err = indexLetters(nil, q, alpha.LetterIndex())
+	case alphabet.QLetters:
+		_, qSeq, err = indexQLetters(nil, q, alpha.LetterIndex())
+	default:
+		return nil, ErrTypeNotHandled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &StripedProfile{
+		alpha:   alpha,
+		let:     len(a.Matrix),
+		la:      la,
+		gapOpen: a.GapOpen,
+		query:   qSeq,
+		n:       len(qSeq),
+		seg:     (len(qSeq) + lanes - 1) / lanes,
+	}
+	p.pack()
+	return p, nil
+}
+
+// pack fills the lane data of the profile. If any query score or gap cost
+// cannot be held in a lane, the lane data is left empty.
+func (p *StripedProfile) pack() {
+	let, la := p.let, p.la
+
+	// Gap costs are held as positive values to be subtracted.
+	for _, q := range p.query {
+		if !inLane(-(p.gapOpen + la[q])) || !inLane(-la[q]) {
+			return
+		}
+	}
+	var lo, hi int
+	rowOpen := make([]uint64, let)
+	rowExt := make([]uint64, let)
+	rowOK := make([]bool, let)
+	for l := 0; l < let; l++ {
+		open, ext := -(p.gapOpen + la[l*let]), -la[l*let]
+		if inLane(open) && inLane(ext) {
+			rowOpen[l], rowExt[l] = broadcast(open), broadcast(ext)
+			rowOK[l] = true
+		}
+		for _, q := range p.query {
+			s := la[l*let+q]
+			if s < lo {
+				lo = s
+			}
+			if s > hi {
+				hi = s
+			}
+		}
+	}
+	bias := -lo
+	if hi+bias > laneMax {
+		return
+	}
+
+	seg := p.seg
+	score := make([]uint64, let*seg)
+	open := make([]uint64, seg)
+	ext := make([]uint64, seg)
+	valid := make([]uint64, seg)
+	for s := 0; s < seg; s++ {
+		for t := 0; t < lanes; t++ {
+			j := t*seg + s
+			if j >= p.n {
+				continue
+			}
+			shift := uint(t * laneBits)
+			q := p.query[j]
+			for l := 0; l < let; l++ {
+				score[l*seg+s] |= uint64(la[l*let+q]+bias) << shift
+			}
+			open[s] |= uint64(-(p.gapOpen + la[q])) << shift
+			ext[s] |= uint64(-la[q]) << shift
+			valid[s] |= laneMax << shift
+		}
+	}
+	p.score = score
+	p.open, p.ext = open, ext
+	p.rowOpen, p.rowExt = rowOpen, rowExt
+	p.rowOK = rowOK
+	p.valid = valid
+	p.bias = broadcast(bias)
+}
+
+// Score returns the score of the best local alignment of reference against the profiled query and
+// the ends of the aligned regions of reference and query. The best alignment is chosen in the same
+// way as by SWAffine. If there is no alignment with a positive score, score, rEnd and qEnd are zero.
+// Score returns an error if the reference alphabet or data type does not match the profile.
+//
+// Scores are calculated four query positions at a time in 16 bit lanes. If the scores or gap
+// costs for the sequences cannot be held in 16 bit lanes or a score overflows, Score falls back
+// to calculating scores one cell at a time.
+func (p *StripedProfile) Score(reference AlphabetSlicer) (score, rEnd, qEnd int, err error) {
+	if reference.Alphabet() != p.alpha {
+		return 0, 0, 0, ErrMismatchedAlphabets
+	}
+	var rSeq []int
+	switch r := reference.Slice().(type) {
+	case alphabet.Letters:
+		rSeq, _, err = indexLetters(r, nil, p.alpha.LetterIndex())
+	case alphabet.QLetters:
+		rSeq, _, err = indexQLetters(r, nil, p.alpha.LetterIndex())
+	default:
+		return 0, 0, 0, ErrTypeNotHandled
+	}
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if p.n == 0 {
+		return 0, 0, 0, nil
+	}
+	if p.packed(rSeq) {
+		var ok bool
+		score, rEnd, qEnd, ok = p.scoreLanes(rSeq)
+		if ok {
+			return score, rEnd, qEnd, nil
+		}
+	}
+	score, rEnd, qEnd = p.scoreCells(rSeq)
+	return score, rEnd, qEnd, nil
+}
+
+// packed returns whether the profile lane data can be used to score rSeq.
+func (p *StripedProfile) packed(rSeq []int) bool {
+	if p.score == nil {
+		return false
+	}
+	for _, r := range rSeq {
+		if !p.rowOK[r] {
+			return false
+		}
+	}
+	return true
+}
+
+// scoreLanes returns the score and end coordinates of the best local alignment of rSeq against
+// the profiled query, calculated in lanes using Farrar's striped layout. Query position t*seg+s
+// is held in lane t of vector s. If a score overflows a lane, ok is returned false.
+func (p *StripedProfile) scoreLanes(rSeq []int) (score, rEnd, qEnd int, ok bool) {
+	var (
+		seg  = p.seg
+		last = seg - 1
+
+		bias      = p.bias
+		valid     = p.valid[:seg]
+		qOpen     = p.open[:seg]
+		qExt      = p.ext[:seg]
+		rOpen     = p.rowOpen
+		rExt      = p.rowExt
+		profile   = p.score
+		lastLanes = uint(lanes-1) * laneBits
+
+		// The diag, up and left layers of the previous and
+		// current table rows, and the diag scores of the
+		// current row that may end the best alignment.
+		buf                 = make([]uint64, 7*seg)
+		mPrev, uPrev, lPrev = buf[:seg], buf[seg : 2*seg], buf[2*seg : 3*seg]
+		mCurr, uCurr, lCurr = buf[3*seg : 4*seg], buf[4*seg : 5*seg], buf[5*seg : 6*seg]
+		cand                = buf[6*seg:]
+	)
+	for i, rVal := range rSeq {
+		var (
+			prof      = profile[rVal*seg : (rVal+1)*seg][:seg]
+			open, ext = rOpen[rVal], rExt[rVal]
+
+			mp, up, lp = mPrev[:seg], uPrev[:seg], lPrev[:seg]
+			mc, uc, lc = mCurr[:seg], uCurr[:seg], lCurr[:seg]
+			cd         = cand[:seg]
+
+			// The diagonal predecessors of the first vector are
+			// in the last vector of the previous row, one lane
+			// down, with the table boundary shifted in to lane 0.
+			md, ud, ld = mp[last] << laneBits, up[last] << laneBits, lp[last] << laneBits
+
+			rowMax, overflow uint64
+		)
+
+		// Calculate the diag and up layers.
+		for s := range mc {
+			g := maxLanes(ud, ld)
+			v := maxLanes(md, g) + prof[s]
+			overflow |= v
+			m := subLanes(v, bias) & valid[s]
+			mc[s] = m
+			c := m & geLanes(md, g)
+			cd[s] = c
+			rowMax = maxLanes(rowMax, c)
+			md, ud, ld = mp[s], up[s], lp[s]
+			uc[s] = maxLanes(subLanes(md, open), subLanes(ud, ext))
+		}
+		if overflow&laneHigh != 0 {
+			return 0, 0, 0, false
+		}
+
+		// Calculate the left layer, first ignoring gaps that extend from
+		// the preceding lane and then lazily extending those gaps until
+		// no score is improved.
+		ml, ll := mc[last]<<laneBits, uint64(0)
+		for s := range lc {
+			ll = maxLanes(subLanes(ml, qOpen[s]), subLanes(ll, qExt[s]))
+			lc[s] = ll
+			ml = mc[s]
+		}
+	lazy:
+		for t := 1; t < lanes; t++ {
+			ll = lc[last] << laneBits
+			for s := range lc {
+				l := subLanes(ll, qExt[s])
+				if geLanes(lc[s], l) == laneAll {
+					break lazy
+				}
+				ll = maxLanes(lc[s], l)
+				lc[s] = ll
+			}
+		}
+
+		if rowMax != 0 {
+			// Fold the row maximum into the last lane.
+			best := maxLanes(rowMax, rowMax<<(2*laneBits))
+			best = maxLanes(best, best<<laneBits) >> lastLanes
+			if int(best) >= score {
+				score, rEnd, qEnd = int(best), i+1, p.lastEnd(cd, rowMax, best)+1
+			}
+		}
+		mPrev, mCurr = mCurr, mPrev
+		uPrev, uCurr = uCurr, uPrev
+		lPrev, lCurr = lCurr, lPrev
+	}
+	return score, rEnd, qEnd, true
+}
+
+// lastEnd returns the greatest query position held in cand with the value v. The
+// lane-wise maximum of cand is given by max.
+func (p *StripedProfile) lastEnd(cand []uint64, max, v uint64) int {
+	t := lanes - 1
+	for max>>uint(t*laneBits)&laneMax != v {
+		t--
+	}
+	shift := uint(t * laneBits)
+	for s := p.seg - 1; s >= 0; s-- {
+		if cand[s]>>shift&laneMax == v {
+			return t*p.seg + s
+		}
+	}
+	panic("align: missing lane value")
+}
+
+// scoreCells returns the score and end coordinates of the best local alignment of rSeq against
+// the profiled query, calculated one cell at a time in linear space.
+func (p *StripedProfile) scoreCells(rSeq []int) (score, rEnd, qEnd int) {
+	var (
+		let, la = p.let, p.la
+		c       = p.n + 1
+
+		// The diag, up and left layers of the previous
+		// and current table rows.
+		buf                 = make([]int, 6*c)
+		mPrev, uPrev, lPrev = buf[:c], buf[c : 2*c], buf[2*c : 3*c]
+		mCurr, uCurr, lCurr = buf[3*c : 4*c], buf[4*c : 5*c], buf[5*c:]
+	)
+	for i, rVal := range rSeq {
+		row := la[rVal*let : (rVal+1)*let]
+		gapR := row[0]
+		for j, qVal := range p.query {
+			diagScore := max3(mPrev[j], uPrev[j], lPrev[j])
+			v := diagScore + row[qVal]
+			if v > 0 {
+				if v >= score && diagScore == mPrev[j] {
+					score, rEnd, qEnd = v, i+1, j+1
+				}
+			} else {
+				v = 0
+			}
+			mCurr[j+1] = v
+
+			v = max2(mPrev[j+1]+p.gapOpen+gapR, uPrev[j+1]+gapR)
+			if v < 0 {
+				v = 0
+			}
+			uCurr[j+1] = v
+
+			gapQ := la[qVal]
+			v = max2(mCurr[j]+p.gapOpen+gapQ, lCurr[j]+gapQ)
+			if v < 0 {
+				v = 0
+			}
+			lCurr[j+1] = v
+		}
+		mPrev, mCurr = mCurr, mPrev
+		uPrev, uCurr = uCurr, uPrev
+		lPrev, lCurr = lCurr, lPrev
+	}
+	return score, rEnd, qEnd
+}
@@ -0,0 +1,525 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package align
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+
+	"fmt"
+)
+
+// AlignN aligns two sequences using the Smith-Waterman algorithm, returning up to n non-intersecting
+// local alignments in order of decreasing score using the declumping method of Waterman and Eggert.
+// The first alignment returned is the alignment returned by Align unless a higher scoring alignment
+// ends with a gap, which can only happen when gap scores are positive. Each subsequent alignment
+// is the best local alignment that does not pass through any table cell used by a previously
+// returned alignment. If n is negative, all non-intersecting alignments with a positive score are
+// returned. AlignN returns an error if the scoring matrix is not square, or the sequence data types
+// or alphabets do not match.
+//
+// Reference:
+//
+// Waterman MS, Eggert M. A new algorithm for best subsequence alignments with application to
+// tRNA-rRNA comparisons. J Mol Biol 197(4):723-728 (1987).
+func (a SW) AlignN(reference, query AlphabetSlicer, n int) ([][]feat.Pair, error) {
+	alpha, rSeq, qSeq, err := indexSeqs(reference, query)
+	if err != nil {
+		return nil, err
+	}
+	la, err := flatten(Linear(a), alpha)
+	if err != nil {
+		return nil, err
+	}
+	r, c := len(rSeq)+1, len(qSeq)+1
+	t := &swTable{
+		let:   len(a),
+		la:    la,
+		rSeq:  rSeq,
+		qSeq:  qSeq,
+		table: make([]int, r*c),
+		mask:  make([]bool, r*c),
+	}
+	return watermanEggert(t, n), nil
+}
+
+// AlignN aligns two sequences using the Smith-Waterman algorithm, returning up to n non-intersecting
+// local alignments in order of decreasing score using the declumping method of Waterman and Eggert.
+// The first alignment returned is the alignment returned by Align unless a higher scoring alignment
+// was not considered by Align, which only considers alignments ending with a match or mismatch that
+// follows another match or mismatch. Each subsequent alignment is the best local alignment that
+// does not pass through any table cell used by a previously returned alignment. If n is negative,
+// all non-intersecting alignments with a positive score are returned. AlignN returns an error if
+// the scoring matrix is not square, or the sequence data types or alphabets do not match.
+func (a SWAffine) AlignN(reference, query AlphabetSlicer, n int) ([][]feat.Pair, error) {
+	alpha, rSeq, qSeq, err := indexSeqs(reference, query)
+	if err != nil {
+		return nil, err
+	}
+	la, err := flatten(a.Matrix, alpha)
+	if err != nil {
+		return nil, err
+	}
+	r, c := len(rSeq)+1, len(qSeq)+1
+	t := &swAffineTable{
+		let:     len(a.Matrix),
+		la:      la,
+		gapOpen: a.GapOpen,
+		rSeq:    rSeq,
+		qSeq:    qSeq,
+		table:   make([][3]int, r*c),
+		mask:    make([]bool, r*c),
+	}
+	return watermanEggert(t, n), nil
+}
+
+// indexSeqs returns the shared alphabet of reference and query and their sequences as
+// alphabet indices.
+func indexSeqs(reference, query AlphabetSlicer) (alpha alphabet.Alphabet, r, q []int, err error) {
+	alpha = reference.Alphabet()
+	if alpha == nil {
+		return nil, nil, nil, ErrNoAlphabet
+	}
+	if alpha != query.Alphabet() {
+		return nil, nil, nil, ErrMismatchedAlphabets
+	}
+	if alpha.IndexOf(alpha.Gap()) != 0 {
+		return nil, nil, nil, ErrNotGappedAlphabet
+	}
+	switch rSeq := reference.Slice().(type) {
+	case alphabet.Letters:
+		qSeq, ok := query.Slice().(alphabet.Letters)
+		if !ok {
+			return nil, nil, nil, ErrMismatchedTypes
+		}
+		r, q, err = indexLetters(rSeq, qSeq, alpha.LetterIndex())
+	case alphabet.QLetters:
+		qSeq, ok := query.Slice().(alphabet.QLetters)
+		if !ok {
+			return nil, nil, nil, ErrMismatchedTypes
+		}
+		r, q, err = indexQLetters(rSeq, qSeq, alpha.LetterIndex())
+	default:
+		return nil, nil, nil, ErrTypeNotHandled
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return alpha, r, q, nil
+}
+
+// declumper is a local alignment dynamic programming table that can exclude cells from
+// subsequent alignments.
+type declumper interface {
+	// fill calculates the table from row i0. Rows after row i1
+	// are only calculated until a row is unchanged.
+	fill(i0, i1 int)
+
+	// best returns the score and end of the best alignment
+	// in the table, and the table layer holding its end.
+	best() (score, i, j, l int)
+
+	// trace returns the alignment ending at (i, j) in layer l
+	// and the first row it uses, excluding the cells it uses
+	// from the table.
+	trace(i, j, l int) (aln []feat.Pair, i0 int)
+}
+
+// watermanEggert returns up to n non-intersecting alignments from t, or all alignments
+// with a positive score if n is negative.
+func watermanEggert(t declumper, n int) [][]feat.Pair {
+	var alns [][]feat.Pair
+	t.fill(1, maxInt)
+	for n < 0 || len(alns) < n {
+		score, i, j, l := t.best()
+		if score == 0 {
+			break
+		}
+		aln, i0 := t.trace(i, j, l)
+		alns = append(alns, aln)
+		t.fill(i0, i)
+	}
+	return alns
+}
+
+// maxInt is the largest int.
+const maxInt = int(^uint(0) >> 1)
+
+// swTable is the linear gap penalty Smith-Waterman dynamic programming table.
+type swTable struct {
+	let int
+	la  []int
+
+	rSeq, qSeq []int
+
+	table []int
+	mask  []bool
+}
+
+func (t *swTable) fill(i0, i1 int) {
+	let, la := t.let, t.la
+	table := t.table
+	c := len(t.qSeq) + 1
+	for i := i0; i <= len(t.rSeq); i++ {
+		rVal := t.rSeq[i-1]
+		changed := false
+		for j := 1; j < c; j++ {
+			p := i*c + j
+			var score int
+			if !t.mask[p] {
+				qVal := t.qSeq[j-1]
+				score = max3(
+					table[p-c-1]+la[rVal*let+qVal],
+					table[p-c]+la[rVal*let],
+					table[p-1]+la[qVal],
+				)
+				if score < 0 {
+					score = 0
+				}
+			}
+			if score != table[p] {
+				table[p] = score
+				changed = true
+			}
+		}
+		if !changed && i > i1 {
+			break
+		}
+	}
+}
+
+// best returns the end chosen by SW.Align unless a higher
+// scoring alignment ends with a gap.
+func (t *swTable) best() (maxS, maxI, maxJ, maxL int) {
+	let, la := t.let, t.la
+	table := t.table
+	c := len(t.qSeq) + 1
+	var gapS, gapI, gapJ int
+	for i := 1; i <= len(t.rSeq); i++ {
+		rVal := t.rSeq[i-1]
+		for j := 1; j < c; j++ {
+			p := i*c + j
+			score := table[p]
+			switch {
+			case score <= 0:
+			case score == table[p-c-1]+la[rVal*let+t.qSeq[j-1]]:
+				if score >= maxS {
+					maxS, maxI, maxJ = score, i, j
+				}
+			case score > gapS:
+				gapS, gapI, gapJ = score, i, j
+			}
+		}
+	}
+	if gapS > maxS {
+		return gapS, gapI, gapJ, diag
+	}
+	return maxS, maxI, maxJ, diag
+}
+
+func (t *swTable) trace(i, j, _ int) ([]feat.Pair, int) {
+	let, la := t.let, t.la
+	table := t.table
+	c := len(t.qSeq) + 1
+
+	var aln []feat.Pair
+	score, last := 0, diag
+	maxI, maxJ := i, j
+	end := i*c + j
+loop:
+	for i > 0 && j > 0 {
+		var (
+			rVal = t.rSeq[i-1]
+			qVal = t.qSeq[j-1]
+		)
+		p := i*c + j
+		if table[p] != 0 {
+			t.mask[p] = true
+		}
+		switch table[p] {
+		case 0:
+			break loop
+		case table[p-c-1] + la[rVal*let+qVal]:
+			if last != diag {
+				aln = append(aln, &featPair{
+					a:     feature{start: i, end: maxI},
+					b:     feature{start: j, end: maxJ},
+					score: score,
+				})
+				maxI, maxJ = i, j
+				score = 0
+			}
+			score += table[p] - table[p-c-1]
+			i--
+			j--
+			last = diag
+		case table[p-c] + la[rVal*let]:
+			if last != up && p != end {
+				aln = append(aln, &featPair{
+					a:     feature{start: i, end: maxI},
+					b:     feature{start: j, end: maxJ},
+					score: score,
+				})
+				maxI, maxJ = i, j
+				score = 0
+			}
+			score += table[p] - table[p-c]
+			i--
+			last = up
+		case table[p-1] + la[qVal]:
+			if last != left && p != end {
+				aln = append(aln, &featPair{
+					a:     feature{start: i, end: maxI},
+					b:     feature{start: j, end: maxJ},
+					score: score,
+				})
+				maxI, maxJ = i, j
+				score = 0
+			}
+			score += table[p] - table[p-1]
+			j--
+			last = left
+		default:
+			panic(fmt.Sprintf("align: waterman eggert internal error: no path at row: %d col:%d\n", i, j))
+		}
+	}
+
+	aln = append(aln, &featPair{
+		a:     feature{start: i, end: maxI},
+		b:     feature{start: j, end: maxJ},
+		score: score,
+	})
+
+	for i, j := 0, len(aln)-1; i < j; i, j = i+1, j-1 {
+		aln[i], aln[j] = aln[j], aln[i]
+	}
+
+	return aln, i + 1
+}
+
+// swAffineTable is the affine gap penalty Smith-Waterman dynamic programming table.
+type swAffineTable struct {
+	let     int
+	la      []int
+	gapOpen int
+
+	rSeq, qSeq []int
+
+	table [][3]int
+	mask  []bool
+}
+
+func (t *swAffineTable) fill(i0, i1 int) {
+	let, la := t.let, t.la
+	table := t.table
+	c := len(t.qSeq) + 1
+	for i := i0; i <= len(t.rSeq); i++ {
+		rVal := t.rSeq[i-1]
+		changed := false
+		for j := 1; j < c; j++ {
+			p := i*c + j
+			var scores [3]int
+			if !t.mask[p] {
+				qVal := t.qSeq[j-1]
+
+				score := max3(table[p-c-1][diag], table[p-c-1][up], table[p-c-1][left]) + la[rVal*let+qVal]
+				if score > 0 {
+					scores[diag] = score
+				}
+
+				score = max2(
+					table[p-c][diag]+t.gapOpen+la[rVal*let],
+					table[p-c][up]+la[rVal*let],
+				)
+				if score > 0 {
+					scores[up] = score
+				}
+
+				score = max2(
+					table[p-1][diag]+t.gapOpen+la[qVal],
+					table[p-1][left]+la[qVal],
+				)
+				if score > 0 {
+					scores[left] = score
+				}
+			}
+			if scores != table[p] {
+				table[p] = scores
+				changed = true
+			}
+		}
+		if !changed && i > i1 {
+			break
+		}
+	}
+}
+
+// best returns the end chosen by SWAffine.Align unless a higher
+// scoring alignment ends elsewhere in any layer of the table.
+func (t *swAffineTable) best() (maxS, maxI, maxJ, maxL int) {
+	table := t.table
+	c := len(t.qSeq) + 1
+	var anyS, anyI, anyJ, anyL int
+	for i := 1; i <= len(t.rSeq); i++ {
+		for j := 1; j < c; j++ {
+			p := i*c + j
+			score := table[p][diag]
+			d := table[p-c-1]
+			if score > 0 && score >= maxS && max3(d[diag], d[up], d[left]) == d[diag] {
+				maxS, maxI, maxJ = score, i, j
+			}
+			for l, score := range table[p] {
+				if score > anyS {
+					anyS, anyI, anyJ, anyL = score, i, j, l
+				}
+			}
+		}
+	}
+	if anyS > maxS {
+		return anyS, anyI, anyJ, anyL
+	}
+	return maxS, maxI, maxJ, diag
+}
+
+func (t *swAffineTable) trace(i, j, l int) ([]feat.Pair, int) {
+	let, la := t.let, t.la
+	table := t.table
+	c := len(t.qSeq) + 1
+
+	var aln []feat.Pair
+	score, last, layer := 0, diag, l
+	maxI, maxJ := i, j
+	end := i*c + j
+loop:
+	for i > 0 && j > 0 {
+		var (
+			rVal = t.rSeq[i-1]
+			qVal = t.qSeq[j-1]
+		)
+		p := i*c + j
+		if table[p][layer] != 0 {
+			t.mask[p] = true
+		}
+		switch table[p][layer] {
+		case 0:
+			break loop
+		case table[p-c][up] + la[rVal*let]:
+			if last != up && p != end {
+				aln = append(aln, &featPair{
+					a:     feature{start: i, end: maxI},
+					b:     feature{start: j, end: maxJ},
+					score: score,
+				})
+				maxI, maxJ = i, j
+				score = 0
+			}
+			score += table[p][layer] - table[p-c][up]
+			i--
+			layer = up
+			last = up
+		case table[p-1][left] + la[qVal]:
+			if last != left && p != end {
+				aln = append(aln, &featPair{
+					a:     feature{start: i, end: maxI},
+					b:     feature{start: j, end: maxJ},
+					score: score,
+				})
+				maxI, maxJ = i, j
+				score = 0
+			}
+			score += table[p][layer] - table[p-1][left]
+			j--
+			layer = left
+			last = left
+		case table[p-c][diag] + t.gapOpen + la[rVal*let]:
+			if last != up && p != end {
+				aln = append(aln, &featPair{
+					a:     feature{start: i, end: maxI},
+					b:     feature{start: j, end: maxJ},
+					score: score,
+				})
+				maxI, maxJ = i, j
+				score = 0
+			}
+			score += table[p][layer] - table[p-c][diag]
+			i--
+			layer = diag
+			last = up
+		case table[p-1][diag] + t.gapOpen + la[qVal]:
+			if last != left && p != end {
+				aln = append(aln, &featPair{
+					a:     feature{start: i, end: maxI},
+					b:     feature{start: j, end: maxJ},
+					score: score,
+				})
+				maxI, maxJ = i, j
+				score = 0
+			}
+			score += table[p][layer] - table[p-1][diag]
+			j--
+			layer = diag
+			last = left
+		case table[p-c-1][diag] + la[rVal*let+qVal]:
+			if last != diag {
+				aln = append(aln, &featPair{
+					a:     feature{start: i, end: maxI},
+					b:     feature{start: j, end: maxJ},
+					score: score,
+				})
+				maxI, maxJ = i, j
+				score = 0
+			}
+			score += table[p][layer] - table[p-c-1][diag]
+			i--
+			j--
+			layer = diag
+			last = diag
+		case table[p-c-1][up] + la[rVal*let+qVal]:
+			if last != diag {
+				aln = append(aln, &featPair{
+					a:     feature{start: i, end: maxI},
+					b:     feature{start: j, end: maxJ},
+					score: score,
+				})
+				maxI, maxJ = i, j
+				score = 0
+			}
+			score += table[p][layer] - table[p-c-1][up]
+			i--
+			j--
+			layer = up
+			last = diag
+		case table[p-c-1][left] + la[rVal*let+qVal]:
+			if last != diag {
+				aln = append(aln, &featPair{
+					a:     feature{start: i, end: maxI},
+					b:     feature{start: j, end: maxJ},
+					score: score,
+				})
+				maxI, maxJ = i, j
+				score = 0
+			}
+			score += table[p][layer] - table[p-c-1][left]
+			i--
+			j--
+			layer = left
+			last = diag
+		default:
+			panic(fmt.Sprintf("align: waterman eggert affine internal error: no path at row: %d col:%d layer:%s\n", i, j, "mul"[layer:layer+1]))
+		}
+	}
+
+	aln = append(aln, &featPair{
+		a:     feature{start: i, end: maxI},
+		b:     feature{start: j, end: maxJ},
+		score: score,
+	})
+
+	for i, j := 0, len(aln)-1; i < j; i, j = i+1, j-1 {
+		aln[i], aln[j] = aln[j], aln[i]
+	}
+
+	return aln, i + 1
+}
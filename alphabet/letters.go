@@ -313,6 +313,9 @@ var phredSolexaTable = func() [256]Qsolexa {
 		if Q > 127 {
 			Q = 127
 		}
+		if Q < -127 {
+			Q = -127
+		}
 		t[q] = Qsolexa(Q)
 	}
 	return t
@@ -411,14 +414,14 @@ func (qs Qsolexa) Qphred() Qphred { return solexaPhredTable[int(qs)+128] }
 // score. Since solexa scores can extend into negative territory, the table is
 // shifted 128 into the positive.
 var solexaPhredTable = func() [256]Qphred {
-	t := [256]Qphred{0: 255, 255: 0}
-	for q := range t[1:255] {
-		qs := q - 127
-		Q := Qphred(10*math.Log10(math.Pow(10, float64(qs)/10)) + 0.5)
+	var t [256]Qphred
+	for q := range t {
+		qs := q - 128
+		Q := 10*math.Log10(math.Pow(10, float64(qs)/10)+1) + 0.5
 		if Q > 254 {
 			Q = 254
 		}
-		t[q+1] = Q
+		t[q] = Qphred(Q)
 	}
 	return t
 }()
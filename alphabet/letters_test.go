@@ -78,6 +78,35 @@ func (s *S) TestSolexa(c *check.C) {
 	c.Check(Qsolexa(127).ProbE(), check.Equals, 0.)
 }
 
+func (s *S) TestSolexaPhredConversion(c *check.C) {
+	for _, t := range []struct {
+		qs Qsolexa
+		qp Qphred
+	}{
+		{qs: -128, qp: 0},
+		{qs: -5, qp: 1},
+		{qs: 0, qp: 3},
+		{qs: 10, qp: 10},
+		{qs: 20, qp: 20},
+		{qs: 127, qp: 127},
+	} {
+		c.Check(t.qs.Qphred(), check.Equals, t.qp, check.Commentf("Qsolexa %d", t.qs))
+	}
+	for _, t := range []struct {
+		qp Qphred
+		qs Qsolexa
+	}{
+		{qp: 0, qs: -127},
+		{qp: 1, qs: -6},
+		{qp: 3, qs: 0},
+		{qp: 10, qs: 10},
+		{qp: 20, qs: 20},
+		{qp: 127, qs: 127},
+	} {
+		c.Check(t.qp.Qsolexa(), check.Equals, t.qs, check.Commentf("Qphred %d", t.qp))
+	}
+}
+
 func (s *S) TestInterconversion(c *check.C) {
 	for q := 0; q < 127; q++ {
 		if 10 <= q && q < 127 {
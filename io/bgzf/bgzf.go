@@ -0,0 +1,111 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bgzf provides types to read and write BGZF block compressed files
+// and their .gzi indexes.
+//
+// BGZF files are valid gzip streams made up of a series of gzip members, each
+// holding at most 64 KiB of data, so they can be read by the standard gzip
+// tools. The compressed size of each member is recorded in a gzip extra field,
+// allowing random access to the uncompressed data via virtual file offsets.
+//
+// The specification can be found in the SAM/BAM format specification at
+// https://samtools.github.io/hts-specs/SAMv1.pdf.
+package bgzf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// BlockSize is the maximum number of uncompressed bytes held in a
+	// block written by a Writer.
+	BlockSize = 0xff00
+
+	// MaxBlockSize is the maximum size of a compressed BGZF block.
+	MaxBlockSize = 0x10000
+
+	headerLen  = 18
+	trailerLen = 8
+)
+
+var (
+	ErrNoBlockSize   = errors.New("bgzf: could not determine block size")
+	ErrBlockOverflow = errors.New("bgzf: block overflow")
+	ErrCorrupt       = errors.New("bgzf: corrupt block")
+	ErrChecksum      = errors.New("bgzf: checksum error")
+	ErrNotASeeker    = errors.New("bgzf: underlying reader is not an io.ReadSeeker")
+	ErrClosed        = errors.New("bgzf: writer closed")
+	ErrBadOffset     = errors.New("bgzf: invalid offset")
+)
+
+// magicBlock is the BGZF end of file marker.
+var magicBlock = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0xff, 0x06, 0x00, 0x42, 0x43, 0x02, 0x00,
+	0x1b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00,
+}
+
+// Offset is a BGZF virtual file offset. File is the offset of the start of a
+// compressed block in the underlying file and Block is the offset of a position
+// within the uncompressed data of that block.
+type Offset struct {
+	File  int64
+	Block uint16
+}
+
+// MakeOffset returns the Offset corresponding to the 64 bit virtual offset v.
+func MakeOffset(v uint64) Offset {
+	return Offset{File: int64(v >> 16), Block: uint16(v)}
+}
+
+// Virtual returns the 64 bit virtual file offset representation of o.
+func (o Offset) Virtual() uint64 { return uint64(o.File)<<16 | uint64(o.Block) }
+
+func (o Offset) String() string { return fmt.Sprintf("%d:%d", o.File, o.Block) }
+
+// Less returns whether o is before p.
+func (o Offset) Less(p Offset) bool {
+	return o.File < p.File || (o.File == p.File && o.Block < p.Block)
+}
+
+// Chunk is a region of a BGZF file between two virtual offsets.
+type Chunk struct {
+	Begin Offset
+	End   Offset
+}
+
+// IsBGZF returns whether the bytes in b begin with a BGZF block header. If b
+// is shorter than a BGZF header IsBGZF returns false.
+func IsBGZF(b []byte) bool {
+	_, err := blockSize(b)
+	return err == nil
+}
+
+// blockSize returns the total size of the block with the header in h. h must
+// hold the complete gzip header including the extra field.
+func blockSize(h []byte) (int, error) {
+	if len(h) < headerLen || h[0] != 0x1f || h[1] != 0x8b || h[2] != 8 || h[3]&0x4 == 0 {
+		return 0, ErrNoBlockSize
+	}
+	xlen := int(binary.LittleEndian.Uint16(h[10:12]))
+	if len(h) < 12+xlen {
+		return 0, ErrNoBlockSize
+	}
+	extra := h[12 : 12+xlen]
+	for len(extra) >= 4 {
+		slen := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+slen {
+			break
+		}
+		if extra[0] == 'B' && extra[1] == 'C' && slen == 2 {
+			return int(binary.LittleEndian.Uint16(extra[4:6])) + 1, nil
+		}
+		extra = extra[4+slen:]
+	}
+	return 0, ErrNoBlockSize
+}
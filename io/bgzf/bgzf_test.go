@@ -0,0 +1,174 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func testData(n int) []byte {
+	rnd := rand.New(rand.NewSource(1))
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = "acgtACGTN\n"[rnd.Intn(10)]
+	}
+	return b
+}
+
+func compress(c *check.C, data []byte, chunk int) ([]byte, *Writer) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for len(data) > 0 {
+		n := chunk
+		if n > len(data) {
+			n = len(data)
+		}
+		_, err := w.Write(data[:n])
+		c.Assert(err, check.Equals, nil)
+		data = data[n:]
+	}
+	c.Assert(w.Close(), check.Equals, nil)
+	return buf.Bytes(), w
+}
+
+func (s *S) TestOffset(c *check.C) {
+	for _, o := range []Offset{{}, {File: 1, Block: 2}, {File: 1 << 40, Block: 0xffff}} {
+		c.Check(MakeOffset(o.Virtual()), check.Equals, o)
+	}
+	c.Check(Offset{File: 1, Block: 3}.Less(Offset{File: 2}), check.Equals, true)
+	c.Check(Offset{File: 2}.Less(Offset{File: 1, Block: 3}), check.Equals, false)
+	c.Check(Offset{File: 2, Block: 1}.Less(Offset{File: 2, Block: 2}), check.Equals, true)
+}
+
+func (s *S) TestRoundTrip(c *check.C) {
+	for _, n := range []int{0, 1, 100, BlockSize, BlockSize + 1, 5*BlockSize + 17} {
+		data := testData(n)
+		for _, chunk := range []int{1 << 10, 1 << 20} {
+			z, _ := compress(c, data, chunk)
+			c.Check(IsBGZF(z), check.Equals, true)
+			c.Check(bytes.HasSuffix(z, magicBlock), check.Equals, true)
+
+			r, err := NewReader(bytes.NewReader(z))
+			c.Assert(err, check.Equals, nil)
+			got, err := ioutil.ReadAll(r)
+			c.Check(err, check.Equals, nil)
+			c.Check(bytes.Equal(got, data), check.Equals, true, check.Commentf("n=%d chunk=%d", n, chunk))
+
+			// BGZF files are valid multi-member gzip files.
+			gz, err := gzip.NewReader(bytes.NewReader(z))
+			c.Assert(err, check.Equals, nil)
+			got, err = ioutil.ReadAll(gz)
+			c.Check(err, check.Equals, nil)
+			c.Check(bytes.Equal(got, data), check.Equals, true)
+		}
+	}
+}
+
+func (s *S) TestNotBGZF(c *check.C) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("not a bgzf file"))
+	gz.Close()
+	c.Check(IsBGZF(buf.Bytes()), check.Equals, false)
+	_, err := NewReader(&buf)
+	c.Check(err, check.Equals, ErrNoBlockSize)
+}
+
+func (s *S) TestChecksum(c *check.C) {
+	z, _ := compress(c, testData(100), 100)
+	z[len(z)-len(magicBlock)-trailerLen] ^= 0xff
+	_, err := NewReader(bytes.NewReader(z))
+	c.Check(err, check.Equals, ErrChecksum)
+}
+
+func (s *S) TestSeek(c *check.C) {
+	var (
+		buf  bytes.Buffer
+		offs []Offset
+		recs []string
+	)
+	w := NewWriter(&buf)
+	for i := 0; i < 20000; i++ {
+		offs = append(offs, w.Offset())
+		rec := fmt.Sprintf("record %d\n", i)
+		recs = append(recs, rec)
+		_, err := io.WriteString(w, rec)
+		c.Assert(err, check.Equals, nil)
+	}
+	c.Assert(w.Close(), check.Equals, nil)
+	c.Assert(offs[len(offs)-1].File > 0, check.Equals, true)
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	c.Assert(err, check.Equals, nil)
+	for i := range recs {
+		c.Check(r.Offset(), check.Equals, offs[i])
+		b := make([]byte, len(recs[i]))
+		_, err = io.ReadFull(r, b)
+		c.Assert(err, check.Equals, nil)
+	}
+	for _, i := range []int{19999, 0, 5000, 5001, 17, 12345, 12345} {
+		c.Assert(r.Seek(offs[i]), check.Equals, nil)
+		b := make([]byte, len(recs[i]))
+		_, err = io.ReadFull(r, b)
+		c.Assert(err, check.Equals, nil)
+		c.Check(string(b), check.Equals, recs[i])
+	}
+
+	r, err = NewReader(bytes.NewBufferString(buf.String()))
+	c.Assert(err, check.Equals, nil)
+	c.Check(r.Seek(offs[10]), check.Equals, ErrNotASeeker)
+}
+
+func (s *S) TestIndex(c *check.C) {
+	data := testData(7*BlockSize + 1001)
+	z, w := compress(c, data, 1<<12)
+
+	idx, err := BuildIndex(bytes.NewReader(z))
+	c.Assert(err, check.Equals, nil)
+	c.Check(idx, check.DeepEquals, w.Index())
+	c.Check(len(idx), check.Equals, 8)
+
+	var buf bytes.Buffer
+	n, err := idx.WriteTo(&buf)
+	c.Assert(err, check.Equals, nil)
+	c.Check(n, check.Equals, int64(buf.Len()))
+	got, err := ReadIndex(&buf)
+	c.Assert(err, check.Equals, nil)
+	c.Check(got, check.DeepEquals, idx)
+
+	ra := NewReaderAt(bytes.NewReader(z), idx)
+	for _, t := range []struct{ off, n int }{
+		{0, 10},
+		{BlockSize - 5, 10},
+		{3*BlockSize + 7, 2*BlockSize + 3},
+		{0, len(data)},
+		{len(data) - 10, 10},
+	} {
+		p := make([]byte, t.n)
+		n, err := ra.ReadAt(p, int64(t.off))
+		c.Check(err, check.Equals, nil)
+		c.Check(n, check.Equals, t.n)
+		c.Check(bytes.Equal(p, data[t.off:t.off+t.n]), check.Equals, true)
+	}
+	p := make([]byte, 20)
+	m, err := ra.ReadAt(p, int64(len(data)-10))
+	c.Check(err, check.Equals, io.EOF)
+	c.Check(m, check.Equals, 10)
+}
@@ -0,0 +1,85 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// readBlock reads a complete raw BGZF block from r into buf, reallocating buf if
+// necessary, and returns the block.
+func readBlock(r io.Reader, buf []byte) ([]byte, error) {
+	if cap(buf) < MaxBlockSize {
+		buf = make([]byte, MaxBlockSize)
+	}
+	buf = buf[:12]
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = ErrCorrupt
+		}
+		return nil, err
+	}
+	if buf[0] != 0x1f || buf[1] != 0x8b || buf[2] != 0x08 || buf[3]&0x04 == 0 {
+		return nil, ErrNoBlockSize
+	}
+	xlen := int(binary.LittleEndian.Uint16(buf[10:12]))
+	buf = buf[:12+xlen]
+	if _, err := io.ReadFull(r, buf[12:]); err != nil {
+		return nil, ErrCorrupt
+	}
+	size, err := blockSize(buf)
+	if err != nil {
+		return nil, err
+	}
+	if size < len(buf)+trailerLen || size > MaxBlockSize {
+		return nil, ErrCorrupt
+	}
+	n := len(buf)
+	buf = buf[:size]
+	if _, err := io.ReadFull(r, buf[n:]); err != nil {
+		return nil, ErrCorrupt
+	}
+	return buf, nil
+}
+
+// decompressor decompresses raw BGZF blocks.
+type decompressor struct {
+	fr  io.ReadCloser
+	src bytes.Reader
+}
+
+// inflate decompresses the raw block into dst, reallocating dst if necessary,
+// and returns the uncompressed data.
+func (d *decompressor) inflate(dst, raw []byte) ([]byte, error) {
+	xlen := int(binary.LittleEndian.Uint16(raw[10:12]))
+	trailer := raw[len(raw)-trailerLen:]
+	sum := binary.LittleEndian.Uint32(trailer[:4])
+	isize := int(binary.LittleEndian.Uint32(trailer[4:]))
+	if isize > MaxBlockSize {
+		return nil, ErrCorrupt
+	}
+	if cap(dst) < isize {
+		dst = make([]byte, isize, MaxBlockSize)
+	}
+	dst = dst[:isize]
+
+	d.src.Reset(raw[12+xlen : len(raw)-trailerLen])
+	if d.fr == nil {
+		d.fr = flate.NewReader(&d.src)
+	} else if err := d.fr.(flate.Resetter).Reset(&d.src, nil); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(d.fr, dst); err != nil {
+		return nil, ErrCorrupt
+	}
+	if crc32.ChecksumIEEE(dst) != sum {
+		return nil, ErrChecksum
+	}
+	return dst, nil
+}
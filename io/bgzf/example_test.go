@@ -0,0 +1,88 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf_test
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/io/bgzf"
+	"github.com/biogo/biogo/io/seqio"
+	"github.com/biogo/biogo/io/seqio/fai"
+	"github.com/biogo/biogo/io/seqio/fasta"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"fmt"
+	"io"
+)
+
+const fa = `>chr1 first chromosome
+GATTACAGATTACAGATT
+ACAGATTACAGATTACAG
+ATT
+>chr2
+CCCCCGGGGGAAAAATTT
+TT
+`
+
+func compress(data string) []byte {
+	var buf bytes.Buffer
+	w := bgzf.NewWriter(&buf)
+	io.WriteString(w, data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func Example_reader() {
+	z := compress(fa)
+
+	r, err := bgzf.NewReader(bytes.NewReader(z))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	sc := seqio.NewScanner(fasta.NewReader(r, linear.NewSeq("", nil, alphabet.DNA)))
+	for sc.Next() {
+		s := sc.Seq()
+		fmt.Printf("%s %d\n", s.Name(), s.Len())
+	}
+	if err := sc.Error(); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// chr1 39
+	// chr2 20
+}
+
+func Example_faidx() {
+	z := compress(fa)
+
+	gzi, err := bgzf.BuildIndex(bytes.NewReader(z))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	r, err := bgzf.NewReader(bytes.NewReader(z))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	idx, err := fai.NewIndex(r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	f := fai.NewFile(bgzf.NewReaderAt(bytes.NewReader(z), gzi), idx, alphabet.DNA)
+	s, err := f.Region("chr1:15-25")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%v [%d,%d)\n", s, s.Start(), s.End())
+
+	// Output:
+	// "chr1" GATTACAGATT [14,25)
+}
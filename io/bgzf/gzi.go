@@ -0,0 +1,167 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+// IndexEntry is a single .gzi index entry, relating the file offset of the
+// start of a compressed block to the offset of its data in the uncompressed
+// stream.
+type IndexEntry struct {
+	Compressed   uint64
+	Uncompressed uint64
+}
+
+// Index is a .gzi index of a BGZF file. As in the .gzi format, the implicit
+// first block at offset zero is not included in the Index.
+type Index []IndexEntry
+
+// ReadIndex returns an Index read from the .gzi formatted stream provided by r.
+func ReadIndex(r io.Reader) (Index, error) {
+	var n uint64
+	err := binary.Read(r, binary.LittleEndian, &n)
+	if err != nil {
+		return nil, err
+	}
+	idx := make(Index, n)
+	err = binary.Read(r, binary.LittleEndian, idx)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// WriteTo writes the Index to w in .gzi format.
+func (idx Index) WriteTo(w io.Writer) (int64, error) {
+	err := binary.Write(w, binary.LittleEndian, uint64(len(idx)))
+	if err != nil {
+		return 0, err
+	}
+	err = binary.Write(w, binary.LittleEndian, idx)
+	if err != nil {
+		return 8, err
+	}
+	return int64(8 + 16*len(idx)), nil
+}
+
+// BuildIndex returns an Index of the BGZF stream provided by r. The block data
+// is not decompressed, so BuildIndex does not validate the block contents.
+func BuildIndex(r io.Reader) (Index, error) {
+	var (
+		idx  Index
+		buf  []byte
+		file uint64
+		data uint64
+	)
+	for {
+		raw, err := readBlock(r, buf)
+		if err == io.EOF {
+			return idx, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf = raw
+		file += uint64(len(raw))
+		isize := binary.LittleEndian.Uint32(raw[len(raw)-4:])
+		if isize == 0 {
+			continue
+		}
+		data += uint64(isize)
+		idx = append(idx, IndexEntry{Compressed: file, Uncompressed: data})
+	}
+}
+
+// block returns the compressed and uncompressed offsets of the start of the
+// block containing the uncompressed offset off.
+func (idx Index) block(off uint64) IndexEntry {
+	i := sort.Search(len(idx), func(i int) bool { return idx[i].Uncompressed > off })
+	if i == 0 {
+		return IndexEntry{}
+	}
+	return idx[i-1]
+}
+
+// ReaderAt provides random access to the uncompressed data of a BGZF file
+// using a .gzi Index. A ReaderAt can be used wherever an io.ReaderAt of the
+// uncompressed data is required, for example to construct a fai.File on a
+// bgzip compressed FASTA file.
+type ReaderAt struct {
+	r   io.ReaderAt
+	idx Index
+	d   decompressor
+
+	raw  []byte
+	data []byte
+
+	// cached is the compressed offset of the
+	// cached block in data and start is its
+	// uncompressed offset.
+	cached uint64
+	start  uint64
+	valid  bool
+}
+
+// NewReaderAt returns a ReaderAt that reads from the BGZF file provided by r
+// using the Index idx.
+func NewReaderAt(r io.ReaderAt, idx Index) *ReaderAt {
+	return &ReaderAt{r: r, idx: idx}
+}
+
+// ReadAt reads len(p) bytes of uncompressed data into p starting at the
+// uncompressed offset off. It satisfies io.ReaderAt, but is not safe for
+// concurrent use.
+func (ra *ReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrBadOffset
+	}
+	var n int
+	for n < len(p) {
+		pos := uint64(off) + uint64(n)
+		b := ra.idx.block(pos)
+		if err := ra.load(b); err != nil {
+			return n, err
+		}
+		if pos < ra.start || pos-ra.start >= uint64(len(ra.data)) {
+			return n, io.EOF
+		}
+		n += copy(p[n:], ra.data[pos-ra.start:])
+	}
+	return n, nil
+}
+
+// load reads and decompresses the block described by b, skipping any empty
+// blocks, unless it is already cached.
+func (ra *ReaderAt) load(b IndexEntry) error {
+	if ra.valid && ra.cached == b.Compressed {
+		return nil
+	}
+	ra.valid = false
+	off := int64(b.Compressed)
+	for {
+		raw, err := readBlock(io.NewSectionReader(ra.r, off, MaxBlockSize), ra.raw)
+		if err != nil {
+			return err
+		}
+		ra.raw = raw
+		ra.data, err = ra.d.inflate(ra.data, raw)
+		if err != nil {
+			return err
+		}
+		if len(ra.data) != 0 {
+			break
+		}
+		off += int64(len(raw))
+	}
+	ra.cached, ra.start, ra.valid = b.Compressed, b.Uncompressed, true
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"io"
+)
+
+// Reader implements BGZF block decompression. A Reader can be used wherever
+// an io.Reader of the uncompressed data is required.
+type Reader struct {
+	r io.Reader
+	d decompressor
+
+	raw  []byte
+	data []byte
+	pos  int
+
+	// block is the file offset of the current block
+	// and next is the offset of the following block.
+	block, next int64
+
+	err error
+}
+
+// NewReader returns a new Reader reading BGZF blocks from r. The first block
+// is read by NewReader and an error is returned if it is not a valid BGZF
+// block.
+func NewReader(r io.Reader) (*Reader, error) {
+	bg := &Reader{r: r}
+	err := bg.nextBlock()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	bg.err = err
+	return bg, nil
+}
+
+// nextBlock reads and decompresses the block following the current block.
+func (bg *Reader) nextBlock() error {
+	raw, err := readBlock(bg.r, bg.raw)
+	if err != nil {
+		bg.data, bg.pos = bg.data[:0], 0
+		return err
+	}
+	bg.raw = raw
+	bg.data, err = bg.d.inflate(bg.data, raw)
+	if err != nil {
+		return err
+	}
+	bg.pos = 0
+	bg.block = bg.next
+	bg.next += int64(len(raw))
+	return nil
+}
+
+// Read reads uncompressed data into p, returning the number of bytes read and
+// any error. At the end of the BGZF stream Read returns io.EOF.
+func (bg *Reader) Read(p []byte) (int, error) {
+	var n int
+	for n < len(p) {
+		if bg.pos == len(bg.data) {
+			if bg.err != nil {
+				break
+			}
+			bg.err = bg.nextBlock()
+			continue
+		}
+		c := copy(p[n:], bg.data[bg.pos:])
+		bg.pos += c
+		n += c
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, bg.err
+}
+
+// ReadByte reads and returns the next uncompressed byte.
+func (bg *Reader) ReadByte() (byte, error) {
+	for bg.pos == len(bg.data) {
+		if bg.err != nil {
+			return 0, bg.err
+		}
+		bg.err = bg.nextBlock()
+	}
+	b := bg.data[bg.pos]
+	bg.pos++
+	return b, nil
+}
+
+// Offset returns the virtual offset of the next byte to be read.
+func (bg *Reader) Offset() Offset {
+	if bg.pos == len(bg.data) {
+		return Offset{File: bg.next}
+	}
+	return Offset{File: bg.block, Block: uint16(bg.pos)}
+}
+
+// Seek moves the Reader to the virtual offset off. The underlying reader must
+// be an io.ReadSeeker.
+func (bg *Reader) Seek(off Offset) error {
+	rs, ok := bg.r.(io.ReadSeeker)
+	if !ok {
+		return ErrNotASeeker
+	}
+	if off.File != bg.block || len(bg.data) == 0 {
+		_, err := rs.Seek(off.File, 0)
+		if err != nil {
+			return err
+		}
+		bg.next = off.File
+		bg.err = bg.nextBlock()
+		if bg.err != nil && bg.err != io.EOF {
+			return bg.err
+		}
+	}
+	if int(off.Block) > len(bg.data) {
+		return ErrBadOffset
+	}
+	bg.pos = int(off.Block)
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bgzf
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// Writer implements BGZF block compression. Data written to a Writer is
+// buffered into blocks of at most BlockSize bytes which are compressed and
+// written to the underlying io.Writer as complete BGZF blocks.
+type Writer struct {
+	w     io.Writer
+	level int
+
+	fw   *flate.Writer
+	buf  []byte
+	comp bytes.Buffer
+
+	// file is the number of compressed bytes
+	// written and data is the number of
+	// uncompressed bytes written in complete
+	// blocks.
+	file int64
+	data int64
+
+	idx Index
+
+	closed bool
+}
+
+// NewWriter returns a new Writer writing BGZF blocks to w with the default
+// compression level.
+func NewWriter(w io.Writer) *Writer {
+	bg, _ := NewWriterLevel(w, flate.DefaultCompression)
+	return bg
+}
+
+// NewWriterLevel returns a new Writer writing BGZF blocks to w with the given
+// compression level. Valid levels are those accepted by compress/flate.
+func NewWriterLevel(w io.Writer, level int) (*Writer, error) {
+	fw, err := flate.NewWriter(nil, level)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		w:     w,
+		level: level,
+		fw:    fw,
+		buf:   make([]byte, 0, BlockSize),
+	}, nil
+}
+
+// Write writes p to the BGZF stream, returning the number of bytes written and
+// any error.
+func (bg *Writer) Write(p []byte) (int, error) {
+	if bg.closed {
+		return 0, ErrClosed
+	}
+	var n int
+	for len(p) > 0 {
+		c := copy(bg.buf[len(bg.buf):cap(bg.buf)], p)
+		bg.buf = bg.buf[:len(bg.buf)+c]
+		p = p[c:]
+		n += c
+		if len(bg.buf) == cap(bg.buf) {
+			if err := bg.Flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Offset returns the virtual offset of the next byte to be written.
+func (bg *Writer) Offset() Offset {
+	return Offset{File: bg.file, Block: uint16(len(bg.buf))}
+}
+
+// Index returns a .gzi index of the blocks written so far.
+func (bg *Writer) Index() Index { return append(Index(nil), bg.idx...) }
+
+// Flush writes any buffered data to the underlying io.Writer as a complete
+// block. Flush is a no-op if there is no buffered data.
+func (bg *Writer) Flush() error {
+	if bg.closed {
+		return ErrClosed
+	}
+	if len(bg.buf) == 0 {
+		return nil
+	}
+	err := bg.writeBlock(bg.buf)
+	bg.buf = bg.buf[:0]
+	return err
+}
+
+// Close flushes any buffered data and writes the BGZF end of file marker. It
+// does not close the underlying io.Writer.
+func (bg *Writer) Close() error {
+	if bg.closed {
+		return nil
+	}
+	err := bg.Flush()
+	bg.closed = true
+	if err != nil {
+		return err
+	}
+	_, err = bg.w.Write(magicBlock)
+	return err
+}
+
+func (bg *Writer) writeBlock(p []byte) error {
+	bg.comp.Reset()
+	bg.comp.Write([]byte{
+		0x1f, 0x8b, 0x08, 0x04, // ID1, ID2, CM, FLG
+		0x00, 0x00, 0x00, 0x00, // MTIME
+		0x00, 0xff, // XFL, OS
+		0x06, 0x00, // XLEN
+		'B', 'C', 0x02, 0x00, // SI1, SI2, SLEN
+		0x00, 0x00, // BSIZE (filled below)
+	})
+	bg.fw.Reset(&bg.comp)
+	if _, err := bg.fw.Write(p); err != nil {
+		return err
+	}
+	if err := bg.fw.Close(); err != nil {
+		return err
+	}
+	var trailer [trailerLen]byte
+	binary.LittleEndian.PutUint32(trailer[:4], crc32.ChecksumIEEE(p))
+	binary.LittleEndian.PutUint32(trailer[4:], uint32(len(p)))
+	bg.comp.Write(trailer[:])
+
+	block := bg.comp.Bytes()
+	if len(block) > MaxBlockSize {
+		return ErrBlockOverflow
+	}
+	binary.LittleEndian.PutUint16(block[16:18], uint16(len(block)-1))
+	if _, err := bg.w.Write(block); err != nil {
+		return err
+	}
+
+	bg.file += int64(len(block))
+	bg.data += int64(len(p))
+	bg.idx = append(bg.idx, IndexEntry{Compressed: uint64(bg.file), Uncompressed: uint64(bg.data)})
+	return nil
+}
@@ -0,0 +1,620 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bam provides types to read and write BAM binary alignment files
+// and their .bai indexes.
+//
+// BAM records are represented by the types of the sam package. The
+// specification can be found at https://samtools.github.io/hts-specs/SAMv1.pdf.
+package bam
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/bgzf"
+	"github.com/biogo/biogo/io/featio"
+	"github.com/biogo/biogo/io/featio/sam"
+
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ featio.Reader = (*Reader)(nil)
+	_ featio.Writer = (*Writer)(nil)
+	_ featio.Reader = (*Iterator)(nil)
+)
+
+var (
+	ErrNoMagic     = errors.New("bam: missing magic number")
+	ErrCorrupt     = errors.New("bam: corrupt record")
+	ErrRefMismatch = errors.New("bam: reference length mismatch")
+	ErrUnknownRef  = errors.New("bam: unknown reference")
+	ErrNotHandled  = errors.New("bam: type not handled")
+)
+
+var bamMagic = [4]byte{'B', 'A', 'M', 0x1}
+
+const (
+	cigarOps = "MIDNSHP=X"
+	seqCodes = "=ACMGRSVTWYHKDBN"
+)
+
+var seqIndex [256]byte
+
+func init() {
+	for i := range seqIndex {
+		seqIndex[i] = 0xf
+	}
+	for i, c := range []byte(seqCodes) {
+		seqIndex[c] = byte(i)
+		seqIndex[c|0x20] = byte(i)
+	}
+}
+
+// Reader implements BAM data reading.
+type Reader struct {
+	r *bgzf.Reader
+
+	// Header is the header of the BAM stream.
+	Header *sam.Header
+
+	refs  []*sam.Reference
+	index map[*sam.Reference]int
+
+	last bgzf.Chunk
+	buf  []byte
+}
+
+// NewReader returns a new Reader reading BAM data from r. The BAM header is
+// read before NewReader returns. References listed in the binary header but
+// not in the header text are added to Header as @SQ lines.
+func NewReader(r io.Reader) (*Reader, error) {
+	bg, err := bgzf.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	br := &Reader{r: bg, index: make(map[*sam.Reference]int)}
+
+	var magic [4]byte
+	err = binary.Read(bg, binary.LittleEndian, &magic)
+	if err != nil || magic != bamMagic {
+		return nil, ErrNoMagic
+	}
+	text, err := br.readBytes()
+	if err != nil {
+		return nil, err
+	}
+	sr, err := sam.NewReader(bytes.NewReader(bytes.TrimRight(text, "\x00")))
+	if err != nil {
+		return nil, err
+	}
+	br.Header = sr.Header
+
+	var n int32
+	err = binary.Read(bg, binary.LittleEndian, &n)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < int(n); i++ {
+		name, err := br.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		var l int32
+		err = binary.Read(bg, binary.LittleEndian, &l)
+		if err != nil {
+			return nil, err
+		}
+		sn := string(bytes.TrimRight(name, "\x00"))
+		ref := br.Header.Ref(sn)
+		switch {
+		case ref == nil:
+			err = br.Header.Add(&sam.HeaderLine{Type: "SQ", Tags: []sam.Tag{
+				{Tag: "SN", Value: sn},
+				{Tag: "LN", Value: strconv.Itoa(int(l))},
+			}})
+			if err != nil {
+				return nil, err
+			}
+			ref = br.Header.Ref(sn)
+		case ref.SeqLen < 0:
+			ref.SeqLen = int(l)
+		case ref.SeqLen != int(l):
+			return nil, ErrRefMismatch
+		}
+		br.index[ref] = len(br.refs)
+		br.refs = append(br.refs, ref)
+	}
+	return br, nil
+}
+
+// readBytes reads a length prefixed byte slice.
+func (br *Reader) readBytes() ([]byte, error) {
+	var n int32
+	err := binary.Read(br.r, binary.LittleEndian, &n)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, ErrCorrupt
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(br.r, b)
+	return b, err
+}
+
+// Refs returns the references of the BAM stream in the order of their
+// reference IDs.
+func (br *Reader) Refs() []*sam.Reference { return br.refs }
+
+// Read reads a single alignment and returns it as a *sam.Record, or an
+// error.
+func (br *Reader) Read() (feat.Feature, error) {
+	begin := br.r.Offset()
+	var size [4]byte
+	n, err := io.ReadFull(br.r, size[:])
+	if err != nil {
+		if err == io.EOF && n == 0 {
+			return nil, io.EOF
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	l := int(int32(binary.LittleEndian.Uint32(size[:])))
+	if l < 32 {
+		return nil, ErrCorrupt
+	}
+	if cap(br.buf) < l {
+		br.buf = make([]byte, l)
+	}
+	br.buf = br.buf[:l]
+	_, err = io.ReadFull(br.r, br.buf)
+	if err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	rec, err := br.decode(br.buf)
+	if err != nil {
+		return nil, err
+	}
+	br.last = bgzf.Chunk{Begin: begin, End: br.r.Offset()}
+	return rec, nil
+}
+
+// LastChunk returns the region of the BAM stream holding the record most
+// recently returned by Read.
+func (br *Reader) LastChunk() bgzf.Chunk { return br.last }
+
+// Seek moves the Reader to the virtual offset off. The underlying reader
+// must be an io.ReadSeeker.
+func (br *Reader) Seek(off bgzf.Offset) error { return br.r.Seek(off) }
+
+func (br *Reader) ref(id int32) (*sam.Reference, error) {
+	if id == -1 {
+		return nil, nil
+	}
+	if id < 0 || int(id) >= len(br.refs) {
+		return nil, ErrCorrupt
+	}
+	return br.refs[id], nil
+}
+
+func (br *Reader) decode(b []byte) (*sam.Record, error) {
+	le := binary.LittleEndian
+	var (
+		rec = &sam.Record{
+			Pos:     int(int32(le.Uint32(b[4:8]))),
+			MapQ:    b[9],
+			Flags:   sam.Flags(le.Uint16(b[14:16])),
+			MatePos: int(int32(le.Uint32(b[24:28]))),
+			TempLen: int(int32(le.Uint32(b[28:32]))),
+		}
+		lName  = int(b[8])
+		nCigar = int(le.Uint16(b[12:14]))
+		lSeq   = int(int32(le.Uint32(b[16:20])))
+		err    error
+	)
+	rec.Ref, err = br.ref(int32(le.Uint32(b[0:4])))
+	if err != nil {
+		return nil, err
+	}
+	rec.MateRef, err = br.ref(int32(le.Uint32(b[20:24])))
+	if err != nil {
+		return nil, err
+	}
+	if lName == 0 || lSeq < 0 || len(b) < 32+lName+4*nCigar+(lSeq+1)/2+lSeq {
+		return nil, ErrCorrupt
+	}
+	b = b[32:]
+	rec.QName = string(b[:lName-1])
+	b = b[lName:]
+
+	if nCigar != 0 {
+		rec.Cigar = make(sam.Cigar, nCigar)
+		for i := range rec.Cigar {
+			op := le.Uint32(b[4*i:])
+			if int(op&0xf) >= len(cigarOps) {
+				return nil, ErrCorrupt
+			}
+			rec.Cigar[i] = sam.CigarOp{Type: sam.CigarOpType(cigarOps[op&0xf]), Len: int(op >> 4)}
+		}
+		b = b[4*nCigar:]
+	}
+
+	if lSeq != 0 {
+		rec.Seq = make([]byte, lSeq)
+		for i := range rec.Seq {
+			c := b[i>>1]
+			if i&1 == 0 {
+				c >>= 4
+			}
+			rec.Seq[i] = seqCodes[c&0xf]
+		}
+		b = b[(lSeq+1)/2:]
+		if b[0] != 0xff {
+			rec.Qual = append([]byte(nil), b[:lSeq]...)
+		}
+		b = b[lSeq:]
+	}
+
+	for len(b) != 0 {
+		var a sam.Aux
+		a, b, err = decodeAux(b)
+		if err != nil {
+			return nil, err
+		}
+		rec.Aux = append(rec.Aux, a)
+	}
+	return rec, nil
+}
+
+// auxSize returns the size in bytes of binary aux values of type t.
+func auxSize(t byte) int {
+	switch t {
+	case 'A', 'c', 'C':
+		return 1
+	case 's', 'S':
+		return 2
+	case 'i', 'I', 'f':
+		return 4
+	}
+	return 0
+}
+
+func decodeAux(b []byte) (sam.Aux, []byte, error) {
+	if len(b) < 4 {
+		return sam.Aux{}, nil, ErrCorrupt
+	}
+	a := sam.Aux{Tag: [2]byte{b[0], b[1]}, Type: b[2]}
+	t := b[2]
+	b = b[3:]
+	switch t {
+	case 'Z', 'H':
+		i := bytes.IndexByte(b, 0)
+		if i < 0 {
+			return sam.Aux{}, nil, ErrCorrupt
+		}
+		if t == 'Z' {
+			a.Value = string(b[:i])
+		} else {
+			v, err := hex.DecodeString(string(b[:i]))
+			if err != nil {
+				return sam.Aux{}, nil, ErrCorrupt
+			}
+			a.Value = v
+		}
+		return a, b[i+1:], nil
+	case 'B':
+		if len(b) < 5 {
+			return sam.Aux{}, nil, ErrCorrupt
+		}
+		st := b[0]
+		n := int(binary.LittleEndian.Uint32(b[1:5]))
+		sz := auxSize(st)
+		b = b[5:]
+		if sz == 0 || st == 'A' || n < 0 || len(b) < n*sz {
+			return sam.Aux{}, nil, ErrCorrupt
+		}
+		a.Value = decodeArray(st, n, b)
+		return a, b[n*sz:], nil
+	}
+	sz := auxSize(t)
+	if sz == 0 || len(b) < sz {
+		return sam.Aux{}, nil, ErrCorrupt
+	}
+	le := binary.LittleEndian
+	switch t {
+	case 'A':
+		a.Value = b[0]
+	case 'c':
+		a.Type, a.Value = 'i', int(int8(b[0]))
+	case 'C':
+		a.Type, a.Value = 'i', int(b[0])
+	case 's':
+		a.Type, a.Value = 'i', int(int16(le.Uint16(b)))
+	case 'S':
+		a.Type, a.Value = 'i', int(le.Uint16(b))
+	case 'i':
+		a.Type, a.Value = 'i', int(int32(le.Uint32(b)))
+	case 'I':
+		a.Type, a.Value = 'i', int(le.Uint32(b))
+	case 'f':
+		a.Value = math.Float32frombits(le.Uint32(b))
+	}
+	return a, b[sz:], nil
+}
+
+func decodeArray(t byte, n int, b []byte) interface{} {
+	le := binary.LittleEndian
+	switch t {
+	case 'c':
+		v := make([]int8, n)
+		for i := range v {
+			v[i] = int8(b[i])
+		}
+		return v
+	case 'C':
+		return append([]uint8(nil), b[:n]...)
+	case 's':
+		v := make([]int16, n)
+		for i := range v {
+			v[i] = int16(le.Uint16(b[2*i:]))
+		}
+		return v
+	case 'S':
+		v := make([]uint16, n)
+		for i := range v {
+			v[i] = le.Uint16(b[2*i:])
+		}
+		return v
+	case 'i':
+		v := make([]int32, n)
+		for i := range v {
+			v[i] = int32(le.Uint32(b[4*i:]))
+		}
+		return v
+	case 'I':
+		v := make([]uint32, n)
+		for i := range v {
+			v[i] = le.Uint32(b[4*i:])
+		}
+		return v
+	default:
+		v := make([]float32, n)
+		for i := range v {
+			v[i] = math.Float32frombits(le.Uint32(b[4*i:]))
+		}
+		return v
+	}
+}
+
+// Writer implements BAM data writing.
+type Writer struct {
+	bg *bgzf.Writer
+
+	refs  map[*sam.Reference]int32
+	names map[string]int32
+
+	buf bytes.Buffer
+}
+
+// NewWriter returns a new Writer writing BAM data with the header h to w
+// with the default compression level. The header is written before
+// NewWriter returns.
+func NewWriter(w io.Writer, h *sam.Header) (*Writer, error) {
+	return NewWriterLevel(w, h, -1)
+}
+
+// NewWriterLevel returns a new Writer writing BAM data with the header h to
+// w with the given compression level.
+func NewWriterLevel(w io.Writer, h *sam.Header, level int) (*Writer, error) {
+	bg, err := bgzf.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, err
+	}
+	bw := &Writer{
+		bg:    bg,
+		refs:  make(map[*sam.Reference]int32),
+		names: make(map[string]int32),
+	}
+
+	var text bytes.Buffer
+	for _, l := range h.Lines {
+		text.WriteString(l.String())
+		text.WriteByte('\n')
+	}
+	b := &bw.buf
+	b.Write(bamMagic[:])
+	binary.Write(b, binary.LittleEndian, int32(text.Len()))
+	b.Write(text.Bytes())
+	binary.Write(b, binary.LittleEndian, int32(len(h.Refs)))
+	for i, r := range h.Refs {
+		binary.Write(b, binary.LittleEndian, int32(len(r.SeqName)+1))
+		b.WriteString(r.SeqName)
+		b.WriteByte(0)
+		binary.Write(b, binary.LittleEndian, int32(r.SeqLen))
+		bw.refs[r] = int32(i)
+		bw.names[r.SeqName] = int32(i)
+	}
+	_, err = bg.Write(b.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	// Start the first record in a new block.
+	err = bg.Flush()
+	if err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+func (bw *Writer) refID(r *sam.Reference) (int32, error) {
+	if r == nil {
+		return -1, nil
+	}
+	if id, ok := bw.refs[r]; ok {
+		return id, nil
+	}
+	if id, ok := bw.names[r.SeqName]; ok {
+		return id, nil
+	}
+	return 0, ErrUnknownRef
+}
+
+// Write writes a single record and return the number of uncompressed bytes
+// written and any error. Only *sam.Record values are handled.
+func (bw *Writer) Write(f feat.Feature) (n int, err error) {
+	rec, ok := f.(*sam.Record)
+	if !ok {
+		return 0, ErrNotHandled
+	}
+	refID, err := bw.refID(rec.Ref)
+	if err != nil {
+		return 0, err
+	}
+	mateID, err := bw.refID(rec.MateRef)
+	if err != nil {
+		return 0, err
+	}
+	if len(rec.QName) > 254 || len(rec.Cigar) > 0xffff {
+		return 0, ErrCorrupt
+	}
+	if rec.Qual != nil && len(rec.Qual) != len(rec.Seq) {
+		return 0, sam.ErrLenMismatch
+	}
+
+	end := rec.Pos + rec.Cigar.RefLen()
+	if end == rec.Pos {
+		end++
+	}
+	b := &bw.buf
+	b.Reset()
+	le := binary.LittleEndian
+	var h [36]byte
+	le.PutUint32(h[4:], uint32(refID))
+	le.PutUint32(h[8:], uint32(int32(rec.Pos)))
+	h[12] = byte(len(rec.QName) + 1)
+	h[13] = rec.MapQ
+	le.PutUint16(h[14:], uint16(reg2bin(rec.Pos, end)))
+	le.PutUint16(h[16:], uint16(len(rec.Cigar)))
+	le.PutUint16(h[18:], uint16(rec.Flags))
+	le.PutUint32(h[20:], uint32(len(rec.Seq)))
+	le.PutUint32(h[24:], uint32(mateID))
+	le.PutUint32(h[28:], uint32(int32(rec.MatePos)))
+	le.PutUint32(h[32:], uint32(int32(rec.TempLen)))
+	b.Write(h[:])
+	b.WriteString(rec.QName)
+	b.WriteByte(0)
+	for _, op := range rec.Cigar {
+		i := strings.IndexByte(cigarOps, byte(op.Type))
+		if i < 0 {
+			return 0, sam.ErrBadCigar
+		}
+		binary.Write(b, le, uint32(op.Len)<<4|uint32(i))
+	}
+	for i := 0; i < len(rec.Seq); i += 2 {
+		c := seqIndex[rec.Seq[i]] << 4
+		if i+1 < len(rec.Seq) {
+			c |= seqIndex[rec.Seq[i+1]]
+		}
+		b.WriteByte(c)
+	}
+	if rec.Qual != nil {
+		b.Write(rec.Qual)
+	} else {
+		for range rec.Seq {
+			b.WriteByte(0xff)
+		}
+	}
+	for _, a := range rec.Aux {
+		err = encodeAux(b, a)
+		if err != nil {
+			return 0, err
+		}
+	}
+	p := b.Bytes()
+	le.PutUint32(p, uint32(len(p)-4))
+	return bw.bg.Write(p)
+}
+
+func encodeAux(b *bytes.Buffer, a sam.Aux) error {
+	le := binary.LittleEndian
+	b.Write(a.Tag[:])
+	switch v := a.Value.(type) {
+	case byte:
+		if a.Type != 'A' {
+			return sam.ErrBadAux
+		}
+		b.WriteByte('A')
+		b.WriteByte(v)
+	case int:
+		switch {
+		case v < math.MinInt32 || v > math.MaxUint32:
+			return sam.ErrBadAux
+		case v < math.MinInt16:
+			b.WriteByte('i')
+			binary.Write(b, le, int32(v))
+		case v < math.MinInt8:
+			b.WriteByte('s')
+			binary.Write(b, le, int16(v))
+		case v < 0:
+			b.WriteByte('c')
+			b.WriteByte(byte(int8(v)))
+		case v <= math.MaxUint8:
+			b.WriteByte('C')
+			b.WriteByte(byte(v))
+		case v <= math.MaxUint16:
+			b.WriteByte('S')
+			binary.Write(b, le, uint16(v))
+		default:
+			b.WriteByte('I')
+			binary.Write(b, le, uint32(v))
+		}
+	case float32:
+		b.WriteByte('f')
+		binary.Write(b, le, v)
+	case string:
+		b.WriteByte('Z')
+		b.WriteString(v)
+		b.WriteByte(0)
+	case []byte:
+		if a.Type == 'H' {
+			b.WriteByte('H')
+			b.WriteString(strings.ToUpper(hex.EncodeToString(v)))
+			b.WriteByte(0)
+			break
+		}
+		writeArray(b, 'C', len(v), v)
+	case []int8:
+		writeArray(b, 'c', len(v), v)
+	case []int16:
+		writeArray(b, 's', len(v), v)
+	case []uint16:
+		writeArray(b, 'S', len(v), v)
+	case []int32:
+		writeArray(b, 'i', len(v), v)
+	case []uint32:
+		writeArray(b, 'I', len(v), v)
+	case []float32:
+		writeArray(b, 'f', len(v), v)
+	default:
+		return sam.ErrBadAux
+	}
+	return nil
+}
+
+func writeArray(b *bytes.Buffer, t byte, n int, v interface{}) {
+	b.WriteByte('B')
+	b.WriteByte(t)
+	binary.Write(b, binary.LittleEndian, int32(n))
+	binary.Write(b, binary.LittleEndian, v)
+}
+
+// Close writes any buffered data and the BGZF end of file marker. It does
+// not close the underlying io.Writer.
+func (bw *Writer) Close() error { return bw.bg.Close() }
@@ -0,0 +1,244 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/io/featio/sam"
+
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const samText = `@HD	VN:1.6	SO:coordinate
+@SQ	SN:ref	LN:45
+@SQ	SN:ref2	LN:1000
+@RG	ID:rg1	SM:sample1
+@CO	a comment
+r001	99	ref	7	30	8M2I4M1D3M	=	37	39	TTAGATAAAGGATACTG	*	NM:i:3	XA:A:x	XN:i:-40000	XU:i:70000
+r002	0	ref	9	30	3S6M1P1I4M	*	0	0	AAAAGATAAGGATA	*	RG:Z:rg1
+r003	2064	ref	29	17	6H5M	ref2	10	0	TAGGC	!#%'+	XB:B:c,-1,2,3	XF:f:1.5	XH:H:1AE3	XS:B:S,1,65535	XG:B:f,0.5,-2
+r004	4	*	0	255	*	*	0	0	CTCAAN	*
+`
+
+func readSAM(c *check.C, text string) (*sam.Header, []*sam.Record) {
+	r, err := sam.NewReader(strings.NewReader(text))
+	c.Assert(err, check.Equals, nil)
+	var recs []*sam.Record
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		recs = append(recs, f.(*sam.Record))
+	}
+	return r.Header, recs
+}
+
+func writeBAM(c *check.C, h *sam.Header, recs []*sam.Record) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, h)
+	c.Assert(err, check.Equals, nil)
+	for _, r := range recs {
+		_, err = w.Write(r)
+		c.Assert(err, check.Equals, nil)
+	}
+	c.Assert(w.Close(), check.Equals, nil)
+	return buf.Bytes()
+}
+
+func (s *S) TestRoundTrip(c *check.C) {
+	h, recs := readSAM(c, samText)
+	b := writeBAM(c, h, recs)
+
+	r, err := NewReader(bytes.NewReader(b))
+	c.Assert(err, check.Equals, nil)
+	c.Check(len(r.Refs()), check.Equals, 2)
+	c.Check(r.Refs()[1].Len(), check.Equals, 1000)
+
+	var buf bytes.Buffer
+	w := sam.NewWriter(&buf)
+	_, err = w.WriteHeader(r.Header)
+	c.Assert(err, check.Equals, nil)
+	var got []*sam.Record
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		got = append(got, f.(*sam.Record))
+		_, err = w.Write(f)
+		c.Assert(err, check.Equals, nil)
+	}
+	c.Check(buf.String(), check.Equals, samText)
+
+	c.Assert(len(got), check.Equals, 4)
+	q := got[2].QSeq(alphabet.DNA)
+	c.Check(q.Name(), check.Equals, "r003")
+	c.Check(q.Seq, check.DeepEquals, alphabet.QLetters{{L: 'T', Q: 0}, {L: 'A', Q: 2}, {L: 'G', Q: 4}, {L: 'G', Q: 6}, {L: 'C', Q: 10}})
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	_, err := NewReader(bytes.NewReader(nil))
+	c.Check(err, check.Not(check.Equals), nil)
+
+	h, recs := readSAM(c, samText)
+	b := writeBAM(c, h, recs[:1])
+	r, err := NewReader(bytes.NewReader(b))
+	c.Assert(err, check.Equals, nil)
+	rec, err := r.Read()
+	c.Assert(err, check.Equals, nil)
+	c.Check(rec.Name(), check.Equals, "r001")
+	_, err = r.Read()
+	c.Check(err, check.Equals, io.EOF)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, &sam.Header{})
+	c.Assert(err, check.Equals, nil)
+	_, err = w.Write(recs[0])
+	c.Check(err, check.Equals, ErrUnknownRef)
+}
+
+func (s *S) TestReg2Bin(c *check.C) {
+	for _, t := range []struct {
+		beg, end, bin int
+	}{
+		{-1, 0, 4680},
+		{0, 1, 4681},
+		{0, 1 << 14, 4681},
+		{0, 1<<14 + 1, 585},
+		{1 << 26, 1<<26 + 1, 4681 + 1<<12},
+		{0, 1 << 29, 0},
+	} {
+		c.Check(reg2bin(t.beg, t.end), check.Equals, t.bin, check.Commentf("[%d,%d)", t.beg, t.end))
+		if t.beg >= 0 {
+			found := false
+			for _, b := range reg2bins(t.beg, t.end) {
+				found = found || b == t.bin
+			}
+			c.Check(found, check.Equals, true)
+		}
+	}
+}
+
+func (s *S) TestIndex(c *check.C) {
+	rnd := rand.New(rand.NewSource(1))
+	h := &sam.Header{}
+	for _, l := range []*sam.HeaderLine{
+		{Type: "HD", Tags: []sam.Tag{{Tag: "VN", Value: "1.6"}, {Tag: "SO", Value: "coordinate"}}},
+		{Type: "SQ", Tags: []sam.Tag{{Tag: "SN", Value: "chr1"}, {Tag: "LN", Value: "200000"}}},
+		{Type: "SQ", Tags: []sam.Tag{{Tag: "SN", Value: "chr2"}, {Tag: "LN", Value: "100000"}}},
+		{Type: "SQ", Tags: []sam.Tag{{Tag: "SN", Value: "chr3"}, {Tag: "LN", Value: "100000"}}},
+	} {
+		c.Assert(h.Add(l), check.Equals, nil)
+	}
+	var recs []*sam.Record
+	seq := []byte(strings.Repeat("ACGT", 25))
+	for i, ref := range h.Refs[:2] {
+		pos := 0
+		for j := 0; j < 1500; j++ {
+			pos += rnd.Intn(100)
+			l := 1 + rnd.Intn(len(seq))
+			if rnd.Intn(50) == 0 {
+				l = 20000
+			}
+			rec := &sam.Record{
+				QName: fmt.Sprintf("r%d.%d", i, j),
+				Ref:   ref,
+				Pos:   pos,
+				MapQ:  30,
+				Cigar: sam.Cigar{{Type: sam.CigarMatch, Len: l}},
+				Seq:   seq[:l%len(seq)+1],
+			}
+			rec.Cigar[0].Len = len(rec.Seq)
+			if l == 20000 {
+				rec.Cigar = sam.Cigar{{Type: sam.CigarMatch, Len: 1}, {Type: sam.CigarSkipped, Len: 20000}, {Type: sam.CigarMatch, Len: len(rec.Seq) - 1}}
+			}
+			if j%100 == 0 {
+				rec.Flags = sam.Unmapped
+				rec.Cigar = nil
+			}
+			recs = append(recs, rec)
+		}
+	}
+	for j := 0; j < 10; j++ {
+		recs = append(recs, &sam.Record{QName: fmt.Sprintf("u%d", j), Flags: sam.Unmapped, Pos: -1, MatePos: -1, MapQ: 0xff})
+	}
+	b := writeBAM(c, h, recs)
+
+	r, err := NewReader(bytes.NewReader(b))
+	c.Assert(err, check.Equals, nil)
+	built, err := BuildIndex(r)
+	c.Assert(err, check.Equals, nil)
+	var buf bytes.Buffer
+	_, err = built.WriteTo(&buf)
+	c.Assert(err, check.Equals, nil)
+	idx, err := ReadIndex(&buf)
+	c.Assert(err, check.Equals, nil)
+	c.Check(idx.NumRefs(), check.Equals, 3)
+	c.Check(idx.Unmapped, check.Equals, uint64(10))
+	st, ok := idx.ReferenceStats(0)
+	c.Check(ok, check.Equals, true)
+	c.Check(st.Mapped, check.Equals, uint64(1485))
+	c.Check(st.Unmapped, check.Equals, uint64(15))
+	_, ok = idx.ReferenceStats(2)
+	c.Check(ok, check.Equals, false)
+
+	r, err = NewReader(bytes.NewReader(b))
+	c.Assert(err, check.Equals, nil)
+	for q := 0; q < 50; q++ {
+		refID := rnd.Intn(3)
+		beg := rnd.Intn(80000)
+		end := beg + 1 + rnd.Intn(5000)
+		var want []string
+		for _, rec := range recs {
+			if rec.Ref != h.Refs[refID] {
+				continue
+			}
+			e := rec.End()
+			if e == rec.Pos {
+				e++
+			}
+			if rec.Pos < end && e > beg {
+				want = append(want, rec.QName)
+			}
+		}
+		it, err := NewIterator(r, idx, r.Refs()[refID], beg, end)
+		c.Assert(err, check.Equals, nil)
+		var got []string
+		for {
+			f, err := it.Read()
+			if err == io.EOF {
+				break
+			}
+			c.Assert(err, check.Equals, nil)
+			got = append(got, f.Name())
+		}
+		c.Check(got, check.DeepEquals, want, check.Commentf("chr%d:[%d,%d)", refID+1, beg, end))
+	}
+
+	_, err = NewIterator(r, idx, h.Refs[0], 0, 1)
+	c.Check(err, check.Equals, ErrUnknownRef)
+
+	r, err = NewReader(bytes.NewReader(writeBAM(c, h, []*sam.Record{recs[1], recs[0]})))
+	c.Assert(err, check.Equals, nil)
+	_, err = BuildIndex(r)
+	c.Check(err, check.Equals, ErrNotSorted)
+}
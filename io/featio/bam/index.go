@@ -0,0 +1,432 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bam
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/bgzf"
+	"github.com/biogo/biogo/io/featio/sam"
+
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+)
+
+var (
+	ErrNoIndexMagic = errors.New("bam: missing index magic number")
+	ErrNotSorted    = errors.New("bam: records not sorted by coordinate")
+)
+
+var baiMagic = [4]byte{'B', 'A', 'I', 0x1}
+
+const (
+	// statsBin is the pseudo-bin used by samtools
+	// to hold reference statistics.
+	statsBin = 37450
+
+	// tileShift is the log2 size of the linear
+	// index windows.
+	tileShift = 14
+)
+
+// ReferenceStats holds the mapping statistics of a reference.
+type ReferenceStats struct {
+	// Chunk is the region of the BAM file
+	// holding records placed on the reference.
+	Chunk bgzf.Chunk
+
+	// Mapped and Unmapped are the number of
+	// mapped and placed unmapped records.
+	Mapped, Unmapped uint64
+}
+
+// Index is a .bai BAM index.
+type Index struct {
+	refs []refIndex
+
+	// Unmapped is the number of unplaced
+	// unmapped records.
+	Unmapped uint64
+
+	lastRef, lastPos int
+}
+
+type refIndex struct {
+	bins      map[uint32][]bgzf.Chunk
+	intervals []bgzf.Offset
+	stats     *ReferenceStats
+}
+
+// NumRefs returns the number of references described by the index.
+func (i *Index) NumRefs() int { return len(i.refs) }
+
+// ReferenceStats returns the mapping statistics of the reference with the
+// given ID, and whether they are available.
+func (i *Index) ReferenceStats(id int) (ReferenceStats, bool) {
+	if id < 0 || id >= len(i.refs) || i.refs[id].stats == nil {
+		return ReferenceStats{}, false
+	}
+	return *i.refs[id].stats, true
+}
+
+// BuildIndex reads the remaining records from r and returns an index of
+// them. The records must be sorted by coordinate.
+func BuildIndex(r *Reader) (*Index, error) {
+	idx := &Index{refs: make([]refIndex, len(r.refs)), lastRef: -1}
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec := f.(*sam.Record)
+		id := -1
+		if rec.Ref != nil {
+			id = r.index[rec.Ref]
+		}
+		err = idx.add(id, rec, r.LastChunk())
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, ref := range idx.refs {
+		var last bgzf.Offset
+		for j, o := range ref.intervals {
+			if o == (bgzf.Offset{}) {
+				ref.intervals[j] = last
+			} else {
+				last = o
+			}
+		}
+	}
+	return idx, nil
+}
+
+func (i *Index) add(id int, rec *sam.Record, c bgzf.Chunk) error {
+	if id < 0 {
+		i.Unmapped++
+		i.lastRef = len(i.refs)
+		return nil
+	}
+	if id < i.lastRef || (id == i.lastRef && rec.Pos < i.lastPos) {
+		return ErrNotSorted
+	}
+	i.lastRef, i.lastPos = id, rec.Pos
+
+	ref := &i.refs[id]
+	if ref.bins == nil {
+		ref.bins = make(map[uint32][]bgzf.Chunk)
+		ref.stats = &ReferenceStats{Chunk: c}
+	}
+	if rec.Flags&sam.Unmapped == 0 {
+		ref.stats.Mapped++
+	} else {
+		ref.stats.Unmapped++
+	}
+	ref.stats.Chunk.End = c.End
+
+	beg, end := rec.Pos, rec.Pos+rec.Cigar.RefLen()
+	if end == beg {
+		end++
+	}
+	bin := uint32(reg2bin(beg, end))
+	chunks := ref.bins[bin]
+	if n := len(chunks); n != 0 && chunks[n-1].End.File == c.Begin.File {
+		chunks[n-1].End = c.End
+	} else {
+		ref.bins[bin] = append(chunks, c)
+	}
+
+	for w := beg >> tileShift; w <= (end-1)>>tileShift; w++ {
+		for len(ref.intervals) <= w {
+			ref.intervals = append(ref.intervals, bgzf.Offset{})
+		}
+		if ref.intervals[w] == (bgzf.Offset{}) {
+			ref.intervals[w] = c.Begin
+		}
+	}
+	return nil
+}
+
+// Chunks returns the regions of the BAM file that may hold records
+// overlapping the half open interval [beg, end) of the reference with the
+// given ID.
+func (i *Index) Chunks(id, beg, end int) []bgzf.Chunk {
+	if id < 0 || id >= len(i.refs) || i.refs[id].bins == nil {
+		return nil
+	}
+	ref := i.refs[id]
+	var min bgzf.Offset
+	if n := len(ref.intervals); n != 0 {
+		w := beg >> tileShift
+		if w >= n {
+			w = n - 1
+		}
+		min = ref.intervals[w]
+	}
+	var chunks []bgzf.Chunk
+	for _, b := range reg2bins(beg, end) {
+		for _, c := range ref.bins[uint32(b)] {
+			if min.Less(c.End) {
+				chunks = append(chunks, c)
+			}
+		}
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	sort.Sort(byBegin(chunks))
+	merged := chunks[:1]
+	for _, c := range chunks[1:] {
+		last := &merged[len(merged)-1]
+		if !last.End.Less(c.Begin) {
+			if last.End.Less(c.End) {
+				last.End = c.End
+			}
+			continue
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+type byBegin []bgzf.Chunk
+
+func (c byBegin) Len() int           { return len(c) }
+func (c byBegin) Less(i, j int) bool { return c[i].Begin.Less(c[j].Begin) }
+func (c byBegin) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+type uint32s []uint32
+
+func (u uint32s) Len() int           { return len(u) }
+func (u uint32s) Less(i, j int) bool { return u[i] < u[j] }
+func (u uint32s) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+
+// reg2bin returns the smallest bin containing the half open interval
+// [beg, end).
+func reg2bin(beg, end int) int {
+	end--
+	switch {
+	case beg>>14 == end>>14:
+		return ((1<<15)-1)/7 + (beg >> 14)
+	case beg>>17 == end>>17:
+		return ((1<<12)-1)/7 + (beg >> 17)
+	case beg>>20 == end>>20:
+		return ((1<<9)-1)/7 + (beg >> 20)
+	case beg>>23 == end>>23:
+		return ((1<<6)-1)/7 + (beg >> 23)
+	case beg>>26 == end>>26:
+		return ((1<<3)-1)/7 + (beg >> 26)
+	}
+	return 0
+}
+
+// reg2bins returns the bins that may hold records overlapping the half open
+// interval [beg, end).
+func reg2bins(beg, end int) []int {
+	if beg < 0 {
+		beg = 0
+	}
+	end--
+	list := []int{0}
+	for _, l := range []struct{ off, shift uint }{
+		{1, 26}, {9, 23}, {73, 20}, {585, 17}, {4681, 14},
+	} {
+		for k := int(l.off) + beg>>l.shift; k <= int(l.off)+end>>l.shift; k++ {
+			list = append(list, k)
+		}
+	}
+	return list
+}
+
+// ReadIndex reads a .bai index from r.
+func ReadIndex(r io.Reader) (*Index, error) {
+	var magic [4]byte
+	err := binary.Read(r, binary.LittleEndian, &magic)
+	if err != nil || magic != baiMagic {
+		return nil, ErrNoIndexMagic
+	}
+	var n int32
+	err = binary.Read(r, binary.LittleEndian, &n)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, ErrCorrupt
+	}
+	idx := &Index{refs: make([]refIndex, n)}
+	for i := range idx.refs {
+		ref := &idx.refs[i]
+		var nBin int32
+		err = binary.Read(r, binary.LittleEndian, &nBin)
+		if err != nil {
+			return nil, err
+		}
+		if nBin > 0 {
+			ref.bins = make(map[uint32][]bgzf.Chunk)
+		}
+		for j := 0; j < int(nBin); j++ {
+			var h struct {
+				Bin    uint32
+				NChunk int32
+			}
+			err = binary.Read(r, binary.LittleEndian, &h)
+			if err != nil {
+				return nil, err
+			}
+			if h.NChunk < 0 {
+				return nil, ErrCorrupt
+			}
+			v := make([]uint64, 2*h.NChunk)
+			err = binary.Read(r, binary.LittleEndian, v)
+			if err != nil {
+				return nil, err
+			}
+			if h.Bin == statsBin && h.NChunk == 2 {
+				ref.stats = &ReferenceStats{
+					Chunk:    bgzf.Chunk{Begin: bgzf.MakeOffset(v[0]), End: bgzf.MakeOffset(v[1])},
+					Mapped:   v[2],
+					Unmapped: v[3],
+				}
+				continue
+			}
+			chunks := make([]bgzf.Chunk, h.NChunk)
+			for k := range chunks {
+				chunks[k] = bgzf.Chunk{Begin: bgzf.MakeOffset(v[2*k]), End: bgzf.MakeOffset(v[2*k+1])}
+			}
+			ref.bins[h.Bin] = chunks
+		}
+		var nIntv int32
+		err = binary.Read(r, binary.LittleEndian, &nIntv)
+		if err != nil {
+			return nil, err
+		}
+		if nIntv < 0 {
+			return nil, ErrCorrupt
+		}
+		v := make([]uint64, nIntv)
+		err = binary.Read(r, binary.LittleEndian, v)
+		if err != nil {
+			return nil, err
+		}
+		ref.intervals = make([]bgzf.Offset, nIntv)
+		for k, o := range v {
+			ref.intervals[k] = bgzf.MakeOffset(o)
+		}
+	}
+	err = binary.Read(r, binary.LittleEndian, &idx.Unmapped)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// WriteTo writes the index to w in .bai format.
+func (i *Index) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	le := binary.LittleEndian
+	b.Write(baiMagic[:])
+	binary.Write(&b, le, int32(len(i.refs)))
+	for _, ref := range i.refs {
+		bins := make(uint32s, 0, len(ref.bins))
+		for bin := range ref.bins {
+			bins = append(bins, bin)
+		}
+		sort.Sort(bins)
+		n := len(bins)
+		if ref.stats != nil {
+			n++
+		}
+		binary.Write(&b, le, int32(n))
+		for _, bin := range bins {
+			chunks := ref.bins[bin]
+			binary.Write(&b, le, bin)
+			binary.Write(&b, le, int32(len(chunks)))
+			for _, c := range chunks {
+				binary.Write(&b, le, [2]uint64{c.Begin.Virtual(), c.End.Virtual()})
+			}
+		}
+		if s := ref.stats; s != nil {
+			binary.Write(&b, le, uint32(statsBin))
+			binary.Write(&b, le, int32(2))
+			binary.Write(&b, le, [4]uint64{s.Chunk.Begin.Virtual(), s.Chunk.End.Virtual(), s.Mapped, s.Unmapped})
+		}
+		binary.Write(&b, le, int32(len(ref.intervals)))
+		for _, o := range ref.intervals {
+			binary.Write(&b, le, o.Virtual())
+		}
+	}
+	binary.Write(&b, le, i.Unmapped)
+	n, err := w.Write(b.Bytes())
+	return int64(n), err
+}
+
+// Iterator returns the records of a BAM file overlapping a reference
+// region using an Index.
+type Iterator struct {
+	r        *Reader
+	ref      *sam.Reference
+	beg, end int
+
+	chunks []bgzf.Chunk
+	cur    *bgzf.Chunk
+}
+
+// NewIterator returns an Iterator reading the records of r that overlap the
+// half open interval [beg, end) of ref using the index idx. The reader
+// underlying r must be an io.ReadSeeker.
+func NewIterator(r *Reader, idx *Index, ref *sam.Reference, beg, end int) (*Iterator, error) {
+	id, ok := r.index[ref]
+	if !ok {
+		return nil, ErrUnknownRef
+	}
+	return &Iterator{r: r, ref: ref, beg: beg, end: end, chunks: idx.Chunks(id, beg, end)}, nil
+}
+
+// Read returns the next overlapping record as a *sam.Record. At the end of
+// the region Read returns io.EOF.
+func (it *Iterator) Read() (feat.Feature, error) {
+	for {
+		if it.cur == nil {
+			if len(it.chunks) == 0 {
+				return nil, io.EOF
+			}
+			it.cur = &it.chunks[0]
+			it.chunks = it.chunks[1:]
+			err := it.r.Seek(it.cur.Begin)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !it.r.r.Offset().Less(it.cur.End) {
+			it.cur = nil
+			continue
+		}
+		f, err := it.r.Read()
+		if err == io.EOF {
+			it.cur = nil
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec := f.(*sam.Record)
+		if rec.Ref != it.ref || rec.Pos >= it.end {
+			it.cur = nil
+			continue
+		}
+		end := rec.End()
+		if end == rec.Pos {
+			end++
+		}
+		if end > it.beg {
+			return rec, nil
+		}
+	}
+}
@@ -0,0 +1,643 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bbi provides types to read UCSC bigWig and bigBed files.
+//
+// bigWig and bigBed files share the BBI container format: a chromosome
+// B+ tree, compressed data blocks indexed by an R-tree and a set of zoom
+// levels holding precomputed summaries of the data at decreasing
+// resolution. Both file types allow queries over a chromosome range for
+// raw data or summary statistics.
+//
+// The format is described in Kent et al. (2010) doi:10.1093/bioinformatics/btq351.
+package bbi
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+)
+
+const (
+	bigWigMagic    = 0x888ffc26
+	bigBedMagic    = 0x8789f2eb
+	chromTreeMagic = 0x78ca8c91
+	rTreeMagic     = 0x2468ace0
+)
+
+var (
+	ErrBadMagic     = errors.New("bbi: bad magic number")
+	ErrCorrupt      = errors.New("bbi: corrupt file")
+	ErrUnknownChrom = errors.New("bbi: unknown chromosome")
+	ErrBadRange     = errors.New("bbi: invalid query range")
+)
+
+// header is the BBI file header.
+type header struct {
+	Magic             uint32
+	Version           uint16
+	ZoomLevels        uint16
+	ChromTreeOffset   uint64
+	FullDataOffset    uint64
+	FullIndexOffset   uint64
+	FieldCount        uint16
+	DefinedFieldCount uint16
+	AutoSQLOffset     uint64
+	TotalSummary      uint64
+	UncompressBufSize uint32
+	ExtensionOffset   uint64
+}
+
+// zoomHeader describes a zoom level.
+type zoomHeader struct {
+	ReductionLevel uint32
+	Reserved       uint32
+	DataOffset     uint64
+	IndexOffset    uint64
+}
+
+// summaryRecord is the stored form of a total summary.
+type summaryRecord struct {
+	BasesCovered uint64
+	Min, Max     float64
+	Sum, SumSq   float64
+}
+
+// zoomRecord is a zoom level data record.
+type zoomRecord struct {
+	ChromID    uint32
+	Start, End uint32
+	Valid      uint32
+	Min, Max   float32
+	Sum, SumSq float32
+}
+
+type chromTreeHeader struct {
+	Magic     uint32
+	BlockSize uint32
+	KeySize   uint32
+	ValSize   uint32
+	ItemCount uint64
+	Reserved  uint64
+}
+
+type rTreeHeader struct {
+	Magic         uint32
+	BlockSize     uint32
+	ItemCount     uint64
+	StartChromIx  uint32
+	StartBase     uint32
+	EndChromIx    uint32
+	EndBase       uint32
+	EndFileOffset uint64
+	ItemsPerSlot  uint32
+	Reserved      uint32
+}
+
+type nodeHeader struct {
+	IsLeaf   uint8
+	Reserved uint8
+	Count    uint16
+}
+
+type rTreeBounds struct {
+	StartChromIx uint32
+	StartBase    uint32
+	EndChromIx   uint32
+	EndBase      uint32
+}
+
+// overlaps returns whether the query on chromosome id over [start, end)
+// overlaps the bounds.
+func (b rTreeBounds) overlaps(id, start, end uint32) bool {
+	return less(id, start, b.EndChromIx, b.EndBase) && less(b.StartChromIx, b.StartBase, id, end)
+}
+
+func less(aHi, aLo, bHi, bLo uint32) bool {
+	return aHi < bHi || (aHi == bHi && aLo < bLo)
+}
+
+// Chrom describes a chromosome held in a BBI file.
+type Chrom struct {
+	Name   string
+	ID     int
+	Length int
+}
+
+// Summary holds summary statistics for a chromosome region. Valid is the
+// number of bases in the region with data; when zoom level data are used it
+// may be fractional since zoom records are apportioned between regions by
+// their overlap. Min and Max are NaN for regions without data.
+type Summary struct {
+	Chrom      string
+	Start, End int
+
+	Valid      float64
+	Min, Max   float64
+	Sum, SumSq float64
+}
+
+// Mean returns the mean value of the bases with data in the region.
+func (s Summary) Mean() float64 { return s.Sum / s.Valid }
+
+// Std returns the standard deviation of the values of the bases with data in
+// the region.
+func (s Summary) Std() float64 {
+	if s.Valid <= 1 {
+		return 0
+	}
+	v := (s.SumSq - s.Sum*s.Sum/s.Valid) / (s.Valid - 1)
+	if v < 0 {
+		return 0
+	}
+	return math.Sqrt(v)
+}
+
+// interval is a half-open interval of a chromosome with a value.
+type interval struct {
+	start, end int
+	value      float64
+}
+
+// File holds the data common to bigWig and bigBed files.
+type File struct {
+	r     io.ReaderAt
+	order binary.ByteOrder
+	hdr   header
+	zooms []zoomHeader
+
+	chroms []Chrom
+	byName map[string]int
+
+	// raw returns the data for a region in the
+	// form used to calculate summaries.
+	raw func(id uint32, start, end int) ([]interval, error)
+}
+
+func newFile(r io.ReaderAt, magic uint32) (*File, error) {
+	f := &File{r: r, byName: make(map[string]int)}
+	var m [4]byte
+	_, err := r.ReadAt(m[:], 0)
+	if err != nil {
+		if err == io.EOF {
+			err = ErrCorrupt
+		}
+		return nil, err
+	}
+	switch {
+	case binary.LittleEndian.Uint32(m[:]) == magic:
+		f.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(m[:]) == magic:
+		f.order = binary.BigEndian
+	default:
+		return nil, ErrBadMagic
+	}
+
+	sr := io.NewSectionReader(r, 0, 1<<63-1)
+	err = binary.Read(sr, f.order, &f.hdr)
+	if err != nil {
+		return nil, corrupt(err)
+	}
+	f.zooms = make([]zoomHeader, f.hdr.ZoomLevels)
+	err = binary.Read(sr, f.order, f.zooms)
+	if err != nil {
+		return nil, corrupt(err)
+	}
+	sort.Sort(byReduction(f.zooms))
+
+	err = f.readChroms()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func corrupt(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrCorrupt
+	}
+	return err
+}
+
+type byReduction []zoomHeader
+
+func (z byReduction) Len() int           { return len(z) }
+func (z byReduction) Less(i, j int) bool { return z[i].ReductionLevel < z[j].ReductionLevel }
+func (z byReduction) Swap(i, j int)      { z[i], z[j] = z[j], z[i] }
+
+// readAt reads binary data into v from the file at offset off.
+func (f *File) readAt(off int64, v interface{}) error {
+	return corrupt(binary.Read(io.NewSectionReader(f.r, off, 1<<63-1-off), f.order, v))
+}
+
+func (f *File) readChroms() error {
+	var h chromTreeHeader
+	off := int64(f.hdr.ChromTreeOffset)
+	err := f.readAt(off, &h)
+	if err != nil {
+		return err
+	}
+	if h.Magic != chromTreeMagic || h.ValSize != 8 {
+		return ErrCorrupt
+	}
+	err = f.readChromNode(off+int64(binary.Size(h)), int(h.KeySize), 0, make(map[int64]bool))
+	if err != nil {
+		return err
+	}
+	sort.Sort(byID(f.chroms))
+	for i, c := range f.chroms {
+		f.byName[c.Name] = i
+	}
+	return nil
+}
+
+// maxTreeDepth is the maximum depth of the chromosome B+ tree and the R-trees
+// held in a file. Trees written by the UCSC tools are only a few levels deep.
+const maxTreeDepth = 64
+
+// visit marks the tree node at off at the given depth as seen, returning
+// ErrCorrupt if the node is not valid, has already been seen or is too deep
+// in the tree.
+func visit(seen map[int64]bool, off int64, depth int) error {
+	if off < 0 || seen[off] || depth > maxTreeDepth {
+		return ErrCorrupt
+	}
+	seen[off] = true
+	return nil
+}
+
+func (f *File) readChromNode(off int64, keySize, depth int, seen map[int64]bool) error {
+	err := visit(seen, off, depth)
+	if err != nil {
+		return err
+	}
+	sr := io.NewSectionReader(f.r, off, 1<<63-1-off)
+	var n nodeHeader
+	err = binary.Read(sr, f.order, &n)
+	if err != nil {
+		return corrupt(err)
+	}
+	key := make([]byte, keySize)
+	var children []int64
+	for i := 0; i < int(n.Count); i++ {
+		_, err = io.ReadFull(sr, key)
+		if err != nil {
+			return corrupt(err)
+		}
+		if n.IsLeaf != 0 {
+			var v struct{ ID, Size uint32 }
+			err = binary.Read(sr, f.order, &v)
+			if err != nil {
+				return corrupt(err)
+			}
+			f.chroms = append(f.chroms, Chrom{
+				Name:   string(bytes.TrimRight(key, "\x00")),
+				ID:     int(v.ID),
+				Length: int(v.Size),
+			})
+			continue
+		}
+		var child uint64
+		err = binary.Read(sr, f.order, &child)
+		if err != nil {
+			return corrupt(err)
+		}
+		children = append(children, int64(child))
+	}
+	for _, c := range children {
+		err = f.readChromNode(c, keySize, depth+1, seen)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type byID []Chrom
+
+func (c byID) Len() int           { return len(c) }
+func (c byID) Less(i, j int) bool { return c[i].ID < c[j].ID }
+func (c byID) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// Chroms returns the chromosomes held in the file, sorted by ID.
+func (f *File) Chroms() []Chrom { return f.chroms }
+
+// chrom returns the chromosome with the given name.
+func (f *File) chrom(name string) (Chrom, bool) {
+	i, ok := f.byName[name]
+	if !ok {
+		return Chrom{}, false
+	}
+	return f.chroms[i], true
+}
+
+// chromName returns the name of the chromosome with the given ID.
+func (f *File) chromName(id uint32) string {
+	i := sort.Search(len(f.chroms), func(i int) bool { return f.chroms[i].ID >= int(id) })
+	if i < len(f.chroms) && f.chroms[i].ID == int(id) {
+		return f.chroms[i].Name
+	}
+	return ""
+}
+
+// Zooms returns the reduction levels of the zoom levels held in the file in
+// ascending order.
+func (f *File) Zooms() []int {
+	z := make([]int, len(f.zooms))
+	for i, h := range f.zooms {
+		z[i] = int(h.ReductionLevel)
+	}
+	return z
+}
+
+// Total returns the summary of all the data in the file. The Chrom, Start and
+// End fields of the returned Summary are not set.
+func (f *File) Total() (Summary, error) {
+	if f.hdr.TotalSummary == 0 {
+		return Summary{Min: math.NaN(), Max: math.NaN()}, nil
+	}
+	var s summaryRecord
+	err := f.readAt(int64(f.hdr.TotalSummary), &s)
+	if err != nil {
+		return Summary{}, err
+	}
+	return Summary{
+		Valid: float64(s.BasesCovered),
+		Min:   s.Min,
+		Max:   s.Max,
+		Sum:   s.Sum,
+		SumSq: s.SumSq,
+	}, nil
+}
+
+// query returns the chromosome ID for the named chromosome after checking the
+// query range.
+func (f *File) query(chrom string, start, end int) (uint32, error) {
+	c, ok := f.chrom(chrom)
+	if !ok {
+		return 0, ErrUnknownChrom
+	}
+	if start < 0 || end < start {
+		return 0, ErrBadRange
+	}
+	return uint32(c.ID), nil
+}
+
+// block is the location of a data block.
+type block struct {
+	offset, size uint64
+}
+
+// blocks returns the data blocks indexed by the R-tree at off that overlap
+// the query.
+func (f *File) blocks(off int64, id uint32, start, end int) ([]block, error) {
+	var h rTreeHeader
+	err := f.readAt(off, &h)
+	if err != nil {
+		return nil, err
+	}
+	if h.Magic != rTreeMagic {
+		return nil, ErrCorrupt
+	}
+	var b []block
+	err = f.rTreeNode(off+int64(binary.Size(h)), id, clamp(start), clamp(end), 0, make(map[int64]bool), &b)
+	return b, err
+}
+
+func clamp(v int) uint32 {
+	if v > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(v)
+}
+
+func (f *File) rTreeNode(off int64, id, start, end uint32, depth int, seen map[int64]bool, b *[]block) error {
+	err := visit(seen, off, depth)
+	if err != nil {
+		return err
+	}
+	sr := io.NewSectionReader(f.r, off, 1<<63-1-off)
+	var n nodeHeader
+	err = binary.Read(sr, f.order, &n)
+	if err != nil {
+		return corrupt(err)
+	}
+	var children []int64
+	for i := 0; i < int(n.Count); i++ {
+		var r rTreeBounds
+		err = binary.Read(sr, f.order, &r)
+		if err != nil {
+			return corrupt(err)
+		}
+		if n.IsLeaf != 0 {
+			var v block
+			err = binary.Read(sr, f.order, &v.offset)
+			if err == nil {
+				err = binary.Read(sr, f.order, &v.size)
+			}
+			if err != nil {
+				return corrupt(err)
+			}
+			if r.overlaps(id, start, end) {
+				*b = append(*b, v)
+			}
+			continue
+		}
+		var child uint64
+		err = binary.Read(sr, f.order, &child)
+		if err != nil {
+			return corrupt(err)
+		}
+		if r.overlaps(id, start, end) {
+			children = append(children, int64(child))
+		}
+	}
+	for _, c := range children {
+		err = f.rTreeNode(c, id, start, end, depth+1, seen, b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readBlock returns the uncompressed content of the data block b.
+func (f *File) readBlock(b block) ([]byte, error) {
+	max := f.hdr.UncompressBufSize
+	if b.offset > math.MaxInt64 || b.size > math.MaxInt64-b.offset || (max != 0 && b.size > compressBound(max)) {
+		return nil, ErrCorrupt
+	}
+
+	// The block is read without preallocating b.size bytes
+	// so that a corrupt size is bounded by the file length.
+	buf, err := ioutil.ReadAll(io.NewSectionReader(f.r, int64(b.offset), int64(b.size)))
+	if err != nil {
+		return nil, corrupt(err)
+	}
+	if uint64(len(buf)) != b.size {
+		return nil, ErrCorrupt
+	}
+	if max == 0 {
+		return buf, nil
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	defer zr.Close()
+	buf, err = ioutil.ReadAll(io.LimitReader(zr, int64(max)+1))
+	if err != nil {
+		return nil, corrupt(err)
+	}
+	if len(buf) > int(max) {
+		return nil, ErrCorrupt
+	}
+	return buf, nil
+}
+
+// compressBound returns the largest zlib compressed size of n bytes of data.
+// It is the bound given by zlib's compressBound.
+func compressBound(n uint32) uint64 {
+	u := uint64(n)
+	return u + u>>12 + u>>14 + u>>25 + 13
+}
+
+// Summaries returns summary statistics for n equally sized bins spanning
+// [start, end) of the named chromosome. The zoom level with the largest
+// reduction level that is no more than half the bin size is used; if no zoom
+// level is suitable the summaries are calculated from the raw data.
+func (f *File) Summaries(chrom string, start, end, n int) ([]Summary, error) {
+	id, err := f.query(chrom, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || end-start < n {
+		return nil, ErrBadRange
+	}
+	s := make([]Summary, n)
+	for i := range s {
+		s[i] = Summary{
+			Chrom: chrom,
+			Start: start + i*(end-start)/n,
+			End:   start + (i+1)*(end-start)/n,
+			Min:   math.Inf(1),
+			Max:   math.Inf(-1),
+		}
+	}
+
+	var z *zoomHeader
+	for i := range f.zooms {
+		if int(f.zooms[i].ReductionLevel) > (end-start)/n/2 {
+			break
+		}
+		z = &f.zooms[i]
+	}
+	if z == nil {
+		iv, err := f.raw(id, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range iv {
+			addRaw(s, v)
+		}
+	} else {
+		zr, err := f.zoomRecords(z, id, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range zr {
+			addZoom(s, r)
+		}
+	}
+
+	for i := range s {
+		if s[i].Valid == 0 {
+			s[i].Min = math.NaN()
+			s[i].Max = math.NaN()
+		}
+	}
+	return s, nil
+}
+
+// bins returns the indexes of the first and last summary bins in s that
+// overlap [start, end).
+func bins(s []Summary, start, end int) (int, int) {
+	i := sort.Search(len(s), func(i int) bool { return s[i].End > start })
+	j := sort.Search(len(s), func(i int) bool { return s[i].Start >= end })
+	return i, j
+}
+
+func overlap(s *Summary, start, end int) int {
+	if start < s.Start {
+		start = s.Start
+	}
+	if end > s.End {
+		end = s.End
+	}
+	return end - start
+}
+
+func addRaw(s []Summary, v interval) {
+	i, j := bins(s, v.start, v.end)
+	for ; i < j; i++ {
+		o := float64(overlap(&s[i], v.start, v.end))
+		if o <= 0 {
+			continue
+		}
+		s[i].Valid += o
+		s[i].Sum += o * v.value
+		s[i].SumSq += o * v.value * v.value
+		s[i].Min = math.Min(s[i].Min, v.value)
+		s[i].Max = math.Max(s[i].Max, v.value)
+	}
+}
+
+func addZoom(s []Summary, r zoomRecord) {
+	start, end := int(r.Start), int(r.End)
+	i, j := bins(s, start, end)
+	for ; i < j; i++ {
+		o := overlap(&s[i], start, end)
+		if o <= 0 {
+			continue
+		}
+		frac := float64(o) / float64(end-start)
+		s[i].Valid += frac * float64(r.Valid)
+		s[i].Sum += frac * float64(r.Sum)
+		s[i].SumSq += frac * float64(r.SumSq)
+		s[i].Min = math.Min(s[i].Min, float64(r.Min))
+		s[i].Max = math.Max(s[i].Max, float64(r.Max))
+	}
+}
+
+// zoomRecords returns the zoom level records of z that overlap the query.
+func (f *File) zoomRecords(z *zoomHeader, id uint32, start, end int) ([]zoomRecord, error) {
+	blocks, err := f.blocks(int64(z.IndexOffset), id, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var recs []zoomRecord
+	for _, b := range blocks {
+		buf, err := f.readBlock(b)
+		if err != nil {
+			return nil, err
+		}
+		br := bytes.NewReader(buf)
+		for br.Len() > 0 {
+			var r zoomRecord
+			err = binary.Read(br, f.order, &r)
+			if err != nil {
+				return nil, corrupt(err)
+			}
+			if r.ChromID == id && int(r.Start) < end && int(r.End) > start {
+				recs = append(recs, r)
+			}
+		}
+	}
+	return recs, nil
+}
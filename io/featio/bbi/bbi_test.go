@@ -0,0 +1,413 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bbi
+
+import (
+	"github.com/biogo/biogo/io/featio/bed"
+	"github.com/biogo/biogo/io/featio/wig"
+	"github.com/biogo/biogo/seq"
+
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+// testBlock is an uncompressed data block and its bounds.
+type testBlock struct {
+	chromID    uint32
+	start, end uint32
+	data       []byte
+}
+
+type testZoom struct {
+	reduction uint32
+	blocks    []testBlock
+}
+
+type testFile struct {
+	magic             uint32
+	fieldCount        uint16
+	definedFieldCount uint16
+	autoSQL           string
+	chroms            []Chrom
+	blocks            []testBlock
+	zooms             []testZoom
+}
+
+// encode returns the BBI file described by f. Trees are written with
+// two levels and at most two items per leaf.
+func (f testFile) encode(c *check.C, order binary.ByteOrder, compress bool) []byte {
+	var (
+		buf bytes.Buffer
+		h   = header{
+			Magic:             f.magic,
+			Version:           4,
+			ZoomLevels:        uint16(len(f.zooms)),
+			FieldCount:        f.fieldCount,
+			DefinedFieldCount: f.definedFieldCount,
+		}
+		zh = make([]zoomHeader, len(f.zooms))
+	)
+	write := func(v interface{}) {
+		c.Assert(binary.Write(&buf, order, v), check.Equals, nil)
+	}
+	buf.Write(make([]byte, binary.Size(h)+len(zh)*binary.Size(zoomHeader{})))
+
+	if f.autoSQL != "" {
+		h.AutoSQLOffset = uint64(buf.Len())
+		buf.WriteString(f.autoSQL)
+		buf.WriteByte(0)
+	}
+
+	h.ChromTreeOffset = uint64(buf.Len())
+	keySize := 0
+	for _, ch := range f.chroms {
+		if len(ch.Name) > keySize {
+			keySize = len(ch.Name)
+		}
+	}
+	key := func(name string) []byte {
+		k := make([]byte, keySize)
+		copy(k, name)
+		return k
+	}
+	write(chromTreeHeader{Magic: chromTreeMagic, BlockSize: 2, KeySize: uint32(keySize), ValSize: 8, ItemCount: uint64(len(f.chroms))})
+	leaves := (len(f.chroms) + 1) / 2
+	root := int64(buf.Len())
+	leafStart := root + int64(binary.Size(nodeHeader{})+leaves*(keySize+8))
+	write(nodeHeader{Count: uint16(leaves)})
+	for i := 0; i < leaves; i++ {
+		buf.Write(key(f.chroms[2*i].Name))
+		write(uint64(leafStart) + uint64(i*(binary.Size(nodeHeader{})+2*(keySize+8))))
+	}
+	for i := 0; i < leaves; i++ {
+		n := len(f.chroms) - 2*i
+		if n > 2 {
+			n = 2
+		}
+		write(nodeHeader{IsLeaf: 1, Count: uint16(n)})
+		for _, ch := range f.chroms[2*i : 2*i+n] {
+			buf.Write(key(ch.Name))
+			write(uint32(ch.ID))
+			write(uint32(ch.Length))
+		}
+		if n == 1 {
+			buf.Write(make([]byte, keySize+8))
+		}
+	}
+
+	maxSize := 0
+	writeBlocks := func(blocks []testBlock) []block {
+		loc := make([]block, len(blocks))
+		for i, b := range blocks {
+			data := b.data
+			if len(data) > maxSize {
+				maxSize = len(data)
+			}
+			if compress {
+				var z bytes.Buffer
+				zw := zlib.NewWriter(&z)
+				zw.Write(data)
+				zw.Close()
+				data = z.Bytes()
+			}
+			loc[i] = block{offset: uint64(buf.Len()), size: uint64(len(data))}
+			buf.Write(data)
+		}
+		return loc
+	}
+	writeIndex := func(blocks []testBlock, loc []block) {
+		write(rTreeHeader{Magic: rTreeMagic, BlockSize: 2, ItemCount: uint64(len(blocks)), ItemsPerSlot: 1})
+		leaves := (len(blocks) + 1) / 2
+		leafStart := buf.Len() + binary.Size(nodeHeader{}) + leaves*24
+		leafSize := binary.Size(nodeHeader{}) + 2*32
+		write(nodeHeader{Count: uint16(leaves)})
+		for i := 0; i < leaves; i++ {
+			first := blocks[2*i]
+			last := blocks[2*i]
+			if 2*i+1 < len(blocks) {
+				last = blocks[2*i+1]
+			}
+			write(rTreeBounds{first.chromID, first.start, last.chromID, last.end})
+			write(uint64(leafStart + i*leafSize))
+		}
+		for i := 0; i < leaves; i++ {
+			n := len(blocks) - 2*i
+			if n > 2 {
+				n = 2
+			}
+			write(nodeHeader{IsLeaf: 1, Count: uint16(n)})
+			for j, b := range blocks[2*i : 2*i+n] {
+				write(rTreeBounds{b.chromID, b.start, b.chromID, b.end})
+				write(loc[2*i+j].offset)
+				write(loc[2*i+j].size)
+			}
+			if n == 1 {
+				buf.Write(make([]byte, 32))
+			}
+		}
+	}
+
+	h.FullDataOffset = uint64(buf.Len())
+	write(uint64(len(f.blocks)))
+	loc := writeBlocks(f.blocks)
+	h.FullIndexOffset = uint64(buf.Len())
+	writeIndex(f.blocks, loc)
+
+	for i, z := range f.zooms {
+		zh[i].ReductionLevel = z.reduction
+		zh[i].DataOffset = uint64(buf.Len())
+		write(uint32(len(z.blocks)))
+		loc := writeBlocks(z.blocks)
+		zh[i].IndexOffset = uint64(buf.Len())
+		writeIndex(z.blocks, loc)
+	}
+
+	h.TotalSummary = uint64(buf.Len())
+	write(summaryRecord{BasesCovered: 10, Min: -1, Max: 1, Sum: 2, SumSq: 3})
+
+	if compress {
+		h.UncompressBufSize = uint32(maxSize)
+	}
+	b := buf.Bytes()
+	var hb bytes.Buffer
+	c.Assert(binary.Write(&hb, order, h), check.Equals, nil)
+	c.Assert(binary.Write(&hb, order, zh), check.Equals, nil)
+	copy(b, hb.Bytes())
+	return b
+}
+
+func encodeData(c *check.C, order binary.ByteOrder, v ...interface{}) []byte {
+	var buf bytes.Buffer
+	for _, d := range v {
+		switch d := d.(type) {
+		case string:
+			buf.WriteString(d)
+			buf.WriteByte(0)
+		default:
+			c.Assert(binary.Write(&buf, order, d), check.Equals, nil)
+		}
+	}
+	return buf.Bytes()
+}
+
+var testChroms = []Chrom{
+	{Name: "chr1", ID: 0, Length: 1000},
+	{Name: "chr2", ID: 1, Length: 500},
+	{Name: "chrUn", ID: 2, Length: 50},
+}
+
+type bgItem struct {
+	Start, End uint32
+	Value      float32
+}
+
+type varItem struct {
+	Start uint32
+	Value float32
+}
+
+func bigWigFile(c *check.C, order binary.ByteOrder) testFile {
+	return testFile{
+		magic:  bigWigMagic,
+		chroms: testChroms,
+		blocks: []testBlock{
+			{0, 0, 200, encodeData(c, order,
+				sectionHeader{ChromID: 0, Start: 0, End: 200, Type: bedGraphSection, ItemCount: 2},
+				bgItem{0, 100, 1}, bgItem{100, 200, 2},
+			)},
+			{0, 300, 325, encodeData(c, order,
+				sectionHeader{ChromID: 0, Start: 300, End: 325, ItemStep: 10, ItemSpan: 5, Type: fixedSection, ItemCount: 3},
+				float32(3), float32(4), float32(5),
+			)},
+			{1, 0, 60, encodeData(c, order,
+				sectionHeader{ChromID: 1, Start: 0, End: 60, ItemSpan: 10, Type: variableSection, ItemCount: 2},
+				varItem{0, 7}, varItem{50, 8},
+			)},
+		},
+		zooms: []testZoom{
+			{reduction: 100, blocks: []testBlock{
+				{0, 0, 400, encodeData(c, order,
+					zoomRecord{ChromID: 0, Start: 0, End: 100, Valid: 100, Min: 1, Max: 1, Sum: 100, SumSq: 100},
+					zoomRecord{ChromID: 0, Start: 100, End: 200, Valid: 100, Min: 2, Max: 2, Sum: 200, SumSq: 400},
+					zoomRecord{ChromID: 0, Start: 300, End: 400, Valid: 15, Min: 3, Max: 5, Sum: 60, SumSq: 250},
+				)},
+				{1, 0, 100, encodeData(c, order,
+					zoomRecord{ChromID: 1, Start: 0, End: 100, Valid: 20, Min: 7, Max: 8, Sum: 150, SumSq: 1130},
+				)},
+			}},
+		},
+	}
+}
+
+func (s *S) TestBigWig(c *check.C) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, compress := range []bool{false, true} {
+			data := bigWigFile(c, order).encode(c, order, compress)
+			bw, err := NewBigWig(bytes.NewReader(data))
+			c.Assert(err, check.Equals, nil)
+			c.Check(bw.Chroms(), check.DeepEquals, testChroms)
+			c.Check(bw.Zooms(), check.DeepEquals, []int{100})
+			tot, err := bw.Total()
+			c.Check(err, check.Equals, nil)
+			c.Check(tot, check.DeepEquals, Summary{Valid: 10, Min: -1, Max: 1, Sum: 2, SumSq: 3})
+
+			f, err := bw.Query("chr1", 150, 320)
+			c.Assert(err, check.Equals, nil)
+			c.Check(f, check.DeepEquals, []*wig.Feature{
+				{Chrom: "chr1", FeatStart: 100, FeatEnd: 200, Value: 2},
+				{Chrom: "chr1", FeatStart: 300, FeatEnd: 305, Value: 3},
+				{Chrom: "chr1", FeatStart: 310, FeatEnd: 315, Value: 4},
+			})
+			f, err = bw.Query("chr2", 0, 500)
+			c.Assert(err, check.Equals, nil)
+			c.Check(f, check.DeepEquals, []*wig.Feature{
+				{Chrom: "chr2", FeatStart: 0, FeatEnd: 10, Value: 7},
+				{Chrom: "chr2", FeatStart: 50, FeatEnd: 60, Value: 8},
+			})
+			f, err = bw.Query("chrUn", 0, 50)
+			c.Check(err, check.Equals, nil)
+			c.Check(f, check.HasLen, 0)
+			_, err = bw.Query("chr3", 0, 50)
+			c.Check(err, check.Equals, ErrUnknownChrom)
+
+			// Raw data.
+			sum, err := bw.Summaries("chr1", 0, 400, 4)
+			c.Assert(err, check.Equals, nil)
+			c.Check(sum[0], check.DeepEquals, Summary{Chrom: "chr1", Start: 0, End: 100, Valid: 100, Min: 1, Max: 1, Sum: 100, SumSq: 100})
+			c.Check(sum[1], check.DeepEquals, Summary{Chrom: "chr1", Start: 100, End: 200, Valid: 100, Min: 2, Max: 2, Sum: 200, SumSq: 400})
+			c.Check(sum[2].Valid, check.Equals, 0.)
+			c.Check(math.IsNaN(sum[2].Min), check.Equals, true)
+			c.Check(sum[3], check.DeepEquals, Summary{Chrom: "chr1", Start: 300, End: 400, Valid: 15, Min: 3, Max: 5, Sum: 60, SumSq: 250})
+			c.Check(sum[3].Mean(), check.Equals, 4.)
+
+			// Zoom data apportioned between bins.
+			sum, err = bw.Summaries("chr1", 0, 700, 2)
+			c.Assert(err, check.Equals, nil)
+			c.Check(sum[0], check.DeepEquals, Summary{Chrom: "chr1", Start: 0, End: 350, Valid: 207.5, Min: 1, Max: 5, Sum: 330, SumSq: 625})
+			c.Check(sum[1], check.DeepEquals, Summary{Chrom: "chr1", Start: 350, End: 700, Valid: 7.5, Min: 3, Max: 5, Sum: 30, SumSq: 125})
+
+			_, err = bw.Summaries("chr1", 0, 10, 20)
+			c.Check(err, check.Equals, ErrBadRange)
+		}
+	}
+}
+
+func bigBedFile(c *check.C, order binary.ByteOrder) testFile {
+	return testFile{
+		magic:             bigBedMagic,
+		fieldCount:        7,
+		definedFieldCount: 6,
+		autoSQL:           "table test\n\"test\"\n(\n)",
+		chroms:            testChroms,
+		blocks: []testBlock{
+			{0, 10, 30, encodeData(c, order,
+				bedHeader{ChromID: 0, Start: 10, End: 20}, "a\t100\t+\textra",
+				bedHeader{ChromID: 0, Start: 15, End: 30}, "b\t200\t-\textra",
+			)},
+			{1, 0, 5, encodeData(c, order,
+				bedHeader{ChromID: 1, Start: 0, End: 5}, "c\t0\t.\textra",
+			)},
+		},
+	}
+}
+
+func (s *S) TestBigBed(c *check.C) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, compress := range []bool{false, true} {
+			data := bigBedFile(c, order).encode(c, order, compress)
+			bb, err := NewBigBed(bytes.NewReader(data))
+			c.Assert(err, check.Equals, nil)
+			c.Check(bb.BedType, check.Equals, 6)
+			sql, err := bb.AutoSQL()
+			c.Check(err, check.Equals, nil)
+			c.Check(sql, check.Equals, "table test\n\"test\"\n(\n)")
+
+			f, err := bb.Query("chr1", 0, 12)
+			c.Assert(err, check.Equals, nil)
+			c.Check(f, check.DeepEquals, []bed.Bed{
+				&bed.Bed6{Chrom: "chr1", ChromStart: 10, ChromEnd: 20, FeatName: "a", FeatScore: 100, FeatStrand: seq.Plus},
+			})
+			recs, err := bb.Records("chr1", 0, 100)
+			c.Assert(err, check.Equals, nil)
+			c.Check(recs, check.DeepEquals, []string{"chr1\t10\t20\ta\t100\t+\textra", "chr1\t15\t30\tb\t200\t-\textra"})
+			f, err = bb.Query("chr2", 0, 100)
+			c.Assert(err, check.Equals, nil)
+			c.Check(f, check.HasLen, 1)
+
+			sum, err := bb.Summaries("chr1", 10, 30, 2)
+			c.Assert(err, check.Equals, nil)
+			c.Check(sum, check.DeepEquals, []Summary{
+				{Chrom: "chr1", Start: 10, End: 20, Valid: 10, Min: 1, Max: 2, Sum: 15, SumSq: 25},
+				{Chrom: "chr1", Start: 20, End: 30, Valid: 10, Min: 1, Max: 1, Sum: 10, SumSq: 10},
+			})
+		}
+	}
+}
+
+func (s *S) TestErrors(c *check.C) {
+	data := bigBedFile(c, binary.LittleEndian).encode(c, binary.LittleEndian, false)
+	_, err := NewBigWig(bytes.NewReader(data))
+	c.Check(err, check.Equals, ErrBadMagic)
+	_, err = NewBigBed(bytes.NewReader(data[:70]))
+	c.Check(err, check.Equals, ErrCorrupt)
+	_, err = NewBigBed(bytes.NewReader(nil))
+	c.Check(err, check.Equals, ErrCorrupt)
+
+	var h header
+	c.Assert(binary.Read(bytes.NewReader(data), binary.LittleEndian, &h), check.Equals, nil)
+	corrupt := func(data []byte, off uint64, v uint64) []byte {
+		b := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint64(b[off:], v)
+		return b
+	}
+
+	// A chromosome tree node that is its own child.
+	var ch chromTreeHeader
+	c.Assert(binary.Read(bytes.NewReader(data[h.ChromTreeOffset:]), binary.LittleEndian, &ch), check.Equals, nil)
+	root := h.ChromTreeOffset + uint64(binary.Size(ch))
+	_, err = NewBigBed(bytes.NewReader(corrupt(data, root+uint64(binary.Size(nodeHeader{}))+uint64(ch.KeySize), root)))
+	c.Check(err, check.Equals, ErrCorrupt)
+
+	// An R-tree node that is its own child.
+	root = h.FullIndexOffset + uint64(binary.Size(rTreeHeader{}))
+	child := root + uint64(binary.Size(nodeHeader{})+binary.Size(rTreeBounds{}))
+	bb, err := NewBigBed(bytes.NewReader(corrupt(data, child, root)))
+	c.Assert(err, check.Equals, nil)
+	_, err = bb.Query("chr1", 0, 100)
+	c.Check(err, check.Equals, ErrCorrupt)
+
+	// A data block with a size beyond the end of the file, and a compressed
+	// data block larger than the uncompressed buffer size allows.
+	for _, t := range []struct {
+		compress bool
+		size     uint64
+	}{
+		{compress: false, size: 1 << 62},
+		{compress: true, size: 1 << 20},
+	} {
+		data := bigBedFile(c, binary.LittleEndian).encode(c, binary.LittleEndian, t.compress)
+		var h header
+		c.Assert(binary.Read(bytes.NewReader(data), binary.LittleEndian, &h), check.Equals, nil)
+		child := h.FullIndexOffset + uint64(binary.Size(rTreeHeader{})+binary.Size(nodeHeader{})+binary.Size(rTreeBounds{}))
+		leaf := binary.LittleEndian.Uint64(data[child:])
+		size := leaf + uint64(binary.Size(nodeHeader{})+binary.Size(rTreeBounds{})+8)
+		bb, err := NewBigBed(bytes.NewReader(corrupt(data, size, t.size)))
+		c.Assert(err, check.Equals, nil)
+		_, err = bb.Query("chr1", 0, 100)
+		c.Check(err, check.Equals, ErrCorrupt, check.Commentf("compress=%t", t.compress))
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bbi
+
+import (
+	"github.com/biogo/biogo/io/featio/bed"
+
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// bedHeader is the fixed part of a bigBed record.
+type bedHeader struct {
+	ChromID    uint32
+	Start, End uint32
+}
+
+// BigBed provides random access to the records of a bigBed file.
+type BigBed struct {
+	*File
+
+	// BedType is the BED type of features
+	// returned by Query: 3, 4, 5, 6 or 12.
+	BedType int
+}
+
+// NewBigBed returns a BigBed that reads from the bigBed file provided by r.
+// The file header, zoom level headers and chromosome index are read by
+// NewBigBed; records are read as they are queried.
+func NewBigBed(r io.ReaderAt) (*BigBed, error) {
+	f, err := newFile(r, bigBedMagic)
+	if err != nil {
+		return nil, err
+	}
+	b := &BigBed{File: f}
+	n := int(f.hdr.DefinedFieldCount)
+	if n == 0 {
+		n = int(f.hdr.FieldCount)
+	}
+	switch {
+	case n >= 12:
+		b.BedType = 12
+	case n >= 6:
+		b.BedType = 6
+	case n >= 3:
+		b.BedType = n
+	default:
+		return nil, ErrCorrupt
+	}
+	f.raw = b.coverage
+	return b, nil
+}
+
+// AutoSQL returns the autoSql description of the bigBed fields held in the
+// file, or the empty string if the file does not include one.
+func (b *BigBed) AutoSQL() (string, error) {
+	if b.hdr.AutoSQLOffset == 0 {
+		return "", nil
+	}
+	off := int64(b.hdr.AutoSQLOffset)
+	s, err := bufio.NewReader(io.NewSectionReader(b.r, off, 1<<63-1-off)).ReadString(0)
+	if err != nil {
+		return "", corrupt(err)
+	}
+	return s[:len(s)-1], nil
+}
+
+// Query returns the records for the named chromosome that overlap
+// [start, end) as bed.Bed features of the type specified by BedType.
+func (b *BigBed) Query(chrom string, start, end int) ([]bed.Bed, error) {
+	lines, err := b.Records(chrom, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, l := range lines {
+		buf.WriteString(l)
+		buf.WriteByte('\n')
+	}
+	r, err := bed.NewReader(&buf, b.BedType)
+	if err != nil {
+		return nil, err
+	}
+	f := make([]bed.Bed, len(lines))
+	for i := range f {
+		rec, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		f[i] = rec.(bed.Bed)
+	}
+	return f, nil
+}
+
+// Records returns the records for the named chromosome that overlap
+// [start, end) as tab-delimited BED lines, including any fields beyond those
+// returned by Query.
+func (b *BigBed) Records(chrom string, start, end int) ([]string, error) {
+	id, err := b.query(chrom, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	err = b.records(id, start, end, func(h bedHeader, rest []byte) {
+		l := fmt.Sprintf("%s\t%d\t%d", chrom, h.Start, h.End)
+		if len(rest) != 0 {
+			l += "\t" + string(rest)
+		}
+		lines = append(lines, l)
+	})
+	return lines, err
+}
+
+func (b *BigBed) records(id uint32, start, end int, fn func(bedHeader, []byte)) error {
+	blocks, err := b.blocks(int64(b.hdr.FullIndexOffset), id, start, end)
+	if err != nil {
+		return err
+	}
+	for _, blk := range blocks {
+		buf, err := b.readBlock(blk)
+		if err != nil {
+			return err
+		}
+		for len(buf) > 0 {
+			var h bedHeader
+			err = binary.Read(bytes.NewReader(buf), b.order, &h)
+			if err != nil {
+				return corrupt(err)
+			}
+			buf = buf[binary.Size(h):]
+			i := bytes.IndexByte(buf, 0)
+			if i < 0 {
+				return ErrCorrupt
+			}
+			rest := buf[:i]
+			buf = buf[i+1:]
+			if h.ChromID == id && int(h.Start) < end && int(h.End) > start {
+				fn(h, rest)
+			}
+		}
+	}
+	return nil
+}
+
+// coverage returns the depth of coverage by records over the query range.
+func (b *BigBed) coverage(id uint32, start, end int) ([]interval, error) {
+	var edges []edge
+	err := b.records(id, start, end, func(h bedHeader, _ []byte) {
+		edges = append(edges, edge{pos: int(h.Start), delta: 1}, edge{pos: int(h.End), delta: -1})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(byPos(edges))
+	var (
+		iv    []interval
+		depth int
+	)
+	for i, e := range edges {
+		depth += e.delta
+		if depth > 0 && i+1 < len(edges) && edges[i+1].pos > e.pos {
+			iv = append(iv, interval{start: e.pos, end: edges[i+1].pos, value: float64(depth)})
+		}
+	}
+	return iv, nil
+}
+
+type edge struct {
+	pos, delta int
+}
+
+type byPos []edge
+
+func (e byPos) Len() int           { return len(e) }
+func (e byPos) Less(i, j int) bool { return e[i].pos < e[j].pos }
+func (e byPos) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
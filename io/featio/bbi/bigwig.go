@@ -0,0 +1,126 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bbi
+
+import (
+	"github.com/biogo/biogo/io/featio/wig"
+
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// bigWig section types.
+const (
+	bedGraphSection = 1
+	variableSection = 2
+	fixedSection    = 3
+)
+
+// sectionHeader is the header of a bigWig data section.
+type sectionHeader struct {
+	ChromID   uint32
+	Start     uint32
+	End       uint32
+	ItemStep  uint32
+	ItemSpan  uint32
+	Type      uint8
+	Reserved  uint8
+	ItemCount uint16
+}
+
+// BigWig provides random access to the data of a bigWig file.
+type BigWig struct {
+	*File
+}
+
+// NewBigWig returns a BigWig that reads from the bigWig file provided by r.
+// The file header, zoom level headers and chromosome index are read by
+// NewBigWig; data are read as they are queried.
+func NewBigWig(r io.ReaderAt) (*BigWig, error) {
+	f, err := newFile(r, bigWigMagic)
+	if err != nil {
+		return nil, err
+	}
+	b := &BigWig{File: f}
+	f.raw = b.intervals
+	return b, nil
+}
+
+// Query returns the signal values stored for the named chromosome that
+// overlap [start, end). Values are returned as stored in the file and are
+// not clipped to the query range.
+func (b *BigWig) Query(chrom string, start, end int) ([]*wig.Feature, error) {
+	id, err := b.query(chrom, start, end)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := b.intervals(id, start, end)
+	if err != nil {
+		return nil, err
+	}
+	f := make([]*wig.Feature, len(iv))
+	for i, v := range iv {
+		f[i] = &wig.Feature{Chrom: chrom, FeatStart: v.start, FeatEnd: v.end, Value: v.value}
+	}
+	return f, nil
+}
+
+func (b *BigWig) intervals(id uint32, start, end int) ([]interval, error) {
+	blocks, err := b.blocks(int64(b.hdr.FullIndexOffset), id, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var iv []interval
+	for _, blk := range blocks {
+		buf, err := b.readBlock(blk)
+		if err != nil {
+			return nil, err
+		}
+		br := bytes.NewReader(buf)
+		var h sectionHeader
+		err = binary.Read(br, b.order, &h)
+		if err != nil {
+			return nil, corrupt(err)
+		}
+		if h.ChromID != id {
+			continue
+		}
+		pos := int(h.Start)
+		for i := 0; i < int(h.ItemCount); i++ {
+			var v interval
+			switch h.Type {
+			case bedGraphSection:
+				var item struct {
+					Start, End uint32
+					Value      float32
+				}
+				err = binary.Read(br, b.order, &item)
+				v = interval{start: int(item.Start), end: int(item.End), value: float64(item.Value)}
+			case variableSection:
+				var item struct {
+					Start uint32
+					Value float32
+				}
+				err = binary.Read(br, b.order, &item)
+				v = interval{start: int(item.Start), end: int(item.Start + h.ItemSpan), value: float64(item.Value)}
+			case fixedSection:
+				var value float32
+				err = binary.Read(br, b.order, &value)
+				v = interval{start: pos, end: pos + int(h.ItemSpan), value: float64(value)}
+				pos += int(h.ItemStep)
+			default:
+				return nil, ErrCorrupt
+			}
+			if err != nil {
+				return nil, corrupt(err)
+			}
+			if v.start < end && v.end > start {
+				iv = append(iv, v)
+			}
+		}
+	}
+	return iv, nil
+}
@@ -50,6 +50,14 @@ const (
 	blockStartsField
 )
 
+// ENCODE peak fields following the BED6 fields.
+const (
+	signalValueField = iota + strandField + 1
+	pValueField
+	qValueField
+	peakField
+)
+
 var (
 	_ featio.Reader = (*Reader)(nil)
 	_ featio.Writer = (*Writer)(nil)
@@ -59,15 +67,21 @@ var (
 	_ feat.Feature = (*Bed5)(nil)
 	_ feat.Feature = (*Bed6)(nil)
 	_ feat.Feature = (*Bed12)(nil)
+	_ feat.Feature = (*NarrowPeak)(nil)
+	_ feat.Feature = (*BroadPeak)(nil)
 
 	_ Bed = (*Bed3)(nil)
 	_ Bed = (*Bed4)(nil)
 	_ Bed = (*Bed5)(nil)
 	_ Bed = (*Bed6)(nil)
 	_ Bed = (*Bed12)(nil)
+	_ Bed = (*NarrowPeak)(nil)
+	_ Bed = (*BroadPeak)(nil)
 
 	_ feat.Orienter = (*Bed6)(nil)
 	_ feat.Orienter = (*Bed12)(nil)
+	_ feat.Orienter = (*NarrowPeak)(nil)
+	_ feat.Orienter = (*BroadPeak)(nil)
 )
 
 type Bed interface {
@@ -102,6 +116,14 @@ func mustAtoi(f []byte, column int) int {
 	return int(i)
 }
 
+func mustAtof(f []byte, column int) float64 {
+	v, err := strconv.ParseFloat(unsafeString(f), 64)
+	if err != nil {
+		panic(&csv.ParseError{Column: column, Err: err})
+	}
+	return v
+}
+
 func mustAtob(f []byte, column int) byte {
 	b, err := strconv.ParseUint(unsafeString(f), 0, 8)
 	if err != nil {
@@ -355,6 +377,110 @@ func (b *Bed12) Orientation() feat.Orientation { return feat.Orientation(b.FeatS
 func (b *Bed12) canBed(i int) bool             { return i <= 12 }
 func (b *Bed12) Format(fs fmt.State, c rune)   { format(b, fs, c) }
 
+// NarrowPeak is an ENCODE narrowPeak (BED6+4) feature describing a point-source
+// peak. Negative SignalValue, PValue, QValue and Peak values indicate that the
+// value is not available. Peak is the offset of the peak summit from ChromStart.
+type NarrowPeak struct {
+	Chrom       string
+	ChromStart  int
+	ChromEnd    int
+	FeatName    string
+	FeatScore   int
+	FeatStrand  seq.Strand
+	SignalValue float64
+	PValue      float64
+	QValue      float64
+	Peak        int
+}
+
+func parseNarrowPeak(line []byte) (b *NarrowPeak, err error) {
+	const n = 10
+	defer handlePanic(b, &err)
+	f := bytes.SplitN(line, []byte{'\t'}, n+1)
+	if len(f) < n {
+		return nil, ErrBadBedType
+	}
+	b = &NarrowPeak{
+		Chrom:       string(f[chromField]),
+		ChromStart:  mustAtoi(f[startField], startField),
+		ChromEnd:    mustAtoi(f[endField], endField),
+		FeatName:    string(f[nameField]),
+		FeatScore:   mustAtoi(f[scoreField], scoreField),
+		FeatStrand:  mustAtos(f[strandField], strandField),
+		SignalValue: mustAtof(f[signalValueField], signalValueField),
+		PValue:      mustAtof(f[pValueField], pValueField),
+		QValue:      mustAtof(f[qValueField], qValueField),
+		Peak:        mustAtoi(f[peakField], peakField),
+	}
+	return
+}
+
+func (b *NarrowPeak) Start() int                    { return b.ChromStart }
+func (b *NarrowPeak) End() int                      { return b.ChromEnd }
+func (b *NarrowPeak) Len() int                      { return b.ChromEnd - b.ChromStart }
+func (b *NarrowPeak) Name() string                  { return b.FeatName }
+func (b *NarrowPeak) Description() string           { return "narrowPeak feature" }
+func (b *NarrowPeak) Location() feat.Feature        { return Chrom(b.Chrom) }
+func (b *NarrowPeak) Orientation() feat.Orientation { return feat.Orientation(b.FeatStrand) }
+func (b *NarrowPeak) canBed(i int) bool             { return i <= 10 }
+func (b *NarrowPeak) Format(fs fmt.State, c rune)   { format(b, fs, c) }
+
+// BroadPeak is an ENCODE broadPeak (BED6+3) feature describing a broad region
+// of enrichment. Negative SignalValue, PValue and QValue values indicate that
+// the value is not available.
+type BroadPeak struct {
+	Chrom       string
+	ChromStart  int
+	ChromEnd    int
+	FeatName    string
+	FeatScore   int
+	FeatStrand  seq.Strand
+	SignalValue float64
+	PValue      float64
+	QValue      float64
+}
+
+func parseBroadPeak(line []byte) (b *BroadPeak, err error) {
+	const n = 9
+	defer handlePanic(b, &err)
+	f := bytes.SplitN(line, []byte{'\t'}, n+1)
+	if len(f) < n {
+		return nil, ErrBadBedType
+	}
+	b = &BroadPeak{
+		Chrom:       string(f[chromField]),
+		ChromStart:  mustAtoi(f[startField], startField),
+		ChromEnd:    mustAtoi(f[endField], endField),
+		FeatName:    string(f[nameField]),
+		FeatScore:   mustAtoi(f[scoreField], scoreField),
+		FeatStrand:  mustAtos(f[strandField], strandField),
+		SignalValue: mustAtof(f[signalValueField], signalValueField),
+		PValue:      mustAtof(f[pValueField], pValueField),
+		QValue:      mustAtof(f[qValueField], qValueField),
+	}
+	return
+}
+
+func (b *BroadPeak) Start() int                    { return b.ChromStart }
+func (b *BroadPeak) End() int                      { return b.ChromEnd }
+func (b *BroadPeak) Len() int                      { return b.ChromEnd - b.ChromStart }
+func (b *BroadPeak) Name() string                  { return b.FeatName }
+func (b *BroadPeak) Description() string           { return "broadPeak feature" }
+func (b *BroadPeak) Location() feat.Feature        { return Chrom(b.Chrom) }
+func (b *BroadPeak) Orientation() feat.Orientation { return feat.Orientation(b.FeatStrand) }
+func (b *BroadPeak) canBed(i int) bool             { return i <= 9 }
+func (b *BroadPeak) Format(fs fmt.State, c rune)   { format(b, fs, c) }
+
+// BED types for the ENCODE peak formats. The values are the number of columns
+// in each format.
+const (
+	BroadPeakType  = 9
+	NarrowPeakType = 10
+)
+
+// isPeak returns whether the bed type b is one of the ENCODE peak formats.
+func isPeak(b int) bool { return b == BroadPeakType || b == NarrowPeakType }
+
 // BED format reader type.
 type Reader struct {
 	r       *bufio.Reader
@@ -362,10 +488,11 @@ type Reader struct {
 	line    int
 }
 
-// Returns a new BED format reader using r.
+// Returns a new BED format reader using r. The BED type b must be 3, 4, 5, 6
+// or 12, or BroadPeakType or NarrowPeakType for ENCODE peak files.
 func NewReader(r io.Reader, b int) (*Reader, error) {
 	switch b {
-	case 3, 4, 5, 6, 12:
+	case 3, 4, 5, 6, 12, BroadPeakType, NarrowPeakType:
 	default:
 		return nil, ErrBadBedType
 	}
@@ -395,6 +522,10 @@ func (r *Reader) Read() (f feat.Feature, err error) {
 		f, err = parseBed6(line)
 	case 12:
 		f, err = parseBed12(line)
+	case BroadPeakType:
+		f, err = parseBroadPeak(line)
+	case NarrowPeakType:
+		f, err = parseNarrowPeak(line)
 	default:
 		return nil, ErrBadBedType
 	}
@@ -436,30 +567,23 @@ func format(b Bed, fs fmt.State, c rune) {
 			width = bv.NumField()
 		}
 		for i := 0; i < width; i++ {
-			f := bv.Field(i).Interface()
-			if i >= rgbField {
-				switch i {
-				case rgbField:
-					rv := reflect.ValueOf(f)
-					if reflect.DeepEqual(rv.Interface(), color.RGBA{}) {
-						fs.Write([]byte{'0'})
-					} else {
-						fmt.Fprintf(fs, "%d,%d,%d",
-							rv.Field(0).Interface(), rv.Field(1).Interface(), rv.Field(2).Interface())
-					}
-				case blockCountField:
-					fmt.Fprint(fs, f)
-				case blockSizesField, blockStartsField:
-					av := reflect.ValueOf(f)
-					l := av.Len()
-					for j := 0; j < l; j++ {
-						fmt.Fprint(fs, av.Index(j).Interface())
-						if j < l-1 {
-							fs.Write([]byte{','})
-						}
+			switch f := bv.Field(i).Interface().(type) {
+			case color.RGBA:
+				if f == (color.RGBA{}) {
+					fs.Write([]byte{'0'})
+				} else {
+					fmt.Fprintf(fs, "%d,%d,%d", f.R, f.G, f.B)
+				}
+			case []int:
+				for j, v := range f {
+					fmt.Fprint(fs, v)
+					if j < len(f)-1 {
+						fs.Write([]byte{','})
 					}
 				}
-			} else {
+			case float64:
+				fs.Write(strconv.AppendFloat(nil, f, 'g', -1, 64))
+			default:
 				fmt.Fprint(fs, f)
 			}
 			if i < width-1 {
@@ -477,10 +601,11 @@ type Writer struct {
 	BedType int
 }
 
-// Returns a new BED format writer using w.
+// Returns a new BED format writer using w. The BED type b must be 3, 4, 5, 6
+// or 12, or BroadPeakType or NarrowPeakType for ENCODE peak files.
 func NewWriter(w io.Writer, b int) (*Writer, error) {
 	switch b {
-	case 3, 4, 5, 6, 12:
+	case 3, 4, 5, 6, 12, BroadPeakType, NarrowPeakType:
 	default:
 		return nil, ErrBadBedType
 	}
@@ -512,6 +637,13 @@ func (w *Writer) Write(f feat.Feature) (n int, err error) {
 		if !f.canBed(w.BedType) {
 			return 0, ErrBadBedType
 		}
+		if isPeak(w.BedType) {
+			switch f.(type) {
+			case *NarrowPeak, *BroadPeak:
+			default:
+				return 0, ErrBadBedType
+			}
+		}
 		return fmt.Fprintf(w.w, "%*s", w.BedType, f)
 	}
 
@@ -6,6 +6,7 @@ package bed
 
 import (
 	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/feat/gene"
 	"github.com/biogo/biogo/seq"
 
 	"bytes"
@@ -318,6 +319,10 @@ func (s *S) TestWriteFeature(c *check.C) {
 			&ctf{tf: tf{chrom: Chrom("test chrom"), start: 1, end: 99, name: "test feat"}, score: 100, strand: +1}, 12,
 			"test chrom\t1\t99\ttest feat\t100\t+\n", ErrBadBedType,
 		},
+		{
+			&ctf{tf: tf{chrom: Chrom("test chrom"), start: 1, end: 99, name: "test feat"}, score: 100, strand: +1}, NarrowPeakType,
+			"test chrom\t1\t99\ttest feat\t100\t+\n", ErrBadBedType,
+		},
 	} {
 		buf := &bytes.Buffer{}
 		w, err := NewWriter(buf, f.typ)
@@ -328,3 +333,205 @@ func (s *S) TestWriteFeature(c *check.C) {
 		c.Check(buf.String(), check.Equals, f.line, check.Commentf("Test: %d type: Bed%d", i, f.typ))
 	}
 }
+
+var peakTests = []struct {
+	typ  int
+	line string
+	bed  Bed
+}{
+	{
+		NarrowPeakType, "chr1\t9356548\t9356648\t.\t0\t.\t182\t5.0945\t-1\t50\n",
+		&NarrowPeak{"chr1", 9356548, 9356648, ".", 0, seq.None, 182, 5.0945, -1, 50},
+	},
+	{
+		NarrowPeakType, "chr2\t100\t250\tpeak1\t1000\t+\t12.5\t1e-05\t0.25\t-1\n",
+		&NarrowPeak{"chr2", 100, 250, "peak1", 1000, seq.Plus, 12.5, 1e-5, 0.25, -1},
+	},
+	{
+		BroadPeakType, "chr1\t9356548\t9357548\tregion1\t900\t-\t3.25\t-1\t-1\n",
+		&BroadPeak{"chr1", 9356548, 9357548, "region1", 900, seq.Minus, 3.25, -1, -1},
+	},
+}
+
+func (s *S) TestReadPeak(c *check.C) {
+	for i, t := range peakTests {
+		r, err := NewReader(strings.NewReader(t.line), t.typ)
+		c.Assert(err, check.Equals, nil)
+		f, err := r.Read()
+		c.Check(err, check.Equals, nil)
+		c.Check(f, check.DeepEquals, t.bed, check.Commentf("Test: %d", i))
+	}
+
+	r, err := NewReader(strings.NewReader(peakTests[0].line), BroadPeakType)
+	c.Assert(err, check.Equals, nil)
+	f, err := r.Read()
+	c.Check(err, check.Equals, nil)
+	c.Check(f, check.DeepEquals, &BroadPeak{"chr1", 9356548, 9356648, ".", 0, seq.None, 182, 5.0945, -1})
+
+	r, err = NewReader(strings.NewReader(peakTests[2].line), NarrowPeakType)
+	c.Assert(err, check.Equals, nil)
+	_, err = r.Read()
+	c.Check(err, check.ErrorMatches, fmt.Sprintf("%s.*", ErrBadBedType))
+
+	r, err = NewReader(strings.NewReader("chr1\t0\t10\t.\t0\t.\tnone\t-1\t-1\t5\n"), NarrowPeakType)
+	c.Assert(err, check.Equals, nil)
+	_, err = r.Read()
+	c.Check(err, check.ErrorMatches, `.*line 1, column 6: .*invalid syntax`)
+}
+
+func (s *S) TestWritePeak(c *check.C) {
+	for i, t := range peakTests {
+		buf := &bytes.Buffer{}
+		w, err := NewWriter(buf, t.typ)
+		c.Assert(err, check.Equals, nil)
+		n, err := w.Write(t.bed)
+		c.Check(err, check.Equals, nil)
+		c.Check(n, check.Equals, buf.Len())
+		c.Check(buf.String(), check.Equals, t.line, check.Commentf("Test: %d", i))
+
+		buf.Reset()
+		w, err = NewWriter(buf, 6)
+		c.Assert(err, check.Equals, nil)
+		_, err = w.Write(t.bed)
+		c.Check(err, check.Equals, nil)
+		c.Check(buf.String(), check.Equals, strings.Join(strings.Split(t.line, "\t")[:6], "\t")+"\n")
+
+		c.Check(fmt.Sprintf("%s", t.bed), check.Equals, strings.TrimSuffix(t.line, "\n"))
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, BroadPeakType)
+	c.Assert(err, check.Equals, nil)
+	_, err = w.Write(peakTests[1].bed)
+	c.Check(err, check.Equals, nil)
+	c.Check(buf.String(), check.Equals, "chr2\t100\t250\tpeak1\t1000\t+\t12.5\t1e-05\t0.25\n")
+
+	for _, b := range []Bed{
+		&Bed12{"chr1", 11873, 14409, "uc001aaa.3", 3, seq.Plus, 11873, 11873, color.RGBA{}, 3, []int{354, 109, 1189}, []int{0, 739, 1347}},
+		peakTests[2].bed,
+	} {
+		buf.Reset()
+		w, err = NewWriter(buf, NarrowPeakType)
+		c.Assert(err, check.Equals, nil)
+		n, err := w.Write(b)
+		c.Check(n, check.Equals, 0)
+		c.Check(err, check.Equals, ErrBadBedType)
+		c.Check(buf.String(), check.Equals, "")
+	}
+}
+
+func (s *S) TestTranscript(c *check.C) {
+	for _, t := range []struct {
+		bed    *Bed12
+		coding bool
+		utr5   [2]int
+		utr3   [2]int
+		err    error
+	}{
+		{
+			bed: &Bed12{
+				Chrom: "chr1", ChromStart: 1000, ChromEnd: 5000, FeatName: "tx1", FeatStrand: seq.Plus,
+				ThickStart: 1200, ThickEnd: 4500,
+				BlockCount: 3, BlockSizes: []int{500, 200, 1000}, BlockStarts: []int{0, 1500, 3000},
+			},
+			coding: true,
+			utr5:   [2]int{0, 200},
+			utr3:   [2]int{3500, 4000},
+		},
+		{
+			bed: &Bed12{
+				Chrom: "chr2", ChromStart: 1000, ChromEnd: 5000, FeatName: "tx2", FeatStrand: seq.Minus,
+				ThickStart: 1200, ThickEnd: 4500,
+				BlockCount: 3, BlockSizes: []int{500, 200, 1000}, BlockStarts: []int{0, 1500, 3000},
+			},
+			coding: true,
+			utr5:   [2]int{3500, 4000},
+			utr3:   [2]int{0, 200},
+		},
+		{
+			bed: &Bed12{
+				Chrom: "chr3", ChromStart: 100, ChromEnd: 300, FeatName: "nc1", FeatStrand: seq.Plus,
+				ThickStart: 100, ThickEnd: 100,
+				BlockCount: 2, BlockSizes: []int{50, 50}, BlockStarts: []int{0, 150},
+			},
+		},
+		{
+			bed: &Bed12{
+				Chrom: "chr3", ChromStart: 100, ChromEnd: 400, FeatStrand: seq.Plus,
+				ThickStart: 100, ThickEnd: 100,
+				BlockCount: 2, BlockSizes: []int{50, 50}, BlockStarts: []int{0, 150},
+			},
+			err: ErrBadBlocks,
+		},
+		{
+			bed: &Bed12{
+				Chrom: "chr3", ChromStart: 100, ChromEnd: 300, FeatStrand: seq.Plus,
+				ThickStart: 50, ThickEnd: 200,
+				BlockCount: 1, BlockSizes: []int{200}, BlockStarts: []int{0},
+			},
+			err: ErrBadThick,
+		},
+		{
+			bed: &Bed12{
+				Chrom: "chr3", ChromStart: 100, ChromEnd: 300, FeatStrand: seq.Plus,
+				BlockCount: 2, BlockSizes: []int{200}, BlockStarts: []int{0},
+			},
+			err: ErrMissingBlockValues,
+		},
+	} {
+		tr, err := t.bed.Transcript()
+		c.Check(err, check.Equals, t.err)
+		if err != nil {
+			continue
+		}
+		c.Check(tr.Start(), check.Equals, t.bed.ChromStart)
+		c.Check(tr.End(), check.Equals, t.bed.ChromEnd)
+		c.Check(tr.Location().Name(), check.Equals, t.bed.Chrom)
+		c.Check(tr.Orientation(), check.Equals, feat.Orientation(t.bed.FeatStrand))
+		c.Check(len(tr.Exons()), check.Equals, t.bed.BlockCount)
+		ct, ok := tr.(*gene.CodingTranscript)
+		c.Check(ok, check.Equals, t.coding)
+		if ok {
+			c.Check([2]int{ct.UTR5start(), ct.UTR5end()}, check.Equals, t.utr5)
+			c.Check([2]int{ct.UTR3start(), ct.UTR3end()}, check.Equals, t.utr3)
+		}
+
+		b, err := FromTranscript(tr)
+		c.Assert(err, check.Equals, nil)
+		c.Check(b, check.DeepEquals, t.bed)
+	}
+}
+
+func (s *S) TestFromTranscript(c *check.C) {
+	g := &gene.Gene{ID: "g1", Chrom: Chrom("chr1"), Offset: 1000, Orient: feat.Reverse}
+	tr := &gene.CodingTranscript{ID: "tx1", Loc: g, Offset: 0, Orient: feat.Forward, CDSstart: 100, CDSend: 900}
+	c.Assert(tr.SetExons(
+		gene.Exon{Transcript: tr, Offset: 0, Length: 300},
+		gene.Exon{Transcript: tr, Offset: 600, Length: 400},
+	), check.Equals, nil)
+	c.Assert(g.SetFeatures(tr), check.Equals, nil)
+
+	b, err := FromTranscript(tr)
+	c.Assert(err, check.Equals, nil)
+	c.Check(b, check.DeepEquals, &Bed12{
+		Chrom: "chr1", ChromStart: 1000, ChromEnd: 2000, FeatName: "tx1", FeatStrand: seq.Minus,
+		ThickStart: 1100, ThickEnd: 1900,
+		BlockCount: 2, BlockSizes: []int{300, 400}, BlockStarts: []int{0, 600},
+	})
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, 12)
+	c.Assert(err, check.Equals, nil)
+	_, err = w.Write(b)
+	c.Assert(err, check.Equals, nil)
+	r, err := NewReader(&buf, 12)
+	c.Assert(err, check.Equals, nil)
+	f, err := r.Read()
+	c.Assert(err, check.Equals, nil)
+	got, err := f.(*Bed12).Transcript()
+	c.Assert(err, check.Equals, nil)
+	c.Check(got.(*gene.CodingTranscript).UTR5start(), check.Equals, 900)
+
+	_, err = FromTranscript(&gene.NonCodingTranscript{ID: "empty"})
+	c.Check(err, check.Equals, ErrNoExons)
+}
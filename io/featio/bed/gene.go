@@ -0,0 +1,109 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bed
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/feat/gene"
+	"github.com/biogo/biogo/seq"
+
+	"errors"
+)
+
+var (
+	ErrBadBlocks    = errors.New("bed: blocks do not span feature")
+	ErrBadThick     = errors.New("bed: thick region outside feature")
+	ErrNoExons      = errors.New("bed: transcript has no exons")
+	ErrNoTranscript = errors.New("bed: transcript has no location")
+)
+
+// Transcript returns the gene transcript described by b. The transcript is
+// located on Chrom(b.Chrom) with its exons defined by the blocks of b. If the
+// thick region of b is empty a *gene.NonCodingTranscript is returned,
+// otherwise a *gene.CodingTranscript is returned with its CDS defined by the
+// thick region. The orientation of the transcript is taken from the strand
+// of b.
+func (b *Bed12) Transcript() (gene.Transcript, error) {
+	if b.BlockCount != len(b.BlockSizes) || b.BlockCount != len(b.BlockStarts) {
+		return nil, ErrMissingBlockValues
+	}
+	if b.BlockCount == 0 {
+		return nil, ErrNoExons
+	}
+	if b.ThickStart < b.ChromStart || b.ThickEnd > b.ChromEnd || b.ThickStart > b.ThickEnd {
+		return nil, ErrBadThick
+	}
+
+	var t gene.Transcript
+	if b.ThickStart == b.ThickEnd {
+		t = &gene.NonCodingTranscript{
+			ID:     b.FeatName,
+			Loc:    Chrom(b.Chrom),
+			Offset: b.ChromStart,
+			Orient: feat.Orientation(b.FeatStrand),
+		}
+	} else {
+		t = &gene.CodingTranscript{
+			ID:       b.FeatName,
+			Loc:      Chrom(b.Chrom),
+			Offset:   b.ChromStart,
+			Orient:   feat.Orientation(b.FeatStrand),
+			CDSstart: b.ThickStart - b.ChromStart,
+			CDSend:   b.ThickEnd - b.ChromStart,
+		}
+	}
+	exons := make([]gene.Exon, b.BlockCount)
+	for i := range exons {
+		exons[i] = gene.Exon{Transcript: t, Offset: b.BlockStarts[i], Length: b.BlockSizes[i]}
+	}
+	err := t.SetExons(exons...)
+	if err != nil {
+		return nil, err
+	}
+	if t.End() != b.ChromEnd {
+		return nil, ErrBadBlocks
+	}
+	return t, nil
+}
+
+// FromTranscript returns a Bed12 describing the transcript t. The coordinates,
+// strand and chromosome name of the returned Bed12 are those of t relative to
+// the base of its location chain, so t may be located on a gene or directly on
+// a chromosome. The blocks are defined by the exons of t and, if t is a
+// *gene.CodingTranscript, the thick region by its CDS. For other transcripts
+// the thick region is empty and placed at the feature start.
+func FromTranscript(t gene.Transcript) (*Bed12, error) {
+	exons := t.Exons()
+	if len(exons) == 0 {
+		return nil, ErrNoExons
+	}
+	start, ref := feat.BasePositionOf(t, 0)
+	if ref == t {
+		return nil, ErrNoTranscript
+	}
+	ori, _ := feat.BaseOrientationOf(t)
+
+	b := &Bed12{
+		Chrom:       ref.Name(),
+		ChromStart:  start,
+		ChromEnd:    start + t.Len(),
+		FeatName:    t.Name(),
+		FeatStrand:  seq.Strand(ori),
+		ThickStart:  start,
+		ThickEnd:    start,
+		BlockCount:  len(exons),
+		BlockSizes:  make([]int, len(exons)),
+		BlockStarts: make([]int, len(exons)),
+	}
+	if ct, ok := t.(*gene.CodingTranscript); ok {
+		b.ThickStart = start + ct.CDSstart
+		b.ThickEnd = start + ct.CDSend
+	}
+	for i, e := range exons {
+		b.BlockSizes[i] = e.Len()
+		b.BlockStarts[i] = e.Start()
+	}
+	return b, nil
+}
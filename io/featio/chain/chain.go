@@ -0,0 +1,320 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package chain provides types to read and write UCSC chain format alignment
+// files.
+//
+// The specification can be found at http://genome.ucsc.edu/goldenPath/help/chain.html.
+package chain
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/feat/genome"
+	"github.com/biogo/biogo/io/featio"
+	"github.com/biogo/biogo/seq"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ featio.Reader = (*Reader)(nil)
+	_ featio.Writer = (*Writer)(nil)
+
+	_ feat.Feature  = (*Chain)(nil)
+	_ feat.Orienter = (*Chain)(nil)
+	_ feat.Feature  = (*Feature)(nil)
+	_ feat.Orienter = (*Feature)(nil)
+	_ feat.Pair     = (*Block)(nil)
+)
+
+var (
+	ErrBadHeader    = errors.New("chain: malformed chain header")
+	ErrFieldMissing = errors.New("chain: missing fields")
+	ErrBadStrand    = errors.New("chain: invalid strand")
+	ErrBadBlocks    = errors.New("chain: blocks do not match header")
+	ErrNotHandled   = errors.New("chain: type not handled")
+)
+
+const (
+	keywordField = iota
+	scoreField
+	tNameField
+	tSizeField
+	tStrandField
+	tStartField
+	tEndField
+	qNameField
+	qSizeField
+	qStrandField
+	qStartField
+	qEndField
+	idField
+)
+
+// Feature is a gap-free aligned segment of a sequence. Coordinates are
+// zero-based half-open positions on the forward strand of Chrom.
+type Feature struct {
+	Chrom      *genome.Chromosome
+	FeatStart  int
+	FeatEnd    int
+	FeatStrand seq.Strand
+}
+
+func (f *Feature) Start() int { return f.FeatStart }
+func (f *Feature) End() int   { return f.FeatEnd }
+func (f *Feature) Len() int   { return f.FeatEnd - f.FeatStart }
+func (f *Feature) Name() string {
+	return fmt.Sprintf("%s:[%d,%d)", f.Chrom.Chr, f.FeatStart, f.FeatEnd)
+}
+func (f *Feature) Description() string           { return "chain block" }
+func (f *Feature) Location() feat.Feature        { return f.Chrom }
+func (f *Feature) Orientation() feat.Orientation { return feat.Orientation(f.FeatStrand) }
+
+// Block is a gap-free aligned block between a target and a query sequence.
+type Block struct {
+	Target, Query Feature
+}
+
+// Features returns the target and query features of the block.
+func (b *Block) Features() [2]feat.Feature { return [2]feat.Feature{&b.Target, &b.Query} }
+
+// Chain is an ordered set of gap-free aligned blocks between a target and a
+// query sequence.
+type Chain struct {
+	Score float64
+	ID    int
+
+	// Target and Query are the aligned sequences. Start and end
+	// positions are given as they appear in the chain header, on
+	// the strand of the sequence specified by TStrand or QStrand.
+	Target  *genome.Chromosome
+	TStrand seq.Strand
+	TStart  int
+	TEnd    int
+	Query   *genome.Chromosome
+	QStrand seq.Strand
+	QStart  int
+	QEnd    int
+
+	// Blocks holds the gap-free aligned blocks of the chain
+	// in chain order. Block features are positioned on the
+	// forward strand of the aligned sequences.
+	Blocks []*Block
+}
+
+func (c *Chain) Start() int                    { return c.TStart }
+func (c *Chain) End() int                      { return c.TEnd }
+func (c *Chain) Len() int                      { return c.TEnd - c.TStart }
+func (c *Chain) Name() string                  { return c.Query.Chr }
+func (c *Chain) Description() string           { return "chain" }
+func (c *Chain) Location() feat.Feature        { return c.Target }
+func (c *Chain) Orientation() feat.Orientation { return feat.Orientation(c.QStrand) }
+
+// Pairs returns the blocks of the chain as feat.Pair values.
+func (c *Chain) Pairs() []feat.Pair {
+	p := make([]feat.Pair, len(c.Blocks))
+	for i, b := range c.Blocks {
+		p[i] = b
+	}
+	return p
+}
+
+// forward returns the forward strand coordinates of the segment [start, end)
+// given in strand coordinates on a sequence of length size.
+func forward(start, end, size int, strand seq.Strand) (int, int) {
+	if strand == seq.Minus {
+		return size - end, size - start
+	}
+	return start, end
+}
+
+func readLine(r *bufio.Reader, line *int) (string, error) {
+	b, err := r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(b) == 0) {
+		return "", err
+	}
+	*line++
+	return string(bytes.TrimRight(b, "\r\n")), nil
+}
+
+func parseStrand(s string) (seq.Strand, error) {
+	switch s {
+	case "+":
+		return seq.Plus, nil
+	case "-":
+		return seq.Minus, nil
+	}
+	return seq.None, ErrBadStrand
+}
+
+// Reader is a chain format reader.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader returns a new chain format reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read reads a single chain, returning it as a *Chain, or an error.
+func (r *Reader) Read() (feat.Feature, error) {
+	var line string
+	for {
+		var err error
+		line, err = readLine(r.r, &r.line)
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, &csv.ParseError{Line: r.line, Err: err}
+		}
+		if len(strings.TrimSpace(line)) != 0 && !strings.HasPrefix(line, "#") {
+			break
+		}
+	}
+	c, err := r.header(line)
+	if err != nil {
+		return nil, err
+	}
+
+	t, q := c.TStart, c.QStart
+	for {
+		line, err = readLine(r.r, &r.line)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, &csv.ParseError{Line: r.line, Err: err}
+		}
+		f := strings.Fields(line)
+		if len(f) != 1 && len(f) != 3 {
+			return nil, &csv.ParseError{Line: r.line, Column: len(f), Err: ErrFieldMissing}
+		}
+		var n [3]int
+		for i, v := range f {
+			n[i], err = strconv.Atoi(v)
+			if err != nil {
+				return nil, &csv.ParseError{Line: r.line, Column: i, Err: err}
+			}
+		}
+		size, dt, dq := n[0], n[1], n[2]
+		b := &Block{
+			Target: Feature{Chrom: c.Target, FeatStrand: c.TStrand},
+			Query:  Feature{Chrom: c.Query, FeatStrand: c.QStrand},
+		}
+		b.Target.FeatStart, b.Target.FeatEnd = forward(t, t+size, c.Target.Length, c.TStrand)
+		b.Query.FeatStart, b.Query.FeatEnd = forward(q, q+size, c.Query.Length, c.QStrand)
+		c.Blocks = append(c.Blocks, b)
+		t += size + dt
+		q += size + dq
+		if len(f) == 1 {
+			break
+		}
+	}
+	if t != c.TEnd || q != c.QEnd {
+		return nil, &csv.ParseError{Line: r.line, Err: ErrBadBlocks}
+	}
+
+	return c, nil
+}
+
+func (r *Reader) header(line string) (*Chain, error) {
+	f := strings.Fields(line)
+	if len(f) < idField || f[keywordField] != "chain" {
+		return nil, &csv.ParseError{Line: r.line, Err: ErrBadHeader}
+	}
+	c := &Chain{
+		Target: &genome.Chromosome{Chr: f[tNameField]},
+		Query:  &genome.Chromosome{Chr: f[qNameField]},
+	}
+	var err error
+	c.Score, err = strconv.ParseFloat(f[scoreField], 64)
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: scoreField, Err: err}
+	}
+	for _, v := range []struct {
+		field int
+		dst   *int
+	}{
+		{tSizeField, &c.Target.Length},
+		{tStartField, &c.TStart},
+		{tEndField, &c.TEnd},
+		{qSizeField, &c.Query.Length},
+		{qStartField, &c.QStart},
+		{qEndField, &c.QEnd},
+	} {
+		*v.dst, err = strconv.Atoi(f[v.field])
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: v.field, Err: err}
+		}
+	}
+	if len(f) > idField {
+		c.ID, err = strconv.Atoi(f[idField])
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: idField, Err: err}
+		}
+	}
+	c.TStrand, err = parseStrand(f[tStrandField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: tStrandField, Err: err}
+	}
+	c.QStrand, err = parseStrand(f[qStrandField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: qStrandField, Err: err}
+	}
+	return c, nil
+}
+
+// Writer is a chain format writer.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a new chain format writer using w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes a single chain and returns the number of bytes written and
+// any error. Only *Chain values are handled. Gaps between blocks are
+// calculated from the positions of adjacent blocks.
+func (w *Writer) Write(f feat.Feature) (n int, err error) {
+	c, ok := f.(*Chain)
+	if !ok {
+		return 0, ErrNotHandled
+	}
+	if len(c.Blocks) == 0 {
+		return 0, ErrBadBlocks
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "chain %s %s %d %s %d %d %s %d %s %d %d %d\n",
+		strconv.FormatFloat(c.Score, 'f', -1, 64),
+		c.Target.Chr, c.Target.Length, c.TStrand, c.TStart, c.TEnd,
+		c.Query.Chr, c.Query.Length, c.QStrand, c.QStart, c.QEnd,
+		c.ID,
+	)
+	for i, blk := range c.Blocks {
+		fmt.Fprint(&b, blk.Query.Len())
+		if i < len(c.Blocks)-1 {
+			_, tEnd := forward(blk.Target.FeatStart, blk.Target.FeatEnd, c.Target.Length, c.TStrand)
+			_, qEnd := forward(blk.Query.FeatStart, blk.Query.FeatEnd, c.Query.Length, c.QStrand)
+			next := c.Blocks[i+1]
+			tNext, _ := forward(next.Target.FeatStart, next.Target.FeatEnd, c.Target.Length, c.TStrand)
+			qNext, _ := forward(next.Query.FeatStart, next.Query.FeatEnd, c.Query.Length, c.QStrand)
+			fmt.Fprintf(&b, "\t%d\t%d", tNext-tEnd, qNext-qEnd)
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return w.w.Write(b.Bytes())
+}
@@ -0,0 +1,146 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package chain
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/feat/genome"
+	"github.com/biogo/biogo/seq"
+
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const chains = `chain 4900 chrY 58368225 + 25985403 25985638 chr5 151006098 - 43257292 43257528 1
+9	1	0
+10	0	5
+61	4	0
+16	0	4
+42	3	0
+16	0	8
+14	1	0
+3	7	0
+48
+
+chain 1500.5 chr1 1000 + 100 160 chr2 500 + 10 75 2
+20	10	15
+30
+
+`
+
+func (s *S) TestRead(c *check.C) {
+	r := NewReader(strings.NewReader("#comment\n" + chains))
+
+	f, err := r.Read()
+	c.Assert(err, check.Equals, nil)
+	ch := f.(*Chain)
+	c.Check(ch.Score, check.Equals, 4900.)
+	c.Check(ch.ID, check.Equals, 1)
+	c.Check(*ch.Target, check.DeepEquals, genome.Chromosome{Chr: "chrY", Length: 58368225})
+	c.Check(*ch.Query, check.DeepEquals, genome.Chromosome{Chr: "chr5", Length: 151006098})
+	c.Check(ch.TStrand, check.Equals, seq.Plus)
+	c.Check(ch.QStrand, check.Equals, seq.Minus)
+	c.Check(ch.Location(), check.Equals, ch.Target)
+	c.Check(ch.Start(), check.Equals, 25985403)
+	c.Check(ch.End(), check.Equals, 25985638)
+	c.Assert(ch.Blocks, check.HasLen, 9)
+	c.Check(ch.Blocks[0], check.DeepEquals, &Block{
+		Target: Feature{ch.Target, 25985403, 25985412, seq.Plus},
+		Query:  Feature{ch.Query, 107748797, 107748806, seq.Minus},
+	})
+	c.Check(ch.Blocks[1], check.DeepEquals, &Block{
+		Target: Feature{ch.Target, 25985413, 25985423, seq.Plus},
+		Query:  Feature{ch.Query, 107748787, 107748797, seq.Minus},
+	})
+	c.Check(ch.Blocks[8], check.DeepEquals, &Block{
+		Target: Feature{ch.Target, 25985590, 25985638, seq.Plus},
+		Query:  Feature{ch.Query, 107748570, 107748618, seq.Minus},
+	})
+	p := ch.Pairs()
+	c.Assert(p, check.HasLen, 9)
+	c.Check(p[0].Features()[1].Location(), check.Equals, ch.Query)
+	ori, _ := feat.BaseOrientationOf(p[0].Features()[1])
+	c.Check(ori, check.Equals, feat.Reverse)
+
+	f, err = r.Read()
+	c.Assert(err, check.Equals, nil)
+	ch = f.(*Chain)
+	c.Check(ch.Score, check.Equals, 1500.5)
+	c.Check(ch.Blocks, check.DeepEquals, []*Block{
+		{Target: Feature{ch.Target, 100, 120, seq.Plus}, Query: Feature{ch.Query, 10, 30, seq.Plus}},
+		{Target: Feature{ch.Target, 130, 160, seq.Plus}, Query: Feature{ch.Query, 45, 75, seq.Plus}},
+	})
+
+	_, err = r.Read()
+	c.Check(err, check.Equals, io.EOF)
+}
+
+func (s *S) TestReadError(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		err string
+	}{
+		{
+			"chain 100 chr1 1000 + 100 160 chr2 500\n",
+			`.*line 1, column 0: chain: malformed chain header`,
+		},
+		{
+			"chain 100 chr1 1000 * 100 160 chr2 500 + 10 75 2\n60\n\n",
+			`.*line 1, column 4: chain: invalid strand`,
+		},
+		{
+			"chain 100 chr1 1000 + 100 160 chr2 500 + 10 75 2\n20\t10\n30\n",
+			`.*line 2, column 2: chain: missing fields`,
+		},
+		{
+			"chain 100 chr1 1000 + 100 160 chr2 500 + 10 75 2\n20\t10\t15\n",
+			`.*line 2, column 0: unexpected EOF`,
+		},
+		{
+			"chain 100 chr1 1000 + 100 160 chr2 500 + 10 75 2\n20\t10\t10\n30\n",
+			`.*line 3, column 0: chain: blocks do not match header`,
+		},
+	} {
+		_, err := NewReader(strings.NewReader(t.in)).Read()
+		c.Check(err, check.ErrorMatches, t.err)
+	}
+}
+
+func (s *S) TestWrite(c *check.C) {
+	var (
+		buf bytes.Buffer
+		w   = NewWriter(&buf)
+		r   = NewReader(strings.NewReader(chains))
+		n   int
+	)
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		_n, err := w.Write(f)
+		c.Check(err, check.Equals, nil)
+		n += _n
+	}
+	c.Check(n, check.Equals, buf.Len())
+	c.Check(buf.String(), check.Equals, chains)
+
+	_, err := w.Write(&Feature{})
+	c.Check(err, check.Equals, ErrNotHandled)
+	_, err = w.Write(&Chain{})
+	c.Check(err, check.Equals, ErrBadBlocks)
+}
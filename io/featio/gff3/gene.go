@@ -0,0 +1,154 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gff3
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/feat/gene"
+
+	"sort"
+)
+
+// Genes links the features in fs and assembles the gene hierarchies they
+// describe. A gene is a feature without parents that has at least one child
+// with exon, CDS or UTR children; each such child becomes a transcript of the
+// gene. Transcripts with CDS children are returned as *gene.CodingTranscript
+// spanning the CDS features, and others as *gene.NonCodingTranscript. When a
+// transcript has no exon children its exons are formed by merging its CDS and
+// UTR children. The Desc fields of genes and transcripts are taken from their
+// Name attributes.
+func Genes(fs []*Feature) ([]*gene.Gene, error) {
+	err := Link(fs)
+	if err != nil {
+		return nil, err
+	}
+	var genes []*gene.Gene
+	for _, f := range fs {
+		if len(f.Parents) != 0 {
+			continue
+		}
+		g := &gene.Gene{
+			ID:     f.Name(),
+			Chrom:  f.Location(),
+			Offset: f.FeatStart,
+			Orient: f.Orientation(),
+			Desc:   f.FeatAttributes.Get("Name"),
+		}
+		var ts []feat.Feature
+		for _, c := range f.Children {
+			t, err := transcript(g, f, c)
+			if err != nil {
+				return nil, err
+			}
+			if t != nil {
+				ts = append(ts, t)
+			}
+		}
+		if len(ts) == 0 {
+			continue
+		}
+		err = g.SetFeatures(ts...)
+		if err != nil {
+			return nil, err
+		}
+		genes = append(genes, g)
+	}
+	return genes, nil
+}
+
+// A Span is a half open interval.
+type Span struct{ Start, End int }
+
+// Spans is a slice of Span that sorts by start position.
+type Spans []Span
+
+func (s Spans) Len() int           { return len(s) }
+func (s Spans) Less(i, j int) bool { return s[i].Start < s[j].Start }
+func (s Spans) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Merge returns the union of the spans in the receiver, joining abutting spans.
+// The receiver is sorted in place.
+func (s Spans) Merge() Spans {
+	if len(s) == 0 {
+		return nil
+	}
+	sort.Sort(s)
+	m := Spans{s[0]}
+	for _, v := range s[1:] {
+		last := &m[len(m)-1]
+		if v.Start <= last.End {
+			if v.End > last.End {
+				last.End = v.End
+			}
+			continue
+		}
+		m = append(m, v)
+	}
+	return m
+}
+
+// transcript returns the transcript described by the feature t, a child of
+// the gene feature g, or nil if t has no exon, CDS or UTR children.
+func transcript(gn *gene.Gene, g, t *Feature) (gene.Transcript, error) {
+	var (
+		exons, parts Spans
+		cds          = Span{Start: t.FeatEnd, End: t.FeatStart}
+		coding       bool
+	)
+	for _, c := range t.Children {
+		s := Span{c.FeatStart - t.FeatStart, c.FeatEnd - t.FeatStart}
+		switch c.Type {
+		case "exon":
+			exons = append(exons, s)
+		case "CDS":
+			coding = true
+			if c.FeatStart < cds.Start {
+				cds.Start = c.FeatStart
+			}
+			if c.FeatEnd > cds.End {
+				cds.End = c.FeatEnd
+			}
+			parts = append(parts, s)
+		case "five_prime_UTR", "three_prime_UTR", "UTR":
+			parts = append(parts, s)
+		}
+	}
+	if exons == nil {
+		if parts == nil {
+			return nil, nil
+		}
+		exons = parts.Merge()
+	}
+
+	orient := feat.Forward
+	if t.FeatStrand*g.FeatStrand < 0 {
+		orient = feat.Reverse
+	}
+	var tr gene.Transcript
+	if coding {
+		tr = &gene.CodingTranscript{
+			ID:       t.Name(),
+			Loc:      gn,
+			Offset:   t.FeatStart - g.FeatStart,
+			Orient:   orient,
+			Desc:     t.FeatAttributes.Get("Name"),
+			CDSstart: cds.Start - t.FeatStart,
+			CDSend:   cds.End - t.FeatStart,
+		}
+	} else {
+		tr = &gene.NonCodingTranscript{
+			ID:     t.Name(),
+			Loc:    gn,
+			Offset: t.FeatStart - g.FeatStart,
+			Orient: orient,
+			Desc:   t.FeatAttributes.Get("Name"),
+		}
+	}
+	e := make([]gene.Exon, len(exons))
+	for i, s := range exons {
+		e[i] = gene.Exon{Transcript: tr, Offset: s.Start, Length: s.End - s.Start}
+	}
+	return tr, tr.SetExons(e...)
+}
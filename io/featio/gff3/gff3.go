@@ -0,0 +1,605 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gff3 provides types to read and write version 3 General Feature
+// Format files according to the Sequence Ontology specification.
+//
+// The specification can be found at https://github.com/The-Sequence-Ontology/Specifications/blob/master/gff3.md.
+package gff3
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/featio"
+	"github.com/biogo/biogo/io/featio/gff"
+	"github.com/biogo/biogo/io/seqio"
+	"github.com/biogo/biogo/io/seqio/fasta"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ featio.Reader = (*Reader)(nil)
+	_ featio.Writer = (*Writer)(nil)
+)
+
+// Version is the GFF version that is read and written.
+const Version = 3
+
+var (
+	ErrBadFeature    = errors.New("gff3: feature start greater than feature end")
+	ErrFieldMissing  = errors.New("gff3: missing fields")
+	ErrBadStrand     = errors.New("gff3: invalid strand")
+	ErrBadPhase      = errors.New("gff3: invalid phase")
+	ErrBadAttribute  = errors.New("gff3: malformed attribute")
+	ErrBadEscape     = errors.New("gff3: invalid percent encoding")
+	ErrBadMetaLine   = errors.New("gff3: incomplete metaline")
+	ErrNotHandled    = errors.New("gff3: type not handled")
+	ErrCannotHeader  = errors.New("gff3: cannot write header: data written")
+	ErrAfterFASTA    = errors.New("gff3: feature after FASTA section")
+	ErrMissingParent = errors.New("gff3: parent feature not found")
+)
+
+const (
+	nameField = iota
+	sourceField
+	typeField
+	startField
+	endField
+	scoreField
+	strandField
+	phaseField
+	attributeField
+	lastField
+)
+
+// An Attribute is a GFF3 attribute tag and its values. Multiple values are
+// separated by commas in GFF3 files.
+type Attribute struct {
+	Tag    string
+	Values []string
+}
+
+// Attributes is a collection of GFF3 attributes.
+type Attributes []Attribute
+
+// Get returns the first value of the attribute with the given tag.
+func (a Attributes) Get(tag string) string {
+	for _, tv := range a {
+		if tv.Tag == tag && len(tv.Values) != 0 {
+			return tv.Values[0]
+		}
+	}
+	return ""
+}
+
+// Values returns all the values of the attribute with the given tag.
+func (a Attributes) Values(tag string) []string {
+	for _, tv := range a {
+		if tv.Tag == tag {
+			return tv.Values
+		}
+	}
+	return nil
+}
+
+// A Feature represents a GFF3 feature line.
+type Feature struct {
+	// The ID of the landmark establishing the coordinate system for the
+	// feature.
+	SeqName string
+
+	// The source of the feature, typically the name of a program or
+	// database.
+	Source string
+
+	// The type of the feature, constrained to be a Sequence Ontology
+	// term or accession.
+	Type string
+
+	// FeatStart and FeatEnd are zero-based half open. Translation from the
+	// one-based closed coordinates of GFF3 is handled by the gff3 package.
+	FeatStart, FeatEnd int
+
+	// The score of the feature. A nil value indicates the score is not
+	// available.
+	FeatScore *float64
+
+	// The strand of the feature - one of seq.Plus, seq.Minus or seq.None.
+	// Features with an unknown strand, '?', are read as seq.None.
+	FeatStrand seq.Strand
+
+	// The phase of CDS features, or gff.NoFrame.
+	FeatPhase gff.Frame
+
+	// The decoded attributes of the feature.
+	FeatAttributes Attributes
+
+	// Parents and Children hold the features related by Parent
+	// attributes. They are set by Link.
+	Parents  []*Feature
+	Children []*Feature
+}
+
+func (f *Feature) Start() int { return f.FeatStart }
+func (f *Feature) End() int   { return f.FeatEnd }
+func (f *Feature) Len() int   { return f.FeatEnd - f.FeatStart }
+
+// Name returns the ID attribute of the feature, or if that is absent, a name
+// constructed from the feature type and location.
+func (f *Feature) Name() string {
+	if id := f.FeatAttributes.Get("ID"); id != "" {
+		return id
+	}
+	return fmt.Sprintf("%s/%s:[%d,%d)", f.Type, f.SeqName, f.FeatStart, f.FeatEnd)
+}
+func (f *Feature) Description() string    { return fmt.Sprintf("%s/%s", f.Type, f.Source) }
+func (f *Feature) Location() feat.Feature { return gff.Sequence{SeqName: f.SeqName} }
+
+// Orientation returns the orientation of the feature corresponding to its
+// strand.
+func (f *Feature) Orientation() feat.Orientation { return feat.Orientation(f.FeatStrand) }
+
+// Metadata holds the directives of a GFF3 file.
+type Metadata struct {
+	Version     string // The full version given by ##gff-version.
+	Species     string // ##species
+	GenomeBuild string // ##genome-build
+	Other       []string
+}
+
+// A Reader can parse GFF3 formatted io.Reader and return feat.Features.
+type Reader struct {
+	r     *bufio.Reader
+	line  int
+	fasta *fasta.Reader
+
+	// Template is the sequence type used to return sequences held in
+	// the ##FASTA section. It defaults to a *linear.Seq with the
+	// alphabet.DNAredundant alphabet.
+	Template seqio.SequenceAppender
+
+	Metadata
+}
+
+// NewReader returns a new GFF3 format reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:        bufio.NewReader(r),
+		Template: linear.NewSeq("", nil, alphabet.DNAredundant),
+	}
+}
+
+// Read reads a single feature and returns it or an error. Feature lines are
+// returned as *Feature, ##sequence-region directives as *gff.Region and the
+// sequences in the ##FASTA section as seq.Sequence values of the Reader's
+// Template type. A call to Read may have side effects on the Reader's
+// Metadata field.
+func (r *Reader) Read() (feat.Feature, error) {
+	if r.fasta != nil {
+		return r.readSeq()
+	}
+	var line []byte
+	for {
+		var err error
+		line, err = r.r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err == io.EOF {
+				return nil, err
+			}
+			return nil, &csv.ParseError{Line: r.line, Err: err}
+		}
+		r.line++
+		line = bytes.TrimRight(line, "\r\n")
+		switch {
+		case len(bytes.TrimSpace(line)) == 0:
+			continue
+		case line[0] == '>':
+			// An implicit FASTA section.
+			r.fasta = fasta.NewReader(io.MultiReader(bytes.NewReader(append(line, '\n')), r.r), r.Template)
+			return r.readSeq()
+		case bytes.HasPrefix(line, []byte("##")):
+			f, err := r.directive(string(line[2:]))
+			if f != nil || err != nil {
+				return f, err
+			}
+			if r.fasta != nil {
+				return r.readSeq()
+			}
+			continue
+		case line[0] == '#':
+			continue
+		}
+		break
+	}
+
+	fields := strings.Split(string(line), "\t")
+	if len(fields) < lastField-1 {
+		return nil, &csv.ParseError{Line: r.line, Column: len(fields), Err: ErrFieldMissing}
+	}
+	return r.parse(fields)
+}
+
+func (r *Reader) readSeq() (feat.Feature, error) {
+	s, err := r.fasta.Read()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// directive handles a ## line. It returns a non-nil feature only for
+// sequence-region directives.
+func (r *Reader) directive(d string) (feat.Feature, error) {
+	fields := strings.Fields(d)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	switch fields[0] {
+	case "#":
+		// The ### forward reference resolution directive.
+	case "gff-version":
+		if len(fields) < 2 {
+			return nil, &csv.ParseError{Line: r.line, Err: ErrBadMetaLine}
+		}
+		major := fields[1]
+		if i := strings.Index(major, "."); i >= 0 {
+			major = major[:i]
+		}
+		if v, err := strconv.Atoi(major); err != nil || v != Version {
+			return nil, &csv.ParseError{Line: r.line, Err: ErrNotHandled}
+		}
+		r.Version = fields[1]
+	case "sequence-region":
+		if len(fields) < 4 {
+			return nil, &csv.ParseError{Line: r.line, Err: ErrBadMetaLine}
+		}
+		name, err := unescape(fields[1])
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: 1, Err: err}
+		}
+		start, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: 2, Err: err}
+		}
+		end, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: 3, Err: err}
+		}
+		return &gff.Region{
+			Sequence:    gff.Sequence{SeqName: name},
+			RegionStart: feat.OneToZero(start),
+			RegionEnd:   end,
+		}, nil
+	case "species":
+		r.Species = strings.TrimSpace(d[len("species"):])
+	case "genome-build":
+		r.GenomeBuild = strings.TrimSpace(d[len("genome-build"):])
+	case "FASTA":
+		r.fasta = fasta.NewReader(r.r, r.Template)
+	default:
+		r.Other = append(r.Other, d)
+	}
+	return nil, nil
+}
+
+func (r *Reader) parse(fields []string) (*Feature, error) {
+	var (
+		f   = &Feature{}
+		err error
+	)
+	for i, p := range []*string{&f.SeqName, &f.Source, &f.Type} {
+		*p, err = unescape(fields[i])
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: i, Err: err}
+		}
+	}
+	start, err := strconv.Atoi(fields[startField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: startField, Err: err}
+	}
+	f.FeatStart = feat.OneToZero(start)
+	f.FeatEnd, err = strconv.Atoi(fields[endField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: endField, Err: err}
+	}
+	if f.FeatStart > f.FeatEnd {
+		return nil, &csv.ParseError{Line: r.line, Column: endField, Err: ErrBadFeature}
+	}
+	if fields[scoreField] != "." {
+		s, err := strconv.ParseFloat(fields[scoreField], 64)
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: scoreField, Err: err}
+		}
+		f.FeatScore = &s
+	}
+	switch fields[strandField] {
+	case "+":
+		f.FeatStrand = seq.Plus
+	case "-":
+		f.FeatStrand = seq.Minus
+	case ".", "?":
+		f.FeatStrand = seq.None
+	default:
+		return nil, &csv.ParseError{Line: r.line, Column: strandField, Err: ErrBadStrand}
+	}
+	switch fields[phaseField] {
+	case ".":
+		f.FeatPhase = gff.NoFrame
+	case "0", "1", "2":
+		f.FeatPhase = gff.Frame(fields[phaseField][0] - '0')
+	default:
+		return nil, &csv.ParseError{Line: r.line, Column: phaseField, Err: ErrBadPhase}
+	}
+	if len(fields) > attributeField {
+		f.FeatAttributes, err = parseAttributes(fields[attributeField])
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: attributeField, Err: err}
+		}
+	}
+	return f, nil
+}
+
+// parseAttributes parses the attribute column of a feature line.
+func parseAttributes(s string) (Attributes, error) {
+	if s == "." || s == "" {
+		return nil, nil
+	}
+	var a Attributes
+	for _, tv := range strings.Split(s, ";") {
+		tv = strings.TrimSpace(tv)
+		if tv == "" {
+			continue
+		}
+		i := strings.Index(tv, "=")
+		if i <= 0 {
+			return nil, ErrBadAttribute
+		}
+		tag, err := unescape(tv[:i])
+		if err != nil {
+			return nil, err
+		}
+		vals := strings.Split(tv[i+1:], ",")
+		for j, v := range vals {
+			vals[j], err = unescape(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		a = append(a, Attribute{Tag: tag, Values: vals})
+	}
+	return a, nil
+}
+
+// unescape decodes the percent-encoded characters in s.
+func unescape(s string) (string, error) {
+	if strings.IndexByte(s, '%') < 0 {
+		return s, nil
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b = append(b, s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", ErrBadEscape
+		}
+		h, ok := unhex(s[i+1])
+		if !ok {
+			return "", ErrBadEscape
+		}
+		l, ok := unhex(s[i+2])
+		if !ok {
+			return "", ErrBadEscape
+		}
+		b = append(b, h<<4|l)
+		i += 2
+	}
+	return string(b), nil
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// Characters that must be escaped in columns and in attribute tags and
+// values, in addition to control characters and '%'.
+const (
+	columnReserved    = ""
+	attributeReserved = ";=&,"
+)
+
+// escape percent-encodes control characters, '%' and the reserved
+// characters in s.
+func escape(s, reserved string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c == 0x7f || c == '%' || strings.IndexByte(reserved, c) >= 0 {
+			if b == nil {
+				b = append(make([]byte, 0, len(s)+8), s[:i]...)
+			}
+			b = append(b, fmt.Sprintf("%%%02X", c)...)
+		} else if b != nil {
+			b = append(b, c)
+		}
+	}
+	if b == nil {
+		return s
+	}
+	return string(b)
+}
+
+// Link sets the Parents and Children fields of the features in fs from
+// their ID and Parent attributes, replacing any previous links. Features
+// spanning several lines share an ID; children are linked to the first
+// line of such a parent. If a Parent attribute refers to an ID that is not
+// present in fs, ErrMissingParent is returned.
+func Link(fs []*Feature) error {
+	ids := make(map[string]*Feature)
+	for _, f := range fs {
+		f.Parents = nil
+		f.Children = nil
+		if id := f.FeatAttributes.Get("ID"); id != "" {
+			if _, ok := ids[id]; !ok {
+				ids[id] = f
+			}
+		}
+	}
+	for _, f := range fs {
+		for _, id := range f.FeatAttributes.Values("Parent") {
+			p, ok := ids[id]
+			if !ok {
+				return ErrMissingParent
+			}
+			f.Parents = append(f.Parents, p)
+			p.Children = append(p.Children, f)
+		}
+	}
+	return nil
+}
+
+// A Writer outputs features and sequences into GFF3 format.
+type Writer struct {
+	w         io.Writer
+	Precision int
+	Width     int
+	header    bool
+	fasta     bool
+}
+
+// NewWriter returns a new GFF3 format writer using w. When header is true,
+// a version header will be written to the GFF3. Sequences are written in
+// the ##FASTA section with width letters per line.
+func NewWriter(w io.Writer, width int, header bool) *Writer {
+	gw := &Writer{
+		w:         w,
+		Width:     width,
+		Precision: -1,
+	}
+
+	if header {
+		gw.WriteMetaData(Version)
+	}
+
+	return gw
+}
+
+// Write writes a single feature and return the number of bytes written and
+// any error. Features are written as a GFF3 feature line with reserved
+// characters percent-encoded, *gff.Region values are written as
+// ##sequence-region directives and seq.Sequences are written in the ##FASTA
+// section, which is started by the first sequence written. No features may
+// be written after a sequence.
+func (w *Writer) Write(f feat.Feature) (n int, err error) {
+	w.header = true
+	switch f := f.(type) {
+	case seq.Sequence:
+		if !w.fasta {
+			n, err = io.WriteString(w.w, "##FASTA\n")
+			if err != nil {
+				return n, err
+			}
+			w.fasta = true
+		}
+		_n, err := fasta.NewWriter(w.w, w.Width).Write(f)
+		return n + _n, err
+	}
+	if w.fasta {
+		return 0, ErrAfterFASTA
+	}
+	switch f := f.(type) {
+	case *Feature:
+		if f.FeatStart > f.FeatEnd {
+			return 0, ErrBadFeature
+		}
+		return io.WriteString(w.w, w.format(f))
+	case *gff.Region:
+		return fmt.Fprintf(w.w, "##sequence-region %s %d %d\n", escape(f.SeqName, " "), feat.ZeroToOne(f.RegionStart), f.RegionEnd)
+	default:
+		return fmt.Fprintf(w.w, "##sequence-region %s %d %d\n", escape(f.Name(), " "), feat.ZeroToOne(f.Start()), f.End())
+	}
+}
+
+func (w *Writer) format(f *Feature) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\t%s\t%s\t%d\t%d\t",
+		escape(f.SeqName, columnReserved),
+		escape(f.Source, columnReserved),
+		escape(f.Type, columnReserved),
+		feat.ZeroToOne(f.FeatStart),
+		f.FeatEnd,
+	)
+	switch {
+	case f.FeatScore == nil || math.IsNaN(*f.FeatScore):
+		b.WriteByte('.')
+	case w.Precision < 0:
+		fmt.Fprintf(&b, "%v", *f.FeatScore)
+	default:
+		fmt.Fprintf(&b, "%.*f", w.Precision, *f.FeatScore)
+	}
+	fmt.Fprintf(&b, "\t%s\t%s\t", f.FeatStrand, f.FeatPhase)
+	if len(f.FeatAttributes) == 0 {
+		b.WriteByte('.')
+	}
+	for i, a := range f.FeatAttributes {
+		if i != 0 {
+			b.WriteByte(';')
+		}
+		b.WriteString(escape(a.Tag, attributeReserved))
+		b.WriteByte('=')
+		for j, v := range a.Values {
+			if j != 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(escape(v, attributeReserved))
+		}
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// WriteMetaData writes a directive line to a GFF3 file. Strings and byte
+// slices are written verbatim, and an int is interpreted as a version
+// number and can only be written before any other data. All other types
+// return an ErrNotHandled.
+func (w *Writer) WriteMetaData(d interface{}) (n int, err error) {
+	defer func() { w.header = true }()
+	switch d := d.(type) {
+	case string:
+		return fmt.Fprintf(w.w, "##%s\n", d)
+	case []byte:
+		return fmt.Fprintf(w.w, "##%s\n", d)
+	case int:
+		if w.header {
+			return 0, ErrCannotHeader
+		}
+		return fmt.Fprintf(w.w, "##gff-version %d\n", d)
+	}
+	return 0, ErrNotHandled
+}
+
+// WriteComment writes a comment line to a GFF3 file.
+func (w *Writer) WriteComment(c string) (n int, err error) {
+	return fmt.Fprintf(w.w, "# %s\n", c)
+}
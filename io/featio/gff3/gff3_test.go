@@ -0,0 +1,202 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gff3
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/feat/gene"
+	"github.com/biogo/biogo/io/featio/gff"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const gff3 = `##gff-version 3.1.26
+##sequence-region ctg123 1 1497228
+##species https://www.ncbi.nlm.nih.gov/Taxonomy/Browser/wwwtax.cgi?id=9606
+# a comment
+ctg123	.	gene	1000	9000	.	+	.	ID=gene00001;Name=EDEN;Note=protein kinase%2C putative%3B see %25
+ctg123	.	mRNA	1050	9000	.	+	.	ID=mRNA00001;Parent=gene00001;Name=EDEN.1
+ctg123	.	exon	1050	1500	.	+	.	Parent=mRNA00001
+ctg123	.	exon	3000	3902	.	+	.	Parent=mRNA00001
+ctg123	.	exon	5000	5500	.	+	.	Parent=mRNA00001
+ctg123	.	exon	7000	9000	.	+	.	Parent=mRNA00001
+ctg123	.	CDS	1201	1500	.	+	0	ID=cds00001;Parent=mRNA00001
+ctg123	.	CDS	3000	3902	.	+	0	ID=cds00001;Parent=mRNA00001
+ctg123	.	CDS	5000	5500	.	+	0	ID=cds00001;Parent=mRNA00001
+ctg123	.	CDS	7000	7600	.	+	0	ID=cds00001;Parent=mRNA00001
+ctg123	.	ncRNA	1000	2000	0.5	+	.	ID=nc00001;Parent=gene00001;Dbxref=EMBL:AA816246,NCBI_gi:10727410
+ctg123	.	exon	1000	1200	.	+	.	Parent=nc00001
+ctg123	.	exon	1800	2000	.	+	.	Parent=nc00001
+###
+##FASTA
+>ctg123
+ACGTACGTAC
+GTAC
+`
+
+func (s *S) TestRead(c *check.C) {
+	r := NewReader(strings.NewReader(gff3))
+	var fs []*Feature
+	var (
+		region *gff.Region
+		sq     seq.Sequence
+	)
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		switch f := f.(type) {
+		case *Feature:
+			fs = append(fs, f)
+		case *gff.Region:
+			region = f
+		case seq.Sequence:
+			sq = f
+		default:
+			c.Fatalf("unexpected feature type %T", f)
+		}
+	}
+	c.Check(r.Version, check.Equals, "3.1.26")
+	c.Check(r.Species, check.Equals, "https://www.ncbi.nlm.nih.gov/Taxonomy/Browser/wwwtax.cgi?id=9606")
+	c.Check(region, check.DeepEquals, &gff.Region{Sequence: gff.Sequence{SeqName: "ctg123"}, RegionStart: 0, RegionEnd: 1497228})
+	c.Assert(sq, check.NotNil)
+	c.Check(sq.Name(), check.Equals, "ctg123")
+	c.Check(sq.(*linear.Seq).Seq.String(), check.Equals, "ACGTACGTACGTAC")
+
+	c.Assert(len(fs), check.Equals, 13)
+	g := fs[0]
+	c.Check(g.Name(), check.Equals, "gene00001")
+	c.Check(g.FeatStart, check.Equals, 999)
+	c.Check(g.FeatEnd, check.Equals, 9000)
+	c.Check(g.FeatStrand, check.Equals, seq.Plus)
+	c.Check(g.FeatPhase, check.Equals, gff.NoFrame)
+	c.Check(g.FeatAttributes.Get("Note"), check.Equals, "protein kinase, putative; see %")
+	c.Check(fs[10].FeatAttributes.Values("Dbxref"), check.DeepEquals, []string{"EMBL:AA816246", "NCBI_gi:10727410"})
+	c.Check(*fs[10].FeatScore, check.Equals, 0.5)
+	c.Check(fs[6].FeatPhase, check.Equals, gff.Frame0)
+
+	c.Assert(Link(fs), check.Equals, nil)
+	c.Check(g.Children, check.DeepEquals, []*Feature{fs[1], fs[10]})
+	c.Check(fs[1].Parents, check.DeepEquals, []*Feature{g})
+	c.Check(len(fs[1].Children), check.Equals, 8)
+}
+
+func (s *S) TestRoundTrip(c *check.C) {
+	r := NewReader(strings.NewReader(gff3))
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 10, true)
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		_, err = w.Write(f)
+		c.Assert(err, check.Equals, nil)
+	}
+	_, err := w.Write(&Feature{SeqName: "ctg123", FeatEnd: 1})
+	c.Check(err, check.Equals, ErrAfterFASTA)
+
+	lines := strings.Split(buf.String(), "\n")
+	c.Check(lines[0], check.Equals, "##gff-version 3")
+	c.Check(lines[1], check.Equals, "##sequence-region ctg123 1 1497228")
+	c.Check(lines[2], check.Equals, "ctg123\t.\tgene\t1000\t9000\t.\t+\t.\tID=gene00001;Name=EDEN;Note=protein kinase%2C putative%3B see %25")
+	c.Check(lines[12], check.Equals, "ctg123\t.\tncRNA\t1000\t2000\t0.5\t+\t.\tID=nc00001;Parent=gene00001;Dbxref=EMBL:AA816246,NCBI_gi:10727410")
+	c.Check(strings.Join(lines[15:], "\n"), check.Equals, "##FASTA\n>ctg123\nACGTACGTAC\nGTAC\n")
+}
+
+func (s *S) TestGenes(c *check.C) {
+	r := NewReader(strings.NewReader(gff3))
+	var fs []*Feature
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		if f, ok := f.(*Feature); ok {
+			fs = append(fs, f)
+		}
+	}
+	genes, err := Genes(fs)
+	c.Assert(err, check.Equals, nil)
+	c.Assert(len(genes), check.Equals, 1)
+	g := genes[0]
+	c.Check(g.ID, check.Equals, "gene00001")
+	c.Check(g.Desc, check.Equals, "EDEN")
+	c.Check(g.Start(), check.Equals, 999)
+	c.Check(g.End(), check.Equals, 9000)
+	c.Check(g.Orientation(), check.Equals, feat.Forward)
+
+	ts := gene.TranscriptsOf(g)
+	c.Assert(len(ts), check.Equals, 2)
+	mrna, ok := ts[0].(*gene.CodingTranscript)
+	c.Assert(ok, check.Equals, true)
+	c.Check(mrna.ID, check.Equals, "mRNA00001")
+	c.Check(mrna.Start(), check.Equals, 50)
+	c.Check(mrna.CDSstart, check.Equals, 151)
+	c.Check(mrna.CDSend, check.Equals, 6551)
+	c.Check(len(mrna.Exons()), check.Equals, 4)
+	c.Check(mrna.Exons()[1].Start(), check.Equals, 1950)
+	c.Check(mrna.Exons().SplicedLen(), check.Equals, 451+903+501+2001)
+
+	nc, ok := ts[1].(*gene.NonCodingTranscript)
+	c.Assert(ok, check.Equals, true)
+	c.Check(nc.Start(), check.Equals, 0)
+	c.Check(len(nc.Introns()), check.Equals, 1)
+
+	_, err = Genes([]*Feature{{Type: "exon", FeatAttributes: Attributes{{Tag: "Parent", Values: []string{"none"}}}}})
+	c.Check(err, check.Equals, ErrMissingParent)
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{"##gff-version 2\n", ErrNotHandled},
+		{"ctg\t.\tgene\t1\t10\t.\t+\n", ErrFieldMissing},
+		{"ctg\t.\tgene\t10\t1\t.\t+\t.\t.\n", ErrBadFeature},
+		{"ctg\t.\tgene\t1\t10\t.\tx\t.\t.\n", ErrBadStrand},
+		{"ctg\t.\tgene\t1\t10\t.\t+\t3\t.\n", ErrBadPhase},
+		{"ctg\t.\tgene\t1\t10\t.\t+\t.\tID\n", ErrBadAttribute},
+		{"ctg\t.\tgene\t1\t10\t.\t+\t.\tID=a%2\n", ErrBadEscape},
+	} {
+		_, err := NewReader(strings.NewReader(t.in)).Read()
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err)
+	}
+}
+
+func (s *S) TestMergeSpans(c *check.C) {
+	for _, t := range []struct {
+		in, want Spans
+	}{
+		{in: nil, want: nil},
+		{in: Spans{{5, 10}}, want: Spans{{5, 10}}},
+		{in: Spans{{20, 30}, {0, 10}, {10, 15}}, want: Spans{{0, 15}, {20, 30}}},
+		{in: Spans{{0, 30}, {5, 10}, {25, 40}, {41, 50}}, want: Spans{{0, 40}, {41, 50}}},
+	} {
+		c.Check(t.in.Merge(), check.DeepEquals, t.want)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gtf
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/feat/gene"
+	"github.com/biogo/biogo/io/featio/gff3"
+
+	"sort"
+)
+
+// Genes assembles the gene hierarchies described by the gene_id and
+// transcript_id attributes of the features in fs. Genes and transcripts are
+// returned in the order of their first feature. The extent of each
+// transcript is defined by its exon features or, in their absence, by the
+// union of its CDS, UTR, start_codon and stop_codon features; the extent of
+// each gene is the union of its transcripts. Gene and transcript lines are
+// used only for gene_name and transcript_name attributes, which are set as
+// the Desc fields of the genes and transcripts. Transcripts with CDS
+// features are returned as *gene.CodingTranscript with the coding region
+// including the stop codon, and others as *gene.NonCodingTranscript.
+func Genes(fs []*Feature) ([]*gene.Gene, error) {
+	type transcript struct {
+		id    string
+		feats []*Feature
+	}
+	type gn struct {
+		id          string
+		feats       []*Feature
+		transcripts []*transcript
+		tIndex      map[string]*transcript
+	}
+	var (
+		genes []*gn
+		index = make(map[string]*gn)
+	)
+	for _, f := range fs {
+		g, ok := index[f.GeneID]
+		if !ok {
+			g = &gn{id: f.GeneID, tIndex: make(map[string]*transcript)}
+			index[f.GeneID] = g
+			genes = append(genes, g)
+		}
+		g.feats = append(g.feats, f)
+		if f.TranscriptID == "" {
+			continue
+		}
+		t, ok := g.tIndex[f.TranscriptID]
+		if !ok {
+			t = &transcript{id: f.TranscriptID}
+			g.tIndex[f.TranscriptID] = t
+			g.transcripts = append(g.transcripts, t)
+		}
+		t.feats = append(t.feats, f)
+	}
+
+	var gs []*gene.Gene
+	for _, g := range genes {
+		var (
+			parts  []*model
+			start  = maxInt
+			strand = g.feats[0].FeatStrand
+		)
+		for _, t := range g.transcripts {
+			m := build(t.id, t.feats)
+			if m == nil {
+				continue
+			}
+			if m.exons[0].Start < start {
+				start = m.exons[0].Start
+			}
+			parts = append(parts, m)
+		}
+		if parts == nil {
+			continue
+		}
+		gg := &gene.Gene{
+			ID:     g.id,
+			Chrom:  g.feats[0].Location(),
+			Offset: start,
+			Orient: feat.Orientation(strand),
+			Desc:   attr(g.feats, "gene_name"),
+		}
+		ts := make([]feat.Feature, 0, len(parts))
+		for _, m := range parts {
+			t, err := m.transcript(gg)
+			if err != nil {
+				return nil, err
+			}
+			ts = append(ts, t)
+		}
+		err := gg.SetFeatures(ts...)
+		if err != nil {
+			return nil, err
+		}
+		gs = append(gs, gg)
+	}
+	return gs, nil
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+// attr returns the first value of the named attribute in fs.
+func attr(fs []*Feature, tag string) string {
+	for _, f := range fs {
+		if v := f.FeatAttributes.Get(tag); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// model is the structure of a transcript in chromosome coordinates.
+type model struct {
+	id, desc string
+	strand   feat.Orientation
+	exons    gff3.Spans
+	cds      *gff3.Span
+}
+
+// build returns the transcript model described by fs, or nil if fs does not
+// describe any exons.
+func build(id string, fs []*Feature) *model {
+	m := &model{id: id, desc: attr(fs, "transcript_name")}
+	var (
+		parts, cds gff3.Spans
+		coding     bool
+	)
+	for _, f := range fs {
+		s := gff3.Span{Start: f.FeatStart, End: f.FeatEnd}
+		switch f.Feature {
+		case "exon":
+			m.exons = append(m.exons, s)
+		case "CDS", "start_codon", "stop_codon":
+			coding = coding || f.Feature == "CDS"
+			cds = append(cds, s)
+			parts = append(parts, s)
+		case "UTR", "5UTR", "3UTR", "five_prime_utr", "three_prime_utr":
+			parts = append(parts, s)
+		default:
+			continue
+		}
+		m.strand = feat.Orientation(f.FeatStrand)
+	}
+	if coding {
+		m.cds = &gff3.Span{Start: maxInt}
+		for _, s := range cds {
+			if s.Start < m.cds.Start {
+				m.cds.Start = s.Start
+			}
+			if s.End > m.cds.End {
+				m.cds.End = s.End
+			}
+		}
+	}
+	if m.exons == nil {
+		if parts == nil {
+			return nil
+		}
+		m.exons = parts.Merge()
+	}
+	sort.Sort(m.exons)
+	return m
+}
+
+// transcript returns the gene.Transcript described by m located on g.
+func (m *model) transcript(g *gene.Gene) (gene.Transcript, error) {
+	start := m.exons[0].Start
+	orient := feat.Forward
+	if m.strand*g.Orient < 0 {
+		orient = feat.Reverse
+	}
+	var t gene.Transcript
+	if m.cds != nil {
+		t = &gene.CodingTranscript{
+			ID:       m.id,
+			Loc:      g,
+			Offset:   start - g.Offset,
+			Orient:   orient,
+			Desc:     m.desc,
+			CDSstart: m.cds.Start - start,
+			CDSend:   m.cds.End - start,
+		}
+	} else {
+		t = &gene.NonCodingTranscript{
+			ID:     m.id,
+			Loc:    g,
+			Offset: start - g.Offset,
+			Orient: orient,
+			Desc:   m.desc,
+		}
+	}
+	exons := make([]gene.Exon, len(m.exons))
+	for i, e := range m.exons {
+		exons[i] = gene.Exon{Transcript: t, Offset: e.Start - start, Length: e.End - e.Start}
+	}
+	return t, t.SetExons(exons...)
+}
@@ -0,0 +1,313 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gtf provides types to read and write Gene Transfer Format (GTF2.2)
+// files.
+//
+// GTF is a refinement of GFF version 2 in which every feature carries
+// gene_id and transcript_id attributes. The specification can be found at
+// http://mblab.wustl.edu/GTF22.html.
+package gtf
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/featio"
+	"github.com/biogo/biogo/io/featio/gff"
+	"github.com/biogo/biogo/seq"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ featio.Reader = (*Reader)(nil)
+	_ featio.Writer = (*Writer)(nil)
+)
+
+var (
+	ErrBadFeature   = errors.New("gtf: feature start greater than feature end")
+	ErrFieldMissing = errors.New("gtf: missing fields")
+	ErrBadStrand    = errors.New("gtf: invalid strand")
+	ErrBadFrame     = errors.New("gtf: invalid frame")
+	ErrBadAttribute = errors.New("gtf: malformed attribute")
+	ErrNoGeneID     = errors.New("gtf: missing gene_id")
+	ErrNotHandled   = errors.New("gtf: type not handled")
+)
+
+const (
+	nameField = iota
+	sourceField
+	featureField
+	startField
+	endField
+	scoreField
+	strandField
+	frameField
+	attributeField
+	lastField
+)
+
+// A Feature represents a GTF feature line.
+type Feature struct {
+	SeqName string
+	Source  string
+	Feature string
+
+	// FeatStart and FeatEnd are zero-based half open. Translation from
+	// the one-based closed coordinates of GTF is handled by the gtf
+	// package.
+	FeatStart, FeatEnd int
+
+	// The score of the feature. A nil value indicates the score is not
+	// available.
+	FeatScore *float64
+
+	FeatStrand seq.Strand
+	FeatFrame  gff.Frame
+
+	// GeneID and TranscriptID hold the gene_id and transcript_id
+	// attributes. GeneID is always present. TranscriptID may be empty
+	// for gene level features.
+	GeneID       string
+	TranscriptID string
+
+	// FeatAttributes holds the remaining attributes in order with
+	// their values unquoted.
+	FeatAttributes gff.Attributes
+}
+
+func (f *Feature) Start() int { return f.FeatStart }
+func (f *Feature) End() int   { return f.FeatEnd }
+func (f *Feature) Len() int   { return f.FeatEnd - f.FeatStart }
+
+// Name returns the transcript ID of the feature, or the gene ID if the
+// feature is not associated with a transcript.
+func (f *Feature) Name() string {
+	if f.TranscriptID != "" {
+		return f.TranscriptID
+	}
+	return f.GeneID
+}
+func (f *Feature) Description() string    { return fmt.Sprintf("%s/%s", f.Feature, f.Source) }
+func (f *Feature) Location() feat.Feature { return gff.Sequence{SeqName: f.SeqName} }
+
+// Orientation returns the orientation of the feature corresponding to its
+// strand.
+func (f *Feature) Orientation() feat.Orientation { return feat.Orientation(f.FeatStrand) }
+
+// A Reader can parse GTF formatted io.Reader and return feat.Features.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader returns a new GTF format reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read reads a single feature and returns it as a *Feature, or an error.
+// Comment lines are ignored. Features without a gene_id attribute result in
+// an ErrNoGeneID error.
+func (r *Reader) Read() (feat.Feature, error) {
+	var line []byte
+	for {
+		var err error
+		line, err = r.r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err == io.EOF {
+				return nil, err
+			}
+			return nil, &csv.ParseError{Line: r.line, Err: err}
+		}
+		r.line++
+		line = bytes.TrimRight(line, "\r\n")
+		if len(bytes.TrimSpace(line)) != 0 && line[0] != '#' {
+			break
+		}
+	}
+
+	fields := strings.SplitN(string(line), "\t", lastField)
+	if len(fields) < lastField {
+		return nil, &csv.ParseError{Line: r.line, Column: len(fields), Err: ErrFieldMissing}
+	}
+	f := &Feature{
+		SeqName: fields[nameField],
+		Source:  fields[sourceField],
+		Feature: fields[featureField],
+	}
+	start, err := strconv.Atoi(fields[startField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: startField, Err: err}
+	}
+	f.FeatStart = feat.OneToZero(start)
+	f.FeatEnd, err = strconv.Atoi(fields[endField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: endField, Err: err}
+	}
+	if f.FeatStart > f.FeatEnd {
+		return nil, &csv.ParseError{Line: r.line, Column: endField, Err: ErrBadFeature}
+	}
+	if fields[scoreField] != "." {
+		s, err := strconv.ParseFloat(fields[scoreField], 64)
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: scoreField, Err: err}
+		}
+		f.FeatScore = &s
+	}
+	switch fields[strandField] {
+	case "+":
+		f.FeatStrand = seq.Plus
+	case "-":
+		f.FeatStrand = seq.Minus
+	case ".":
+		f.FeatStrand = seq.None
+	default:
+		return nil, &csv.ParseError{Line: r.line, Column: strandField, Err: ErrBadStrand}
+	}
+	switch fields[frameField] {
+	case ".":
+		f.FeatFrame = gff.NoFrame
+	case "0", "1", "2":
+		f.FeatFrame = gff.Frame(fields[frameField][0] - '0')
+	default:
+		return nil, &csv.ParseError{Line: r.line, Column: frameField, Err: ErrBadFrame}
+	}
+
+	attrs, err := parseAttributes(fields[attributeField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: attributeField, Err: err}
+	}
+	for _, a := range attrs {
+		switch a.Tag {
+		case "gene_id":
+			f.GeneID = a.Value
+		case "transcript_id":
+			f.TranscriptID = a.Value
+		default:
+			f.FeatAttributes = append(f.FeatAttributes, a)
+		}
+	}
+	if f.GeneID == "" {
+		return nil, &csv.ParseError{Line: r.line, Column: attributeField, Err: ErrNoGeneID}
+	}
+	return f, nil
+}
+
+// parseAttributes parses a GTF attribute column of the form
+//
+//	gene_id "g1"; transcript_id "t1"; exon_number 1;
+//
+// Quoted values may contain semicolons and backslash escaped quotes.
+func parseAttributes(s string) (gff.Attributes, error) {
+	var a gff.Attributes
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" || s[0] == '#' {
+			return a, nil
+		}
+		i := strings.IndexAny(s, " \t")
+		if i <= 0 {
+			return nil, ErrBadAttribute
+		}
+		tag := s[:i]
+		s = strings.TrimLeft(s[i:], " \t")
+
+		var val string
+		if s != "" && s[0] == '"' {
+			end := 1
+			for ; end < len(s) && s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(s) {
+				return nil, ErrBadAttribute
+			}
+			v, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, ErrBadAttribute
+			}
+			val, s = v, s[end+1:]
+		} else {
+			end := strings.IndexAny(s, "; \t")
+			if end < 0 {
+				end = len(s)
+			}
+			val, s = s[:end], s[end:]
+		}
+		a = append(a, gff.Attribute{Tag: tag, Value: val})
+
+		s = strings.TrimLeft(s, " \t")
+		switch {
+		case s == "":
+		case s[0] == ';':
+			s = s[1:]
+		default:
+			return nil, ErrBadAttribute
+		}
+	}
+}
+
+// A Writer outputs features into GTF format.
+type Writer struct {
+	w         io.Writer
+	Precision int
+}
+
+// NewWriter returns a new GTF format writer using w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, Precision: -1}
+}
+
+// Write writes a single feature and return the number of bytes written and
+// any error. Only *Feature values are handled. The gene_id and
+// transcript_id attributes are written first, and all attribute values are
+// quoted.
+func (w *Writer) Write(f feat.Feature) (n int, err error) {
+	g, ok := f.(*Feature)
+	if !ok {
+		return 0, ErrNotHandled
+	}
+	if g.FeatStart > g.FeatEnd {
+		return 0, ErrBadFeature
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\t%s\t%s\t%d\t%d\t",
+		g.SeqName,
+		g.Source,
+		g.Feature,
+		feat.ZeroToOne(g.FeatStart),
+		g.FeatEnd,
+	)
+	switch {
+	case g.FeatScore == nil || math.IsNaN(*g.FeatScore):
+		b.WriteByte('.')
+	case w.Precision < 0:
+		fmt.Fprintf(&b, "%v", *g.FeatScore)
+	default:
+		fmt.Fprintf(&b, "%.*f", w.Precision, *g.FeatScore)
+	}
+	fmt.Fprintf(&b, "\t%s\t%s\tgene_id %s;", g.FeatStrand, g.FeatFrame, strconv.Quote(g.GeneID))
+	if g.TranscriptID != "" {
+		fmt.Fprintf(&b, " transcript_id %s;", strconv.Quote(g.TranscriptID))
+	}
+	for _, a := range g.FeatAttributes {
+		fmt.Fprintf(&b, " %s %s;", a.Tag, strconv.Quote(a.Value))
+	}
+	b.WriteByte('\n')
+	return w.w.Write(b.Bytes())
+}
+
+// WriteComment writes a comment line to a GTF file.
+func (w *Writer) WriteComment(c string) (n int, err error) {
+	return fmt.Fprintf(w.w, "# %s\n", c)
+}
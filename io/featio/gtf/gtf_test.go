@@ -0,0 +1,134 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gtf
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/feat/gene"
+	"github.com/biogo/biogo/io/featio/gff"
+	"github.com/biogo/biogo/seq"
+
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const gtf = `#!genome-build GRCh38
+chr1	HAVANA	gene	1000	5000	.	-	.	gene_id "G1"; gene_name "ABC; D";
+chr1	HAVANA	transcript	1000	5000	.	-	.	gene_id "G1"; transcript_id "T1"; transcript_name "ABC-201";
+chr1	HAVANA	exon	4001	5000	.	-	.	gene_id "G1"; transcript_id "T1"; exon_number 1;
+chr1	HAVANA	CDS	4001	4500	.	-	0	gene_id "G1"; transcript_id "T1"; exon_number 1;
+chr1	HAVANA	exon	1000	2000	.	-	.	gene_id "G1"; transcript_id "T1"; exon_number 2;
+chr1	HAVANA	CDS	1504	2000	.	-	1	gene_id "G1"; transcript_id "T1"; exon_number 2;
+chr1	HAVANA	stop_codon	1501	1503	.	-	0	gene_id "G1"; transcript_id "T1"; exon_number 2;
+chr1	HAVANA	exon	1800	2200	.	-	.	gene_id "G1"; transcript_id "T2"; tag "basic"; tag "retained \"intron\"";
+chr2	ENSEMBL	exon	10	20	12.5	+	.	gene_id "G2"; transcript_id "T3";
+`
+
+func readAll(c *check.C, in string) []*Feature {
+	r := NewReader(strings.NewReader(in))
+	var fs []*Feature
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		fs = append(fs, f.(*Feature))
+	}
+	return fs
+}
+
+func (s *S) TestRead(c *check.C) {
+	fs := readAll(c, gtf)
+	c.Assert(len(fs), check.Equals, 9)
+	c.Check(fs[0].GeneID, check.Equals, "G1")
+	c.Check(fs[0].TranscriptID, check.Equals, "")
+	c.Check(fs[0].FeatAttributes, check.DeepEquals, gff.Attributes{{Tag: "gene_name", Value: "ABC; D"}})
+	c.Check(fs[2].Name(), check.Equals, "T1")
+	c.Check(fs[2].FeatStart, check.Equals, 4000)
+	c.Check(fs[2].FeatEnd, check.Equals, 5000)
+	c.Check(fs[2].FeatStrand, check.Equals, seq.Minus)
+	c.Check(fs[2].FeatAttributes.Get("exon_number"), check.Equals, "1")
+	c.Check(fs[5].FeatFrame, check.Equals, gff.Frame1)
+	c.Check(fs[7].FeatAttributes, check.DeepEquals, gff.Attributes{{Tag: "tag", Value: "basic"}, {Tag: "tag", Value: `retained "intron"`}})
+	c.Check(*fs[8].FeatScore, check.Equals, 12.5)
+}
+
+func (s *S) TestRoundTrip(c *check.C) {
+	fs := readAll(c, gtf)
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, f := range fs {
+		_, err := w.Write(f)
+		c.Assert(err, check.Equals, nil)
+	}
+	c.Check(strings.Split(buf.String(), "\n")[3], check.Equals,
+		`chr1	HAVANA	CDS	4001	4500	.	-	0	gene_id "G1"; transcript_id "T1"; exon_number "1";`)
+	c.Check(readAll(c, buf.String()), check.DeepEquals, fs)
+}
+
+func (s *S) TestGenes(c *check.C) {
+	genes, err := Genes(readAll(c, gtf))
+	c.Assert(err, check.Equals, nil)
+	c.Assert(len(genes), check.Equals, 2)
+
+	g := genes[0]
+	c.Check(g.ID, check.Equals, "G1")
+	c.Check(g.Desc, check.Equals, "ABC; D")
+	c.Check(g.Start(), check.Equals, 999)
+	c.Check(g.End(), check.Equals, 5000)
+	c.Check(g.Orientation(), check.Equals, feat.Reverse)
+	c.Check(g.Location(), check.Equals, feat.Feature(gff.Sequence{SeqName: "chr1"}))
+
+	ts := gene.TranscriptsOf(g)
+	c.Assert(len(ts), check.Equals, 2)
+	t1, ok := ts[0].(*gene.CodingTranscript)
+	c.Assert(ok, check.Equals, true)
+	c.Check(t1.Desc, check.Equals, "ABC-201")
+	c.Check(t1.Start(), check.Equals, 0)
+	c.Check(t1.CDSstart, check.Equals, 501)
+	c.Check(t1.CDSend, check.Equals, 3501)
+	c.Check(t1.UTR5start(), check.Equals, 3501)
+	c.Check(t1.UTR5end(), check.Equals, 4001)
+	c.Check(len(t1.Exons()), check.Equals, 2)
+
+	t2, ok := ts[1].(*gene.NonCodingTranscript)
+	c.Assert(ok, check.Equals, true)
+	c.Check(t2.Start(), check.Equals, 800)
+	c.Check(t2.Len(), check.Equals, 401)
+
+	c.Check(genes[1].Orientation(), check.Equals, feat.Forward)
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{"chr1\tsrc\texon\t1\t10\t.\t+\t.\n", ErrFieldMissing},
+		{"chr1\tsrc\texon\t10\t1\t.\t+\t.\tgene_id \"g\";\n", ErrBadFeature},
+		{"chr1\tsrc\texon\t1\t10\t.\t*\t.\tgene_id \"g\";\n", ErrBadStrand},
+		{"chr1\tsrc\texon\t1\t10\t.\t+\tx\tgene_id \"g\";\n", ErrBadFrame},
+		{"chr1\tsrc\texon\t1\t10\t.\t+\t.\tgene_id \"g;\n", ErrBadAttribute},
+		{"chr1\tsrc\texon\t1\t10\t.\t+\t.\ttranscript_id \"t\";\n", ErrNoGeneID},
+	} {
+		_, err := NewReader(strings.NewReader(t.in)).Read()
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err)
+	}
+}
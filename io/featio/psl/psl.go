@@ -0,0 +1,377 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package psl provides types to read and write BLAT PSL format files.
+//
+// The specification can be found at http://genome.ucsc.edu/FAQ/FAQformat.html#format2.
+package psl
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/feat/genome"
+	"github.com/biogo/biogo/io/featio"
+	"github.com/biogo/biogo/seq"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ featio.Reader = (*Reader)(nil)
+	_ featio.Writer = (*Writer)(nil)
+
+	_ feat.Feature  = (*Record)(nil)
+	_ feat.Orienter = (*Record)(nil)
+	_ feat.Feature  = (*Feature)(nil)
+	_ feat.Orienter = (*Feature)(nil)
+	_ feat.Pair     = (*Block)(nil)
+)
+
+var (
+	ErrFieldMissing = errors.New("psl: missing fields")
+	ErrBadStrand    = errors.New("psl: invalid strand")
+	ErrBadBlocks    = errors.New("psl: inconsistent blocks")
+	ErrNotHandled   = errors.New("psl: type not handled")
+)
+
+const (
+	matchesField = iota
+	misMatchesField
+	repMatchesField
+	nCountField
+	qNumInsertField
+	qBaseInsertField
+	tNumInsertField
+	tBaseInsertField
+	strandField
+	qNameField
+	qSizeField
+	qStartField
+	qEndField
+	tNameField
+	tSizeField
+	tStartField
+	tEndField
+	blockCountField
+	blockSizesField
+	qStartsField
+	tStartsField
+
+	numFields
+)
+
+// Feature is a gap-free aligned segment of a sequence. Coordinates are
+// zero-based half-open positions on the forward strand of Chrom.
+type Feature struct {
+	Chrom      *genome.Chromosome
+	FeatStart  int
+	FeatEnd    int
+	FeatStrand seq.Strand
+}
+
+func (f *Feature) Start() int { return f.FeatStart }
+func (f *Feature) End() int   { return f.FeatEnd }
+func (f *Feature) Len() int   { return f.FeatEnd - f.FeatStart }
+func (f *Feature) Name() string {
+	return fmt.Sprintf("%s:[%d,%d)", f.Chrom.Chr, f.FeatStart, f.FeatEnd)
+}
+func (f *Feature) Description() string           { return "psl block" }
+func (f *Feature) Location() feat.Feature        { return f.Chrom }
+func (f *Feature) Orientation() feat.Orientation { return feat.Orientation(f.FeatStrand) }
+
+// Block is a gap-free aligned block between a target and a query sequence.
+type Block struct {
+	Target, Query Feature
+}
+
+// Features returns the target and query features of the block.
+func (b *Block) Features() [2]feat.Feature { return [2]feat.Feature{&b.Target, &b.Query} }
+
+// Record is a PSL alignment between a query and a target sequence.
+type Record struct {
+	Matches     int // Number of matching bases that are not repeats.
+	MisMatches  int // Number of mismatching bases.
+	RepMatches  int // Number of matching bases that are repeats.
+	NCount      int // Number of N bases.
+	QNumInsert  int // Number of inserts in the query.
+	QBaseInsert int // Number of bases inserted in the query.
+	TNumInsert  int // Number of inserts in the target.
+	TBaseInsert int // Number of bases inserted in the target.
+
+	// QStrand is the strand of the query. TStrand is the
+	// strand of the target for translated alignments and
+	// is seq.None for untranslated alignments.
+	QStrand seq.Strand
+	TStrand seq.Strand
+
+	// Query and Target are the aligned sequences. Start and end
+	// positions are on the forward strand of the sequence.
+	Query  *genome.Chromosome
+	QStart int
+	QEnd   int
+	Target *genome.Chromosome
+	TStart int
+	TEnd   int
+
+	// Blocks holds the gap-free aligned blocks in the order
+	// they appear in the record.
+	Blocks []*Block
+}
+
+func (r *Record) Start() int                    { return r.TStart }
+func (r *Record) End() int                      { return r.TEnd }
+func (r *Record) Len() int                      { return r.TEnd - r.TStart }
+func (r *Record) Name() string                  { return r.Query.Chr }
+func (r *Record) Description() string           { return "psl record" }
+func (r *Record) Location() feat.Feature        { return r.Target }
+func (r *Record) Orientation() feat.Orientation { return feat.Orientation(r.QStrand) }
+
+// Pairs returns the blocks of the record as feat.Pair values.
+func (r *Record) Pairs() []feat.Pair {
+	p := make([]feat.Pair, len(r.Blocks))
+	for i, b := range r.Blocks {
+		p[i] = b
+	}
+	return p
+}
+
+// isProtein returns whether a translated alignment has a protein query,
+// in which case target blocks are three times the length of query blocks.
+// The test follows the UCSC pslIsProtein function.
+func isProtein(tStrand seq.Strand, tSize, tStart, tEnd, lastTStart, lastSize int) bool {
+	if tStrand == seq.Minus {
+		return tStart == tSize-(lastTStart+3*lastSize)
+	}
+	return tEnd == lastTStart+3*lastSize
+}
+
+// forward returns the forward strand coordinates of the segment [start, end)
+// given in strand coordinates on a sequence of length size.
+func forward(start, end, size int, strand seq.Strand) (int, int) {
+	if strand == seq.Minus {
+		return size - end, size - start
+	}
+	return start, end
+}
+
+func readLine(r *bufio.Reader, line *int) (string, error) {
+	b, err := r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(b) == 0) {
+		return "", err
+	}
+	*line++
+	return string(bytes.TrimRight(b, "\r\n")), nil
+}
+
+// isHeader returns whether line is part of a psLayout header or is a blank,
+// track or browser line.
+func isHeader(line string) bool {
+	return len(strings.TrimSpace(line)) == 0 ||
+		strings.HasPrefix(line, "psLayout") ||
+		strings.HasPrefix(line, "match") ||
+		strings.HasPrefix(line, " ") ||
+		strings.HasPrefix(line, "---") ||
+		strings.HasPrefix(line, "track") ||
+		strings.HasPrefix(line, "browser")
+}
+
+func parseStrand(s string) (seq.Strand, error) {
+	switch s {
+	case "+":
+		return seq.Plus, nil
+	case "-":
+		return seq.Minus, nil
+	}
+	return seq.None, ErrBadStrand
+}
+
+func parseList(s string) ([]int, error) {
+	s = strings.TrimSuffix(s, ",")
+	if s == "" {
+		return nil, nil
+	}
+	f := strings.Split(s, ",")
+	l := make([]int, len(f))
+	for i, v := range f {
+		var err error
+		l[i], err = strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// Reader is a PSL format reader. A psLayout header at the start of the
+// stream is skipped.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader returns a new PSL format reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read reads a single PSL record, returning it as a *Record, or an error.
+func (r *Reader) Read() (feat.Feature, error) {
+	var line string
+	for {
+		var err error
+		line, err = readLine(r.r, &r.line)
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, &csv.ParseError{Line: r.line, Err: err}
+		}
+		if !isHeader(line) {
+			break
+		}
+	}
+
+	f := strings.Split(line, "\t")
+	if len(f) < numFields {
+		return nil, &csv.ParseError{Line: r.line, Column: len(f), Err: ErrFieldMissing}
+	}
+	var n [numFields]int
+	for _, i := range []int{
+		matchesField, misMatchesField, repMatchesField, nCountField,
+		qNumInsertField, qBaseInsertField, tNumInsertField, tBaseInsertField,
+		qSizeField, qStartField, qEndField,
+		tSizeField, tStartField, tEndField,
+		blockCountField,
+	} {
+		var err error
+		n[i], err = strconv.Atoi(f[i])
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: i, Err: err}
+		}
+	}
+	var lists [3][]int
+	for j, i := range []int{blockSizesField, qStartsField, tStartsField} {
+		var err error
+		lists[j], err = parseList(f[i])
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: i, Err: err}
+		}
+		if len(lists[j]) != n[blockCountField] {
+			return nil, &csv.ParseError{Line: r.line, Column: i, Err: ErrBadBlocks}
+		}
+	}
+	sizes, qStarts, tStarts := lists[0], lists[1], lists[2]
+
+	rec := &Record{
+		Matches:     n[matchesField],
+		MisMatches:  n[misMatchesField],
+		RepMatches:  n[repMatchesField],
+		NCount:      n[nCountField],
+		QNumInsert:  n[qNumInsertField],
+		QBaseInsert: n[qBaseInsertField],
+		TNumInsert:  n[tNumInsertField],
+		TBaseInsert: n[tBaseInsertField],
+		Query:       &genome.Chromosome{Chr: f[qNameField], Length: n[qSizeField]},
+		QStart:      n[qStartField],
+		QEnd:        n[qEndField],
+		Target:      &genome.Chromosome{Chr: f[tNameField], Length: n[tSizeField]},
+		TStart:      n[tStartField],
+		TEnd:        n[tEndField],
+	}
+	var err error
+	switch s := f[strandField]; len(s) {
+	case 1:
+		rec.QStrand, err = parseStrand(s)
+	case 2:
+		rec.QStrand, err = parseStrand(s[:1])
+		if err == nil {
+			rec.TStrand, err = parseStrand(s[1:])
+		}
+	default:
+		err = ErrBadStrand
+	}
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: strandField, Err: err}
+	}
+
+	tMul := 1
+	if last := len(sizes) - 1; rec.TStrand != seq.None && last >= 0 &&
+		isProtein(rec.TStrand, rec.Target.Length, rec.TStart, rec.TEnd, tStarts[last], sizes[last]) {
+		tMul = 3
+	}
+	tStrand := rec.TStrand
+	if tStrand == seq.None {
+		tStrand = seq.Plus
+	}
+	rec.Blocks = make([]*Block, len(sizes))
+	for i, size := range sizes {
+		b := &Block{
+			Target: Feature{Chrom: rec.Target, FeatStrand: tStrand},
+			Query:  Feature{Chrom: rec.Query, FeatStrand: rec.QStrand},
+		}
+		b.Target.FeatStart, b.Target.FeatEnd = forward(tStarts[i], tStarts[i]+size*tMul, rec.Target.Length, tStrand)
+		b.Query.FeatStart, b.Query.FeatEnd = forward(qStarts[i], qStarts[i]+size, rec.Query.Length, rec.QStrand)
+		rec.Blocks[i] = b
+	}
+
+	return rec, nil
+}
+
+// Writer is a PSL format writer. No psLayout header is written.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a new PSL format writer using w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes a single PSL record and returns the number of bytes written
+// and any error. Only *Record values are handled. Block sizes are written
+// as the lengths of the query features of the blocks.
+func (w *Writer) Write(f feat.Feature) (n int, err error) {
+	r, ok := f.(*Record)
+	if !ok {
+		return 0, ErrNotHandled
+	}
+	strand := r.QStrand.String()
+	tStrand := r.TStrand
+	if tStrand != seq.None {
+		strand += tStrand.String()
+	} else {
+		tStrand = seq.Plus
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t%d\t%d\t%d\t%s\t%d\t%d\t%d\t%d\t",
+		r.Matches, r.MisMatches, r.RepMatches, r.NCount,
+		r.QNumInsert, r.QBaseInsert, r.TNumInsert, r.TBaseInsert,
+		strand,
+		r.Query.Chr, r.Query.Length, r.QStart, r.QEnd,
+		r.Target.Chr, r.Target.Length, r.TStart, r.TEnd,
+		len(r.Blocks),
+	)
+	for _, blk := range r.Blocks {
+		fmt.Fprintf(&b, "%d,", blk.Query.Len())
+	}
+	b.WriteByte('\t')
+	for _, blk := range r.Blocks {
+		s, _ := forward(blk.Query.FeatStart, blk.Query.FeatEnd, r.Query.Length, r.QStrand)
+		fmt.Fprintf(&b, "%d,", s)
+	}
+	b.WriteByte('\t')
+	for _, blk := range r.Blocks {
+		s, _ := forward(blk.Target.FeatStart, blk.Target.FeatEnd, r.Target.Length, tStrand)
+		fmt.Fprintf(&b, "%d,", s)
+	}
+	b.WriteByte('\n')
+	return w.w.Write(b.Bytes())
+}
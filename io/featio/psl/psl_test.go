@@ -0,0 +1,173 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package psl
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/feat/genome"
+	"github.com/biogo/biogo/seq"
+
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const header = `psLayout version 3
+
+match	mis- 	rep. 	N's	Q gap	Q gap	T gap	T gap	strand	Q        	Q   	Q    	Q  	T        	T   	T    	T  	block	blockSizes 	qStarts	 tStarts
+     	match	match	   	count	bases	count	bases	      	name     	size	start	end	name     	size	start	end	count
+---------------------------------------------------------------------------------------------------------------------------------------------------------------
+`
+
+var (
+	seq1   = &genome.Chromosome{Chr: "seq1", Length: 51}
+	seq2   = &genome.Chromosome{Chr: "seq2", Length: 100}
+	prot   = &genome.Chromosome{Chr: "prot", Length: 50}
+	contig = &genome.Chromosome{Chr: "FS_CONTIG_48080_1", Length: 1955}
+	chr1   = &genome.Chromosome{Chr: "chr1", Length: 1000}
+	chr22  = &genome.Chromosome{Chr: "chr22", Length: 47748585}
+)
+
+var pslTests = []struct {
+	line string
+	rec  *Record
+}{
+	{
+		"51\t0\t0\t0\t0\t0\t0\t0\t+\tseq1\t51\t0\t51\tchr1\t1000\t100\t151\t1\t51,\t0,\t100,\n",
+		&Record{
+			Matches: 51,
+			QStrand: seq.Plus,
+			Query:   seq1, QStart: 0, QEnd: 51,
+			Target: chr1, TStart: 100, TEnd: 151,
+			Blocks: []*Block{
+				{Target: Feature{chr1, 100, 151, seq.Plus}, Query: Feature{seq1, 0, 51, seq.Plus}},
+			},
+		},
+	},
+	{
+		"48\t2\t0\t0\t0\t0\t1\t5\t-\tseq2\t100\t10\t60\tchr1\t1000\t200\t255\t2\t20,30,\t40,60,\t200,225,\n",
+		&Record{
+			Matches: 48, MisMatches: 2,
+			TNumInsert: 1, TBaseInsert: 5,
+			QStrand: seq.Minus,
+			Query:   seq2, QStart: 10, QEnd: 60,
+			Target: chr1, TStart: 200, TEnd: 255,
+			Blocks: []*Block{
+				{Target: Feature{chr1, 200, 220, seq.Plus}, Query: Feature{seq2, 40, 60, seq.Minus}},
+				{Target: Feature{chr1, 225, 255, seq.Plus}, Query: Feature{seq2, 10, 40, seq.Minus}},
+			},
+		},
+	},
+	{
+		"59\t9\t0\t0\t1\t823\t1\t96\t+-\tFS_CONTIG_48080_1\t1955\t171\t1062\tchr22\t47748585\t13073589\t13073753\t2\t48,20,\t171,1042,\t34674832,34674976,\n",
+		&Record{
+			Matches: 59, MisMatches: 9,
+			QNumInsert: 1, QBaseInsert: 823,
+			TNumInsert: 1, TBaseInsert: 96,
+			QStrand: seq.Plus, TStrand: seq.Minus,
+			Query: contig, QStart: 171, QEnd: 1062,
+			Target: chr22, TStart: 13073589, TEnd: 13073753,
+			Blocks: []*Block{
+				{Target: Feature{chr22, 13073705, 13073753, seq.Minus}, Query: Feature{contig, 171, 219, seq.Plus}},
+				{Target: Feature{chr22, 13073589, 13073609, seq.Minus}, Query: Feature{contig, 1042, 1062, seq.Plus}},
+			},
+		},
+	},
+	{
+		"10\t0\t0\t0\t0\t0\t0\t0\t++\tprot\t50\t0\t10\tchr1\t1000\t100\t130\t1\t10,\t0,\t100,\n",
+		&Record{
+			Matches: 10,
+			QStrand: seq.Plus, TStrand: seq.Plus,
+			Query: prot, QStart: 0, QEnd: 10,
+			Target: chr1, TStart: 100, TEnd: 130,
+			Blocks: []*Block{
+				{Target: Feature{chr1, 100, 130, seq.Plus}, Query: Feature{prot, 0, 10, seq.Plus}},
+			},
+		},
+	},
+}
+
+func (s *S) TestRead(c *check.C) {
+	var in []string
+	for _, t := range pslTests {
+		in = append(in, t.line)
+	}
+	for _, h := range []string{"", header} {
+		r := NewReader(strings.NewReader(h + strings.Join(in, "")))
+		for i, t := range pslTests {
+			f, err := r.Read()
+			c.Assert(err, check.Equals, nil)
+			c.Check(f, check.DeepEquals, t.rec, check.Commentf("Test: %d", i))
+		}
+		_, err := r.Read()
+		c.Check(err, check.Equals, io.EOF)
+	}
+
+	rec := pslTests[1].rec
+	c.Check(rec.Name(), check.Equals, "seq2")
+	c.Check(rec.Location(), check.Equals, chr1)
+	p := rec.Pairs()
+	c.Assert(p, check.HasLen, 2)
+	c.Check(p[1].Features(), check.DeepEquals, [2]feat.Feature{
+		&Feature{chr1, 225, 255, seq.Plus},
+		&Feature{seq2, 10, 40, seq.Minus},
+	})
+	ori, ref := feat.BaseOrientationOf(p[1].Features()[1])
+	c.Check(ori, check.Equals, feat.Reverse)
+	c.Check(ref, check.Equals, seq2)
+}
+
+func (s *S) TestReadError(c *check.C) {
+	for _, t := range []struct {
+		line string
+		err  string
+	}{
+		{
+			"51\t0\t0\t0\t0\t0\t0\t0\t+\tseq1\t51\t0\t51\tchr1\t1000\t100\t151\t1\t51,\t0,\n",
+			`.*line 1, column 20: psl: missing fields`,
+		},
+		{
+			"51\t0\t0\t0\t0\t0\t0\t0\t*\tseq1\t51\t0\t51\tchr1\t1000\t100\t151\t1\t51,\t0,\t100,\n",
+			`.*line 1, column 8: psl: invalid strand`,
+		},
+		{
+			"51\t0\t0\t0\t0\t0\t0\t0\t+\tseq1\t51\t0\t51\tchr1\t1000\t100\t151\t2\t51,\t0,\t100,\n",
+			`.*line 1, column 18: psl: inconsistent blocks`,
+		},
+		{
+			"51\tx\t0\t0\t0\t0\t0\t0\t+\tseq1\t51\t0\t51\tchr1\t1000\t100\t151\t1\t51,\t0,\t100,\n",
+			`.*line 1, column 1: .*invalid syntax`,
+		},
+	} {
+		_, err := NewReader(strings.NewReader(t.line)).Read()
+		c.Check(err, check.ErrorMatches, t.err)
+	}
+}
+
+func (s *S) TestWrite(c *check.C) {
+	var (
+		buf bytes.Buffer
+		w   = NewWriter(&buf)
+	)
+	for i, t := range pslTests {
+		buf.Reset()
+		n, err := w.Write(t.rec)
+		c.Check(err, check.Equals, nil)
+		c.Check(n, check.Equals, buf.Len())
+		c.Check(buf.String(), check.Equals, t.line, check.Commentf("Test: %d", i))
+	}
+	_, err := w.Write(&Feature{})
+	c.Check(err, check.Equals, ErrNotHandled)
+}
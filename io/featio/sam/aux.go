@@ -0,0 +1,238 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// An Aux is a SAM optional field. The dynamic type of Value depends on the
+// field type:
+//
+//	A: byte
+//	i: int
+//	f: float32
+//	Z: string
+//	H: []byte
+//	B: []int8, []uint8, []int16, []uint16, []int32, []uint32 or []float32
+type Aux struct {
+	Tag   [2]byte
+	Type  byte
+	Value interface{}
+}
+
+// NewAux returns an Aux with the given tag and value, inferring the field
+// type from the type of value.
+func NewAux(tag string, value interface{}) (Aux, error) {
+	if len(tag) != 2 {
+		return Aux{}, ErrBadAux
+	}
+	a := Aux{Tag: [2]byte{tag[0], tag[1]}, Value: value}
+	switch v := value.(type) {
+	case byte:
+		a.Type = 'A'
+	case int:
+		a.Type = 'i'
+	case float32:
+		a.Type = 'f'
+	case float64:
+		a.Type, a.Value = 'f', float32(v)
+	case string:
+		a.Type = 'Z'
+	case []byte:
+		a.Type = 'H'
+	case []int8, []int16, []uint16, []int32, []uint32, []float32:
+		a.Type = 'B'
+	default:
+		return Aux{}, ErrBadAux
+	}
+	return a, nil
+}
+
+// ParseAux parses a SAM optional field of the form TAG:TYPE:VALUE.
+func ParseAux(s string) (Aux, error) {
+	if len(s) < 5 || s[2] != ':' || s[4] != ':' {
+		return Aux{}, ErrBadAux
+	}
+	a := Aux{Tag: [2]byte{s[0], s[1]}, Type: s[3]}
+	v := s[5:]
+	var err error
+	switch a.Type {
+	case 'A':
+		if len(v) != 1 {
+			return Aux{}, ErrBadAux
+		}
+		a.Value = v[0]
+	case 'i':
+		a.Value, err = strconv.Atoi(v)
+	case 'f':
+		var f float64
+		f, err = strconv.ParseFloat(v, 32)
+		a.Value = float32(f)
+	case 'Z':
+		a.Value = v
+	case 'H':
+		a.Value, err = hex.DecodeString(v)
+	case 'B':
+		a.Value, err = parseArray(v)
+	default:
+		return Aux{}, ErrBadAux
+	}
+	if err != nil {
+		return Aux{}, ErrBadAux
+	}
+	return a, nil
+}
+
+func parseArray(s string) (interface{}, error) {
+	f := strings.Split(s, ",")
+	if len(f[0]) != 1 {
+		return nil, ErrBadAux
+	}
+	t, f := f[0][0], f[1:]
+	if t == 'f' {
+		v := make([]float32, len(f))
+		for i, e := range f {
+			x, err := strconv.ParseFloat(e, 32)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = float32(x)
+		}
+		return v, nil
+	}
+	var (
+		signed bool
+		bits   int
+	)
+	switch t {
+	case 'c', 'C':
+		bits = 8
+	case 's', 'S':
+		bits = 16
+	case 'i', 'I':
+		bits = 32
+	default:
+		return nil, ErrBadAux
+	}
+	signed = t >= 'a'
+	var (
+		x   = make([]int64, len(f))
+		err error
+	)
+	for i, e := range f {
+		if signed {
+			x[i], err = strconv.ParseInt(e, 10, bits)
+		} else {
+			var u uint64
+			u, err = strconv.ParseUint(e, 10, bits)
+			x[i] = int64(u)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch t {
+	case 'c':
+		v := make([]int8, len(x))
+		for i, e := range x {
+			v[i] = int8(e)
+		}
+		return v, nil
+	case 'C':
+		v := make([]uint8, len(x))
+		for i, e := range x {
+			v[i] = uint8(e)
+		}
+		return v, nil
+	case 's':
+		v := make([]int16, len(x))
+		for i, e := range x {
+			v[i] = int16(e)
+		}
+		return v, nil
+	case 'S':
+		v := make([]uint16, len(x))
+		for i, e := range x {
+			v[i] = uint16(e)
+		}
+		return v, nil
+	case 'i':
+		v := make([]int32, len(x))
+		for i, e := range x {
+			v[i] = int32(e)
+		}
+		return v, nil
+	default:
+		v := make([]uint32, len(x))
+		for i, e := range x {
+			v[i] = uint32(e)
+		}
+		return v, nil
+	}
+}
+
+// String returns the SAM representation of a.
+func (a Aux) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%c%c:%c:", a.Tag[0], a.Tag[1], a.Type)
+	switch v := a.Value.(type) {
+	case byte:
+		if a.Type == 'A' {
+			b.WriteByte(v)
+		} else {
+			fmt.Fprint(&b, v)
+		}
+	case float32:
+		b.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	case []byte:
+		if a.Type == 'H' {
+			b.WriteString(strings.ToUpper(hex.EncodeToString(v)))
+			break
+		}
+		b.WriteByte('C')
+		for _, e := range v {
+			fmt.Fprintf(&b, ",%d", e)
+		}
+	case []int8:
+		b.WriteByte('c')
+		for _, e := range v {
+			fmt.Fprintf(&b, ",%d", e)
+		}
+	case []int16:
+		b.WriteByte('s')
+		for _, e := range v {
+			fmt.Fprintf(&b, ",%d", e)
+		}
+	case []uint16:
+		b.WriteByte('S')
+		for _, e := range v {
+			fmt.Fprintf(&b, ",%d", e)
+		}
+	case []int32:
+		b.WriteByte('i')
+		for _, e := range v {
+			fmt.Fprintf(&b, ",%d", e)
+		}
+	case []uint32:
+		b.WriteByte('I')
+		for _, e := range v {
+			fmt.Fprintf(&b, ",%d", e)
+		}
+	case []float32:
+		b.WriteByte('f')
+		for _, e := range v {
+			b.WriteByte(',')
+			b.WriteString(strconv.FormatFloat(float64(e), 'g', -1, 32))
+		}
+	default:
+		fmt.Fprint(&b, v)
+	}
+	return b.String()
+}
@@ -0,0 +1,181 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	"github.com/biogo/biogo/feat"
+
+	"bytes"
+	"strconv"
+)
+
+// CigarOpType is the type of a CIGAR operation.
+type CigarOpType byte
+
+const (
+	CigarMatch       CigarOpType = 'M' // Alignment match; sequence match or mismatch.
+	CigarInsertion   CigarOpType = 'I' // Insertion to the reference.
+	CigarDeletion    CigarOpType = 'D' // Deletion from the reference.
+	CigarSkipped     CigarOpType = 'N' // Skipped region from the reference.
+	CigarSoftClipped CigarOpType = 'S' // Soft clipping; clipped sequence present in SEQ.
+	CigarHardClipped CigarOpType = 'H' // Hard clipping; clipped sequence not present in SEQ.
+	CigarPadded      CigarOpType = 'P' // Padding; silent deletion from padded reference.
+	CigarEqual       CigarOpType = '=' // Sequence match.
+	CigarMismatch    CigarOpType = 'X' // Sequence mismatch.
+)
+
+// Consumes returns whether the operation consumes query and reference
+// positions.
+func (t CigarOpType) Consumes() (query, reference bool) {
+	switch t {
+	case CigarMatch, CigarEqual, CigarMismatch:
+		return true, true
+	case CigarInsertion, CigarSoftClipped:
+		return true, false
+	case CigarDeletion, CigarSkipped:
+		return false, true
+	}
+	return false, false
+}
+
+func (t CigarOpType) valid() bool {
+	switch t {
+	case CigarMatch, CigarInsertion, CigarDeletion, CigarSkipped, CigarSoftClipped,
+		CigarHardClipped, CigarPadded, CigarEqual, CigarMismatch:
+		return true
+	}
+	return false
+}
+
+// A CigarOp is a single CIGAR operation.
+type CigarOp struct {
+	Type CigarOpType
+	Len  int
+}
+
+func (o CigarOp) String() string { return strconv.Itoa(o.Len) + string(o.Type) }
+
+// A Cigar is a sequence of CIGAR operations. A nil Cigar is unavailable.
+type Cigar []CigarOp
+
+// ParseCigar parses a CIGAR string. The unavailable CIGAR, "*", is returned
+// as a nil Cigar.
+func ParseCigar(s string) (Cigar, error) {
+	if s == "*" {
+		return nil, nil
+	}
+	if s == "" {
+		return nil, ErrBadCigar
+	}
+	var (
+		c Cigar
+		n int
+		d bool
+	)
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if '0' <= b && b <= '9' {
+			n = n*10 + int(b-'0')
+			d = true
+			continue
+		}
+		t := CigarOpType(b)
+		if !d || !t.valid() {
+			return nil, ErrBadCigar
+		}
+		c = append(c, CigarOp{Type: t, Len: n})
+		n, d = 0, false
+	}
+	if d {
+		return nil, ErrBadCigar
+	}
+	return c, nil
+}
+
+// String returns the CIGAR string representation of c.
+func (c Cigar) String() string {
+	if len(c) == 0 {
+		return "*"
+	}
+	var b bytes.Buffer
+	for _, o := range c {
+		b.WriteString(strconv.Itoa(o.Len))
+		b.WriteByte(byte(o.Type))
+	}
+	return b.String()
+}
+
+// RefLen returns the number of reference positions consumed by c.
+func (c Cigar) RefLen() int {
+	var n int
+	for _, o := range c {
+		if _, r := o.Type.Consumes(); r {
+			n += o.Len
+		}
+	}
+	return n
+}
+
+// QueryLen returns the number of query positions consumed by c.
+func (c Cigar) QueryLen() int {
+	var n int
+	for _, o := range c {
+		if q, _ := o.Type.Consumes(); q {
+			n += o.Len
+		}
+	}
+	return n
+}
+
+// CigarFromPairs returns the CIGAR describing the alignment in aln, as
+// returned by the aligners in the align package with the reference as the
+// first feature of each pair, and the reference start position of the
+// alignment. Query positions before and after the aligned region of a query
+// of length qlen are described as soft clipped.
+func CigarFromPairs(aln []feat.Pair, qlen int) (c Cigar, pos int, err error) {
+	if len(aln) == 0 {
+		return nil, -1, ErrBadPairs
+	}
+	var (
+		f          = aln[0].Features()
+		ref, query = f[0].Start(), f[1].Start()
+	)
+	pos = ref
+	add := func(t CigarOpType, n int) {
+		if n == 0 {
+			return
+		}
+		if len(c) != 0 && c[len(c)-1].Type == t {
+			c[len(c)-1].Len += n
+			return
+		}
+		c = append(c, CigarOp{Type: t, Len: n})
+	}
+	add(CigarSoftClipped, query)
+	for _, p := range aln {
+		f := p.Features()
+		if f[0].Start() != ref || f[1].Start() != query {
+			return nil, -1, ErrBadPairs
+		}
+		rl, ql := f[0].Len(), f[1].Len()
+		switch {
+		case rl == 0:
+			add(CigarInsertion, ql)
+		case ql == 0:
+			add(CigarDeletion, rl)
+		case rl == ql:
+			add(CigarMatch, rl)
+		default:
+			return nil, -1, ErrBadPairs
+		}
+		ref += rl
+		query += ql
+	}
+	if query > qlen {
+		return nil, -1, ErrBadPairs
+	}
+	add(CigarSoftClipped, qlen-query)
+	return c, pos, nil
+}
@@ -0,0 +1,204 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	"github.com/biogo/biogo/feat"
+
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// A Tag is a header line field tag and value pair.
+type Tag struct {
+	Tag   string
+	Value string
+}
+
+// A HeaderLine is a SAM header line. Comment lines of type CO hold their
+// text in Text; other lines hold their fields in Tags in order.
+type HeaderLine struct {
+	Type string
+	Tags []Tag
+	Text string
+}
+
+// Get returns the value of the field with the given tag.
+func (l *HeaderLine) Get(tag string) string {
+	for _, t := range l.Tags {
+		if t.Tag == tag {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// String returns the SAM representation of the header line.
+func (l *HeaderLine) String() string {
+	if l.Type == "CO" {
+		return "@CO\t" + l.Text
+	}
+	var b bytes.Buffer
+	b.WriteByte('@')
+	b.WriteString(l.Type)
+	for _, t := range l.Tags {
+		b.WriteByte('\t')
+		b.WriteString(t.Tag)
+		b.WriteByte(':')
+		b.WriteString(t.Value)
+	}
+	return b.String()
+}
+
+func parseHeaderLine(s string) (*HeaderLine, error) {
+	if len(s) < 3 || s[0] != '@' {
+		return nil, ErrBadHeader
+	}
+	l := &HeaderLine{Type: s[1:3]}
+	if l.Type == "CO" {
+		if len(s) > 3 {
+			if s[3] != '\t' {
+				return nil, ErrBadHeader
+			}
+			l.Text = s[4:]
+		}
+		return l, nil
+	}
+	if len(s) > 3 && s[3] != '\t' {
+		return nil, ErrBadHeader
+	}
+	for _, f := range strings.Split(s[3:], "\t")[1:] {
+		if len(f) < 3 || f[2] != ':' {
+			return nil, ErrBadHeader
+		}
+		l.Tags = append(l.Tags, Tag{Tag: f[:2], Value: f[3:]})
+	}
+	return l, nil
+}
+
+// A Reference is a reference sequence described by an @SQ header line.
+type Reference struct {
+	SeqName string
+
+	// SeqLen is the length of the reference
+	// or -1 if the length is not known.
+	SeqLen int
+
+	// Line is the header line describing the
+	// reference. It is nil for references not
+	// described in the header.
+	Line *HeaderLine
+}
+
+func (r *Reference) Start() int             { return 0 }
+func (r *Reference) End() int               { return r.SeqLen }
+func (r *Reference) Len() int               { return r.SeqLen }
+func (r *Reference) Name() string           { return r.SeqName }
+func (r *Reference) Description() string    { return "sam reference" }
+func (r *Reference) Location() feat.Feature { return nil }
+
+// A ReadGroup is a read group described by an @RG header line.
+type ReadGroup struct {
+	ID       string
+	Sample   string
+	Library  string
+	Platform string
+
+	Line *HeaderLine
+}
+
+// A Program is a program described by an @PG header line.
+type Program struct {
+	ID          string
+	Name        string
+	Version     string
+	CommandLine string
+	Previous    string
+
+	Line *HeaderLine
+}
+
+// Header is a SAM file header.
+type Header struct {
+	// Version and SortOrder are the VN and SO
+	// fields of the @HD line.
+	Version   string
+	SortOrder string
+
+	// Lines holds the header lines in the order
+	// they appear.
+	Lines []*HeaderLine
+
+	// Refs, ReadGroups and Programs index the
+	// @SQ, @RG and @PG lines held in Lines.
+	Refs       []*Reference
+	ReadGroups []*ReadGroup
+	Programs   []*Program
+}
+
+// Add appends the header line l to the header, indexing @HD, @SQ, @RG and
+// @PG lines.
+func (h *Header) Add(l *HeaderLine) error {
+	switch l.Type {
+	case "HD":
+		if len(h.Lines) != 0 {
+			return ErrBadHeader
+		}
+		h.Version = l.Get("VN")
+		h.SortOrder = l.Get("SO")
+	case "SQ":
+		r := &Reference{SeqName: l.Get("SN"), SeqLen: -1, Line: l}
+		if r.SeqName == "" || h.Ref(r.SeqName) != nil {
+			return ErrBadHeader
+		}
+		if ln := l.Get("LN"); ln != "" {
+			var err error
+			r.SeqLen, err = strconv.Atoi(ln)
+			if err != nil || r.SeqLen < 0 {
+				return ErrBadHeader
+			}
+		}
+		h.Refs = append(h.Refs, r)
+	case "RG":
+		rg := &ReadGroup{
+			ID:       l.Get("ID"),
+			Sample:   l.Get("SM"),
+			Library:  l.Get("LB"),
+			Platform: l.Get("PL"),
+			Line:     l,
+		}
+		if rg.ID == "" {
+			return ErrBadHeader
+		}
+		h.ReadGroups = append(h.ReadGroups, rg)
+	case "PG":
+		p := &Program{
+			ID:          l.Get("ID"),
+			Name:        l.Get("PN"),
+			Version:     l.Get("VN"),
+			CommandLine: l.Get("CL"),
+			Previous:    l.Get("PP"),
+			Line:        l,
+		}
+		if p.ID == "" {
+			return ErrBadHeader
+		}
+		h.Programs = append(h.Programs, p)
+	}
+	h.Lines = append(h.Lines, l)
+	return nil
+}
+
+// Ref returns the reference with the given name or nil if no such
+// reference is described by the header.
+func (h *Header) Ref(name string) *Reference {
+	for _, r := range h.Refs {
+		if r.SeqName == name {
+			return r
+		}
+	}
+	return nil
+}
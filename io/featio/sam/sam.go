@@ -0,0 +1,445 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sam provides types to read and write Sequence Alignment/Map text
+// format files.
+//
+// The specification can be found at https://samtools.github.io/hts-specs/SAMv1.pdf.
+package sam
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/featio"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ featio.Reader = (*Reader)(nil)
+	_ featio.Writer = (*Writer)(nil)
+)
+
+var (
+	ErrBadHeader     = errors.New("sam: malformed header line")
+	ErrFieldMissing  = errors.New("sam: missing fields")
+	ErrBadFlags      = errors.New("sam: invalid flags")
+	ErrBadPos        = errors.New("sam: invalid position")
+	ErrBadMapQ       = errors.New("sam: invalid mapping quality")
+	ErrBadCigar      = errors.New("sam: malformed cigar")
+	ErrBadAux        = errors.New("sam: malformed optional field")
+	ErrBadQual       = errors.New("sam: invalid quality")
+	ErrLenMismatch   = errors.New("sam: sequence length mismatch")
+	ErrBadPairs      = errors.New("sam: invalid alignment pairs")
+	ErrNotHandled    = errors.New("sam: type not handled")
+	ErrCannotHeader  = errors.New("sam: cannot write header: data written")
+	ErrNoHeaderWrite = errors.New("sam: header not written")
+)
+
+const (
+	qnameField = iota
+	flagField
+	rnameField
+	posField
+	mapqField
+	cigarField
+	rnextField
+	pnextField
+	tlenField
+	seqField
+	qualField
+	lastField
+)
+
+// Flags is the SAM FLAG field.
+type Flags uint16
+
+const (
+	Paired        Flags = 1 << iota // The read is paired in sequencing.
+	ProperPair                      // The read is mapped in a proper pair.
+	Unmapped                        // The read is unmapped.
+	MateUnmapped                    // The mate is unmapped.
+	Reverse                         // The read is mapped to the reverse strand.
+	MateReverse                     // The mate is mapped to the reverse strand.
+	Read1                           // The read is the first read in a pair.
+	Read2                           // The read is the second read in a pair.
+	Secondary                       // The alignment is not primary.
+	QCFail                          // The read fails quality checks.
+	Duplicate                       // The read is a PCR or optical duplicate.
+	Supplementary                   // The alignment is supplementary.
+)
+
+// UnavailableMapQ is the MAPQ value indicating the mapping quality is not
+// available.
+const UnavailableMapQ = 0xff
+
+// A Record is a SAM alignment line.
+type Record struct {
+	QName string
+	Flags Flags
+
+	// Ref is the reference of the alignment and
+	// is nil if unavailable. Pos is the zero-based
+	// leftmost mapping position, or -1 if
+	// unavailable.
+	Ref *Reference
+	Pos int
+
+	MapQ  byte
+	Cigar Cigar
+
+	// MateRef and MatePos describe the alignment
+	// of the mate in the same way as Ref and Pos.
+	MateRef *Reference
+	MatePos int
+	TempLen int
+
+	// Seq holds the read sequence and Qual the
+	// read base qualities as Phred scores. Either
+	// is nil when unavailable.
+	Seq  []byte
+	Qual []byte
+
+	Aux []Aux
+}
+
+func (r *Record) Start() int { return r.Pos }
+func (r *Record) End() int   { return r.Pos + r.Len() }
+
+// Len returns the number of reference positions covered by the alignment.
+func (r *Record) Len() int            { return r.Cigar.RefLen() }
+func (r *Record) Name() string        { return r.QName }
+func (r *Record) Description() string { return "sam record" }
+func (r *Record) Location() feat.Feature {
+	if r.Ref == nil {
+		return nil
+	}
+	return r.Ref
+}
+
+// Orientation returns the orientation of the alignment on the reference.
+func (r *Record) Orientation() feat.Orientation {
+	if r.Flags&Reverse != 0 {
+		return feat.Reverse
+	}
+	return feat.Forward
+}
+
+// Tag returns the optional field with the given tag and whether it is
+// present.
+func (r *Record) Tag(tag string) (Aux, bool) {
+	if len(tag) != 2 {
+		return Aux{}, false
+	}
+	for _, a := range r.Aux {
+		if a.Tag[0] == tag[0] && a.Tag[1] == tag[1] {
+			return a, true
+		}
+	}
+	return Aux{}, false
+}
+
+// QSeq returns the read sequence and qualities of r as a *linear.QSeq with
+// the given alphabet. The sequence is in the orientation of the reference. If
+// the record has no qualities, all letters have a quality of zero.
+func (r *Record) QSeq(alpha alphabet.Alphabet) *linear.QSeq {
+	ql := make([]alphabet.QLetter, len(r.Seq))
+	for i, l := range r.Seq {
+		ql[i].L = alphabet.Letter(l)
+		if r.Qual != nil {
+			ql[i].Q = alphabet.Qphred(r.Qual[i])
+		}
+	}
+	s := linear.NewQSeq(r.QName, nil, alpha, alphabet.Sanger)
+	s.Seq = ql
+	return s
+}
+
+// FromPairs returns a Record describing the alignment of query against ref
+// given by aln, as returned by the aligners of the align package with ref
+// as the reference. The record's MapQ is unavailable and if the pairs
+// provide a Score method the sum of their scores is recorded in an AS
+// field.
+func FromPairs(qname string, ref *Reference, query []byte, aln []feat.Pair) (*Record, error) {
+	c, pos, err := CigarFromPairs(aln, len(query))
+	if err != nil {
+		return nil, err
+	}
+	r := &Record{
+		QName:   qname,
+		Ref:     ref,
+		Pos:     pos,
+		MapQ:    UnavailableMapQ,
+		Cigar:   c,
+		MatePos: -1,
+		Seq:     query,
+	}
+	var (
+		score  int
+		scored = true
+	)
+	for _, p := range aln {
+		s, ok := p.(interface {
+			Score() int
+		})
+		if !ok {
+			scored = false
+			break
+		}
+		score += s.Score()
+	}
+	if scored {
+		r.Aux = []Aux{{Tag: [2]byte{'A', 'S'}, Type: 'i', Value: score}}
+	}
+	return r, nil
+}
+
+// A Reader can parse SAM formatted io.Reader and return feat.Features.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+
+	// Header is the header of the SAM stream.
+	Header *Header
+
+	refs map[string]*Reference
+}
+
+// NewReader returns a new SAM format reader that reads from r. The SAM
+// header is read before NewReader returns.
+func NewReader(r io.Reader) (*Reader, error) {
+	sr := &Reader{
+		r:      bufio.NewReader(r),
+		Header: &Header{},
+		refs:   make(map[string]*Reference),
+	}
+	for {
+		b, err := sr.r.Peek(1)
+		if err != nil || b[0] != '@' {
+			break
+		}
+		line, err := sr.readLine()
+		if err != nil {
+			return nil, &csv.ParseError{Line: sr.line, Err: err}
+		}
+		l, err := parseHeaderLine(line)
+		if err == nil {
+			err = sr.Header.Add(l)
+		}
+		if err != nil {
+			return nil, &csv.ParseError{Line: sr.line, Err: err}
+		}
+	}
+	for _, ref := range sr.Header.Refs {
+		sr.refs[ref.SeqName] = ref
+	}
+	return sr, nil
+}
+
+func (r *Reader) readLine() (string, error) {
+	b, err := r.r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(b) == 0) {
+		return "", err
+	}
+	r.line++
+	return string(bytes.TrimRight(b, "\r\n")), nil
+}
+
+// ref returns the reference with the given name. References that are not
+// described by the header are created with an unknown length.
+func (r *Reader) ref(name string) *Reference {
+	if name == "*" {
+		return nil
+	}
+	ref, ok := r.refs[name]
+	if !ok {
+		ref = &Reference{SeqName: name, SeqLen: -1}
+		r.refs[name] = ref
+	}
+	return ref
+}
+
+// Read reads a single alignment and returns it as a *Record, or an error.
+func (r *Reader) Read() (feat.Feature, error) {
+	line, err := r.readLine()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, &csv.ParseError{Line: r.line, Err: err}
+	}
+
+	fields := strings.Split(line, "\t")
+	if len(fields) < lastField {
+		return nil, &csv.ParseError{Line: r.line, Column: len(fields), Err: ErrFieldMissing}
+	}
+	rec := &Record{
+		QName: fields[qnameField],
+		Ref:   r.ref(fields[rnameField]),
+	}
+	flags, err := strconv.ParseUint(fields[flagField], 10, 16)
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: flagField, Err: ErrBadFlags}
+	}
+	rec.Flags = Flags(flags)
+	rec.Pos, err = parsePos(fields[posField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: posField, Err: err}
+	}
+	mapq, err := strconv.ParseUint(fields[mapqField], 10, 8)
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: mapqField, Err: ErrBadMapQ}
+	}
+	rec.MapQ = byte(mapq)
+	rec.Cigar, err = ParseCigar(fields[cigarField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: cigarField, Err: err}
+	}
+	if fields[rnextField] == "=" {
+		rec.MateRef = rec.Ref
+	} else {
+		rec.MateRef = r.ref(fields[rnextField])
+	}
+	rec.MatePos, err = parsePos(fields[pnextField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: pnextField, Err: err}
+	}
+	rec.TempLen, err = strconv.Atoi(fields[tlenField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: tlenField, Err: err}
+	}
+	if fields[seqField] != "*" {
+		rec.Seq = []byte(fields[seqField])
+		if rec.Cigar != nil && rec.Cigar.QueryLen() != len(rec.Seq) {
+			return nil, &csv.ParseError{Line: r.line, Column: seqField, Err: ErrLenMismatch}
+		}
+	}
+	if fields[qualField] != "*" {
+		if rec.Seq != nil && len(fields[qualField]) != len(rec.Seq) {
+			return nil, &csv.ParseError{Line: r.line, Column: qualField, Err: ErrLenMismatch}
+		}
+		rec.Qual = make([]byte, len(fields[qualField]))
+		for i, q := range []byte(fields[qualField]) {
+			if q < '!' || q > '~' {
+				return nil, &csv.ParseError{Line: r.line, Column: qualField, Err: ErrBadQual}
+			}
+			rec.Qual[i] = q - '!'
+		}
+	}
+	for i, f := range fields[lastField:] {
+		a, err := ParseAux(f)
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: lastField + i, Err: err}
+		}
+		rec.Aux = append(rec.Aux, a)
+	}
+	return rec, nil
+}
+
+func parsePos(s string) (int, error) {
+	p, err := strconv.Atoi(s)
+	if err != nil || p < 0 {
+		return 0, ErrBadPos
+	}
+	return p - 1, nil
+}
+
+// A Writer outputs records into SAM format.
+type Writer struct {
+	w      io.Writer
+	header bool
+	data   bool
+}
+
+// NewWriter returns a new SAM format writer using w. The header must be
+// written with WriteHeader before any records are written.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteHeader writes the SAM header h, writing header lines in the order
+// they are held in h.Lines.
+func (w *Writer) WriteHeader(h *Header) (n int, err error) {
+	if w.data {
+		return 0, ErrCannotHeader
+	}
+	var b bytes.Buffer
+	for _, l := range h.Lines {
+		fmt.Fprintf(&b, "%s\n", l)
+	}
+	w.header = true
+	return w.w.Write(b.Bytes())
+}
+
+// Write writes a single record and return the number of bytes written and
+// any error. Only *Record values are handled.
+func (w *Writer) Write(f feat.Feature) (n int, err error) {
+	r, ok := f.(*Record)
+	if !ok {
+		return 0, ErrNotHandled
+	}
+	if !w.header {
+		return 0, ErrNoHeaderWrite
+	}
+	rnext := refName(r.MateRef)
+	if r.MateRef != nil && r.MateRef == r.Ref {
+		rnext = "="
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\t%d\t%s\t%d\t%d\t%s\t%s\t%d\t%d\t",
+		r.QName,
+		r.Flags,
+		refName(r.Ref),
+		r.Pos+1,
+		r.MapQ,
+		r.Cigar,
+		rnext,
+		r.MatePos+1,
+		r.TempLen,
+	)
+	if r.Seq == nil {
+		b.WriteByte('*')
+	} else {
+		b.Write(r.Seq)
+	}
+	b.WriteByte('\t')
+	if r.Qual == nil {
+		b.WriteByte('*')
+	} else {
+		for _, q := range r.Qual {
+			b.WriteByte(q + '!')
+		}
+	}
+	for _, a := range r.Aux {
+		fmt.Fprintf(&b, "\t%s", a)
+	}
+	b.WriteByte('\n')
+	w.data = true
+	return w.w.Write(b.Bytes())
+}
+
+// WritePairs writes the alignment of query against ref described by aln
+// as a single record. See FromPairs for details of the record.
+func (w *Writer) WritePairs(qname string, ref *Reference, query []byte, aln []feat.Pair) (n int, err error) {
+	r, err := FromPairs(qname, ref, query, aln)
+	if err != nil {
+		return 0, err
+	}
+	return w.Write(r)
+}
+
+func refName(r *Reference) string {
+	if r == nil {
+		return "*"
+	}
+	return r.SeqName
+}
@@ -0,0 +1,315 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sam
+
+import (
+	"github.com/biogo/biogo/align"
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const sam = `@HD	VN:1.6	SO:coordinate
+@SQ	SN:ref	LN:45
+@RG	ID:rg1	SM:sample1	PL:ILLUMINA
+@PG	ID:bwa	PN:bwa	VN:0.7.15	CL:bwa mem ref.fa r.fq
+@CO	free text comment
+r001	99	ref	7	30	8M2I4M1D3M	=	37	39	TTAGATAAAGGATACTG	*	NM:i:3	XA:A:x
+r002	0	ref	9	30	3S6M1P1I4M	*	0	0	AAAAGATAAGGATA	*	RG:Z:rg1
+r003	2064	ref	29	17	6H5M	*	0	0	TAGGC	!#%'+	XB:B:c,-1,2,3	XF:f:1.5	XH:H:1AE3
+r004	4	*	0	255	*	*	0	0	CTCAAA	*
+`
+
+func (s *S) TestRead(c *check.C) {
+	r, err := NewReader(strings.NewReader(sam))
+	c.Assert(err, check.Equals, nil)
+	h := r.Header
+	c.Check(h.Version, check.Equals, "1.6")
+	c.Check(h.SortOrder, check.Equals, "coordinate")
+	c.Check(len(h.Lines), check.Equals, 5)
+	c.Assert(len(h.Refs), check.Equals, 1)
+	c.Check(h.Refs[0].Name(), check.Equals, "ref")
+	c.Check(h.Refs[0].Len(), check.Equals, 45)
+	c.Check(h.ReadGroups[0].Sample, check.Equals, "sample1")
+	c.Check(h.Programs[0].CommandLine, check.Equals, "bwa mem ref.fa r.fq")
+	c.Check(h.Lines[4].Text, check.Equals, "free text comment")
+
+	var recs []*Record
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		recs = append(recs, f.(*Record))
+	}
+	c.Assert(len(recs), check.Equals, 4)
+
+	rec := recs[0]
+	c.Check(rec.Name(), check.Equals, "r001")
+	c.Check(rec.Flags, check.Equals, Paired|ProperPair|MateReverse|Read1)
+	c.Check(rec.Location(), check.Equals, feat.Feature(h.Refs[0]))
+	c.Check(rec.MateRef, check.Equals, h.Refs[0])
+	c.Check(rec.Start(), check.Equals, 6)
+	c.Check(rec.End(), check.Equals, 22)
+	c.Check(rec.MatePos, check.Equals, 36)
+	c.Check(rec.Cigar.QueryLen(), check.Equals, 17)
+	c.Check(rec.Qual, check.IsNil)
+	nm, ok := rec.Tag("NM")
+	c.Check(ok, check.Equals, true)
+	c.Check(nm.Value, check.Equals, 3)
+	xa, _ := rec.Tag("XA")
+	c.Check(xa.Value, check.Equals, byte('x'))
+
+	rec = recs[2]
+	c.Check(rec.Orientation(), check.Equals, feat.Reverse)
+	c.Check(rec.Flags&Supplementary, check.Not(check.Equals), Flags(0))
+	c.Check(rec.Qual, check.DeepEquals, []byte{0, 2, 4, 6, 10})
+	c.Check(rec.Aux[0].Value, check.DeepEquals, []int8{-1, 2, 3})
+	c.Check(rec.Aux[1].Value, check.Equals, float32(1.5))
+	c.Check(rec.Aux[2].Value, check.DeepEquals, []byte{0x1a, 0xe3})
+
+	rec = recs[3]
+	c.Check(rec.Location(), check.IsNil)
+	c.Check(rec.Pos, check.Equals, -1)
+	c.Check(rec.Cigar, check.IsNil)
+	c.Check(rec.MapQ, check.Equals, byte(UnavailableMapQ))
+}
+
+func (s *S) TestRoundTrip(c *check.C) {
+	r, err := NewReader(strings.NewReader(sam))
+	c.Assert(err, check.Equals, nil)
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err = w.Write(&Record{})
+	c.Check(err, check.Equals, ErrNoHeaderWrite)
+	_, err = w.WriteHeader(r.Header)
+	c.Assert(err, check.Equals, nil)
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		_, err = w.Write(f)
+		c.Assert(err, check.Equals, nil)
+	}
+	_, err = w.WriteHeader(r.Header)
+	c.Check(err, check.Equals, ErrCannotHeader)
+	c.Check(buf.String(), check.Equals, sam)
+}
+
+func (s *S) TestCigar(c *check.C) {
+	for _, t := range []struct {
+		in         string
+		cigar      Cigar
+		ref, query int
+		err        error
+	}{
+		{in: "*"},
+		{
+			in:    "3S8M2I4M1D3M",
+			cigar: Cigar{{CigarSoftClipped, 3}, {CigarMatch, 8}, {CigarInsertion, 2}, {CigarMatch, 4}, {CigarDeletion, 1}, {CigarMatch, 3}},
+			ref:   16, query: 20,
+		},
+		{in: "5H10=100N2X", cigar: Cigar{{CigarHardClipped, 5}, {CigarEqual, 10}, {CigarSkipped, 100}, {CigarMismatch, 2}}, ref: 112, query: 12},
+		{in: "", err: ErrBadCigar},
+		{in: "M", err: ErrBadCigar},
+		{in: "10", err: ErrBadCigar},
+		{in: "10Q", err: ErrBadCigar},
+	} {
+		cigar, err := ParseCigar(t.in)
+		c.Check(err, check.Equals, t.err, check.Commentf("%q", t.in))
+		if err != nil {
+			continue
+		}
+		c.Check(cigar, check.DeepEquals, t.cigar)
+		c.Check(cigar.RefLen(), check.Equals, t.ref)
+		c.Check(cigar.QueryLen(), check.Equals, t.query)
+		c.Check(cigar.String(), check.Equals, t.in)
+	}
+}
+
+type span struct{ start, end int }
+
+func (s span) Start() int             { return s.start }
+func (s span) End() int               { return s.end }
+func (s span) Len() int               { return s.end - s.start }
+func (s span) Name() string           { return "" }
+func (s span) Description() string    { return "" }
+func (s span) Location() feat.Feature { return nil }
+
+type pair [2]feat.Feature
+
+func (p pair) Features() [2]feat.Feature { return p }
+
+func (s *S) TestFromPairs(c *check.C) {
+	ref := &linear.Seq{Seq: alphabet.BytesToLetters([]byte("ACACACTA"))}
+	ref.Alpha = alphabet.DNAgapped
+	query := &linear.Seq{Seq: alphabet.BytesToLetters([]byte("AGCACACA"))}
+	query.Alpha = alphabet.DNAgapped
+	smith := align.SW{
+		{0, -1, -1, -1, -1},
+		{-1, 2, -1, -1, -1},
+		{-1, -1, 2, -1, -1},
+		{-1, -1, -1, 2, -1},
+		{-1, -1, -1, -1, 2},
+	}
+	aln, err := smith.Align(ref, query)
+	c.Assert(err, check.Equals, nil)
+
+	chr := &Reference{SeqName: "chr", SeqLen: 8}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err = w.WriteHeader(&Header{Lines: []*HeaderLine{{Type: "SQ", Tags: []Tag{{"SN", "chr"}, {"LN", "8"}}}}})
+	c.Assert(err, check.Equals, nil)
+	_, err = w.WritePairs("q", chr, []byte("AGCACACA"), aln)
+	c.Assert(err, check.Equals, nil)
+	c.Check(buf.String(), check.Equals, "@SQ\tSN:chr\tLN:8\nq\t0\tchr\t1\t255\t1M1I5M1D1M\t*\t0\t0\tAGCACACA\t*\tAS:i:12\n")
+
+	rec, err := FromPairs("q", chr, []byte("TTACGTAC"), []feat.Pair{
+		pair{span{10, 12}, span{2, 4}},
+		pair{span{12, 14}, span{4, 6}},
+		pair{span{14, 15}, span{6, 6}},
+	})
+	c.Assert(err, check.Equals, nil)
+	c.Check(rec.Pos, check.Equals, 10)
+	c.Check(rec.Cigar.String(), check.Equals, "2S4M1D2S")
+	c.Check(rec.Aux, check.IsNil)
+
+	_, err = FromPairs("q", chr, []byte("ACGT"), []feat.Pair{
+		pair{span{0, 2}, span{0, 2}},
+		pair{span{3, 5}, span{2, 4}},
+	})
+	c.Check(err, check.Equals, ErrBadPairs)
+}
+
+func (s *S) TestFromAlignments(c *check.C) {
+	dna := align.Linear{
+		{0, -1, -1, -1, -1},
+		{-1, 2, -1, -1, -1},
+		{-1, -1, 2, -1, -1},
+		{-1, -1, -1, 2, -1},
+		{-1, -1, -1, -1, 2},
+	}
+	gapped := align.Linear{
+		{0, -2, -1, -2, -1},
+		{-2, 7, 2, 0, -4},
+		{-1, 2, 2, -2, 0},
+		{-2, 0, -2, 7, 2},
+		{-1, -4, 0, 2, 10},
+	}
+	for _, t := range []struct {
+		name  string
+		a     align.Aligner
+		r, q  string
+		pos   int
+		cigar string
+		score int
+	}{
+		{
+			name:  "NW",
+			a:     align.NW(dna),
+			r:     "ACGTACGTAC",
+			q:     "ACGTTACGAC",
+			pos:   0,
+			cigar: "3M1I4M1D2M",
+			score: 16,
+		},
+		{
+			name:  "SW",
+			a:     align.SW(dna),
+			r:     "TTTTACGTACGTTTTT",
+			q:     "GGACGTACGTGG",
+			pos:   4,
+			cigar: "2S8M2S",
+			score: 16,
+		},
+		{
+			name:  "SWAffine",
+			a:     align.SWAffine{Matrix: dna, GapOpen: -2},
+			r:     "GGGGACGTACGTCCATGCATGCAAAA",
+			q:     "ACGTACGTATGCATGCA",
+			pos:   4,
+			cigar: "8M2D9M",
+			score: 30,
+		},
+		{
+			name:  "SWAffineBanded",
+			a:     align.SWAffineBanded{Matrix: gapped, GapOpen: -1, Band: 100},
+			r:     "ATATTAATATTA",
+			q:     "ATATTTTAATAAA",
+			pos:   0,
+			cigar: "5M2I4M2D1M1I",
+			score: 73,
+		},
+	} {
+		ref := linear.NewSeq("ref", alphabet.BytesToLetters([]byte(t.r)), alphabet.DNAgapped)
+		query := linear.NewSeq("query", alphabet.BytesToLetters([]byte(t.q)), alphabet.DNAgapped)
+		aln, err := t.a.Align(ref, query)
+		c.Assert(err, check.Equals, nil)
+		for _, p := range aln {
+			f := p.Features()
+			c.Check(f[0].Len() != 0 || f[1].Len() != 0, check.Equals, true, check.Commentf("%s %v", t.name, aln))
+		}
+
+		rec, err := FromPairs("q", &Reference{SeqName: "chr", SeqLen: len(t.r)}, []byte(t.q), aln)
+		c.Assert(err, check.Equals, nil, check.Commentf("%s", t.name))
+		c.Check(rec.Pos, check.Equals, t.pos, check.Commentf("%s", t.name))
+		c.Check(rec.Cigar.String(), check.Equals, t.cigar, check.Commentf("%s", t.name))
+		c.Check(rec.Cigar.QueryLen(), check.Equals, len(t.q), check.Commentf("%s", t.name))
+		for _, op := range rec.Cigar {
+			c.Check(op.Len > 0, check.Equals, true, check.Commentf("%s %v", t.name, rec.Cigar))
+		}
+		as, ok := rec.Tag("AS")
+		c.Assert(ok, check.Equals, true, check.Commentf("%s", t.name))
+		c.Check(as.Value, check.Equals, t.score, check.Commentf("%s", t.name))
+	}
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	const rec = "r\t0\tref\t1\t30\t4M\t*\t0\t0\t"
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{"@SQ\tLN:10\n", ErrBadHeader},
+		{"@SQ\tSN:ref\tLN:x\n", ErrBadHeader},
+		{"@SQ SN:ref\n", ErrBadHeader},
+		{"r\t0\tref\t1\n", ErrFieldMissing},
+		{"r\tx\tref\t1\t30\t4M\t*\t0\t0\tACGT\t*\n", ErrBadFlags},
+		{"r\t0\tref\t-1\t30\t4M\t*\t0\t0\tACGT\t*\n", ErrBadPos},
+		{"r\t0\tref\t1\t256\t4M\t*\t0\t0\tACGT\t*\n", ErrBadMapQ},
+		{"r\t0\tref\t1\t30\t4Z\t*\t0\t0\tACGT\t*\n", ErrBadCigar},
+		{rec + "ACG\t*\n", ErrLenMismatch},
+		{rec + "ACGT\t!!!\n", ErrLenMismatch},
+		{rec + "ACGT\t!! !\n", ErrBadQual},
+		{rec + "ACGT\t*\tNM:i:x\n", ErrBadAux},
+		{rec + "ACGT\t*\tNM:Q:1\n", ErrBadAux},
+	} {
+		r, err := NewReader(strings.NewReader(t.in))
+		if err == nil {
+			_, err = r.Read()
+		}
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err, check.Commentf("%q", t.in))
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vcf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Type is the type of an INFO or FORMAT field value.
+type Type int
+
+const (
+	String Type = iota
+	Integer
+	Float
+	Flag
+	Character
+)
+
+var typeNames = [...]string{
+	String:    "String",
+	Integer:   "Integer",
+	Float:     "Float",
+	Flag:      "Flag",
+	Character: "Character",
+}
+
+func (t Type) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+	return typeNames[t]
+}
+
+func parseType(s string) (Type, bool) {
+	for t, n := range typeNames {
+		if n == s {
+			return Type(t), true
+		}
+	}
+	return 0, false
+}
+
+// Special Number values for INFO and FORMAT definitions.
+const (
+	NumberA       = "A" // One value per alternate allele.
+	NumberR       = "R" // One value per allele including the reference.
+	NumberG       = "G" // One value per possible genotype.
+	NumberUnknown = "." // Unknown or unbounded.
+)
+
+// A Field is a key and value pair. Fields are used for the key=value pairs
+// of structured meta-lines and for the INFO column of records.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Meta is a VCF ## meta-line. Unstructured meta-lines hold their value in
+// Value. Structured meta-lines of the form ##key=<k=v,...> hold their
+// key=value pairs in Fields in order.
+type Meta struct {
+	Key    string
+	Value  string
+	Fields []Field
+}
+
+// Get returns the value of the structured field with the given key.
+func (m *Meta) Get(key string) string {
+	for _, f := range m.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// String returns the meta-line without the leading ##.
+func (m *Meta) String() string {
+	if m.Fields == nil {
+		return m.Key + "=" + m.Value
+	}
+	var b bytes.Buffer
+	b.WriteString(m.Key)
+	b.WriteString("=<")
+	for i, f := range m.Fields {
+		if i != 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		if needsQuote(f) {
+			b.WriteByte('"')
+			for _, c := range []byte(f.Value) {
+				if c == '"' || c == '\\' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(c)
+			}
+			b.WriteByte('"')
+		} else {
+			b.WriteString(f.Value)
+		}
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+func needsQuote(f Field) bool {
+	switch f.Key {
+	case "Description", "Source", "Version":
+		return true
+	}
+	return strings.ContainsAny(f.Value, ",\"<>= ")
+}
+
+// parseMeta parses a meta-line with the leading ## removed.
+func parseMeta(s string) (*Meta, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return nil, ErrBadMeta
+	}
+	m := &Meta{Key: s[:i]}
+	s = s[i+1:]
+	if len(s) == 0 || s[0] != '<' {
+		m.Value = s
+		return m, nil
+	}
+	if s[len(s)-1] != '>' {
+		return nil, ErrBadMeta
+	}
+	s = s[1 : len(s)-1]
+	m.Fields = []Field{}
+	for len(s) != 0 {
+		i := strings.Index(s, "=")
+		if i <= 0 {
+			return nil, ErrBadMeta
+		}
+		f := Field{Key: s[:i]}
+		s = s[i+1:]
+		if len(s) != 0 && s[0] == '"' {
+			var b bytes.Buffer
+			j := 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j == len(s) {
+				return nil, ErrBadMeta
+			}
+			f.Value = b.String()
+			s = s[j+1:]
+		} else {
+			j := strings.Index(s, ",")
+			if j < 0 {
+				j = len(s)
+			}
+			f.Value = s[:j]
+			s = s[j:]
+		}
+		m.Fields = append(m.Fields, f)
+		switch {
+		case len(s) == 0:
+		case s[0] == ',':
+			s = s[1:]
+		default:
+			return nil, ErrBadMeta
+		}
+	}
+	return m, nil
+}
+
+// A Definition describes an INFO or FORMAT field.
+type Definition struct {
+	ID          string
+	Number      string
+	Type        Type
+	Description string
+
+	// Meta is the meta-line holding the definition.
+	Meta *Meta
+}
+
+// A Filter describes a FILTER value.
+type Filter struct {
+	ID          string
+	Description string
+
+	// Meta is the meta-line holding the filter description.
+	Meta *Meta
+}
+
+// A Contig describes a reference sequence.
+type Contig struct {
+	ID string
+
+	// Length is the length of the contig or
+	// -1 if the length is not given.
+	Length int
+
+	// Meta is the meta-line holding the contig description.
+	Meta *Meta
+}
+
+// Header is a VCF file header.
+type Header struct {
+	// FileFormat is the VCF version given
+	// by the ##fileformat meta-line.
+	FileFormat string
+
+	// Meta holds the meta-lines other than
+	// ##fileformat in the order they appear.
+	Meta []*Meta
+
+	// Info, Format, Filter and Contig index
+	// the parsed definitions held in Meta.
+	Info   map[string]*Definition
+	Format map[string]*Definition
+	Filter map[string]*Filter
+	Contig []*Contig
+
+	// Samples holds the sample names
+	// given in the #CHROM line.
+	Samples []string
+}
+
+// NewHeader returns a new empty Header for the given file format version,
+// for example "VCFv4.2".
+func NewHeader(format string) *Header {
+	return &Header{
+		FileFormat: format,
+		Info:       make(map[string]*Definition),
+		Format:     make(map[string]*Definition),
+		Filter:     make(map[string]*Filter),
+	}
+}
+
+// Add appends the meta-line m to the header, indexing INFO, FORMAT, FILTER
+// and contig definitions.
+func (h *Header) Add(m *Meta) error {
+	switch m.Key {
+	case "INFO", "FORMAT":
+		if m.Fields == nil {
+			return ErrBadMeta
+		}
+		d := &Definition{
+			ID:          m.Get("ID"),
+			Number:      m.Get("Number"),
+			Description: m.Get("Description"),
+			Meta:        m,
+		}
+		var ok bool
+		d.Type, ok = parseType(m.Get("Type"))
+		if d.ID == "" || !ok {
+			return ErrBadMeta
+		}
+		if m.Key == "INFO" {
+			h.Info[d.ID] = d
+		} else {
+			h.Format[d.ID] = d
+		}
+	case "FILTER":
+		if m.Fields == nil || m.Get("ID") == "" {
+			return ErrBadMeta
+		}
+		h.Filter[m.Get("ID")] = &Filter{ID: m.Get("ID"), Description: m.Get("Description"), Meta: m}
+	case "contig":
+		if m.Fields == nil || m.Get("ID") == "" {
+			return ErrBadMeta
+		}
+		c := &Contig{ID: m.Get("ID"), Length: -1, Meta: m}
+		if l := m.Get("length"); l != "" {
+			var err error
+			c.Length, err = strconv.Atoi(l)
+			if err != nil {
+				return ErrBadMeta
+			}
+		}
+		h.Contig = append(h.Contig, c)
+	}
+	h.Meta = append(h.Meta, m)
+	return nil
+}
+
+// SampleIndex returns the index of the named sample, or -1 if the sample
+// is not present.
+func (h *Header) SampleIndex(name string) int {
+	for i, s := range h.Samples {
+		if s == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,640 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vcf provides types to read and write Variant Call Format files.
+//
+// The VCF 4.x specifications can be found at https://samtools.github.io/hts-specs/.
+package vcf
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/featio"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ featio.Reader = (*Reader)(nil)
+	_ featio.Writer = (*Writer)(nil)
+)
+
+var (
+	ErrNoFileFormat  = errors.New("vcf: missing fileformat meta-line")
+	ErrBadMeta       = errors.New("vcf: malformed meta-line")
+	ErrBadHeader     = errors.New("vcf: malformed header line")
+	ErrFieldMissing  = errors.New("vcf: missing fields")
+	ErrSampleCount   = errors.New("vcf: sample count mismatch")
+	ErrBadPos        = errors.New("vcf: invalid position")
+	ErrBadInfo       = errors.New("vcf: malformed info field")
+	ErrBadGenotype   = errors.New("vcf: malformed genotype")
+	ErrNotPresent    = errors.New("vcf: field not present")
+	ErrTypeMismatch  = errors.New("vcf: field type mismatch")
+	ErrNotHandled    = errors.New("vcf: type not handled")
+	ErrCannotHeader  = errors.New("vcf: cannot write header: data written")
+	ErrNoHeaderWrite = errors.New("vcf: header not written")
+)
+
+const (
+	chromField = iota
+	posField
+	idField
+	refField
+	altField
+	qualField
+	filterField
+	infoField
+	formatField
+)
+
+var columns = []string{"#CHROM", "POS", "ID", "REF", "ALT", "QUAL", "FILTER", "INFO", "FORMAT"}
+
+// Missing values in Integer fields are returned as MissingInt, and in
+// Float fields as NaN.
+const MissingInt = math.MinInt32
+
+// MissingAllele is the allele index of a missing genotype call.
+const MissingAllele = -1
+
+// Chrom is a reference sequence name used as the location of records.
+type Chrom string
+
+func (c Chrom) Start() int             { return 0 }
+func (c Chrom) End() int               { return 0 }
+func (c Chrom) Len() int               { return 0 }
+func (c Chrom) Name() string           { return string(c) }
+func (c Chrom) Description() string    { return "vcf chrom" }
+func (c Chrom) Location() feat.Feature { return nil }
+
+// A Record is a VCF data line.
+type Record struct {
+	Chrom string
+
+	// Pos is the zero-based position of the first
+	// base of the reference allele. A telomeric
+	// position, POS 0 in VCF, is held as -1.
+	Pos int
+
+	// ID, Alt and Filter are nil when missing.
+	ID     []string
+	Ref    string
+	Alt    []string
+	Filter []string
+
+	// The quality of the record. A nil value
+	// indicates the quality is not available.
+	Qual *float64
+
+	// Info holds the INFO column in order. Flag
+	// fields have an empty Value.
+	Info []Field
+
+	// Format holds the keys of the sample columns
+	// and Samples holds the sample values in the
+	// order of Format. Trailing values may be
+	// omitted from a sample.
+	Format  []string
+	Samples [][]string
+
+	// Header is the header the record is interpreted
+	// under. If Header is not nil, typed accessors
+	// check field types against its definitions.
+	Header *Header
+}
+
+func (r *Record) Start() int { return r.Pos }
+func (r *Record) End() int   { return r.Pos + len(r.Ref) }
+func (r *Record) Len() int   { return len(r.Ref) }
+
+// Name returns the record's IDs separated by semicolons or, if the record
+// has no ID, its chromosome and one-based position.
+func (r *Record) Name() string {
+	if r.ID != nil {
+		return strings.Join(r.ID, ";")
+	}
+	return fmt.Sprintf("%s:%d", r.Chrom, r.Pos+1)
+}
+func (r *Record) Description() string    { return "vcf variant" }
+func (r *Record) Location() feat.Feature { return Chrom(r.Chrom) }
+
+// Passed returns whether the record has passed all filters.
+func (r *Record) Passed() bool { return len(r.Filter) == 1 && r.Filter[0] == "PASS" }
+
+// InfoValue returns the raw value of the INFO field with the given key and
+// whether the field is present.
+func (r *Record) InfoValue(key string) (string, bool) {
+	for _, f := range r.Info {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// InfoFlag returns whether the INFO flag with the given key is present.
+func (r *Record) InfoFlag(key string) bool {
+	_, ok := r.InfoValue(key)
+	return ok
+}
+
+// InfoInts returns the values of the Integer INFO field with the given key.
+func (r *Record) InfoInts(key string) ([]int, error) {
+	v, err := r.info(key, Integer)
+	if err != nil {
+		return nil, err
+	}
+	return parseInts(v)
+}
+
+// InfoFloats returns the values of the Float or Integer INFO field with the
+// given key.
+func (r *Record) InfoFloats(key string) ([]float64, error) {
+	v, err := r.info(key, Float, Integer)
+	if err != nil {
+		return nil, err
+	}
+	return parseFloats(v)
+}
+
+// InfoStrings returns the values of the INFO field with the given key.
+func (r *Record) InfoStrings(key string) ([]string, error) {
+	v, err := r.info(key, String, Character, Integer, Float)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(v, ","), nil
+}
+
+func (r *Record) info(key string, types ...Type) (string, error) {
+	if r.Header != nil {
+		if d, ok := r.Header.Info[key]; ok && !isType(d.Type, types) {
+			return "", ErrTypeMismatch
+		}
+	}
+	v, ok := r.InfoValue(key)
+	if !ok {
+		return "", ErrNotPresent
+	}
+	return v, nil
+}
+
+// SampleValue returns the raw value of the FORMAT field with the given key
+// for the ith sample and whether the field is present. Values omitted from
+// the end of a sample are returned as ".". If the record has no ith sample,
+// the field is not present.
+func (r *Record) SampleValue(i int, key string) (string, bool) {
+	if i < 0 || i >= len(r.Samples) {
+		return "", false
+	}
+	for j, k := range r.Format {
+		if k == key {
+			if j < len(r.Samples[i]) {
+				return r.Samples[i][j], true
+			}
+			return ".", true
+		}
+	}
+	return "", false
+}
+
+// SampleInts returns the values of the Integer FORMAT field with the given
+// key for the ith sample.
+func (r *Record) SampleInts(i int, key string) ([]int, error) {
+	v, err := r.sample(i, key, Integer)
+	if err != nil {
+		return nil, err
+	}
+	return parseInts(v)
+}
+
+// SampleFloats returns the values of the Float or Integer FORMAT field with
+// the given key for the ith sample.
+func (r *Record) SampleFloats(i int, key string) ([]float64, error) {
+	v, err := r.sample(i, key, Float, Integer)
+	if err != nil {
+		return nil, err
+	}
+	return parseFloats(v)
+}
+
+// SampleStrings returns the values of the FORMAT field with the given key
+// for the ith sample.
+func (r *Record) SampleStrings(i int, key string) ([]string, error) {
+	v, err := r.sample(i, key, String, Character, Integer, Float)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(v, ","), nil
+}
+
+// Genotype returns the GT field of the ith sample.
+func (r *Record) Genotype(i int) (Genotype, error) {
+	v, err := r.sample(i, "GT", String)
+	if err != nil {
+		return Genotype{}, err
+	}
+	return ParseGenotype(v)
+}
+
+func (r *Record) sample(i int, key string, types ...Type) (string, error) {
+	if r.Header != nil {
+		if d, ok := r.Header.Format[key]; ok && !isType(d.Type, types) {
+			return "", ErrTypeMismatch
+		}
+	}
+	v, ok := r.SampleValue(i, key)
+	if !ok {
+		return "", ErrNotPresent
+	}
+	return v, nil
+}
+
+func isType(t Type, types []Type) bool {
+	for _, v := range types {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+func parseInts(s string) ([]int, error) {
+	f := strings.Split(s, ",")
+	v := make([]int, len(f))
+	for i, e := range f {
+		if e == "." {
+			v[i] = MissingInt
+			continue
+		}
+		var err error
+		v[i], err = strconv.Atoi(e)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func parseFloats(s string) ([]float64, error) {
+	f := strings.Split(s, ",")
+	v := make([]float64, len(f))
+	for i, e := range f {
+		if e == "." {
+			v[i] = math.NaN()
+			continue
+		}
+		var err error
+		v[i], err = strconv.ParseFloat(e, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// A Genotype is a genotype call.
+type Genotype struct {
+	// Alleles holds the called allele indices
+	// where 0 is the reference allele.
+	Alleles []int
+
+	// Phased indicates that all alleles
+	// are separated by '|'.
+	Phased bool
+}
+
+// ParseGenotype parses a GT field value.
+func ParseGenotype(s string) (Genotype, error) {
+	var g Genotype
+	if s == "" {
+		return g, ErrBadGenotype
+	}
+	g.Phased = true
+	for {
+		i := strings.IndexAny(s, "/|")
+		a := s
+		if i >= 0 {
+			a = s[:i]
+		}
+		if a == "." {
+			g.Alleles = append(g.Alleles, MissingAllele)
+		} else {
+			n, err := strconv.Atoi(a)
+			if err != nil || n < 0 {
+				return Genotype{}, ErrBadGenotype
+			}
+			g.Alleles = append(g.Alleles, n)
+		}
+		if i < 0 {
+			break
+		}
+		if s[i] == '/' {
+			g.Phased = false
+		}
+		s = s[i+1:]
+	}
+	if len(g.Alleles) == 1 {
+		g.Phased = false
+	}
+	return g, nil
+}
+
+// String returns the GT field representation of g.
+func (g Genotype) String() string {
+	sep := "/"
+	if g.Phased {
+		sep = "|"
+	}
+	var b bytes.Buffer
+	for i, a := range g.Alleles {
+		if i != 0 {
+			b.WriteString(sep)
+		}
+		if a == MissingAllele {
+			b.WriteByte('.')
+		} else {
+			b.WriteString(strconv.Itoa(a))
+		}
+	}
+	return b.String()
+}
+
+// IsHomRef returns whether all alleles of g are the reference allele.
+func (g Genotype) IsHomRef() bool {
+	for _, a := range g.Alleles {
+		if a != 0 {
+			return false
+		}
+	}
+	return len(g.Alleles) != 0
+}
+
+// IsMissing returns whether any allele of g is missing.
+func (g Genotype) IsMissing() bool {
+	for _, a := range g.Alleles {
+		if a == MissingAllele {
+			return true
+		}
+	}
+	return false
+}
+
+// A Reader can parse VCF formatted io.Reader and return feat.Features.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+
+	// Header is the header of the VCF stream.
+	Header *Header
+}
+
+// NewReader returns a new VCF format reader that reads from r. The VCF
+// header is read before NewReader returns.
+func NewReader(r io.Reader) (*Reader, error) {
+	vr := &Reader{r: bufio.NewReader(r)}
+	err := vr.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	return vr, nil
+}
+
+func (r *Reader) readLine() (string, error) {
+	b, err := r.r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(b) == 0) {
+		return "", err
+	}
+	r.line++
+	return string(bytes.TrimRight(b, "\r\n")), nil
+}
+
+func (r *Reader) readHeader() error {
+	line, err := r.readLine()
+	if err != nil {
+		if err == io.EOF {
+			err = ErrNoFileFormat
+		}
+		return &csv.ParseError{Line: r.line, Err: err}
+	}
+	const fileformat = "##fileformat="
+	if !strings.HasPrefix(line, fileformat) {
+		return &csv.ParseError{Line: r.line, Err: ErrNoFileFormat}
+	}
+	r.Header = NewHeader(line[len(fileformat):])
+	for {
+		line, err = r.readLine()
+		if err != nil {
+			if err == io.EOF {
+				err = ErrBadHeader
+			}
+			return &csv.ParseError{Line: r.line, Err: err}
+		}
+		if !strings.HasPrefix(line, "##") {
+			break
+		}
+		m, err := parseMeta(line[2:])
+		if err == nil {
+			err = r.Header.Add(m)
+		}
+		if err != nil {
+			return &csv.ParseError{Line: r.line, Err: err}
+		}
+	}
+	fields := strings.Split(line, "\t")
+	if len(fields) < formatField || len(fields) == formatField+1 {
+		return &csv.ParseError{Line: r.line, Column: len(fields), Err: ErrBadHeader}
+	}
+	for i, f := range fields {
+		if i > formatField {
+			r.Header.Samples = fields[i:]
+			break
+		}
+		if f != columns[i] {
+			return &csv.ParseError{Line: r.line, Column: i, Err: ErrBadHeader}
+		}
+	}
+	return nil
+}
+
+// Read reads a single record and returns it as a *Record, or an error.
+func (r *Reader) Read() (feat.Feature, error) {
+	line, err := r.readLine()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, &csv.ParseError{Line: r.line, Err: err}
+	}
+
+	fields := strings.Split(line, "\t")
+	want := formatField
+	if len(r.Header.Samples) != 0 {
+		want += 1 + len(r.Header.Samples)
+	}
+	switch {
+	case len(fields) < formatField:
+		return nil, &csv.ParseError{Line: r.line, Column: len(fields), Err: ErrFieldMissing}
+	case len(fields) != want:
+		return nil, &csv.ParseError{Line: r.line, Column: len(fields), Err: ErrSampleCount}
+	}
+
+	rec := &Record{
+		Chrom:  fields[chromField],
+		ID:     splitMissing(fields[idField], ";"),
+		Ref:    fields[refField],
+		Alt:    splitMissing(fields[altField], ","),
+		Filter: splitMissing(fields[filterField], ";"),
+		Header: r.Header,
+	}
+	pos, err := strconv.Atoi(fields[posField])
+	if err != nil || pos < 0 {
+		return nil, &csv.ParseError{Line: r.line, Column: posField, Err: ErrBadPos}
+	}
+	rec.Pos = pos - 1
+	if fields[qualField] != "." {
+		q, err := strconv.ParseFloat(fields[qualField], 64)
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: qualField, Err: err}
+		}
+		rec.Qual = &q
+	}
+	if fields[infoField] != "." {
+		for _, kv := range strings.Split(fields[infoField], ";") {
+			if kv == "" {
+				return nil, &csv.ParseError{Line: r.line, Column: infoField, Err: ErrBadInfo}
+			}
+			i := strings.Index(kv, "=")
+			if i < 0 {
+				rec.Info = append(rec.Info, Field{Key: kv})
+				continue
+			}
+			if i == 0 {
+				return nil, &csv.ParseError{Line: r.line, Column: infoField, Err: ErrBadInfo}
+			}
+			rec.Info = append(rec.Info, Field{Key: kv[:i], Value: kv[i+1:]})
+		}
+	}
+	if len(fields) > formatField {
+		rec.Format = strings.Split(fields[formatField], ":")
+		rec.Samples = make([][]string, len(fields)-formatField-1)
+		for i, s := range fields[formatField+1:] {
+			rec.Samples[i] = strings.Split(s, ":")
+			if len(rec.Samples[i]) > len(rec.Format) {
+				return nil, &csv.ParseError{Line: r.line, Column: formatField + 1 + i, Err: ErrFieldMissing}
+			}
+		}
+	}
+	return rec, nil
+}
+
+func splitMissing(s, sep string) []string {
+	if s == "." {
+		return nil
+	}
+	return strings.Split(s, sep)
+}
+
+// A Writer outputs records into VCF format.
+type Writer struct {
+	w         io.Writer
+	header    bool
+	data      bool
+	Precision int
+}
+
+// NewWriter returns a new VCF format writer using w. The header must be
+// written with WriteHeader before any records are written.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, Precision: -1}
+}
+
+// WriteHeader writes the VCF header h, writing meta-lines in the order they
+// are held in h.Meta.
+func (w *Writer) WriteHeader(h *Header) (n int, err error) {
+	if w.data {
+		return 0, ErrCannotHeader
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "##fileformat=%s\n", h.FileFormat)
+	for _, m := range h.Meta {
+		fmt.Fprintf(&b, "##%s\n", m)
+	}
+	if len(h.Samples) == 0 {
+		b.WriteString(strings.Join(columns[:formatField], "\t"))
+	} else {
+		b.WriteString(strings.Join(columns, "\t"))
+		for _, s := range h.Samples {
+			b.WriteByte('\t')
+			b.WriteString(s)
+		}
+	}
+	b.WriteByte('\n')
+	w.header = true
+	return w.w.Write(b.Bytes())
+}
+
+// Write writes a single record and return the number of bytes written and
+// any error. Only *Record values are handled.
+func (w *Writer) Write(f feat.Feature) (n int, err error) {
+	r, ok := f.(*Record)
+	if !ok {
+		return 0, ErrNotHandled
+	}
+	if !w.header {
+		return 0, ErrNoHeaderWrite
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\t%d\t%s\t%s\t%s\t",
+		r.Chrom,
+		r.Pos+1,
+		joinMissing(r.ID, ";"),
+		r.Ref,
+		joinMissing(r.Alt, ","),
+	)
+	switch {
+	case r.Qual == nil || math.IsNaN(*r.Qual):
+		b.WriteByte('.')
+	case w.Precision < 0:
+		fmt.Fprintf(&b, "%v", *r.Qual)
+	default:
+		fmt.Fprintf(&b, "%.*f", w.Precision, *r.Qual)
+	}
+	fmt.Fprintf(&b, "\t%s\t", joinMissing(r.Filter, ";"))
+	if len(r.Info) == 0 {
+		b.WriteByte('.')
+	}
+	for i, kv := range r.Info {
+		if i != 0 {
+			b.WriteByte(';')
+		}
+		b.WriteString(kv.Key)
+		if kv.Value != "" {
+			b.WriteByte('=')
+			b.WriteString(kv.Value)
+		}
+	}
+	if r.Format != nil {
+		fmt.Fprintf(&b, "\t%s", strings.Join(r.Format, ":"))
+		for _, s := range r.Samples {
+			fmt.Fprintf(&b, "\t%s", strings.Join(s, ":"))
+		}
+	}
+	b.WriteByte('\n')
+	w.data = true
+	return w.w.Write(b.Bytes())
+}
+
+func joinMissing(s []string, sep string) string {
+	if len(s) == 0 {
+		return "."
+	}
+	return strings.Join(s, sep)
+}
@@ -0,0 +1,214 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vcf
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const vcf = `##fileformat=VCFv4.2
+##fileDate=20090805
+##source=myImputationProgramV3.1
+##contig=<ID=20,length=62435964,assembly=B36,species="Homo sapiens",taxonomy=x>
+##phasing=partial
+##INFO=<ID=NS,Number=1,Type=Integer,Description="Number of Samples With Data">
+##INFO=<ID=AF,Number=A,Type=Float,Description="Allele Frequency">
+##INFO=<ID=DB,Number=0,Type=Flag,Description="dbSNP membership, build 129">
+##FILTER=<ID=q10,Description="Quality below 10">
+##FORMAT=<ID=GT,Number=1,Type=String,Description="Genotype">
+##FORMAT=<ID=GQ,Number=1,Type=Integer,Description="Genotype \"Quality\"">
+##FORMAT=<ID=HQ,Number=2,Type=Integer,Description="Haplotype Quality">
+#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	NA00001	NA00002
+20	14370	rs6054257	G	A	29	PASS	NS=3;AF=0.5;DB	GT:GQ:HQ	0|0:48:1,51	1|0:48:8,.
+20	1110696	.	A	G,T	67.5	q10	NS=2;AF=0.333,.	GT:GQ	1/2:21	./.
+20	1234567	microsat1	GTC	G,GTCT	.	.	.	GT	0/1	1
+`
+
+func (s *S) TestRead(c *check.C) {
+	r, err := NewReader(strings.NewReader(vcf))
+	c.Assert(err, check.Equals, nil)
+	h := r.Header
+	c.Check(h.FileFormat, check.Equals, "VCFv4.2")
+	c.Check(len(h.Meta), check.Equals, 11)
+	c.Check(h.Samples, check.DeepEquals, []string{"NA00001", "NA00002"})
+	c.Check(h.SampleIndex("NA00002"), check.Equals, 1)
+	c.Assert(len(h.Contig), check.Equals, 1)
+	c.Check(h.Contig[0].ID, check.Equals, "20")
+	c.Check(h.Contig[0].Length, check.Equals, 62435964)
+	c.Check(h.Info["AF"].Number, check.Equals, NumberA)
+	c.Check(h.Info["AF"].Type, check.Equals, Float)
+	c.Check(h.Info["DB"].Description, check.Equals, "dbSNP membership, build 129")
+	c.Check(h.Format["GQ"].Description, check.Equals, `Genotype "Quality"`)
+	c.Check(h.Filter["q10"].Description, check.Equals, "Quality below 10")
+
+	var recs []*Record
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		recs = append(recs, f.(*Record))
+	}
+	c.Assert(len(recs), check.Equals, 3)
+
+	rec := recs[0]
+	c.Check(rec.Name(), check.Equals, "rs6054257")
+	c.Check(rec.Start(), check.Equals, 14369)
+	c.Check(rec.End(), check.Equals, 14370)
+	c.Check(rec.Location().Name(), check.Equals, "20")
+	c.Check(rec.Passed(), check.Equals, true)
+	c.Check(*rec.Qual, check.Equals, 29.0)
+	c.Check(rec.InfoFlag("DB"), check.Equals, true)
+	ns, err := rec.InfoInts("NS")
+	c.Check(err, check.Equals, nil)
+	c.Check(ns, check.DeepEquals, []int{3})
+	_, err = rec.InfoInts("AF")
+	c.Check(err, check.Equals, ErrTypeMismatch)
+	_, err = rec.InfoInts("XX")
+	c.Check(err, check.Equals, ErrNotPresent)
+	gt, err := rec.Genotype(1)
+	c.Check(err, check.Equals, nil)
+	c.Check(gt, check.DeepEquals, Genotype{Alleles: []int{1, 0}, Phased: true})
+	hq, err := rec.SampleInts(1, "HQ")
+	c.Check(err, check.Equals, nil)
+	c.Check(hq, check.DeepEquals, []int{8, MissingInt})
+	for _, i := range []int{-1, 2} {
+		_, ok := rec.SampleValue(i, "GT")
+		c.Check(ok, check.Equals, false)
+		_, err = rec.Genotype(i)
+		c.Check(err, check.Equals, ErrNotPresent)
+	}
+
+	rec = recs[1]
+	c.Check(rec.Name(), check.Equals, "20:1110696")
+	c.Check(rec.Passed(), check.Equals, false)
+	c.Check(rec.Alt, check.DeepEquals, []string{"G", "T"})
+	af, err := rec.InfoFloats("AF")
+	c.Check(err, check.Equals, nil)
+	c.Assert(len(af), check.Equals, 2)
+	c.Check(af[0], check.Equals, 0.333)
+	c.Check(math.IsNaN(af[1]), check.Equals, true)
+	gq, err := rec.SampleInts(1, "GQ")
+	c.Check(err, check.Equals, nil)
+	c.Check(gq, check.DeepEquals, []int{MissingInt})
+	gt, err = rec.Genotype(1)
+	c.Check(err, check.Equals, nil)
+	c.Check(gt.IsMissing(), check.Equals, true)
+	c.Check(gt.String(), check.Equals, "./.")
+
+	rec = recs[2]
+	c.Check(rec.Qual, check.IsNil)
+	c.Check(rec.Filter, check.IsNil)
+	c.Check(rec.Info, check.IsNil)
+	c.Check(rec.End(), check.Equals, 1234569)
+	gt, err = rec.Genotype(1)
+	c.Check(err, check.Equals, nil)
+	c.Check(gt, check.DeepEquals, Genotype{Alleles: []int{1}})
+}
+
+func (s *S) TestRoundTrip(c *check.C) {
+	r, err := NewReader(strings.NewReader(vcf))
+	c.Assert(err, check.Equals, nil)
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err = w.Write(&Record{})
+	c.Check(err, check.Equals, ErrNoHeaderWrite)
+	_, err = w.WriteHeader(r.Header)
+	c.Assert(err, check.Equals, nil)
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		_, err = w.Write(f)
+		c.Assert(err, check.Equals, nil)
+	}
+	_, err = w.WriteHeader(r.Header)
+	c.Check(err, check.Equals, ErrCannotHeader)
+	c.Check(buf.String(), check.Equals, vcf)
+}
+
+func (s *S) TestTelomeric(c *check.C) {
+	const in = "##fileformat=VCFv4.2\n#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n" +
+		"20\t0\t.\tN\t.[20:1[\t.\t.\t.\n"
+	r, err := NewReader(strings.NewReader(in))
+	c.Assert(err, check.Equals, nil)
+	f, err := r.Read()
+	c.Assert(err, check.Equals, nil)
+	rec := f.(*Record)
+	c.Check(rec.Pos, check.Equals, -1)
+	c.Check(rec.Start(), check.Equals, -1)
+	c.Check(rec.Name(), check.Equals, "20:0")
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err = w.WriteHeader(r.Header)
+	c.Assert(err, check.Equals, nil)
+	_, err = w.Write(rec)
+	c.Assert(err, check.Equals, nil)
+	c.Check(buf.String(), check.Equals, in)
+}
+
+func (s *S) TestGenotype(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		g   Genotype
+		err error
+	}{
+		{in: "0/1", g: Genotype{Alleles: []int{0, 1}}},
+		{in: "0|1|2", g: Genotype{Alleles: []int{0, 1, 2}, Phased: true}},
+		{in: "0|1/2", g: Genotype{Alleles: []int{0, 1, 2}}},
+		{in: ".|1", g: Genotype{Alleles: []int{MissingAllele, 1}, Phased: true}},
+		{in: "", err: ErrBadGenotype},
+		{in: "0/x", err: ErrBadGenotype},
+	} {
+		g, err := ParseGenotype(t.in)
+		c.Check(err, check.Equals, t.err)
+		c.Check(g, check.DeepEquals, t.g)
+	}
+	c.Check(Genotype{Alleles: []int{0, 0}}.IsHomRef(), check.Equals, true)
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	const head = "##fileformat=VCFv4.2\n#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n"
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{"#CHROM\tPOS\n", ErrNoFileFormat},
+		{"##fileformat=VCFv4.2\n##INFO=<ID=X,Type=Foo>\n", ErrBadMeta},
+		{"##fileformat=VCFv4.2\n##INFO=<ID=X\n", ErrBadMeta},
+		{"##fileformat=VCFv4.2\n#CHROM\tPOS\tID\n", ErrBadHeader},
+		{head + "20\t1\t.\tA\n", ErrFieldMissing},
+		{head + "20\t1\t.\tA\tG\t.\t.\t.\tGT\t0/1\n", ErrSampleCount},
+		{head + "20\tx\t.\tA\tG\t.\t.\t.\n", ErrBadPos},
+		{head + "20\t-1\t.\tA\tG\t.\t.\t.\n", ErrBadPos},
+		{head + "20\t1\t.\tA\tG\t.\t.\tNS=1;;DB\n", ErrBadInfo},
+	} {
+		r, err := NewReader(strings.NewReader(t.in))
+		if err == nil {
+			_, err = r.Read()
+		}
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err)
+	}
+}
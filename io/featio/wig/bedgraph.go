@@ -0,0 +1,103 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wig
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/featio"
+
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ featio.Reader = (*BedGraphReader)(nil)
+	_ featio.Writer = (*BedGraphWriter)(nil)
+)
+
+const (
+	chromField = iota
+	startField
+	endField
+	valueField
+)
+
+// BedGraphReader is a bedGraph format reader.
+type BedGraphReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewBedGraphReader returns a new bedGraph format reader that reads from r.
+func NewBedGraphReader(r io.Reader) *BedGraphReader {
+	return &BedGraphReader{r: bufio.NewReader(r)}
+}
+
+// Read reads a single bedGraph line, returning it as a *Feature, or an error.
+// Comment, track and browser lines are skipped.
+func (r *BedGraphReader) Read() (feat.Feature, error) {
+	var line string
+	for {
+		var err error
+		line, err = readLine(r.r, &r.line)
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, &csv.ParseError{Line: r.line, Err: err}
+		}
+		if !isHeader(line) {
+			break
+		}
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < valueField+1 {
+		return nil, &csv.ParseError{Line: r.line, Column: len(fields), Err: ErrFieldMissing}
+	}
+	f := &Feature{Chrom: fields[chromField]}
+	var err error
+	f.FeatStart, err = strconv.Atoi(fields[startField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: startField, Err: err}
+	}
+	f.FeatEnd, err = strconv.Atoi(fields[endField])
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: endField, Err: err}
+	}
+	if f.FeatStart < 0 || f.FeatEnd < f.FeatStart {
+		return nil, &csv.ParseError{Line: r.line, Column: endField, Err: ErrBadPosition}
+	}
+	f.Value, err = strconv.ParseFloat(fields[valueField], 64)
+	if err != nil {
+		return nil, &csv.ParseError{Line: r.line, Column: valueField, Err: err}
+	}
+	return f, nil
+}
+
+// BedGraphWriter is a bedGraph format writer.
+type BedGraphWriter struct {
+	w         io.Writer
+	Precision int
+}
+
+// NewBedGraphWriter returns a new bedGraph format writer using w.
+func NewBedGraphWriter(w io.Writer) *BedGraphWriter {
+	return &BedGraphWriter{w: w, Precision: -1}
+}
+
+// Write writes a single signal value and returns the number of bytes written
+// and any error. Only *Feature values are handled.
+func (w *BedGraphWriter) Write(f feat.Feature) (n int, err error) {
+	v, ok := f.(*Feature)
+	if !ok {
+		return 0, ErrNotHandled
+	}
+	return fmt.Fprintf(w.w, "%s\t%d\t%d\t%s\n", v.Chrom, v.FeatStart, v.FeatEnd, formatValue(v.Value, w.Precision))
+}
@@ -0,0 +1,115 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wig
+
+import (
+	"github.com/biogo/biogo/feat/genome"
+	"github.com/biogo/biogo/io/featio"
+
+	"errors"
+	"io"
+	"math"
+)
+
+var (
+	ErrUnknownChrom = errors.New("wig: unknown chromosome")
+	ErrOutOfRange   = errors.New("wig: feature out of chromosome range")
+)
+
+// Track is a dense per-base array of signal values for a set of chromosomes.
+// Positions without a value hold NaN.
+type Track struct {
+	Chroms []*genome.Chromosome
+	index  map[string]int
+	values [][]float64
+}
+
+// NewTrack returns a Track holding a value for each position of the provided
+// chromosomes, sized by their Length fields. All values are initially NaN.
+func NewTrack(chroms ...*genome.Chromosome) *Track {
+	t := &Track{
+		Chroms: chroms,
+		index:  make(map[string]int, len(chroms)),
+		values: make([][]float64, len(chroms)),
+	}
+	nan := math.NaN()
+	for i, c := range chroms {
+		t.index[c.Chr] = i
+		v := make([]float64, c.Length)
+		for j := range v {
+			v[j] = nan
+		}
+		t.values[i] = v
+	}
+	return t
+}
+
+// Values returns the values for the named chromosome, or nil if the
+// chromosome is not in the track. The returned slice is the track's storage.
+func (t *Track) Values(chrom string) []float64 {
+	i, ok := t.index[chrom]
+	if !ok {
+		return nil
+	}
+	return t.values[i]
+}
+
+// Set sets the values over the interval of f to f.Value.
+func (t *Track) Set(f *Feature) error {
+	v := t.Values(f.Chrom)
+	if v == nil {
+		return ErrUnknownChrom
+	}
+	if f.FeatStart < 0 || f.FeatEnd > len(v) || f.FeatEnd < f.FeatStart {
+		return ErrOutOfRange
+	}
+	for i := f.FeatStart; i < f.FeatEnd; i++ {
+		v[i] = f.Value
+	}
+	return nil
+}
+
+// Fill sets the track values from all the features read from r, which
+// must return *Feature values such as those returned by Reader and
+// BedGraphReader.
+func (t *Track) Fill(r featio.Reader) error {
+	for {
+		f, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		v, ok := f.(*Feature)
+		if !ok {
+			return ErrNotHandled
+		}
+		err = t.Set(v)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Features returns the values for the named chromosome as features covering
+// maximal runs of equal values. Positions holding NaN are omitted.
+func (t *Track) Features(chrom string) []*Feature {
+	v := t.Values(chrom)
+	var fs []*Feature
+	for i := 0; i < len(v); {
+		if math.IsNaN(v[i]) {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(v) && v[j] == v[i] {
+			j++
+		}
+		fs = append(fs, &Feature{Chrom: chrom, FeatStart: i, FeatEnd: j, Value: v[i]})
+		i = j
+	}
+	return fs
+}
@@ -0,0 +1,270 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package wig provides types to read and write quantitative signal tracks in
+// the UCSC WIG and bedGraph formats.
+//
+// The specifications can be found at http://genome.ucsc.edu/goldenPath/help/wiggle.html
+// and http://genome.ucsc.edu/goldenPath/help/bedgraph.html.
+package wig
+
+import (
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/featio"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ featio.Reader = (*Reader)(nil)
+	_ featio.Writer = (*Writer)(nil)
+
+	_ feat.Feature = (*Feature)(nil)
+)
+
+var (
+	ErrBadDeclaration = errors.New("wig: malformed declaration line")
+	ErrNoDeclaration  = errors.New("wig: data line before declaration")
+	ErrFieldMissing   = errors.New("wig: missing fields")
+	ErrBadPosition    = errors.New("wig: invalid position")
+	ErrNotHandled     = errors.New("wig: type not handled")
+)
+
+// Chrom is a chromosome name used as the location of features.
+type Chrom string
+
+func (c Chrom) Start() int             { return 0 }
+func (c Chrom) End() int               { return 0 }
+func (c Chrom) Len() int               { return 0 }
+func (c Chrom) Name() string           { return string(c) }
+func (c Chrom) Description() string    { return "wig chrom" }
+func (c Chrom) Location() feat.Feature { return nil }
+
+// Feature is a signal value over a half-open interval of a chromosome.
+type Feature struct {
+	Chrom     string
+	FeatStart int
+	FeatEnd   int
+	Value     float64
+}
+
+func (f *Feature) Start() int             { return f.FeatStart }
+func (f *Feature) End() int               { return f.FeatEnd }
+func (f *Feature) Len() int               { return f.FeatEnd - f.FeatStart }
+func (f *Feature) Name() string           { return fmt.Sprintf("%s:[%d,%d)", f.Chrom, f.FeatStart, f.FeatEnd) }
+func (f *Feature) Description() string    { return "signal value" }
+func (f *Feature) Location() feat.Feature { return Chrom(f.Chrom) }
+
+// isHeader returns whether line is a blank, comment, track or browser line.
+func isHeader(line string) bool {
+	return len(strings.TrimSpace(line)) == 0 ||
+		strings.HasPrefix(line, "#") ||
+		strings.HasPrefix(line, "track") ||
+		strings.HasPrefix(line, "browser")
+}
+
+func readLine(r *bufio.Reader, line *int) (string, error) {
+	b, err := r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(b) == 0) {
+		return "", err
+	}
+	*line++
+	return string(bytes.TrimRight(b, "\r\n")), nil
+}
+
+func formatValue(v float64, prec int) string {
+	return strconv.FormatFloat(v, 'g', prec, 64)
+}
+
+type step int
+
+const (
+	none step = iota
+	fixed
+	variable
+)
+
+// Reader is a WIG format reader. Both fixedStep and variableStep sections are
+// handled; bed sections are not.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+
+	mode  step
+	chrom string
+	start int
+	step  int
+	span  int
+}
+
+// NewReader returns a new WIG format reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read reads a single signal value, returning it as a *Feature with zero-based
+// half-open coordinates, or an error.
+func (r *Reader) Read() (feat.Feature, error) {
+	for {
+		line, err := readLine(r.r, &r.line)
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, &csv.ParseError{Line: r.line, Err: err}
+		}
+		if isHeader(line) {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "fixedStep"):
+			err = r.declare(fixed, line)
+		case strings.HasPrefix(line, "variableStep"):
+			err = r.declare(variable, line)
+		default:
+			return r.value(line)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (r *Reader) declare(mode step, line string) error {
+	r.mode = mode
+	r.chrom = ""
+	r.start = -1
+	r.step = 1
+	r.span = 1
+	for i, kv := range strings.Fields(line)[1:] {
+		j := strings.Index(kv, "=")
+		if j < 0 {
+			return &csv.ParseError{Line: r.line, Column: i + 1, Err: ErrBadDeclaration}
+		}
+		k, v := kv[:j], kv[j+1:]
+		if k == "chrom" {
+			r.chrom = v
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return &csv.ParseError{Line: r.line, Column: i + 1, Err: err}
+		}
+		switch k {
+		case "start":
+			r.start = n - 1
+		case "step":
+			r.step = n
+		case "span":
+			r.span = n
+		default:
+			return &csv.ParseError{Line: r.line, Column: i + 1, Err: ErrBadDeclaration}
+		}
+	}
+	if r.chrom == "" || r.span < 1 || r.step < 1 || (mode == fixed && r.start < 0) {
+		return &csv.ParseError{Line: r.line, Err: ErrBadDeclaration}
+	}
+	return nil
+}
+
+func (r *Reader) value(line string) (feat.Feature, error) {
+	f := &Feature{Chrom: r.chrom}
+	fields := strings.Fields(line)
+	var err error
+	switch r.mode {
+	case none:
+		return nil, &csv.ParseError{Line: r.line, Err: ErrNoDeclaration}
+	case fixed:
+		f.FeatStart = r.start
+		r.start += r.step
+		f.Value, err = strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Err: err}
+		}
+	case variable:
+		if len(fields) < 2 {
+			return nil, &csv.ParseError{Line: r.line, Column: len(fields), Err: ErrFieldMissing}
+		}
+		f.FeatStart, err = strconv.Atoi(fields[0])
+		if err != nil || f.FeatStart < 1 {
+			return nil, &csv.ParseError{Line: r.line, Err: ErrBadPosition}
+		}
+		f.FeatStart--
+		f.Value, err = strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, &csv.ParseError{Line: r.line, Column: 1, Err: err}
+		}
+	}
+	f.FeatEnd = f.FeatStart + r.span
+	return f, nil
+}
+
+// Writer is a WIG format writer. By default contiguous runs of values with
+// equal length on the same chromosome are written as fixedStep sections.
+// If Variable is true, variableStep sections are written for each chromosome
+// and value length instead.
+type Writer struct {
+	w io.Writer
+
+	Variable  bool
+	Precision int
+
+	open  bool
+	inVar bool
+	chrom string
+	span  int
+	next  int
+}
+
+// NewWriter returns a new WIG format writer using w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, Precision: -1}
+}
+
+// Write writes a single signal value and returns the number of bytes written
+// and any error. Only *Feature values are handled. A new section declaration
+// is written when the value cannot be added to the current section.
+func (w *Writer) Write(f feat.Feature) (n int, err error) {
+	v, ok := f.(*Feature)
+	if !ok {
+		return 0, ErrNotHandled
+	}
+	if v.Len() < 1 {
+		return 0, ErrBadPosition
+	}
+	var b bytes.Buffer
+	continues := w.open && w.inVar == w.Variable && v.Chrom == w.chrom && v.Len() == w.span
+	if !w.Variable {
+		continues = continues && v.FeatStart == w.next
+	}
+	if !continues {
+		if w.Variable {
+			fmt.Fprintf(&b, "variableStep chrom=%s", v.Chrom)
+		} else {
+			fmt.Fprintf(&b, "fixedStep chrom=%s start=%d step=%d", v.Chrom, v.FeatStart+1, v.Len())
+		}
+		if v.Len() != 1 {
+			fmt.Fprintf(&b, " span=%d", v.Len())
+		}
+		b.WriteByte('\n')
+		w.open = true
+		w.inVar = w.Variable
+		w.chrom = v.Chrom
+		w.span = v.Len()
+	}
+	if w.Variable {
+		fmt.Fprintf(&b, "%d\t", v.FeatStart+1)
+	}
+	b.WriteString(formatValue(v.Value, w.Precision))
+	b.WriteByte('\n')
+	w.next = v.FeatEnd
+	return w.w.Write(b.Bytes())
+}
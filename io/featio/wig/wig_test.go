@@ -0,0 +1,180 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wig
+
+import (
+	"github.com/biogo/biogo/feat/genome"
+	"github.com/biogo/biogo/io/featio"
+
+	"bytes"
+	"encoding/csv"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func readAll(c *check.C, r featio.Reader) []*Feature {
+	var fs []*Feature
+	for {
+		f, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		fs = append(fs, f.(*Feature))
+	}
+	return fs
+}
+
+func writeAll(c *check.C, w featio.Writer, fs []*Feature) {
+	for _, f := range fs {
+		_, err := w.Write(f)
+		c.Assert(err, check.Equals, nil)
+	}
+}
+
+const wigText = `browser position chr19:49304200-49310700
+track type=wiggle_0 name="test"
+# comment
+variableStep chrom=chr19 span=150
+49304701	10.0
+49304901	12.5
+fixedStep chrom=chr19 start=49307401 step=300 span=200
+1000
+900
+
+fixedStep chrom=chr20 start=1 step=1
+0.5
+-1
+`
+
+var wigFeatures = []*Feature{
+	{Chrom: "chr19", FeatStart: 49304700, FeatEnd: 49304850, Value: 10},
+	{Chrom: "chr19", FeatStart: 49304900, FeatEnd: 49305050, Value: 12.5},
+	{Chrom: "chr19", FeatStart: 49307400, FeatEnd: 49307600, Value: 1000},
+	{Chrom: "chr19", FeatStart: 49307700, FeatEnd: 49307900, Value: 900},
+	{Chrom: "chr20", FeatStart: 0, FeatEnd: 1, Value: 0.5},
+	{Chrom: "chr20", FeatStart: 1, FeatEnd: 2, Value: -1},
+}
+
+func (s *S) TestReadWig(c *check.C) {
+	c.Check(readAll(c, NewReader(strings.NewReader(wigText))), check.DeepEquals, wigFeatures)
+
+	for _, t := range []struct {
+		text string
+		err  error
+	}{
+		{"1.0\n", ErrNoDeclaration},
+		{"fixedStep start=1 step=1\n1\n", ErrBadDeclaration},
+		{"fixedStep chrom=chr1 step=1\n1\n", ErrBadDeclaration},
+		{"variableStep chrom=chr1 bogus\n", ErrBadDeclaration},
+		{"variableStep chrom=chr1\n1\n", ErrFieldMissing},
+		{"variableStep chrom=chr1\n0 1\n", ErrBadPosition},
+	} {
+		_, err := NewReader(strings.NewReader(t.text)).Read()
+		c.Assert(err, check.FitsTypeOf, &csv.ParseError{})
+		c.Check(err.(*csv.ParseError).Err, check.Equals, t.err, check.Commentf("%q", t.text))
+	}
+}
+
+func (s *S) TestWriteWig(c *check.C) {
+	var buf bytes.Buffer
+	writeAll(c, NewWriter(&buf), wigFeatures)
+	c.Check(buf.String(), check.Equals, `fixedStep chrom=chr19 start=49304701 step=150 span=150
+10
+fixedStep chrom=chr19 start=49304901 step=150 span=150
+12.5
+fixedStep chrom=chr19 start=49307401 step=200 span=200
+1000
+fixedStep chrom=chr19 start=49307701 step=200 span=200
+900
+fixedStep chrom=chr20 start=1 step=1
+0.5
+-1
+`)
+	c.Check(readAll(c, NewReader(&buf)), check.DeepEquals, wigFeatures)
+
+	buf.Reset()
+	w := NewWriter(&buf)
+	w.Variable = true
+	writeAll(c, w, wigFeatures)
+	c.Check(buf.String(), check.Equals, `variableStep chrom=chr19 span=150
+49304701	10
+49304901	12.5
+variableStep chrom=chr19 span=200
+49307401	1000
+49307701	900
+variableStep chrom=chr20
+1	0.5
+2	-1
+`)
+	c.Check(readAll(c, NewReader(&buf)), check.DeepEquals, wigFeatures)
+}
+
+func (s *S) TestBedGraph(c *check.C) {
+	const text = `track type=bedGraph name="test"
+chr1	0	100	-1.5
+chr1 100 250 2
+chr2	10	20	0.25
+`
+	want := []*Feature{
+		{Chrom: "chr1", FeatStart: 0, FeatEnd: 100, Value: -1.5},
+		{Chrom: "chr1", FeatStart: 100, FeatEnd: 250, Value: 2},
+		{Chrom: "chr2", FeatStart: 10, FeatEnd: 20, Value: 0.25},
+	}
+	got := readAll(c, NewBedGraphReader(strings.NewReader(text)))
+	c.Check(got, check.DeepEquals, want)
+
+	var buf bytes.Buffer
+	writeAll(c, NewBedGraphWriter(&buf), got)
+	c.Check(buf.String(), check.Equals, "chr1\t0\t100\t-1.5\nchr1\t100\t250\t2\nchr2\t10\t20\t0.25\n")
+
+	for _, t := range []struct {
+		text string
+		err  error
+	}{
+		{"chr1\t0\t100\n", ErrFieldMissing},
+		{"chr1\t100\t0\t1\n", ErrBadPosition},
+	} {
+		_, err := NewBedGraphReader(strings.NewReader(t.text)).Read()
+		c.Assert(err, check.FitsTypeOf, &csv.ParseError{})
+		c.Check(err.(*csv.ParseError).Err, check.Equals, t.err)
+	}
+}
+
+func (s *S) TestTrack(c *check.C) {
+	t := NewTrack(
+		&genome.Chromosome{Chr: "chr1", Length: 10},
+		&genome.Chromosome{Chr: "chr2", Length: 5},
+	)
+	c.Check(len(t.Values("chr1")), check.Equals, 10)
+	c.Check(t.Values("chr3"), check.IsNil)
+
+	const text = "chr1\t0\t3\t1\nchr1\t3\t5\t1\nchr1\t7\t10\t2\nchr2\t0\t5\t0\n"
+	c.Assert(t.Fill(NewBedGraphReader(strings.NewReader(text))), check.Equals, nil)
+	v := t.Values("chr1")
+	c.Check(v[4], check.Equals, 1.)
+	c.Check(math.IsNaN(v[5]), check.Equals, true)
+	c.Check(t.Features("chr1"), check.DeepEquals, []*Feature{
+		{Chrom: "chr1", FeatStart: 0, FeatEnd: 5, Value: 1},
+		{Chrom: "chr1", FeatStart: 7, FeatEnd: 10, Value: 2},
+	})
+	c.Check(t.Features("chr2"), check.DeepEquals, []*Feature{
+		{Chrom: "chr2", FeatStart: 0, FeatEnd: 5, Value: 0},
+	})
+
+	c.Check(t.Set(&Feature{Chrom: "chr3", FeatEnd: 1}), check.Equals, ErrUnknownChrom)
+	c.Check(t.Set(&Feature{Chrom: "chr2", FeatStart: 3, FeatEnd: 6}), check.Equals, ErrOutOfRange)
+}
@@ -0,0 +1,188 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package a2m provides types to read and write A2M and A3M format multiple
+// sequence alignment files.
+//
+// Both formats are FASTA files in which upper case letters and '-' are
+// match columns, and lower case letters are insertions relative to the match
+// columns. In A2M, '.' pads insertions so that all rows have the same length.
+// A3M omits the padding, so rows differ in length.
+package a2m
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/io/seqio/alignio"
+	"github.com/biogo/biogo/io/seqio/fasta"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+	"github.com/biogo/biogo/seq/multi"
+
+	"errors"
+	"io"
+)
+
+// ErrMatchMismatch is returned when the rows of an alignment do not have the
+// same number of match columns.
+var ErrMatchMismatch = errors.New("a2m: match column count mismatch")
+
+// Format specifies the layout of an alignment.
+type Format int
+
+const (
+	A2M Format = iota // Insertions are padded with '.'.
+	A3M               // Insertion padding is omitted.
+)
+
+// Reader is an A2M and A3M format reader.
+type Reader struct {
+	r     *fasta.Reader
+	alpha alphabet.Alphabet
+	done  bool
+}
+
+// NewReader returns a new A2M and A3M format reader that reads from r. The
+// rows of the returned alignment are *linear.Seq with the alphabet alpha.
+func NewReader(r io.Reader, alpha alphabet.Alphabet) *Reader {
+	return &Reader{r: fasta.NewReader(r, linear.NewSeq("", nil, alpha)), alpha: alpha}
+}
+
+// Read reads an A2M or A3M alignment and returns it as a *multi.Multi in A2M
+// layout. Insertions are left aligned within the insert columns and padded
+// with '.'. Files hold a single alignment, so the second call to Read returns
+// io.EOF.
+func (r *Reader) Read() (*multi.Multi, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	r.done = true
+
+	var (
+		ss      []seq.Sequence
+		match   = -1
+		inserts []int
+	)
+	for {
+		s, err := r.r.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		ls := s.(*linear.Seq)
+		ins := insertLengths(ls.Seq)
+		if match < 0 {
+			match = len(ins) - 1
+			inserts = ins
+		} else if len(ins)-1 != match {
+			return nil, ErrMatchMismatch
+		}
+		for i, n := range ins {
+			if n > inserts[i] {
+				inserts[i] = n
+			}
+		}
+		ss = append(ss, ls)
+	}
+
+	for _, s := range ss {
+		ls := s.(*linear.Seq)
+		ls.Seq = expand(ls.Seq, inserts)
+	}
+	m, err := multi.NewMulti("", ss, seq.DefaultConsensus)
+	if err != nil {
+		return nil, err
+	}
+	m.Alpha = r.alpha
+	return m, nil
+}
+
+func isInsert(l alphabet.Letter) bool { return 'a' <= l && l <= 'z' }
+
+// insertLengths returns the number of inserted letters before each match
+// column of s and after the last.
+func insertLengths(s alphabet.Letters) []int {
+	ins := []int{0}
+	for _, l := range s {
+		switch {
+		case l == '.':
+		case isInsert(l):
+			ins[len(ins)-1]++
+		default:
+			ins = append(ins, 0)
+		}
+	}
+	return ins
+}
+
+// expand returns s with each insertion padded to the lengths in inserts.
+func expand(s alphabet.Letters, inserts []int) alphabet.Letters {
+	n := len(inserts) - 1
+	for _, l := range inserts {
+		n += l
+	}
+	e := make(alphabet.Letters, 0, n)
+	var (
+		col int
+		ins int
+	)
+	for _, l := range s {
+		switch {
+		case l == '.':
+		case isInsert(l):
+			e = append(e, l)
+			ins++
+		default:
+			e = append(e, alphabet.Letter('.').Repeat(inserts[col]-ins)...)
+			e = append(e, l)
+			col++
+			ins = 0
+		}
+	}
+	return append(e, alphabet.Letter('.').Repeat(inserts[col]-ins)...)
+}
+
+// Writer is an A2M and A3M format writer.
+type Writer struct {
+	w      *fasta.Writer
+	format Format
+}
+
+// NewWriter returns a new writer that writes alignments in the given format
+// to w with width letters on each sequence line.
+func NewWriter(w io.Writer, format Format, width int) *Writer {
+	return &Writer{w: fasta.NewWriter(w, width), format: format}
+}
+
+// Write writes the alignment a and returns the number of bytes written and
+// any error. The rows of a are expected to follow A2M letter case and
+// padding conventions; an alignment without insertions is written unaltered
+// in either format.
+func (w *Writer) Write(a alignio.Alignment) (n int, err error) {
+	names, rows, err := alignio.Letters(a)
+	if err != nil {
+		return 0, err
+	}
+	for i, name := range names {
+		l := rows[i]
+		if w.format == A3M {
+			l = l[:0:0]
+			for _, c := range rows[i] {
+				if c != '.' {
+					l = append(l, c)
+				}
+			}
+		}
+		r := a.Row(i)
+		s := linear.NewSeq(name, l, r.Alphabet())
+		s.Desc = r.Description()
+		_n, err := w.w.Write(s)
+		n += _n
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,82 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package a2m
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const (
+	a3m = `>query the query
+MKVLAAGIVG
+>hit1 first hit
+MKaaVL-AGIvVG
+>hit2
+-KVLAAgggGIV-c
+`
+	a2m = `>query the query
+MK..VLAA...GI.VG.
+>hit1 first hit
+MKaaVL-A...GIvVG.
+>hit2
+-K..VLAAgggGI.V-c
+`
+)
+
+func (s *S) TestRead(c *check.C) {
+	for _, in := range []string{a3m, a2m} {
+		r := NewReader(strings.NewReader(in), alphabet.Protein)
+		m, err := r.Read()
+		c.Assert(err, check.Equals, nil)
+		c.Assert(m.Rows(), check.Equals, 3)
+		for i, t := range []struct{ name, desc, seq string }{
+			{"query", "the query", "MK..VLAA...GI.VG."},
+			{"hit1", "first hit", "MKaaVL-A...GIvVG."},
+			{"hit2", "", "-K..VLAAgggGI.V-c"},
+		} {
+			c.Check(m.Row(i).Name(), check.Equals, t.name)
+			c.Check(m.Row(i).Description(), check.Equals, t.desc)
+			c.Check(m.Row(i).(*linear.Seq).Seq.String(), check.Equals, t.seq)
+		}
+		_, err = r.Read()
+		c.Check(err, check.Equals, io.EOF)
+	}
+
+	_, err := NewReader(strings.NewReader(">a\nACGT\n>b\nACG\n"), alphabet.DNAgapped).Read()
+	c.Check(err, check.Equals, ErrMatchMismatch)
+}
+
+func (s *S) TestWrite(c *check.C) {
+	m, err := NewReader(strings.NewReader(a3m), alphabet.Protein).Read()
+	c.Assert(err, check.Equals, nil)
+	for _, t := range []struct {
+		format Format
+		out    string
+	}{
+		{A2M, a2m},
+		{A3M, a3m},
+	} {
+		var buf bytes.Buffer
+		n, err := NewWriter(&buf, t.format, 60).Write(m)
+		c.Check(err, check.Equals, nil)
+		c.Check(n, check.Equals, buf.Len())
+		c.Check(buf.String(), check.Equals, t.out)
+	}
+}
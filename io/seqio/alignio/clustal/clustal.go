@@ -0,0 +1,292 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package clustal provides types to read and write Clustal .aln format
+// multiple sequence alignment files.
+package clustal
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/seqio/alignio"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+	"github.com/biogo/biogo/seq/multi"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrNoHeader       = errors.New("clustal: missing header")
+	ErrBadLine        = errors.New("clustal: malformed line")
+	ErrLengthMismatch = errors.New("clustal: row length mismatch")
+)
+
+// DefaultHeader is the header line written by a Writer with an empty Header.
+const DefaultHeader = "CLUSTAL W multiple sequence alignment"
+
+// Reader is a Clustal format reader.
+type Reader struct {
+	r     *bufio.Reader
+	alpha alphabet.Alphabet
+	line  int
+	done  bool
+}
+
+// NewReader returns a new Clustal format reader that reads from r. The rows
+// of the returned alignment are *linear.Seq with the alphabet alpha.
+func NewReader(r io.Reader, alpha alphabet.Alphabet) *Reader {
+	return &Reader{r: bufio.NewReader(r), alpha: alpha}
+}
+
+// Read reads a Clustal alignment and returns it as a *multi.Multi. The header
+// line must begin with "CLUSTAL" or be one of the variants written by MUSCLE
+// and PROBCONS. Conservation lines and trailing residue counts are ignored.
+// Clustal files hold a single alignment, so the second call to Read returns
+// io.EOF.
+func (r *Reader) Read() (*multi.Multi, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	var line []byte
+	for {
+		var err error
+		line, err = r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) != 0 {
+			break
+		}
+	}
+	if !isHeader(line) {
+		return nil, r.errorf(ErrNoHeader)
+	}
+	r.done = true
+
+	var (
+		names []string
+		rows  = make(map[string]alphabet.Letters)
+	)
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(line) == 0 || line[0] == ' ' || line[0] == '\t' {
+			// Blank lines separate blocks and lines starting with
+			// white space hold the conservation markup.
+			continue
+		}
+		f := strings.Fields(string(line))
+		switch len(f) {
+		case 2:
+		case 3:
+			if _, err := strconv.Atoi(f[2]); err != nil {
+				return nil, r.errorf(ErrBadLine)
+			}
+		default:
+			return nil, r.errorf(ErrBadLine)
+		}
+		s, ok := rows[f[0]]
+		if !ok {
+			names = append(names, f[0])
+		}
+		rows[f[0]] = append(s, alphabet.BytesToLetters([]byte(f[1]))...)
+	}
+
+	ss := make([]seq.Sequence, len(names))
+	for i, name := range names {
+		if len(rows[name]) != len(rows[names[0]]) {
+			return nil, r.errorf(ErrLengthMismatch)
+		}
+		ss[i] = linear.NewSeq(name, rows[name], r.alpha)
+	}
+	m, err := multi.NewMulti("", ss, seq.DefaultConsensus)
+	if err != nil {
+		return nil, err
+	}
+	m.Alpha = r.alpha
+	return m, nil
+}
+
+func isHeader(line []byte) bool {
+	for _, p := range []string{"CLUSTAL", "MUSCLE", "PROBCONS"} {
+		if bytes.HasPrefix(line, []byte(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reader) errorf(err error) error {
+	return &csv.ParseError{Line: r.line, Err: err}
+}
+
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return nil, err
+	}
+	r.line++
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// Writer is a Clustal format writer.
+type Writer struct {
+	w   io.Writer
+	buf bytes.Buffer
+
+	// Header is the first line of the written file. If Header is empty
+	// DefaultHeader is used.
+	Header string
+
+	// Width is the number of alignment columns in each block.
+	Width int
+
+	// Counts specifies whether cumulative residue counts are written at
+	// the end of each row of a block.
+	Counts bool
+}
+
+// NewWriter returns a new Clustal format writer that writes to w, with
+// blocks of width alignment columns.
+func NewWriter(w io.Writer, width int) *Writer {
+	return &Writer{w: w, Width: width}
+}
+
+// Write writes the alignment a and returns the number of bytes written and
+// any error. Each block is followed by a conservation line marking columns
+// that are fully conserved with '*' and, for protein alignments, columns
+// that are conserved within the Clustal strong and weak residue groups with
+// ':' and '.'.
+func (w *Writer) Write(a alignio.Alignment) (n int, err error) {
+	names, rows, err := alignio.Letters(a)
+	if err != nil {
+		return 0, err
+	}
+	var protein bool
+	if len(names) != 0 {
+		alpha := a.Row(0).Alphabet()
+		protein = alpha != nil && alpha.Moltype() == feat.Protein
+	}
+
+	b := &w.buf
+	b.Reset()
+	if w.Header == "" {
+		b.WriteString(DefaultHeader)
+	} else {
+		b.WriteString(w.Header)
+	}
+	b.WriteString("\n\n")
+
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	width += 6
+
+	var length int
+	if len(rows) != 0 {
+		length = len(rows[0])
+	}
+	cols := w.Width
+	if cols <= 0 {
+		cols = length
+	}
+	counts := make([]int, len(rows))
+	for start := 0; start < length; start += cols {
+		end := start + cols
+		if end > length {
+			end = length
+		}
+		for i, name := range names {
+			b.WriteString(name)
+			b.WriteString(strings.Repeat(" ", width-len(name)))
+			b.WriteString(rows[i][start:end].String())
+			if w.Counts {
+				for _, l := range rows[i][start:end] {
+					if !isGap(l) {
+						counts[i]++
+					}
+				}
+				b.WriteByte(' ')
+				b.WriteString(strconv.Itoa(counts[i]))
+			}
+			b.WriteByte('\n')
+		}
+		cons := make([]byte, 0, end-start)
+		for pos := start; pos < end; pos++ {
+			cons = append(cons, conservation(rows, pos, protein))
+		}
+		b.WriteString(strings.Repeat(" ", width))
+		b.Write(bytes.TrimRight(cons, " "))
+		b.WriteString("\n\n")
+	}
+
+	return w.w.Write(b.Bytes())
+}
+
+// Residue groups used by Clustal to mark partially conserved columns.
+var (
+	strong = []string{"STA", "NEQK", "NHQK", "NDEQ", "QHRK", "MILV", "MILF", "HY", "FYW"}
+	weak   = []string{"CSA", "ATV", "SAG", "STNK", "STPA", "SGND", "SNDEQK", "NDEQHK", "NEQHRK", "FVLIM", "HFY"}
+)
+
+func conservation(rows []alphabet.Letters, pos int, protein bool) byte {
+	col := make([]byte, len(rows))
+	for i, r := range rows {
+		l := r[pos]
+		if isGap(l) {
+			return ' '
+		}
+		col[i] = byte(unicode.ToUpper(rune(l)))
+	}
+	identical := true
+	for _, l := range col[1:] {
+		if l != col[0] {
+			identical = false
+			break
+		}
+	}
+	switch {
+	case identical:
+		return '*'
+	case !protein:
+		return ' '
+	case inGroup(col, strong):
+		return ':'
+	case inGroup(col, weak):
+		return '.'
+	}
+	return ' '
+}
+
+// inGroup returns whether all the letters in col are in one of the groups.
+func inGroup(col []byte, groups []string) bool {
+outer:
+	for _, g := range groups {
+		for _, l := range col {
+			if strings.IndexByte(g, l) < 0 {
+				continue outer
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isGap(l alphabet.Letter) bool { return l == '-' || l == '.' }
@@ -0,0 +1,102 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clustal
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const aln = `CLUSTAL W (1.83) multiple sequence alignment
+
+alpha      MKVLAAGIVG-- 10
+beta       MRVLSAGLVGKE 12
+gamma      MKILTAGF---- 8
+           *::*:**:
+
+alpha      LLST 14
+beta       LLSA 16
+gamma      -LSS 11
+            **:
+`
+
+func (s *S) TestRead(c *check.C) {
+	r := NewReader(strings.NewReader(aln), alphabet.Protein)
+	m, err := r.Read()
+	c.Assert(err, check.Equals, nil)
+	c.Assert(m.Rows(), check.Equals, 3)
+	for i, t := range []struct{ name, seq string }{
+		{"alpha", "MKVLAAGIVG--LLST"},
+		{"beta", "MRVLSAGLVGKELLSA"},
+		{"gamma", "MKILTAGF-----LSS"},
+	} {
+		c.Check(m.Row(i).Name(), check.Equals, t.name)
+		c.Check(m.Row(i).(*linear.Seq).Seq.String(), check.Equals, t.seq)
+	}
+	_, err = r.Read()
+	c.Check(err, check.Equals, io.EOF)
+}
+
+func (s *S) TestWrite(c *check.C) {
+	m, err := NewReader(strings.NewReader(aln), alphabet.Protein).Read()
+	c.Assert(err, check.Equals, nil)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 12)
+	w.Counts = true
+	n, err := w.Write(m)
+	c.Check(err, check.Equals, nil)
+	c.Check(n, check.Equals, buf.Len())
+	c.Check(buf.String(), check.Equals, `CLUSTAL W multiple sequence alignment
+
+alpha      MKVLAAGIVG-- 10
+beta       MRVLSAGLVGKE 12
+gamma      MKILTAGF---- 8
+           *::*:**:
+
+alpha      LLST 14
+beta       LLSA 16
+gamma      -LSS 11
+            **:
+
+`)
+
+	got, err := NewReader(&buf, alphabet.Protein).Read()
+	c.Assert(err, check.Equals, nil)
+	for i := 0; i < m.Rows(); i++ {
+		c.Check(got.Row(i).Name(), check.Equals, m.Row(i).Name())
+		c.Check(got.Row(i).(*linear.Seq).Seq, check.DeepEquals, m.Row(i).(*linear.Seq).Seq)
+	}
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{">seq1\nACGT\n", ErrNoHeader},
+		{"CLUSTAL W\n\na ACGT\nb ACG\n", ErrLengthMismatch},
+		{"CLUSTAL W\n\na AC GT\n", ErrBadLine},
+	} {
+		_, err := NewReader(strings.NewReader(t.in), alphabet.DNAgapped).Read()
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package maf provides types to read UCSC/TBA multiple alignment format
+// (MAF) files.
+package maf
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+	"github.com/biogo/biogo/seq/multi"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadLine        = errors.New("maf: malformed line")
+	ErrBadStrand      = errors.New("maf: invalid strand")
+	ErrSizeMismatch   = errors.New("maf: sequence size does not match text")
+	ErrLengthMismatch = errors.New("maf: row length mismatch")
+)
+
+// Source is the feat.Feature location of the rows of alignment blocks
+// returned by a Reader. It describes the complete source sequence of a row,
+// for example a chromosome.
+type Source struct {
+	Src  string
+	Size int
+}
+
+func (s Source) Start() int             { return 0 }
+func (s Source) End() int               { return s.Size }
+func (s Source) Len() int               { return s.Size }
+func (s Source) Name() string           { return s.Src }
+func (s Source) Description() string    { return "MAF source" }
+func (s Source) Location() feat.Feature { return nil }
+
+// A Component describes the aligned region of a source sequence given by an
+// 's' or 'e' line. Start is zero-based on the given strand, so for minus
+// strand components it is relative to the start of the reverse-complemented
+// source.
+type Component struct {
+	Src     string
+	Start   int
+	Size    int
+	Strand  seq.Strand
+	SrcSize int
+}
+
+// Info is the context of an aligned sequence given by an 'i' line.
+type Info struct {
+	Src         string
+	LeftStatus  byte
+	LeftCount   int
+	RightStatus byte
+	RightCount  int
+}
+
+// Empty is a source that has no aligned bases in a block, given by an 'e'
+// line.
+type Empty struct {
+	Component
+	Status byte
+}
+
+// Quality is the per-column quality of a sequence given by a 'q' line.
+type Quality struct {
+	Src    string
+	Values string
+}
+
+// A Block is a complete MAF alignment block.
+type Block struct {
+	// Params holds the variables of the 'a' line, such as score and pass.
+	Params map[string]string
+
+	Multi      *multi.Multi
+	Components []Component
+	Info       []Info
+	Empty      []Empty
+	Quality    []Quality
+}
+
+// Reader is a MAF format reader.
+type Reader struct {
+	r     *bufio.Reader
+	alpha alphabet.Alphabet
+	line  int
+
+	// Header holds the variables of the "##maf" header line after the
+	// first call to Read or ReadBlock.
+	Header map[string]string
+}
+
+// NewReader returns a new MAF format reader that reads from r. The rows of
+// returned blocks are *linear.Seq with the alphabet alpha.
+func NewReader(r io.Reader, alpha alphabet.Alphabet) *Reader {
+	return &Reader{r: bufio.NewReader(r), alpha: alpha}
+}
+
+// Read reads a single MAF alignment block and returns it as a *multi.Multi.
+// Each row of the returned alignment carries the source name as its ID, the
+// aligned start as its Offset, the strand as its Strand and a Source holding
+// the source name and size as its Loc. Every row has the length of the
+// block and the rows are aligned by letter position; since row offsets are
+// source coordinates, the column methods of the returned *multi.Multi do not
+// reflect the block alignment.
+func (r *Reader) Read() (*multi.Multi, error) {
+	b, err := r.ReadBlock()
+	if err != nil {
+		return nil, err
+	}
+	return b.Multi, nil
+}
+
+// ReadBlock reads and returns a single complete MAF alignment block. Line
+// types that are not described by the MAF specification are ignored.
+func (r *Reader) ReadBlock() (*Block, error) {
+	var line []byte
+	for {
+		var err error
+		line, err = r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(bytes.TrimSpace(line)) == 0:
+			continue
+		case bytes.HasPrefix(line, []byte("##maf")):
+			r.Header = params(string(line[len("##maf"):]))
+			continue
+		case line[0] == '#':
+			continue
+		case line[0] != 'a' || (len(line) > 1 && line[1] != ' ' && line[1] != '\t'):
+			return nil, r.errorf(ErrBadLine)
+		}
+		break
+	}
+
+	b := &Block{Params: params(string(line[1:]))}
+	var ss []seq.Sequence
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			break
+		}
+		f := strings.Fields(string(line))
+		switch f[0] {
+		case "s":
+			if len(f) != 7 {
+				return nil, r.errorf(ErrBadLine)
+			}
+			c, err := component(f[1:6])
+			if err != nil {
+				return nil, r.errorf(err)
+			}
+			var n int
+			for _, l := range f[6] {
+				if l != '-' && l != '.' {
+					n++
+				}
+			}
+			if n != c.Size {
+				return nil, r.errorf(ErrSizeMismatch)
+			}
+			if len(ss) != 0 && len(f[6]) != ss[0].Len() {
+				return nil, r.errorf(ErrLengthMismatch)
+			}
+			s := linear.NewSeq(c.Src, alphabet.BytesToLetters([]byte(f[6])), r.alpha)
+			s.Offset = c.Start
+			s.Strand = c.Strand
+			s.Loc = Source{Src: c.Src, Size: c.SrcSize}
+			ss = append(ss, s)
+			b.Components = append(b.Components, c)
+		case "i":
+			if len(f) != 6 || len(f[2]) != 1 || len(f[4]) != 1 {
+				return nil, r.errorf(ErrBadLine)
+			}
+			i := Info{Src: f[1], LeftStatus: f[2][0], RightStatus: f[4][0]}
+			i.LeftCount, err = strconv.Atoi(f[3])
+			if err != nil {
+				return nil, r.errorf(ErrBadLine)
+			}
+			i.RightCount, err = strconv.Atoi(f[5])
+			if err != nil {
+				return nil, r.errorf(ErrBadLine)
+			}
+			b.Info = append(b.Info, i)
+		case "e":
+			if len(f) != 7 || len(f[6]) != 1 {
+				return nil, r.errorf(ErrBadLine)
+			}
+			c, err := component(f[1:6])
+			if err != nil {
+				return nil, r.errorf(err)
+			}
+			b.Empty = append(b.Empty, Empty{Component: c, Status: f[6][0]})
+		case "q":
+			if len(f) != 3 {
+				return nil, r.errorf(ErrBadLine)
+			}
+			b.Quality = append(b.Quality, Quality{Src: f[1], Values: f[2]})
+		}
+	}
+
+	var err error
+	b.Multi, err = multi.NewMulti("", ss, seq.DefaultConsensus)
+	if err != nil {
+		return nil, err
+	}
+	b.Multi.Alpha = r.alpha
+	return b, nil
+}
+
+// component parses the src, start, size, strand and srcSize fields of an
+// 's' or 'e' line.
+func component(f []string) (Component, error) {
+	c := Component{Src: f[0]}
+	var err error
+	c.Start, err = strconv.Atoi(f[1])
+	if err != nil || c.Start < 0 {
+		return c, ErrBadLine
+	}
+	c.Size, err = strconv.Atoi(f[2])
+	if err != nil || c.Size < 0 {
+		return c, ErrBadLine
+	}
+	switch f[3] {
+	case "+":
+		c.Strand = seq.Plus
+	case "-":
+		c.Strand = seq.Minus
+	default:
+		return c, ErrBadStrand
+	}
+	c.SrcSize, err = strconv.Atoi(f[4])
+	if err != nil || c.SrcSize < c.Start+c.Size {
+		return c, ErrBadLine
+	}
+	return c, nil
+}
+
+// params returns the key=value pairs held in s.
+func params(s string) map[string]string {
+	p := make(map[string]string)
+	for _, f := range strings.Fields(s) {
+		i := strings.Index(f, "=")
+		if i < 0 {
+			p[f] = ""
+			continue
+		}
+		p[f[:i]] = f[i+1:]
+	}
+	return p
+}
+
+func (r *Reader) errorf(err error) error {
+	return &csv.ParseError{Line: r.line, Err: err}
+}
+
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return nil, err
+	}
+	r.line++
+	return bytes.TrimRight(line, "\r\n"), nil
+}
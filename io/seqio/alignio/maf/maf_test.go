@@ -0,0 +1,106 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maf
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const maf = `##maf version=1 scoring=tba.v8
+# tba.v8 (((human chimp) baboon) (mouse rat))
+
+a score=23262.0
+s hg16.chr7    27578828 38 + 158545518 AAA-GGGAATGTTAACCAAATGA---ATTGTCTCTTACGGTG
+s panTro1.chr6 28741140 38 + 161576975 AAA-GGGAATGTTAACCAAATGA---ATTGTCTCTTACGGTG
+i panTro1.chr6 N 0 C 0
+s baboon         116834 38 +   4622798 AAA-GGGAATGTTAACCAAATGA---GTTGTCTCTTATGGTG
+q baboon                               99999999999999999999999999999999999999999999
+s mm4.chr6     53215344 38 + 151104725 -AATGGGAATGTTAAGCAAACGA---ATTGTCTCTCAGTGTG
+s rn3.chr4     81344243 40 + 187371129 -AA-GGGGATGCTAAGCCAATGAGTTGTTGTCTCTCAATGTG
+e mm5.chr7     19180009 77 - 145134094 I
+
+a score=5062.0
+s hg16.chr7    27699739 6 + 158545518 TAAAGA
+s rn3.chr4     81444246 6 - 187371129 taagga
+`
+
+func (s *S) TestReadBlock(c *check.C) {
+	r := NewReader(strings.NewReader(maf), alphabet.DNAgapped)
+	b, err := r.ReadBlock()
+	c.Assert(err, check.Equals, nil)
+	c.Check(r.Header, check.DeepEquals, map[string]string{"version": "1", "scoring": "tba.v8"})
+	c.Check(b.Params, check.DeepEquals, map[string]string{"score": "23262.0"})
+	c.Check(b.Components[0], check.Equals, Component{Src: "hg16.chr7", Start: 27578828, Size: 38, Strand: seq.Plus, SrcSize: 158545518})
+	c.Check(b.Info, check.DeepEquals, []Info{{Src: "panTro1.chr6", LeftStatus: 'N', RightStatus: 'C'}})
+	c.Check(b.Empty, check.DeepEquals, []Empty{{
+		Component: Component{Src: "mm5.chr7", Start: 19180009, Size: 77, Strand: seq.Minus, SrcSize: 145134094},
+		Status:    'I',
+	}})
+	c.Check(b.Quality, check.DeepEquals, []Quality{{Src: "baboon", Values: strings.Repeat("9", 44)}})
+
+	m := b.Multi
+	c.Assert(m.Rows(), check.Equals, 5)
+	for i, t := range []struct {
+		name   string
+		offset int
+		size   int
+		seq    string
+	}{
+		{"hg16.chr7", 27578828, 158545518, "AAA-GGGAATGTTAACCAAATGA---ATTGTCTCTTACGGTG"},
+		{"panTro1.chr6", 28741140, 161576975, "AAA-GGGAATGTTAACCAAATGA---ATTGTCTCTTACGGTG"},
+		{"baboon", 116834, 4622798, "AAA-GGGAATGTTAACCAAATGA---GTTGTCTCTTATGGTG"},
+		{"mm4.chr6", 53215344, 151104725, "-AATGGGAATGTTAAGCAAACGA---ATTGTCTCTCAGTGTG"},
+		{"rn3.chr4", 81344243, 187371129, "-AA-GGGGATGCTAAGCCAATGAGTTGTTGTCTCTCAATGTG"},
+	} {
+		row := m.Row(i).(*linear.Seq)
+		c.Check(row.Name(), check.Equals, t.name)
+		c.Check(row.Start(), check.Equals, t.offset)
+		c.Check(row.Strand, check.Equals, seq.Plus)
+		c.Check(row.Location(), check.Equals, Source{Src: t.name, Size: t.size})
+		c.Check(row.Seq.String(), check.Equals, t.seq)
+	}
+
+	m, err = r.Read()
+	c.Assert(err, check.Equals, nil)
+	c.Assert(m.Rows(), check.Equals, 2)
+	c.Check(m.Row(1).(*linear.Seq).Strand, check.Equals, seq.Minus)
+	_, err = r.Read()
+	c.Check(err, check.Equals, io.EOF)
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{"s hg16.chr7 0 4 + 10 ACGT\n", ErrBadLine},
+		{"a\ns hg16.chr7 0 4 + 10\n", ErrBadLine},
+		{"a\ns hg16.chr7 0 4 . 10 ACGT\n", ErrBadStrand},
+		{"a\ns hg16.chr7 0 4 + 10 AC-T\n", ErrSizeMismatch},
+		{"a\ns hg16.chr7 0 4 + 10 ACGT\ns mm4.chr6 0 3 + 10 ACG\n", ErrLengthMismatch},
+		{"a\ni hg16.chr7 N x C 0\n", ErrBadLine},
+	} {
+		_, err := NewReader(strings.NewReader(t.in), alphabet.DNAgapped).Read()
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err)
+	}
+}
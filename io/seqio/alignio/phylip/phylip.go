@@ -0,0 +1,295 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package phylip provides types to read and write PHYLIP format multiple
+// sequence alignment files in both the sequential and interleaved layouts.
+package phylip
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/io/seqio/alignio"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+	"github.com/biogo/biogo/seq/multi"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadHeader      = errors.New("phylip: malformed header")
+	ErrBadLine        = errors.New("phylip: malformed line")
+	ErrLengthMismatch = errors.New("phylip: row length mismatch")
+	ErrTruncated      = errors.New("phylip: truncated alignment")
+	ErrNameTooLong    = errors.New("phylip: name too long")
+)
+
+// NameWidth is the width of the name field in strict PHYLIP format.
+const NameWidth = 10
+
+// Reader is a PHYLIP format reader.
+type Reader struct {
+	r     *bufio.Reader
+	alpha alphabet.Alphabet
+	line  int
+
+	// Sequential specifies that each sequence is given in full before
+	// the next, possibly over many lines. Otherwise the alignment is read
+	// as interleaved blocks, with names given only in the first block.
+	Sequential bool
+
+	// Relaxed specifies that names are separated from the sequence by
+	// white space and may be of any length. Otherwise names occupy the
+	// first NameWidth columns of a line.
+	Relaxed bool
+}
+
+// NewReader returns a new PHYLIP format reader that reads from r. The rows
+// of returned alignments are *linear.Seq with the alphabet alpha. The reader
+// initially reads strict interleaved PHYLIP.
+func NewReader(r io.Reader, alpha alphabet.Alphabet) *Reader {
+	return &Reader{r: bufio.NewReader(r), alpha: alpha}
+}
+
+// Read reads a single PHYLIP alignment and returns it as a *multi.Multi.
+// Read may be called repeatedly to read files holding many data sets, for
+// example bootstrap replicates. White space within sequence data is ignored.
+func (r *Reader) Read() (*multi.Multi, error) {
+	line, err := r.nextLine()
+	if err != nil {
+		return nil, err
+	}
+	f := strings.Fields(string(line))
+	if len(f) < 2 {
+		return nil, r.errorf(ErrBadHeader)
+	}
+	ntax, err := strconv.Atoi(f[0])
+	if err != nil || ntax < 0 {
+		return nil, r.errorf(ErrBadHeader)
+	}
+	nchar, err := strconv.Atoi(f[1])
+	if err != nil || nchar < 0 {
+		return nil, r.errorf(ErrBadHeader)
+	}
+
+	names := make([]string, ntax)
+	rows := make([]alphabet.Letters, ntax)
+	if r.Sequential {
+		for i := range rows {
+			names[i], rows[i], err = r.named()
+			if err != nil {
+				return nil, err
+			}
+			for len(rows[i]) < nchar {
+				line, err = r.nextLine()
+				if err != nil {
+					return nil, r.truncated(err)
+				}
+				rows[i] = appendResidues(rows[i], line)
+			}
+			if len(rows[i]) != nchar {
+				return nil, r.errorf(ErrLengthMismatch)
+			}
+		}
+	} else {
+		for i := range rows {
+			names[i], rows[i], err = r.named()
+			if err != nil {
+				return nil, err
+			}
+		}
+		for len(rows) != 0 && len(rows[0]) < nchar {
+			for i := range rows {
+				line, err = r.nextLine()
+				if err != nil {
+					return nil, r.truncated(err)
+				}
+				rows[i] = appendResidues(rows[i], line)
+			}
+		}
+		for _, row := range rows {
+			if len(row) != nchar {
+				return nil, r.errorf(ErrLengthMismatch)
+			}
+		}
+	}
+
+	ss := make([]seq.Sequence, ntax)
+	for i, name := range names {
+		ss[i] = linear.NewSeq(name, rows[i], r.alpha)
+	}
+	m, err := multi.NewMulti("", ss, seq.DefaultConsensus)
+	if err != nil {
+		return nil, err
+	}
+	m.Alpha = r.alpha
+	return m, nil
+}
+
+// named reads a line holding a sequence name and the first residues of the
+// sequence.
+func (r *Reader) named() (string, alphabet.Letters, error) {
+	line, err := r.nextLine()
+	if err != nil {
+		return "", nil, r.truncated(err)
+	}
+	var name string
+	if r.Relaxed {
+		line = bytes.TrimLeft(line, " \t")
+		i := bytes.IndexAny(line, " \t")
+		if i < 0 {
+			return "", nil, r.errorf(ErrBadLine)
+		}
+		name, line = string(line[:i]), line[i:]
+	} else {
+		if len(line) < NameWidth {
+			return "", nil, r.errorf(ErrBadLine)
+		}
+		name, line = strings.TrimSpace(string(line[:NameWidth])), line[NameWidth:]
+	}
+	return name, appendResidues(nil, line), nil
+}
+
+func appendResidues(dst alphabet.Letters, line []byte) alphabet.Letters {
+	for _, c := range line {
+		if c == ' ' || c == '\t' {
+			continue
+		}
+		dst = append(dst, alphabet.Letter(c))
+	}
+	return dst
+}
+
+func (r *Reader) truncated(err error) error {
+	if err == io.EOF {
+		return r.errorf(ErrTruncated)
+	}
+	return err
+}
+
+func (r *Reader) errorf(err error) error {
+	return &csv.ParseError{Line: r.line, Err: err}
+}
+
+// nextLine returns the next non-blank line.
+func (r *Reader) nextLine() ([]byte, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+		r.line++
+		line = bytes.TrimRight(line, "\r\n")
+		if len(bytes.TrimSpace(line)) != 0 {
+			return line, nil
+		}
+	}
+}
+
+// Writer is a PHYLIP format writer.
+type Writer struct {
+	w   io.Writer
+	buf bytes.Buffer
+
+	// Width is the number of residues written on each line. If Width is
+	// not positive, each sequence is written on a single line.
+	Width int
+
+	// Sequential and Relaxed specify the layout of the written alignment
+	// as described for Reader.
+	Sequential bool
+	Relaxed    bool
+}
+
+// NewWriter returns a new PHYLIP format writer that writes strict
+// interleaved PHYLIP to w with width residues on each line.
+func NewWriter(w io.Writer, width int) *Writer {
+	return &Writer{w: w, Width: width}
+}
+
+// Write writes the alignment a and returns the number of bytes written and
+// any error. In strict mode, names longer than NameWidth result in an
+// ErrNameTooLong error.
+func (w *Writer) Write(a alignio.Alignment) (n int, err error) {
+	names, rows, err := alignio.Letters(a)
+	if err != nil {
+		return 0, err
+	}
+
+	width := NameWidth
+	if w.Relaxed {
+		width = 0
+		for _, name := range names {
+			if len(name) >= width {
+				width = len(name) + 1
+			}
+		}
+	} else {
+		for _, name := range names {
+			if len(name) > NameWidth {
+				return 0, ErrNameTooLong
+			}
+		}
+	}
+	var length int
+	if len(rows) != 0 {
+		length = len(rows[0])
+	}
+	cols := w.Width
+	if cols <= 0 {
+		cols = length
+	}
+	if cols == 0 {
+		cols = 1
+	}
+	indent := strings.Repeat(" ", width)
+
+	b := &w.buf
+	b.Reset()
+	fmt.Fprintf(b, "%d %d\n", len(rows), length)
+	if w.Sequential {
+		for i, name := range names {
+			b.WriteString(name)
+			b.WriteString(indent[len(name):])
+			for start := 0; start < length || start == 0; start += cols {
+				if start != 0 {
+					b.WriteString(indent)
+				}
+				b.WriteString(rows[i][start:min(start+cols, length)].String())
+				b.WriteByte('\n')
+			}
+		}
+	} else {
+		for start := 0; start < length || start == 0; start += cols {
+			if start != 0 {
+				b.WriteByte('\n')
+			}
+			for i, name := range names {
+				if start == 0 {
+					b.WriteString(name)
+					b.WriteString(indent[len(name):])
+				} else {
+					b.WriteString(indent)
+				}
+				b.WriteString(rows[i][start:min(start+cols, length)].String())
+				b.WriteByte('\n')
+			}
+		}
+	}
+
+	return w.w.Write(b.Bytes())
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
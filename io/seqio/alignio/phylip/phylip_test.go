@@ -0,0 +1,163 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package phylip
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/seq/linear"
+	"github.com/biogo/biogo/seq/multi"
+
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+var want = []struct{ name, seq string }{
+	{"Turkey", "AAGCTNGGGCATTTCAGGGTGAGCCCGGGCAATACAGGGTAT"},
+	{"Salmo gair", "AAGCCTTGGCAGTGCAGGGTGAGCCGTGGCCGGGCACGGTAT"},
+	{"H. Sapiens", "ACCGGTTGGCCGCTCAGGGTACCCGGTGGCCACTCAGGGTAT"},
+}
+
+const (
+	interleaved = `  3   42
+Turkey    AAGCTNGGGC ATTTCAGGGT
+Salmo gairAAGCCTTGGC AGTGCAGGGT
+H. SapiensACCGGTTGGC CGCTCAGGGT
+
+GAGCCCGGGC AATACAGGGT AT
+GAGCCGTGGC CGGGCACGGT AT
+ACCCGGTGGC CACTCAGGGT AT
+`
+	sequential = `3 42
+Turkey    AAGCTNGGGC ATTTCAGGGT
+GAGCCCGGGC AATACAGGGT AT
+Salmo gairAAGCCTTGGC AGTGCAGGGT
+GAGCCGTGGC CGGGCACGGT AT
+H. SapiensACCGGTTGGC CGCTCAGGGT
+ACCCGGTGGCCACTCAGGGT AT
+`
+)
+
+func check3(c *check.C, m *multi.Multi) {
+	c.Assert(m.Rows(), check.Equals, len(want))
+	for i, t := range want {
+		c.Check(m.Row(i).Name(), check.Equals, t.name)
+		c.Check(m.Row(i).(*linear.Seq).Seq.String(), check.Equals, t.seq)
+	}
+}
+
+func (s *S) TestReadInterleaved(c *check.C) {
+	r := NewReader(strings.NewReader(interleaved+interleaved), alphabet.DNAredundant)
+	for i := 0; i < 2; i++ {
+		m, err := r.Read()
+		c.Assert(err, check.Equals, nil)
+		check3(c, m)
+	}
+	_, err := r.Read()
+	c.Check(err, check.Equals, io.EOF)
+}
+
+func (s *S) TestReadSequential(c *check.C) {
+	r := NewReader(strings.NewReader(sequential), alphabet.DNAredundant)
+	r.Sequential = true
+	m, err := r.Read()
+	c.Assert(err, check.Equals, nil)
+	check3(c, m)
+}
+
+func (s *S) TestRoundTrip(c *check.C) {
+	m, err := NewReader(strings.NewReader(interleaved), alphabet.DNAredundant).Read()
+	c.Assert(err, check.Equals, nil)
+	for _, t := range []struct {
+		sequential bool
+		out        string
+	}{
+		{
+			out: `3 42
+Turkey    AAGCTNGGGCATTTCAGGGTGAGCC
+Salmo gairAAGCCTTGGCAGTGCAGGGTGAGCC
+H. SapiensACCGGTTGGCCGCTCAGGGTACCCG
+
+          CGGGCAATACAGGGTAT
+          GTGGCCGGGCACGGTAT
+          GTGGCCACTCAGGGTAT
+`,
+		},
+		{
+			sequential: true,
+			out: `3 42
+Turkey    AAGCTNGGGCATTTCAGGGTGAGCC
+          CGGGCAATACAGGGTAT
+Salmo gairAAGCCTTGGCAGTGCAGGGTGAGCC
+          GTGGCCGGGCACGGTAT
+H. SapiensACCGGTTGGCCGCTCAGGGTACCCG
+          GTGGCCACTCAGGGTAT
+`,
+		},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, 25)
+		w.Sequential = t.sequential
+		n, err := w.Write(m)
+		c.Check(err, check.Equals, nil)
+		c.Check(n, check.Equals, buf.Len())
+		c.Check(buf.String(), check.Equals, t.out)
+
+		r := NewReader(&buf, alphabet.DNAredundant)
+		r.Sequential = t.sequential
+		got, err := r.Read()
+		c.Assert(err, check.Equals, nil)
+		check3(c, got)
+	}
+}
+
+func (s *S) TestRelaxed(c *check.C) {
+	m, err := NewReader(strings.NewReader(interleaved), alphabet.DNAredundant).Read()
+	c.Assert(err, check.Equals, nil)
+	m.Row(1).(*linear.Seq).ID = "Salmo_gairdneri"
+
+	var buf bytes.Buffer
+	_, err = NewWriter(&buf, 0).Write(m)
+	c.Check(err, check.Equals, ErrNameTooLong)
+
+	w := NewWriter(&buf, 0)
+	w.Relaxed = true
+	_, err = w.Write(m)
+	c.Assert(err, check.Equals, nil)
+	c.Check(buf.String(), check.Equals, `3 42
+Turkey          AAGCTNGGGCATTTCAGGGTGAGCCCGGGCAATACAGGGTAT
+Salmo_gairdneri AAGCCTTGGCAGTGCAGGGTGAGCCGTGGCCGGGCACGGTAT
+H. Sapiens      ACCGGTTGGCCGCTCAGGGTACCCGGTGGCCACTCAGGGTAT
+`)
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{"3\n", ErrBadHeader},
+		{"x 4\n", ErrBadHeader},
+		{"2 4\nshort\n", ErrBadLine},
+		{"2 4\na         ACGT\n", ErrTruncated},
+		{"2 4\na         ACGT\nb         ACGTA\n", ErrLengthMismatch},
+	} {
+		_, err := NewReader(strings.NewReader(t.in), alphabet.DNA).Read()
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package alignio
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/alignment"
+	"github.com/biogo/biogo/seq/multi"
+
+	"errors"
+)
+
+var (
+	_ Alignment = (*multi.Multi)(nil)
+	_ Alignment = (*alignment.Seq)(nil)
+)
+
+// ErrNested is returned by Letters when an alignment holds rows that are
+// themselves alignments.
+var ErrNested = errors.New("alignio: nested alignment rows not supported")
+
+// An Alignment is a multiple sequence alignment with addressable rows and
+// columns. Both *multi.Multi and *alignment.Seq satisfy Alignment.
+type Alignment interface {
+	seq.Aligned
+	Row(i int) seq.Sequence
+}
+
+// Letters returns the row names of a and the letters of each row over the
+// full column range of a. Positions outside the extent of a row are filled
+// with the gap letter of the row's alphabet.
+func Letters(a Alignment) (names []string, rows []alphabet.Letters, err error) {
+	n := a.Rows()
+	if n == 0 {
+		return nil, nil, nil
+	}
+	names = make([]string, n)
+	rows = make([]alphabet.Letters, n)
+	for i := range names {
+		names[i] = a.Row(i).Name()
+		rows[i] = make(alphabet.Letters, 0, a.End()-a.Start())
+	}
+	for pos := a.Start(); pos < a.End(); pos++ {
+		col := a.Column(pos, true)
+		if len(col) != n {
+			return nil, nil, ErrNested
+		}
+		for i, l := range col {
+			rows[i] = append(rows[i], l)
+		}
+	}
+	return names, rows, nil
+}
@@ -0,0 +1,357 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stockholm provides types to read and write Stockholm format
+// multiple sequence alignment files.
+package stockholm
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/io/seqio/alignio"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+	"github.com/biogo/biogo/seq/multi"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNoHeader       = errors.New("stockholm: missing header")
+	ErrBadLine        = errors.New("stockholm: malformed line")
+	ErrLengthMismatch = errors.New("stockholm: row length mismatch")
+	ErrUnterminated   = errors.New("stockholm: unterminated alignment")
+)
+
+const header = "# STOCKHOLM 1.0"
+
+// An Annotation is a #=GF or #=GC feature and its text. The text of a #=GC
+// annotation has one character per alignment column.
+type Annotation struct {
+	Tag  string
+	Text string
+}
+
+// A SeqAnnotation is a #=GS or #=GR feature of the named sequence and its
+// text. The text of a #=GR annotation has one character per alignment column.
+type SeqAnnotation struct {
+	Seq  string
+	Tag  string
+	Text string
+}
+
+// A Record is a complete Stockholm alignment. Per-file and per-sequence
+// annotations retain their order and repeated tags. Per-column and
+// per-residue annotations that are split over blocks are joined.
+type Record struct {
+	Multi *multi.Multi
+
+	GF []Annotation    // Per-file annotations.
+	GS []SeqAnnotation // Per-sequence annotations.
+	GC []Annotation    // Per-column annotations.
+	GR []SeqAnnotation // Per-residue annotations.
+}
+
+// Get returns the text of the first #=GF annotation with the given tag.
+func (r *Record) Get(tag string) string {
+	for _, a := range r.GF {
+		if a.Tag == tag {
+			return a.Text
+		}
+	}
+	return ""
+}
+
+// Reader is a Stockholm format reader.
+type Reader struct {
+	r     *bufio.Reader
+	alpha alphabet.Alphabet
+	line  int
+}
+
+// NewReader returns a new Stockholm format reader that reads from r. The rows
+// of returned alignments are *linear.Seq with the alphabet alpha.
+func NewReader(r io.Reader, alpha alphabet.Alphabet) *Reader {
+	return &Reader{r: bufio.NewReader(r), alpha: alpha}
+}
+
+// Read reads a single Stockholm alignment and returns it as a *multi.Multi.
+// Annotations other than the alignment ID and sequence descriptions are
+// discarded; use ReadRecord to obtain them.
+func (r *Reader) Read() (*multi.Multi, error) {
+	rec, err := r.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+	return rec.Multi, nil
+}
+
+// ReadRecord reads and returns a single complete Stockholm alignment. The ID
+// of the returned alignment is taken from the #=GF ID annotation and the
+// description of each row from its #=GS DE annotation.
+func (r *Reader) ReadRecord() (*Record, error) {
+	var line []byte
+	for {
+		var err error
+		line, err = r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) != 0 {
+			break
+		}
+	}
+	if !bytes.HasPrefix(line, []byte("# STOCKHOLM ")) {
+		return nil, r.errorf(ErrNoHeader)
+	}
+
+	var (
+		rec   Record
+		names []string
+		rows  = make(map[string]alphabet.Letters)
+		gc    = make(map[string]int)
+		gr    = make(map[[2]string]int)
+	)
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			if err == io.EOF {
+				err = r.errorf(ErrUnterminated)
+			}
+			return nil, err
+		}
+		l := strings.TrimSpace(string(line))
+		switch {
+		case l == "":
+		case l == "//":
+			return r.finish(&rec, names, rows)
+		case strings.HasPrefix(l, "#=GF"):
+			tag, text, ok := fields(l, 1)
+			if !ok {
+				return nil, r.errorf(ErrBadLine)
+			}
+			rec.GF = append(rec.GF, Annotation{Tag: tag[0], Text: text})
+		case strings.HasPrefix(l, "#=GS"):
+			f, text, ok := fields(l, 2)
+			if !ok {
+				return nil, r.errorf(ErrBadLine)
+			}
+			rec.GS = append(rec.GS, SeqAnnotation{Seq: f[0], Tag: f[1], Text: text})
+		case strings.HasPrefix(l, "#=GC"):
+			f, text, ok := fields(l, 1)
+			if !ok || strings.ContainsAny(text, " \t") {
+				return nil, r.errorf(ErrBadLine)
+			}
+			if i, ok := gc[f[0]]; ok {
+				rec.GC[i].Text += text
+			} else {
+				gc[f[0]] = len(rec.GC)
+				rec.GC = append(rec.GC, Annotation{Tag: f[0], Text: text})
+			}
+		case strings.HasPrefix(l, "#=GR"):
+			f, text, ok := fields(l, 2)
+			if !ok || strings.ContainsAny(text, " \t") {
+				return nil, r.errorf(ErrBadLine)
+			}
+			k := [2]string{f[0], f[1]}
+			if i, ok := gr[k]; ok {
+				rec.GR[i].Text += text
+			} else {
+				gr[k] = len(rec.GR)
+				rec.GR = append(rec.GR, SeqAnnotation{Seq: f[0], Tag: f[1], Text: text})
+			}
+		case l[0] == '#':
+			// Other markup and comments are ignored.
+		default:
+			f := strings.Fields(l)
+			if len(f) != 2 {
+				return nil, r.errorf(ErrBadLine)
+			}
+			s, ok := rows[f[0]]
+			if !ok {
+				names = append(names, f[0])
+			}
+			rows[f[0]] = append(s, alphabet.BytesToLetters([]byte(f[1]))...)
+		}
+	}
+}
+
+// finish checks the lengths of the rows and column annotations of rec and
+// constructs its alignment.
+func (r *Reader) finish(rec *Record, names []string, rows map[string]alphabet.Letters) (*Record, error) {
+	var n int
+	if len(names) != 0 {
+		n = len(rows[names[0]])
+	}
+	desc := make(map[string]string)
+	for _, a := range rec.GS {
+		if a.Tag == "DE" {
+			desc[a.Seq] = a.Text
+		}
+	}
+	ss := make([]seq.Sequence, len(names))
+	for i, name := range names {
+		if len(rows[name]) != n {
+			return nil, r.errorf(ErrLengthMismatch)
+		}
+		s := linear.NewSeq(name, rows[name], r.alpha)
+		s.Desc = desc[name]
+		ss[i] = s
+	}
+	for _, a := range rec.GC {
+		if len(a.Text) != n {
+			return nil, r.errorf(ErrLengthMismatch)
+		}
+	}
+	for _, a := range rec.GR {
+		if len(a.Text) != n {
+			return nil, r.errorf(ErrLengthMismatch)
+		}
+	}
+	var err error
+	rec.Multi, err = multi.NewMulti(rec.Get("ID"), ss, seq.DefaultConsensus)
+	if err != nil {
+		return nil, err
+	}
+	rec.Multi.Alpha = r.alpha
+	return rec, nil
+}
+
+func (r *Reader) errorf(err error) error {
+	return &csv.ParseError{Line: r.line, Err: err}
+}
+
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return nil, err
+	}
+	r.line++
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// fields splits a markup line into its n whitespace separated fields
+// following the markup and the remaining text.
+func fields(l string, n int) (f []string, text string, ok bool) {
+	l = strings.TrimSpace(l[len("#=GX"):])
+	for i := 0; i < n; i++ {
+		if l == "" {
+			return nil, "", false
+		}
+		j := strings.IndexAny(l, " \t")
+		if j < 0 {
+			j = len(l)
+		}
+		f = append(f, l[:j])
+		l = strings.TrimSpace(l[j:])
+	}
+	return f, l, true
+}
+
+// Writer is a Stockholm format writer.
+type Writer struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+// NewWriter returns a new Stockholm format writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes a single alignment as a Stockholm record and returns the
+// number of bytes written and any error. The alignment's name, if it has
+// one, is written as the #=GF ID annotation and non-empty row descriptions
+// are written as #=GS DE annotations.
+func (w *Writer) Write(a alignio.Alignment) (n int, err error) {
+	var rec Record
+	if nm, ok := a.(interface {
+		Name() string
+	}); ok && nm.Name() != "" {
+		rec.GF = []Annotation{{Tag: "ID", Text: nm.Name()}}
+	}
+	for i := 0; i < a.Rows(); i++ {
+		r := a.Row(i)
+		if d := r.Description(); d != "" {
+			rec.GS = append(rec.GS, SeqAnnotation{Seq: r.Name(), Tag: "DE", Text: d})
+		}
+	}
+	return w.write(a, &rec)
+}
+
+// WriteRecord writes a complete Stockholm record and returns the number of
+// bytes written and any error. The alignment is written as a single block
+// with each row followed by its #=GR annotations.
+func (w *Writer) WriteRecord(rec *Record) (n int, err error) {
+	return w.write(rec.Multi, rec)
+}
+
+func (w *Writer) write(a alignio.Alignment, rec *Record) (int, error) {
+	names, rows, err := alignio.Letters(a)
+	if err != nil {
+		return 0, err
+	}
+
+	b := &w.buf
+	b.Reset()
+	b.WriteString(header)
+	b.WriteByte('\n')
+	for _, f := range rec.GF {
+		b.WriteString("#=GF " + f.Tag + " " + f.Text + "\n")
+	}
+	var gs int
+	for _, f := range rec.GS {
+		if len(f.Seq) > gs {
+			gs = len(f.Seq)
+		}
+	}
+	for _, f := range rec.GS {
+		b.WriteString("#=GS " + pad(f.Seq, gs) + " " + f.Tag + " " + f.Text + "\n")
+	}
+	if len(rec.GF) != 0 || len(rec.GS) != 0 {
+		b.WriteByte('\n')
+	}
+
+	var width int
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	for _, f := range rec.GR {
+		if l := len("#=GR ") + len(f.Seq) + 1 + len(f.Tag); l > width {
+			width = l
+		}
+	}
+	for _, f := range rec.GC {
+		if l := len("#=GC ") + len(f.Tag); l > width {
+			width = l
+		}
+	}
+	for i, name := range names {
+		b.WriteString(pad(name, width) + " " + rows[i].String() + "\n")
+		for _, f := range rec.GR {
+			if f.Seq == name {
+				b.WriteString(pad("#=GR "+f.Seq+" "+f.Tag, width) + " " + f.Text + "\n")
+			}
+		}
+	}
+	for _, f := range rec.GC {
+		b.WriteString(pad("#=GC "+f.Tag, width) + " " + f.Text + "\n")
+	}
+	b.WriteString("//\n")
+
+	return w.w.Write(b.Bytes())
+}
+
+func pad(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
@@ -0,0 +1,146 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stockholm
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/alignment"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const interleaved = `# STOCKHOLM 1.0
+#=GF ID   tRNA-test
+#=GF CC   A two line
+#=GF CC   comment.
+#=GS seq1 DE first sequence
+
+seq1         ACDE-FGH
+#=GR seq1 SS ..EE--HH
+seq2         ACDEKFG.
+#=GC SS_cons <<..-->>
+
+seq1         IKLM
+#=GR seq1 SS HH..
+seq2         IK-M
+#=GC SS_cons ..>>
+//
+# STOCKHOLM 1.0
+s ACGT
+//
+`
+
+func (s *S) TestReadRecord(c *check.C) {
+	r := NewReader(strings.NewReader(interleaved), alphabet.Protein)
+	rec, err := r.ReadRecord()
+	c.Assert(err, check.Equals, nil)
+
+	c.Check(rec.GF, check.DeepEquals, []Annotation{
+		{Tag: "ID", Text: "tRNA-test"},
+		{Tag: "CC", Text: "A two line"},
+		{Tag: "CC", Text: "comment."},
+	})
+	c.Check(rec.GS, check.DeepEquals, []SeqAnnotation{{Seq: "seq1", Tag: "DE", Text: "first sequence"}})
+	c.Check(rec.GC, check.DeepEquals, []Annotation{{Tag: "SS_cons", Text: "<<..-->>..>>"}})
+	c.Check(rec.GR, check.DeepEquals, []SeqAnnotation{{Seq: "seq1", Tag: "SS", Text: "..EE--HHHH.."}})
+
+	m := rec.Multi
+	c.Check(m.Name(), check.Equals, "tRNA-test")
+	c.Assert(m.Rows(), check.Equals, 2)
+	c.Check(m.Row(0).Name(), check.Equals, "seq1")
+	c.Check(m.Row(0).Description(), check.Equals, "first sequence")
+	c.Check(m.Row(1).Name(), check.Equals, "seq2")
+	c.Check(m.Row(1).Description(), check.Equals, "")
+	c.Check(m.Len(), check.Equals, 12)
+	c.Check(m.Row(0).(*linear.Seq).Seq.String(), check.Equals, "ACDE-FGHIKLM")
+	c.Check(m.Row(1).(*linear.Seq).Seq.String(), check.Equals, "ACDEKFG.IK-M")
+
+	m, err = r.Read()
+	c.Assert(err, check.Equals, nil)
+	c.Check(m.Rows(), check.Equals, 1)
+	_, err = r.Read()
+	c.Check(err, check.Equals, io.EOF)
+}
+
+func (s *S) TestRoundTrip(c *check.C) {
+	rec, err := NewReader(strings.NewReader(interleaved), alphabet.Protein).ReadRecord()
+	c.Assert(err, check.Equals, nil)
+	var buf bytes.Buffer
+	n, err := NewWriter(&buf).WriteRecord(rec)
+	c.Check(err, check.Equals, nil)
+	c.Check(n, check.Equals, buf.Len())
+	c.Check(buf.String(), check.Equals, `# STOCKHOLM 1.0
+#=GF ID tRNA-test
+#=GF CC A two line
+#=GF CC comment.
+#=GS seq1 DE first sequence
+
+seq1         ACDE-FGHIKLM
+#=GR seq1 SS ..EE--HHHH..
+seq2         ACDEKFG.IK-M
+#=GC SS_cons <<..-->>..>>
+//
+`)
+
+	got, err := NewReader(&buf, alphabet.Protein).ReadRecord()
+	c.Assert(err, check.Equals, nil)
+	c.Check(got.GF, check.DeepEquals, rec.GF)
+	c.Check(got.GS, check.DeepEquals, rec.GS)
+	c.Check(got.GC, check.DeepEquals, rec.GC)
+	c.Check(got.GR, check.DeepEquals, rec.GR)
+}
+
+func (s *S) TestWriteAlignment(c *check.C) {
+	a, err := alignment.NewSeq("aln", []string{"a", "bb"}, [][]alphabet.Letter{
+		[]alphabet.Letter("AA"),
+		[]alphabet.Letter("C-"),
+		[]alphabet.Letter("GG"),
+	}, alphabet.DNAgapped, seq.DefaultConsensus)
+	c.Assert(err, check.Equals, nil)
+	var buf bytes.Buffer
+	_, err = NewWriter(&buf).Write(a)
+	c.Assert(err, check.Equals, nil)
+	c.Check(buf.String(), check.Equals, `# STOCKHOLM 1.0
+#=GF ID aln
+
+a  ACG
+bb A-G
+//
+`)
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{"seq1 ACGT\n//\n", ErrNoHeader},
+		{"# STOCKHOLM 1.0\nseq1 ACGT\nseq2 ACG\n//\n", ErrLengthMismatch},
+		{"# STOCKHOLM 1.0\nseq1 ACGT\n#=GC SS_cons ..\n//\n", ErrLengthMismatch},
+		{"# STOCKHOLM 1.0\nseq1 AC GT\n//\n", ErrBadLine},
+		{"# STOCKHOLM 1.0\n#=GS seq1\n//\n", ErrBadLine},
+		{"# STOCKHOLM 1.0\nseq1 ACGT\n", ErrUnterminated},
+	} {
+		_, err := NewReader(strings.NewReader(t.in), alphabet.DNAgapped).Read()
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err)
+	}
+}
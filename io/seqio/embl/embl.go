@@ -0,0 +1,330 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package embl provides types to read EMBL nucleotide flat-file format files.
+package embl
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/seqio"
+	"github.com/biogo/biogo/io/seqio/genbank"
+	"github.com/biogo/biogo/io/seqio/internal/flatfile"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var _ seqio.Reader = (*Reader)(nil)
+
+var (
+	ErrNoID           = errors.New("embl: missing ID line")
+	ErrBadID          = errors.New("embl: malformed ID line")
+	ErrBadLine        = errors.New("embl: malformed line")
+	ErrBadFeature     = errors.New("embl: malformed feature")
+	ErrLengthMismatch = errors.New("embl: sequence length does not match ID length")
+	ErrUnterminated   = errors.New("embl: unterminated entry")
+)
+
+// Layout of the flat-file format.
+const (
+	valueIndent   = 5
+	featureIndent = 21
+)
+
+// A Reference is a citation associated with an EMBL entry.
+type Reference struct {
+	Number    int      // RN
+	Comment   string   // RC
+	Positions string   // RP
+	CrossRefs []string // RX
+	Group     string   // RG
+	Authors   string   // RA
+	Title     string   // RT
+	Location  string   // RL
+}
+
+// Metadata holds the header fields of an EMBL entry. Multi-line fields are
+// joined with spaces, except the comment which retains its line structure.
+type Metadata struct {
+	// Fields of the ID line.
+	Accession string
+	Version   int
+	Conform   feat.Conformation
+	Moltype   string
+	Class     string
+	Division  string
+	Length    int
+
+	Accessions     []string // AC
+	Project        string   // PR
+	Dates          []string // DT
+	Description    string   // DE
+	Keywords       []string // KW
+	Species        string   // OS
+	Classification string   // OC
+	Organelle      string   // OG
+	References     []Reference
+	CrossRefs      []string // DR
+	Comment        string   // CC
+	Contig         string   // CO
+}
+
+// A Record is a complete EMBL entry. The feature table of an EMBL entry
+// shares its syntax with the GenBank feature table and is represented by
+// genbank.Feature values.
+type Record struct {
+	Metadata
+	Features []*genbank.Feature
+	Seq      *linear.Seq
+}
+
+// Reader is an EMBL format reader.
+type Reader struct {
+	r *flatfile.Reader
+}
+
+// NewReader returns a new EMBL format reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: flatfile.NewReader(r)}
+}
+
+// Read reads a single EMBL entry and returns its sequence as a *linear.Seq.
+// The feature table and header fields of the entry are discarded; use
+// ReadRecord to obtain them.
+func (r *Reader) Read() (seq.Sequence, error) {
+	rec, err := r.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+	return rec.Seq, nil
+}
+
+// ReadRecord reads and returns a single complete EMBL entry. The ID, Desc
+// and Conform fields of the returned sequence are set from the primary
+// accession, the DE lines and the topology given on the ID line, and the
+// Parent of each feature is the returned sequence.
+func (r *Reader) ReadRecord() (*Record, error) {
+	var line []byte
+	for {
+		var err error
+		line, err = r.r.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) != 0 {
+			break
+		}
+	}
+	if !bytes.HasPrefix(line, []byte("ID   ")) {
+		return nil, r.r.Error(ErrNoID)
+	}
+	rec := &Record{}
+	err := rec.parseID(flatfile.Value(line, valueIndent))
+	if err != nil {
+		return nil, r.r.Error(err)
+	}
+	rec.Seq = linear.NewSeq(rec.Accession, nil, alphabet.DNAredundant)
+	rec.Seq.Conform = rec.Conform
+
+	var (
+		ref  *Reference
+		key  string
+		ft   []string
+		last string
+	)
+	for {
+		line, err = r.r.ReadLine()
+		if err != nil {
+			if err == io.EOF {
+				err = r.r.Error(ErrUnterminated)
+			}
+			return nil, err
+		}
+		if len(line) < 2 {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			return nil, r.r.Error(ErrBadLine)
+		}
+		code := string(line[:2])
+		if key != "" && code != "FT" {
+			err = rec.addFeature(key, ft)
+			if err != nil {
+				return nil, r.r.Error(err)
+			}
+			key = ""
+		}
+		v := flatfile.Value(line, valueIndent)
+		switch code {
+		case "//":
+			if len(rec.Seq.Seq) != 0 && len(rec.Seq.Seq) != rec.Length {
+				return nil, r.r.Error(ErrLengthMismatch)
+			}
+			rec.Seq.Desc = rec.Description
+			return rec, nil
+		case "XX", "FH", "SQ":
+		case "  ":
+			if last != "SQ" && last != "  " {
+				return nil, r.r.Error(ErrBadLine)
+			}
+			for _, c := range line {
+				if c == ' ' || ('0' <= c && c <= '9') {
+					continue
+				}
+				rec.Seq.Seq = append(rec.Seq.Seq, alphabet.Letter(c))
+			}
+		case "AC":
+			rec.Accessions = append(rec.Accessions, flatfile.Split(v)...)
+		case "PR":
+			rec.Project = flatfile.Join(rec.Project, v, " ")
+		case "DT":
+			rec.Dates = append(rec.Dates, v)
+		case "DE":
+			rec.Description = flatfile.Join(rec.Description, v, " ")
+		case "KW":
+			rec.Keywords = append(rec.Keywords, flatfile.Split(strings.TrimSuffix(v, "."))...)
+		case "OS":
+			rec.Species = flatfile.Join(rec.Species, v, " ")
+		case "OC":
+			rec.Classification = flatfile.Join(rec.Classification, v, " ")
+		case "OG":
+			rec.Organelle = flatfile.Join(rec.Organelle, v, " ")
+		case "DR":
+			rec.CrossRefs = append(rec.CrossRefs, v)
+		case "CC":
+			rec.Comment = flatfile.Join(rec.Comment, v, "\n")
+		case "CO":
+			rec.Contig = flatfile.Join(rec.Contig, v, "")
+		case "RN":
+			n, err := strconv.Atoi(strings.Trim(v, "[]"))
+			if err != nil {
+				return nil, r.r.Error(ErrBadLine)
+			}
+			rec.References = append(rec.References, Reference{Number: n})
+			ref = &rec.References[len(rec.References)-1]
+		case "RC", "RP", "RX", "RG", "RA", "RT", "RL":
+			if ref == nil {
+				return nil, r.r.Error(ErrBadLine)
+			}
+			switch code {
+			case "RC":
+				ref.Comment = flatfile.Join(ref.Comment, v, " ")
+			case "RP":
+				ref.Positions = flatfile.Join(ref.Positions, v, " ")
+			case "RX":
+				ref.CrossRefs = append(ref.CrossRefs, v)
+			case "RG":
+				ref.Group = flatfile.Join(ref.Group, v, " ")
+			case "RA":
+				ref.Authors = flatfile.Join(ref.Authors, v, " ")
+			case "RT":
+				ref.Title = flatfile.Join(ref.Title, v, " ")
+			case "RL":
+				ref.Location = flatfile.Join(ref.Location, v, " ")
+			}
+		case "FT":
+			if len(line) > valueIndent && line[valueIndent] != ' ' {
+				if key != "" {
+					err = rec.addFeature(key, ft)
+					if err != nil {
+						return nil, r.r.Error(err)
+					}
+				}
+				f := strings.Fields(v)
+				key = f[0]
+				ft = []string{strings.TrimSpace(strings.TrimPrefix(v, key))}
+			} else {
+				if key == "" {
+					return nil, r.r.Error(ErrBadFeature)
+				}
+				ft = append(ft, flatfile.Value(line, featureIndent))
+			}
+		}
+		last = code
+	}
+}
+
+// addFeature adds the feature with the given key and location and qualifier
+// lines to the record.
+func (rec *Record) addFeature(key string, lines []string) error {
+	i := 1
+	for i < len(lines) && !strings.HasPrefix(lines[i], "/") {
+		i++
+	}
+	loc, err := genbank.ParseLocation(strings.Join(lines[:i], ""))
+	if err != nil {
+		return err
+	}
+	q, err := genbank.ParseQualifiers(lines[i:])
+	if err != nil {
+		return err
+	}
+	rec.Features = append(rec.Features, &genbank.Feature{Key: key, Loc: loc, Qualifiers: q, Parent: rec.Seq})
+	return nil
+}
+
+// parseID parses the value of an ID line, either in the current form
+//
+//	X56734; SV 1; linear; mRNA; STD; PLN; 1859 BP.
+//
+// or in the pre-2006 form
+//
+//	X56734  standard; RNA; PLN; 1859 BP.
+func (m *Metadata) parseID(v string) error {
+	f := strings.Split(strings.TrimSuffix(v, "."), ";")
+	for i := range f {
+		f[i] = strings.TrimSpace(f[i])
+	}
+	var length string
+	m.Conform = feat.Linear
+	switch len(f) {
+	case 7:
+		m.Accession = f[0]
+		if strings.HasPrefix(f[1], "SV ") {
+			var err error
+			m.Version, err = strconv.Atoi(f[1][3:])
+			if err != nil {
+				return ErrBadID
+			}
+		}
+		switch f[2] {
+		case "linear":
+		case "circular":
+			m.Conform = feat.Circular
+		default:
+			return ErrBadID
+		}
+		m.Moltype, m.Class, m.Division, length = f[3], f[4], f[5], f[6]
+	case 4:
+		id := strings.Fields(f[0])
+		if len(id) != 2 {
+			return ErrBadID
+		}
+		m.Accession, m.Class = id[0], id[1]
+		m.Moltype = f[1]
+		if strings.HasPrefix(m.Moltype, "circular ") {
+			m.Conform = feat.Circular
+			m.Moltype = strings.TrimPrefix(m.Moltype, "circular ")
+		}
+		m.Division, length = f[2], f[3]
+	default:
+		return ErrBadID
+	}
+	l := strings.Fields(length)
+	if len(l) != 2 || l[1] != "BP" {
+		return ErrBadID
+	}
+	var err error
+	m.Length, err = strconv.Atoi(l[0])
+	if err != nil {
+		return ErrBadID
+	}
+	return nil
+}
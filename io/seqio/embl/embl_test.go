@@ -0,0 +1,158 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package embl
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/seqio/genbank"
+
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const entry = `ID   XX000001; SV 2; circular; genomic DNA; STD; SYN; 120 BP.
+XX
+AC   XX000001; XX000002;
+XX
+DT   17-OCT-2016 (Rel. 130, Created)
+DT   18-OCT-2016 (Rel. 130, Last updated, Version 2)
+XX
+DE   Cloning vector pTEST2, complete
+DE   sequence.
+XX
+KW   cloning vector; synthetic.
+XX
+OS   synthetic construct
+OC   other sequences; artificial sequences.
+XX
+RN   [1]
+RP   1-120
+RA   Doe J., Roe R.;
+RT   ;
+RL   Submitted (17-OCT-2016) to the INSDC.
+XX
+CC   A two line
+CC   comment.
+XX
+FH   Key             Location/Qualifiers
+FH
+FT   source          1..120
+FT                   /organism="synthetic construct"
+FT                   /mol_type="other DNA"
+FT   CDS             complement(join(<1..30,61..>90))
+FT                   /gene="tst"
+FT                   /translation="MSTVMSTVMSTVMSTVMSTVMSTVMSTVMSTVMSTVMSTVMSTV
+FT                   MSTV"
+XX
+SQ   Sequence 120 BP; 36 A; 24 C; 24 G; 36 T; 0 other;
+     gattacagcc gattacagcc gattacagcc gattacagcc gattacagcc gattacagcc        60
+     gattacagcc gattacagcc gattacagcc gattacagcc gattacagcc gattacagcc       120
+//
+`
+
+func (s *S) TestReadRecord(c *check.C) {
+	r := NewReader(strings.NewReader(entry))
+	rec, err := r.ReadRecord()
+	c.Assert(err, check.Equals, nil)
+
+	c.Check(rec.Accession, check.Equals, "XX000001")
+	c.Check(rec.Version, check.Equals, 2)
+	c.Check(rec.Conform, check.Equals, feat.Circular)
+	c.Check(rec.Moltype, check.Equals, "genomic DNA")
+	c.Check(rec.Class, check.Equals, "STD")
+	c.Check(rec.Division, check.Equals, "SYN")
+	c.Check(rec.Length, check.Equals, 120)
+	c.Check(rec.Accessions, check.DeepEquals, []string{"XX000001", "XX000002"})
+	c.Check(rec.Dates, check.HasLen, 2)
+	c.Check(rec.Description, check.Equals, "Cloning vector pTEST2, complete sequence.")
+	c.Check(rec.Keywords, check.DeepEquals, []string{"cloning vector", "synthetic"})
+	c.Check(rec.Species, check.Equals, "synthetic construct")
+	c.Check(rec.References, check.DeepEquals, []Reference{{
+		Number:    1,
+		Positions: "1-120",
+		Authors:   "Doe J., Roe R.;",
+		Title:     ";",
+		Location:  "Submitted (17-OCT-2016) to the INSDC.",
+	}})
+	c.Check(rec.Comment, check.Equals, "A two line\ncomment.")
+
+	c.Check(rec.Seq.Name(), check.Equals, "XX000001")
+	c.Check(rec.Seq.Description(), check.Equals, rec.Description)
+	c.Check(rec.Seq.Conformation(), check.Equals, feat.Circular)
+	c.Check(rec.Seq.Seq, check.DeepEquals, alphabet.Letters(strings.Repeat("gattacagcc", 12)))
+
+	c.Assert(rec.Features, check.HasLen, 2)
+	cds := rec.Features[1]
+	c.Check(cds.Key, check.Equals, "CDS")
+	c.Check(cds.Location(), check.Equals, rec.Seq)
+	c.Check(cds.Orientation(), check.Equals, feat.Reverse)
+	c.Check(cds.Start(), check.Equals, 0)
+	c.Check(cds.End(), check.Equals, 90)
+	c.Check(cds.Loc.String(), check.Equals, "complement(join(<1..30,61..>90))")
+	c.Check(cds.Qualifiers, check.DeepEquals, genbank.Qualifiers{
+		{Name: "gene", Value: "tst", Quoted: true},
+		{Name: "translation", Value: strings.Repeat("MSTV", 12), Quoted: true},
+	})
+
+	_, err = r.ReadRecord()
+	c.Check(err, check.Equals, io.EOF)
+}
+
+func (s *S) TestParseID(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		m   Metadata
+		err error
+	}{
+		{
+			in: "X56734; SV 1; linear; mRNA; STD; PLN; 1859 BP.",
+			m:  Metadata{Accession: "X56734", Version: 1, Conform: feat.Linear, Moltype: "mRNA", Class: "STD", Division: "PLN", Length: 1859},
+		},
+		{
+			in: "X56734  standard; circular RNA; PLN; 1859 BP.",
+			m:  Metadata{Accession: "X56734", Conform: feat.Circular, Moltype: "RNA", Class: "standard", Division: "PLN", Length: 1859},
+		},
+		{in: "X56734; SV 1; linear; mRNA; STD; PLN; 1859 AA.", err: ErrBadID},
+		{in: "X56734; SV 1; twisted; mRNA; STD; PLN; 1859 BP.", err: ErrBadID},
+		{in: "X56734", err: ErrBadID},
+	} {
+		var m Metadata
+		err := m.parseID(t.in)
+		c.Check(err, check.Equals, t.err, check.Commentf("%q", t.in))
+		if err == nil {
+			c.Check(m, check.DeepEquals, t.m)
+		}
+	}
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{"LOCUS       X  10 bp    DNA     linear   SYN 01-JAN-1980\n", ErrNoID},
+		{"ID   X; SV 1; linear; DNA; STD; SYN; 10 BP.\nSQ   Sequence 10 BP;\n     gatt\n//\n", ErrLengthMismatch},
+		{"ID   X; SV 1; linear; DNA; STD; SYN; 10 BP.\nFT                   /note=\"x\"\n//\n", ErrBadFeature},
+		{"ID   X; SV 1; linear; DNA; STD; SYN; 10 BP.\nFT   gene            1..5\nFT                   /note=\"x\n//\n", genbank.ErrBadQualifier},
+		{"ID   X; SV 1; linear; DNA; STD; SYN; 10 BP.\n", ErrUnterminated},
+	} {
+		_, err := NewReader(strings.NewReader(t.in)).ReadRecord()
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err)
+	}
+}
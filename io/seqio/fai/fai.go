@@ -6,10 +6,19 @@
 package fai
 
 import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bufio"
+	"bytes"
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"io"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -20,7 +29,33 @@ const (
 	bytesField
 )
 
-var ErrNonUnique = errors.New("non-unique record name")
+var (
+	ErrNonUnique  = errors.New("non-unique record name")
+	ErrNotFound   = errors.New("sequence not found")
+	ErrOutOfRange = errors.New("region out of range")
+	ErrBadRegion  = errors.New("invalid region")
+	ErrCorrupt    = errors.New("sequence data does not match index")
+
+	ErrNoHeader   = errors.New("sequence data before header")
+	ErrNoName     = errors.New("empty sequence name")
+	ErrLineLength = errors.New("inconsistent line length")
+	ErrBlankLine  = errors.New("sequence data after blank line")
+)
+
+// FormatError is the error type returned by NewIndex when the FASTA input is not
+// suitable for indexing.
+type FormatError struct {
+	Record string // Name of the record being read, empty if before the first header.
+	Line   int    // Line number of the offending line.
+	Err    error
+}
+
+func (e *FormatError) Error() string {
+	if e.Record == "" {
+		return fmt.Sprintf("fai: %v at line %d", e.Err, e.Line)
+	}
+	return fmt.Sprintf("fai: %v in record %q at line %d", e.Err, e.Record, e.Line)
+}
 
 // Index is a FAI index.
 type Index map[string]Record
@@ -110,3 +145,281 @@ func ReadFrom(r io.Reader) (idx Index, err error) {
 		}
 	}
 }
+
+// NewIndex returns an Index built from the FASTA stream provided by an
+// io.Reader. As with samtools faidx, all sequence lines of a record except the
+// last must have the same number of bases and bytes, and blank lines may only
+// appear at the end of a record. If the input does not satisfy these conditions
+// or contains non-unique record names the error returned is a *FormatError
+// identifying the offending record and line.
+func NewIndex(r io.Reader) (Index, error) {
+	var (
+		br = bufio.NewReader(r)
+
+		idx    Index
+		rec    Record
+		inRec  bool
+		short  bool
+		blank  bool
+		offset int64
+	)
+	for line := 1; ; line++ {
+		b, err := readLine(br)
+		if len(b) == 0 {
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		n := len(b)
+
+		if b[0] == '>' {
+			if inRec {
+				idx[rec.Name] = rec
+			}
+			name := b[1:]
+			if i := bytes.IndexAny(name, " \t\r\n"); i >= 0 {
+				name = name[:i]
+			}
+			if len(name) == 0 {
+				return nil, &FormatError{Line: line, Err: ErrNoName}
+			}
+			if idx == nil {
+				idx = make(Index)
+			}
+			if _, exists := idx[string(name)]; exists {
+				return nil, &FormatError{Record: string(name), Line: line, Err: ErrNonUnique}
+			}
+			rec = Record{Name: string(name), Start: offset + int64(n)}
+			inRec, short, blank = true, false, false
+			offset += int64(n)
+			continue
+		}
+
+		term := terminatorLen(b)
+		bases := n - term
+		switch {
+		case bases == 0:
+			blank = true
+		case !inRec:
+			return nil, &FormatError{Line: line, Err: ErrNoHeader}
+		case blank:
+			return nil, &FormatError{Record: rec.Name, Line: line, Err: ErrBlankLine}
+		case short:
+			return nil, &FormatError{Record: rec.Name, Line: line, Err: ErrLineLength}
+		case rec.BasesPerLine == 0:
+			rec.BasesPerLine, rec.BytesPerLine = bases, n
+		case bases > rec.BasesPerLine:
+			return nil, &FormatError{Record: rec.Name, Line: line, Err: ErrLineLength}
+		case bases < rec.BasesPerLine, n-bases != rec.BytesPerLine-rec.BasesPerLine:
+			// This may only be the last line of the record.
+			short = true
+		}
+		rec.Length += bases
+		offset += int64(n)
+
+		if err == io.EOF {
+			break
+		}
+	}
+	if inRec {
+		idx[rec.Name] = rec
+	}
+	return idx, nil
+}
+
+// readLine returns the next line from r including any line terminator.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	b, err := r.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		return b, err
+	}
+	line := append([]byte(nil), b...)
+	for err == bufio.ErrBufferFull {
+		b, err = r.ReadSlice('\n')
+		line = append(line, b...)
+	}
+	return line, err
+}
+
+func terminatorLen(b []byte) int {
+	n := len(b)
+	switch {
+	case n >= 2 && b[n-2] == '\r' && b[n-1] == '\n':
+		return 2
+	case n >= 1 && b[n-1] == '\n':
+		return 1
+	}
+	return 0
+}
+
+// WriteTo writes the Index to w in the standard five column FAI format. Records
+// are written in the order of their Start offset, which for indexes built from
+// a single FASTA file is the order of the sequences in the file.
+func (idx Index) WriteTo(w io.Writer) (n int64, err error) {
+	recs := make([]Record, 0, len(idx))
+	for _, r := range idx {
+		recs = append(recs, r)
+	}
+	sort.Sort(byStart(recs))
+	for _, r := range recs {
+		_n, err := fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", r.Name, r.Length, r.Start, r.BasesPerLine, r.BytesPerLine)
+		n += int64(_n)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+type byStart []Record
+
+func (r byStart) Len() int           { return len(r) }
+func (r byStart) Less(i, j int) bool { return r[i].Start < r[j].Start }
+func (r byStart) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+// Contig is the feat.Feature location of sequences returned by a File. It
+// describes the complete indexed sequence.
+type Contig struct {
+	ID     string
+	Length int
+}
+
+func (c Contig) Start() int             { return 0 }
+func (c Contig) End() int               { return c.Length }
+func (c Contig) Len() int               { return c.Length }
+func (c Contig) Name() string           { return c.ID }
+func (c Contig) Description() string    { return "fai contig" }
+func (c Contig) Location() feat.Feature { return nil }
+
+// File is a FASTA file with random access provided by an Index.
+type File struct {
+	r     io.ReaderAt
+	idx   Index
+	alpha alphabet.Alphabet
+}
+
+// NewFile returns a File that reads sequence data from r using the records
+// in idx. Sequences returned by the File use the alphabet alpha.
+func NewFile(r io.ReaderAt, idx Index, alpha alphabet.Alphabet) *File {
+	return &File{r: r, idx: idx, alpha: alpha}
+}
+
+// Index returns the Index used by the File.
+func (f *File) Index() Index { return f.idx }
+
+// Seq returns the complete sequence with the given name.
+func (f *File) Seq(name string) (*linear.Seq, error) {
+	rec, ok := f.idx[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return f.SeqRange(name, 0, rec.Length)
+}
+
+// SeqRange returns the half-open interval [start, end) of the named sequence
+// in zero-based coordinates. The Offset of the returned sequence is start and
+// its Loc is a Contig describing the named sequence.
+func (f *File) SeqRange(name string, start, end int) (*linear.Seq, error) {
+	rec, ok := f.idx[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if start < 0 || end < start || rec.Length < end {
+		return nil, ErrOutOfRange
+	}
+
+	var b []byte
+	if start < end {
+		first := rec.Position(start)
+		last := rec.Position(end - 1)
+		b = make([]byte, last-first+1)
+		n, err := f.r.ReadAt(b, first)
+		if n < len(b) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		b = stripNewlines(b)
+		if len(b) != end-start {
+			return nil, ErrCorrupt
+		}
+	}
+
+	s := linear.NewSeq(name, alphabet.BytesToLetters(b), f.alpha)
+	s.Offset = start
+	s.Loc = Contig{ID: name, Length: rec.Length}
+	return s, nil
+}
+
+// Region returns the sequence described by the samtools-style region string,
+// region, which is of the form "name", "name:begin" or "name:begin-end" with
+// begin and end specified in one-based inclusive coordinates. As with samtools,
+// an end beyond the end of the named sequence is truncated to the sequence end.
+func (f *File) Region(region string) (*linear.Seq, error) {
+	if _, ok := f.idx[region]; ok {
+		return f.Seq(region)
+	}
+	name, start, end, err := ParseRegion(region)
+	if err != nil {
+		return nil, err
+	}
+	rec, ok := f.idx[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if end < 0 || end > rec.Length {
+		end = rec.Length
+	}
+	return f.SeqRange(name, start, end)
+}
+
+// ParseRegion parses a samtools-style region string of the form "name",
+// "name:begin" or "name:begin-end" where begin and end are one-based and
+// inclusive. Thousands separators are allowed in begin and end. The returned
+// start and end are zero-based half-open coordinates. If the region does not
+// specify an end, end is returned as -1.
+func ParseRegion(region string) (name string, start, end int, err error) {
+	colon := strings.LastIndex(region, ":")
+	if colon < 0 {
+		return region, 0, -1, nil
+	}
+	name = region[:colon]
+	if name == "" {
+		return "", 0, 0, ErrBadRegion
+	}
+	interval := strings.Replace(region[colon+1:], ",", "", -1)
+	begin, last := interval, ""
+	hyphen := strings.Index(interval, "-")
+	if hyphen >= 0 {
+		begin, last = interval[:hyphen], interval[hyphen+1:]
+	}
+	b, err := strconv.Atoi(begin)
+	if err != nil || b < 1 {
+		return "", 0, 0, ErrBadRegion
+	}
+	start, end = b-1, -1
+	if hyphen >= 0 {
+		end, err = strconv.Atoi(last)
+		if err != nil || end < start {
+			return "", 0, 0, ErrBadRegion
+		}
+	}
+	return name, start, end, nil
+}
+
+func stripNewlines(b []byte) []byte {
+	if bytes.IndexAny(b, "\r\n") < 0 {
+		return b
+	}
+	s := b[:0]
+	for _, c := range b {
+		if c != '\n' && c != '\r' {
+			s = append(s, c)
+		}
+	}
+	return s
+}
@@ -5,12 +5,16 @@
 package fai_test
 
 import (
+	"bytes"
 	"encoding/csv"
 	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/biogo/biogo/alphabet"
 	"github.com/biogo/biogo/io/seqio/fai"
+	"github.com/biogo/biogo/io/seqio/fasta"
+	"github.com/biogo/biogo/seq/linear"
 
 	"gopkg.in/check.v1"
 )
@@ -181,3 +185,173 @@ NODE_53327_length_192_cov_1.854167	212	5590	60	61
 		c.Check(idx, check.DeepEquals, t.idx, check.Commentf("Test: %d", i))
 	}
 }
+
+var (
+	fileFasta = `>seq1 first sequence
+ACGTACGTAC
+GTACGTACGT
+ACG
+>seq2
+ttttt
+aaaaa
+>seq:3
+GGGGCCCC
+`
+	fileIndex = fai.Index{
+		"seq1":  fai.Record{Name: "seq1", Length: 23, Start: 21, BasesPerLine: 10, BytesPerLine: 11},
+		"seq2":  fai.Record{Name: "seq2", Length: 10, Start: 53, BasesPerLine: 5, BytesPerLine: 6},
+		"seq:3": fai.Record{Name: "seq:3", Length: 8, Start: 72, BasesPerLine: 8, BytesPerLine: 9},
+	}
+)
+
+func (s *S) TestFileSeqRange(c *check.C) {
+	f := fai.NewFile(strings.NewReader(fileFasta), fileIndex, alphabet.DNA)
+	for i, t := range []struct {
+		name       string
+		start, end int
+		seq        string
+		err        error
+	}{
+		{name: "seq1", start: 0, end: 23, seq: "ACGTACGTACGTACGTACGTACG"},
+		{name: "seq1", start: 8, end: 12, seq: "ACGT"},
+		{name: "seq1", start: 10, end: 10, seq: ""},
+		{name: "seq1", start: 19, end: 23, seq: "TACG"},
+		{name: "seq2", start: 3, end: 7, seq: "ttaa"},
+		{name: "seq:3", start: 0, end: 8, seq: "GGGGCCCC"},
+		{name: "seq1", start: 20, end: 24, err: fai.ErrOutOfRange},
+		{name: "seq1", start: -1, end: 2, err: fai.ErrOutOfRange},
+		{name: "seq4", start: 0, end: 1, err: fai.ErrNotFound},
+	} {
+		sq, err := f.SeqRange(t.name, t.start, t.end)
+		c.Check(err, check.Equals, t.err, check.Commentf("Test: %d", i))
+		if err != nil {
+			continue
+		}
+		c.Check(sq.String(), check.Equals, t.seq, check.Commentf("Test: %d", i))
+		c.Check(sq.Name(), check.Equals, t.name)
+		c.Check(sq.Start(), check.Equals, t.start)
+		c.Check(sq.End(), check.Equals, t.end)
+		c.Check(sq.Location(), check.Equals, fai.Contig{ID: t.name, Length: fileIndex[t.name].Length})
+	}
+}
+
+func (s *S) TestFileRegion(c *check.C) {
+	f := fai.NewFile(strings.NewReader(fileFasta), fileIndex, alphabet.DNA)
+	for i, t := range []struct {
+		region     string
+		start, end int
+		seq        string
+		err        error
+	}{
+		{region: "seq1", start: 0, end: 23, seq: "ACGTACGTACGTACGTACGTACG"},
+		{region: "seq1:9-12", start: 8, end: 12, seq: "ACGT"},
+		{region: "seq1:20", start: 19, end: 23, seq: "TACG"},
+		{region: "seq2:1,0-1,0", start: 9, end: 10, seq: "a"},
+		{region: "seq:3", start: 0, end: 8, seq: "GGGGCCCC"},
+		{region: "seq:3:2-3", start: 1, end: 3, seq: "GG"},
+		{region: "seq1:0-3", err: fai.ErrBadRegion},
+		{region: "seq1:5-3", err: fai.ErrBadRegion},
+		{region: "seq1:20-30", start: 19, end: 23, seq: "TACG"},
+		{region: "seq1:30-40", err: fai.ErrOutOfRange},
+		{region: "chr1:1-10", err: fai.ErrNotFound},
+	} {
+		sq, err := f.Region(t.region)
+		c.Check(err, check.Equals, t.err, check.Commentf("Test: %d", i))
+		if err != nil {
+			continue
+		}
+		c.Check(sq.String(), check.Equals, t.seq, check.Commentf("Test: %d", i))
+		c.Check(sq.Start(), check.Equals, t.start)
+		c.Check(sq.End(), check.Equals, t.end)
+	}
+}
+
+func (s *S) TestNewIndex(c *check.C) {
+	for i, t := range []struct {
+		in  string
+		idx fai.Index
+		err error
+	}{
+		{in: "", idx: nil},
+		{in: fileFasta, idx: fileIndex},
+		{
+			in: ">seq1\r\nACGT\r\nAC\r\n>seq2\r\nA",
+			idx: fai.Index{
+				"seq1": fai.Record{Name: "seq1", Length: 6, Start: 7, BasesPerLine: 4, BytesPerLine: 6},
+				"seq2": fai.Record{Name: "seq2", Length: 1, Start: 24, BasesPerLine: 1, BytesPerLine: 1},
+			},
+		},
+		{
+			in: ">empty\n>seq\nAC\n\n\n",
+			idx: fai.Index{
+				"empty": fai.Record{Name: "empty", Length: 0, Start: 7},
+				"seq":   fai.Record{Name: "seq", Length: 2, Start: 12, BasesPerLine: 2, BytesPerLine: 3},
+			},
+		},
+		{
+			in:  "ACGT\n>seq1\nACGT\n",
+			err: &fai.FormatError{Line: 1, Err: fai.ErrNoHeader},
+		},
+		{
+			in:  ">seq1\nACGT\nACG\nACGT\n",
+			err: &fai.FormatError{Record: "seq1", Line: 4, Err: fai.ErrLineLength},
+		},
+		{
+			in:  ">seq1\nACGT\nACGTA\n",
+			err: &fai.FormatError{Record: "seq1", Line: 3, Err: fai.ErrLineLength},
+		},
+		{
+			in:  ">seq1\nACGT\n\nACGT\n",
+			err: &fai.FormatError{Record: "seq1", Line: 4, Err: fai.ErrBlankLine},
+		},
+		{
+			in:  ">seq1\nACGT\n> desc\nACGT\n",
+			err: &fai.FormatError{Line: 3, Err: fai.ErrNoName},
+		},
+		{
+			in:  ">seq1\nACGT\n>seq1\nACGT\n",
+			err: &fai.FormatError{Record: "seq1", Line: 3, Err: fai.ErrNonUnique},
+		},
+	} {
+		idx, err := fai.NewIndex(strings.NewReader(t.in))
+		c.Check(err, check.DeepEquals, t.err, check.Commentf("Test: %d", i))
+		c.Check(idx, check.DeepEquals, t.idx, check.Commentf("Test: %d", i))
+	}
+}
+
+func (s *S) TestWriteTo(c *check.C) {
+	var buf bytes.Buffer
+	_, err := fileIndex.WriteTo(&buf)
+	c.Assert(err, check.Equals, nil)
+	c.Check(buf.String(), check.Equals, "seq1\t23\t21\t10\t11\nseq2\t10\t53\t5\t6\nseq:3\t8\t72\t8\t9\n")
+	idx, err := fai.ReadFrom(&buf)
+	c.Assert(err, check.Equals, nil)
+	c.Check(idx, check.DeepEquals, fileIndex)
+}
+
+func (s *S) TestIndexFastaWriter(c *check.C) {
+	var buf bytes.Buffer
+	w := fasta.NewWriter(&buf, 7)
+	seqs := []*linear.Seq{
+		linear.NewSeq("a", alphabet.BytesToLetters([]byte("ACGTACGTACGTACGTAC")), alphabet.DNA),
+		linear.NewSeq("b", alphabet.BytesToLetters([]byte("ACGTACG")), alphabet.DNA),
+		linear.NewSeq("c", alphabet.BytesToLetters([]byte("GATTACA")), alphabet.DNA),
+	}
+	seqs[1].Desc = "description"
+	for _, sq := range seqs {
+		_, err := w.Write(sq)
+		c.Assert(err, check.Equals, nil)
+	}
+	idx, err := fai.NewIndex(bytes.NewReader(buf.Bytes()))
+	c.Assert(err, check.Equals, nil)
+	c.Check(len(idx), check.Equals, len(seqs))
+	f := fai.NewFile(bytes.NewReader(buf.Bytes()), idx, alphabet.DNA)
+	for _, want := range seqs {
+		got, err := f.Seq(want.ID)
+		c.Assert(err, check.Equals, nil)
+		c.Check(got.String(), check.Equals, want.String())
+	}
+	got, err := f.Region("a:6-12")
+	c.Assert(err, check.Equals, nil)
+	c.Check(got.String(), check.Equals, "CGTACGT")
+}
@@ -0,0 +1,241 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastq
+
+import (
+	"github.com/biogo/biogo/alphabet"
+
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrNoQuality         = errors.New("fastq: no quality data")
+	ErrUnknownEncoding   = errors.New("fastq: no encoding consistent with quality data")
+	ErrAmbiguousEncoding = errors.New("fastq: ambiguous quality encoding")
+	ErrBadRecord         = errors.New("fastq: malformed record")
+)
+
+// Quality characters delimiting the ranges used by the encoding schemes.
+const (
+	phred33Min = '!'
+	solexaMin  = ';'
+	phred64Min = '@'
+	illumina15 = 'B'
+	phred33Max = 'J'
+	qualMax    = '~'
+)
+
+// A Detector infers the quality encoding of FASTQ data from the range of
+// quality characters it has been shown.
+type Detector struct {
+	min, max byte
+	n        int
+}
+
+// Add adds the quality line q to the detector's sample.
+func (d *Detector) Add(q []byte) {
+	for _, b := range q {
+		if d.n == 0 || b < d.min {
+			d.min = b
+		}
+		if d.n == 0 || b > d.max {
+			d.max = b
+		}
+		d.n++
+	}
+}
+
+// Range returns the minimum and maximum quality characters seen by the
+// detector.
+func (d *Detector) Range() (min, max byte) { return d.min, d.max }
+
+// Candidates returns the encodings consistent with the quality characters
+// seen by the detector, ordered from most to least likely. Encodings that
+// decode identically are represented by a single candidate.
+//
+// Solexa and Illumina 1.3+ encodings cannot be distinguished when no quality
+// characters below '@' have been seen; in that case only Illumina 1.3+ or
+// Illumina 1.5+ is returned since Solexa encoded data routinely include
+// negative scores.
+//
+// When all quality characters are at or below 'J', Sanger is ranked first.
+// Such data are typical of binned Illumina 1.8+ qualities, while Phred+64
+// data with a maximum score of 10 are implausible.
+func (d *Detector) Candidates() []alphabet.Encoding {
+	if d.n == 0 || d.min < phred33Min || d.max > qualMax {
+		return nil
+	}
+	var c []alphabet.Encoding
+	if d.min < solexaMin || d.max <= phred33Max {
+		c = append(c, alphabet.Sanger)
+	}
+	switch {
+	case d.min < solexaMin:
+		// Only Phred+33 encodings use characters below ';'.
+	case d.min < phred64Min:
+		c = append(c, alphabet.Solexa)
+	case d.min == illumina15:
+		c = append(c, alphabet.Illumina1_5)
+	default:
+		c = append(c, alphabet.Illumina1_3)
+	}
+	return c
+}
+
+// Encoding returns the most likely encoding for the quality characters seen by
+// the detector. If more than one encoding is consistent with the data, the most
+// likely encoding is returned with ErrAmbiguousEncoding.
+func (d *Detector) Encoding() (alphabet.Encoding, error) {
+	if d.n == 0 {
+		return alphabet.None, ErrNoQuality
+	}
+	c := d.Candidates()
+	switch len(c) {
+	case 0:
+		return alphabet.None, ErrUnknownEncoding
+	case 1:
+		return c[0], nil
+	}
+	return c[0], ErrAmbiguousEncoding
+}
+
+// DetectEncoding examines the quality lines of up to n records read from r,
+// or all records if n is negative, and returns the most likely encoding and a
+// Detector holding the sample. The returned io.Reader provides the complete
+// content of r, including the examined records, so that it can be passed to
+// NewReader or Convert. If the encoding is ambiguous, the most likely encoding
+// is returned with ErrAmbiguousEncoding.
+func DetectEncoding(r io.Reader, n int) (alphabet.Encoding, *Detector, io.Reader, error) {
+	var buf bytes.Buffer
+	br := bufio.NewReader(io.TeeReader(r, &buf))
+	replay := io.MultiReader(&buf, r)
+
+	var d Detector
+	for i := 0; n < 0 || i < n; i++ {
+		rec, err := readRecord(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return alphabet.None, &d, replay, err
+		}
+		d.Add(rec[3])
+	}
+	enc, err := d.Encoding()
+	return enc, &d, replay, err
+}
+
+// Convert reads FASTQ records from src with quality encoding from and writes
+// them to dst with quality encoding to, returning the number of records
+// converted. Solexa scores are converted to and from Phred scores using the
+// alphabet package conversion tables. Scores that cannot be represented in the
+// destination encoding are saturated. Quality characters that are not valid in
+// the source encoding cause Convert to return an error.
+func Convert(dst io.Writer, src io.Reader, from, to alphabet.Encoding) (int, error) {
+	if from == alphabet.None || to == alphabet.None {
+		return 0, errors.New("fastq: cannot convert with no encoding")
+	}
+	table, valid := conversionTable(from, to)
+
+	br := bufio.NewReader(src)
+	var (
+		n   int
+		buf bytes.Buffer
+	)
+	for {
+		rec, err := readRecord(br)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		buf.Reset()
+		for i, l := range rec {
+			if i == 3 {
+				for j, b := range l {
+					if !valid[b] {
+						return n, fmt.Errorf("fastq: invalid quality %q in record %d at position %d", b, n+1, j+1)
+					}
+					l[j] = table[b]
+				}
+			}
+			buf.Write(l)
+			buf.WriteByte('\n')
+		}
+		_, err = dst.Write(buf.Bytes())
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// conversionTable returns a byte translation table from the from encoding to
+// the to encoding and the set of valid source bytes.
+func conversionTable(from, to alphabet.Encoding) (table [256]byte, valid [256]bool) {
+	lo := byte(phred33Min)
+	switch from {
+	case alphabet.Solexa:
+		lo = solexaMin
+	case alphabet.Illumina1_3, alphabet.Illumina1_5:
+		lo = phred64Min
+	}
+	max := alphabet.Qphred(qualMax - phred33Min)
+	switch to {
+	case alphabet.Solexa, alphabet.Illumina1_3, alphabet.Illumina1_5:
+		max = qualMax - phred64Min
+	}
+	for b := int(lo); b <= qualMax; b++ {
+		valid[b] = true
+		if from == alphabet.Solexa && to == alphabet.Solexa {
+			table[b] = byte(b)
+			continue
+		}
+		q := from.DecodeToQphred(byte(b))
+		if q > max {
+			q = max
+		}
+		if to == alphabet.Solexa {
+			qs := q.Qsolexa()
+			if qs < solexaMin-phred64Min {
+				qs = solexaMin - phred64Min
+			}
+			table[b] = byte(int(qs) + phred64Min)
+			continue
+		}
+		table[b] = q.Encode(to)
+	}
+	return table, valid
+}
+
+// readRecord reads a four line FASTQ record from r, returning the header,
+// sequence, plus and quality lines.
+func readRecord(r *bufio.Reader) ([4][]byte, error) {
+	var rec [4][]byte
+	for i := 0; i < len(rec); {
+		l, err := r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(l) == 0) {
+			if err == io.EOF && i != 0 {
+				err = ErrBadRecord
+			}
+			return rec, err
+		}
+		rec[i] = bytes.TrimRight(l, "\r\n")
+		if i == 0 && len(rec[0]) == 0 {
+			// Skip blank lines between records.
+			continue
+		}
+		i++
+	}
+	if !maybeID1(rec[0]) || !maybeID2(rec[2]) || len(rec[1]) != len(rec[3]) {
+		return rec, ErrBadRecord
+	}
+	return rec, nil
+}
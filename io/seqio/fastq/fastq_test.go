@@ -9,7 +9,10 @@ import (
 	"github.com/biogo/biogo/seq/linear"
 
 	"bytes"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"gopkg.in/check.v1"
@@ -451,3 +454,171 @@ func (s *S) TestWriteFastq(c *check.C) {
 		}
 	}
 }
+
+func (s *S) TestDetectEncoding(c *check.C) {
+	for _, t := range []struct {
+		qual       []string
+		enc        alphabet.Encoding
+		candidates []alphabet.Encoding
+		err        error
+	}{
+		{
+			qual:       []string{"!''*((((***+))%%%++)(%%%%).1***-+*''))**55CCF>>>>>>CCCCCCC65"},
+			enc:        alphabet.Sanger,
+			candidates: []alphabet.Encoding{alphabet.Sanger},
+		},
+		{
+			qual:       []string{";;<;;;;;;;;;;;;?;;;;;;;hh", "hhhhhhhhhhhhhhhhhhhhhhhhh"},
+			enc:        alphabet.Solexa,
+			candidates: []alphabet.Encoding{alphabet.Solexa},
+		},
+		{
+			qual:       []string{"RXMSSXXXXSXQXQXFSXQFQKMXS", "@XMSSXXXXSXQXQXFSXQFQKMXS"},
+			enc:        alphabet.Illumina1_3,
+			candidates: []alphabet.Encoding{alphabet.Illumina1_3},
+		},
+		{
+			qual:       []string{"BBBBBBRXMSSXXXXSXQXQXFSXQF"},
+			enc:        alphabet.Illumina1_5,
+			candidates: []alphabet.Encoding{alphabet.Illumina1_5},
+		},
+		{
+			qual:       []string{"@@@CDFFFHHHHHJJJJ"},
+			enc:        alphabet.Sanger,
+			candidates: []alphabet.Encoding{alphabet.Sanger, alphabet.Illumina1_3},
+			err:        ErrAmbiguousEncoding,
+		},
+		{
+			qual:       []string{"??@CDFFFHHHHHJJJJ"},
+			enc:        alphabet.Sanger,
+			candidates: []alphabet.Encoding{alphabet.Sanger, alphabet.Solexa},
+			err:        ErrAmbiguousEncoding,
+		},
+		{
+			// Binned Illumina 1.8+ qualities.
+			qual:       []string{"FFFFFFFFFFFFFFFF", "FFFFFFFFFFFF"},
+			enc:        alphabet.Sanger,
+			candidates: []alphabet.Encoding{alphabet.Sanger, alphabet.Illumina1_3},
+			err:        ErrAmbiguousEncoding,
+		},
+		{
+			qual: []string{"AAAA\x7f"},
+			enc:  alphabet.None,
+			err:  ErrUnknownEncoding,
+		},
+		{
+			enc: alphabet.None,
+			err: ErrNoQuality,
+		},
+	} {
+		var fq bytes.Buffer
+		for i, q := range t.qual {
+			fmt.Fprintf(&fq, "@r%d\n%s\n+\n%s\n\n", i, strings.Repeat("A", len(q)), q)
+		}
+		enc, d, r, err := DetectEncoding(bytes.NewReader(fq.Bytes()), 1)
+		c.Check(err, check.Equals, t.err)
+		c.Check(enc, check.Equals, t.enc)
+		if len(t.qual) != 0 {
+			c.Check(d.Candidates(), check.DeepEquals, t.candidates)
+		}
+		all, err := ioutil.ReadAll(r)
+		c.Check(err, check.Equals, nil)
+		c.Check(string(all), check.Equals, fq.String())
+
+		enc, _, _, err = DetectEncoding(bytes.NewReader(fq.Bytes()), -1)
+		c.Check(err, check.Equals, t.err)
+		c.Check(enc, check.Equals, t.enc)
+	}
+
+	_, _, _, err := DetectEncoding(strings.NewReader("@r0\nACGT\n+\nIII\n"), -1)
+	c.Check(err, check.Equals, ErrBadRecord)
+}
+
+func (s *S) TestConvert(c *check.C) {
+	const (
+		sanger = "@r0 desc\nACGTACGT\n+\n!\"$+5?IJ\n"
+		phred  = "@r0 desc\nACGTACGT\n+\n@ACJT^hi\n"
+		solexa = "@r0 desc\nACGT\n+r0\n;@Jh\n"
+	)
+	for _, t := range []struct {
+		in       string
+		from, to alphabet.Encoding
+		want     string
+	}{
+		{in: sanger, from: alphabet.Sanger, to: alphabet.Illumina1_3, want: phred},
+		{in: phred, from: alphabet.Illumina1_3, to: alphabet.Sanger, want: sanger},
+		{in: sanger, from: alphabet.Sanger, to: alphabet.Illumina1_5, want: "@r0 desc\nACGTACGT\n+\nBBCJT^hi\n"},
+		{in: solexa, from: alphabet.Solexa, to: alphabet.Sanger, want: "@r0 desc\nACGT\n+r0\n\"$+I\n"},
+		{in: solexa, from: alphabet.Solexa, to: alphabet.Solexa, want: solexa},
+		{in: "@r0\nAC\n+\n!I\n", from: alphabet.Sanger, to: alphabet.Solexa, want: "@r0\nAC\n+\n;h\n"},
+	} {
+		var buf bytes.Buffer
+		n, err := Convert(&buf, strings.NewReader(t.in), t.from, t.to)
+		c.Check(err, check.Equals, nil)
+		c.Check(n, check.Equals, 1)
+		c.Check(buf.String(), check.Equals, t.want)
+	}
+
+	var buf bytes.Buffer
+	_, err := Convert(&buf, strings.NewReader(sanger), alphabet.Illumina1_3, alphabet.Sanger)
+	c.Check(err, check.ErrorMatches, "fastq: invalid quality .* in record 1 at position 1")
+}
+
+func (s *S) TestPairedReader(c *check.C) {
+	const (
+		r1 = "@a/1\nACGT\n+\nIIII\n@b 1:N:0:ATCACG\nGGGG\n+\nIIII\n@c\nTTTT\n+\nIIII\n"
+		r2 = "@a/2\nTGCA\n+\nIIII\n@b 2:N:0:ATCACG\nCCCC\n+\nIIII\n@c\nAAAA\n+\nIIII\n"
+	)
+	template := linear.NewQSeq("", nil, alphabet.DNA, alphabet.Sanger)
+	want := [][2]string{{"a/1", "a/2"}, {"b", "b"}, {"c", "c"}}
+
+	var interleaved bytes.Buffer
+	w := NewInterleavedWriter(&interleaved)
+	pr := NewPairedReader(strings.NewReader(r1), strings.NewReader(r2), template)
+	var got [][2]string
+	for {
+		m1, m2, err := pr.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		got = append(got, [2]string{m1.Name(), m2.Name()})
+		_, err = w.Write(m1, m2)
+		c.Assert(err, check.Equals, nil)
+	}
+	c.Check(got, check.DeepEquals, want)
+
+	got = got[:0]
+	pr = NewInterleavedReader(&interleaved, template)
+	for {
+		m1, m2, err := pr.Read()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.Equals, nil)
+		got = append(got, [2]string{m1.Name(), m2.Name()})
+		c.Check(m1.Len(), check.Equals, 4)
+		c.Check(m2.Len(), check.Equals, 4)
+	}
+	c.Check(got, check.DeepEquals, want)
+
+	for _, t := range []struct {
+		r1, r2 string
+		err    error
+	}{
+		{r1: "@a/1\nA\n+\nI\n", r2: "@b/2\nA\n+\nI\n", err: ErrPairMismatch},
+		{r1: "@a/2\nA\n+\nI\n", r2: "@a/1\nA\n+\nI\n", err: ErrPairMismatch},
+		{r1: "@a 1:N:0:A\nA\n+\nI\n", r2: "@a 1:N:0:A\nA\n+\nI\n", err: ErrPairMismatch},
+		{r1: "@a\nA\n+\nI\n", r2: "", err: ErrUnpaired},
+		{r1: "", r2: "@a\nA\n+\nI\n", err: ErrUnpaired},
+	} {
+		_, _, err := NewPairedReader(strings.NewReader(t.r1), strings.NewReader(t.r2), template).Read()
+		c.Check(err, check.Equals, t.err)
+	}
+
+	pr = NewInterleavedReader(strings.NewReader(r1), template)
+	_, _, err := pr.Read()
+	c.Check(err, check.Equals, ErrPairMismatch)
+	_, _, err = pr.Read()
+	c.Check(err, check.Equals, ErrUnpaired)
+}
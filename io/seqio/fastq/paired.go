@@ -0,0 +1,140 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastq
+
+import (
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrPairMismatch = errors.New("fastq: read pair names do not match")
+	ErrUnpaired     = errors.New("fastq: unpaired read")
+)
+
+// PairedReader reads paired-end FASTQ data, either from two files holding the
+// first and second reads of each pair in the same order, or from a single
+// interleaved file.
+type PairedReader struct {
+	r1, r2 *Reader
+}
+
+// NewPairedReader returns a PairedReader that reads first mates from r1 and
+// second mates from r2 in lock-step. Sequences returned by the PairedReader
+// are copied from the provided template.
+func NewPairedReader(r1, r2 io.Reader, template *linear.QSeq) *PairedReader {
+	return &PairedReader{r1: NewReader(r1, template), r2: NewReader(r2, template)}
+}
+
+// NewInterleavedReader returns a PairedReader that reads alternating first and
+// second mates from r. Sequences returned by the PairedReader are copied from
+// the provided template.
+func NewInterleavedReader(r io.Reader, template *linear.QSeq) *PairedReader {
+	fr := NewReader(r, template)
+	return &PairedReader{r1: fr, r2: fr}
+}
+
+// Read reads a read pair and returns the two mates and potentially an error.
+// If one input ends before the other, or an interleaved input holds an odd
+// number of reads, ErrUnpaired is returned with the unpaired read.
+//
+// Mate names are validated using the /1 and /2 name suffix convention, the
+// Casava 1.8 comment convention where the comment begins with the read number,
+// for example "1:N:0:ATCACG", or otherwise by exact name match. If the names
+// do not match, ErrPairMismatch is returned with both reads.
+func (r *PairedReader) Read() (m1, m2 *linear.QSeq, err error) {
+	s1, err := r.r1.Read()
+	if err != nil && s1 == nil {
+		if err == io.EOF && r.r1 != r.r2 {
+			s2, _err := r.r2.Read()
+			if s2 != nil {
+				return nil, s2.(*linear.QSeq), ErrUnpaired
+			}
+			if _err != io.EOF {
+				err = _err
+			}
+		}
+		return nil, nil, err
+	}
+	m1 = s1.(*linear.QSeq)
+	if err != nil {
+		return m1, nil, err
+	}
+	s2, err := r.r2.Read()
+	if err != nil && s2 == nil {
+		if err == io.EOF {
+			err = ErrUnpaired
+		}
+		return m1, nil, err
+	}
+	m2 = s2.(*linear.QSeq)
+	if err != nil {
+		return m1, m2, err
+	}
+	if !IsPair(m1, m2) {
+		return m1, m2, ErrPairMismatch
+	}
+	return m1, m2, nil
+}
+
+// IsPair returns whether the sequences a and b are named as the first and
+// second mates of a read pair. See PairedReader.Read for the naming
+// conventions that are recognised.
+func IsPair(a, b seq.Sequence) bool {
+	na, ma := mateName(a)
+	nb, mb := mateName(b)
+	if na != nb {
+		return false
+	}
+	return (ma == 0 && mb == 0) || (ma == 1 && mb == 2)
+}
+
+// mateName returns the base name of the read s and its mate number, or zero if
+// the mate number is not known.
+func mateName(s seq.Sequence) (name string, mate int) {
+	name = s.Name()
+	if desc := s.Description(); len(desc) > 1 && desc[1] == ':' {
+		switch desc[0] {
+		case '1':
+			return name, 1
+		case '2':
+			return name, 2
+		}
+	}
+	switch {
+	case strings.HasSuffix(name, "/1"):
+		return name[:len(name)-2], 1
+	case strings.HasSuffix(name, "/2"):
+		return name[:len(name)-2], 2
+	}
+	return name, 0
+}
+
+// InterleavedWriter writes read pairs to a single interleaved FASTQ stream.
+type InterleavedWriter struct {
+	w   *Writer
+	QID bool // Include ID on +lines
+}
+
+// NewInterleavedWriter returns a new interleaved FASTQ writer using w.
+func NewInterleavedWriter(w io.Writer) *InterleavedWriter {
+	return &InterleavedWriter{w: NewWriter(w)}
+}
+
+// Write writes the read pair m1 and m2 and returns the number of bytes
+// written and any error.
+func (w *InterleavedWriter) Write(m1, m2 seq.Sequence) (n int, err error) {
+	w.w.QID = w.QID
+	n, err = w.w.Write(m1)
+	if err != nil {
+		return n, err
+	}
+	_n, err := w.w.Write(m2)
+	return n + _n, err
+}
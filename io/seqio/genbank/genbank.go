@@ -0,0 +1,857 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package genbank provides types to read and write GenBank flat-file format files.
+package genbank
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/seqio"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ seqio.Reader = (*Reader)(nil)
+	_ seqio.Writer = (*Writer)(nil)
+
+	_ feat.Feature  = (*Feature)(nil)
+	_ feat.Orienter = (*Feature)(nil)
+)
+
+var (
+	ErrNoLocus        = errors.New("genbank: missing LOCUS line")
+	ErrBadLocus       = errors.New("genbank: malformed LOCUS line")
+	ErrBadLine        = errors.New("genbank: malformed line")
+	ErrBadFeature     = errors.New("genbank: malformed feature")
+	ErrBadLocation    = errors.New("genbank: malformed location")
+	ErrBadQualifier   = errors.New("genbank: malformed qualifier")
+	ErrLengthMismatch = errors.New("genbank: sequence length does not match LOCUS length")
+	ErrUnterminated   = errors.New("genbank: unterminated record")
+)
+
+// DefaultDate is the LOCUS date written for records without a date.
+const DefaultDate = "01-JAN-1980"
+
+// Layout of the flat-file format.
+const (
+	headerIndent  = 12
+	featureIndent = 21
+	lineWidth     = 79
+)
+
+// Locus holds the fields of a GenBank LOCUS line.
+type Locus struct {
+	Name string
+
+	// Length is the length of the entry in bases or
+	// residues. It is used when writing entries that
+	// have no sequence, such as CONTIG entries.
+	Length int
+
+	// Moltype is the molecule type, for example
+	// "DNA", "ss-RNA" or "mRNA". It is empty for
+	// protein entries.
+	Moltype string
+
+	// Conform is the topology of the entry.
+	Conform feat.Conformation
+
+	Division string
+	Date     string
+}
+
+// A Reference is a citation associated with a GenBank entry.
+type Reference struct {
+	Number int
+
+	// Bases is the text following the reference
+	// number, for example "(bases 1 to 5028)".
+	Bases string
+
+	Authors    string
+	Consortium string
+	Title      string
+	Journal    string
+	PubMed     string
+	Remark     string
+}
+
+// A Field is a header field not otherwise handled by the genbank package.
+// Value holds the lines of the field with the keyword column removed,
+// separated by newlines.
+type Field struct {
+	Keyword string
+	Value   string
+}
+
+// Metadata holds the header fields of a GenBank entry. Multi-line fields
+// are joined with spaces, except COMMENT and DBLINK which retain their line
+// structure and are joined with newlines.
+type Metadata struct {
+	Locus      Locus
+	Definition string
+	Accession  string
+	Version    string
+	DBLink     string
+	Keywords   string
+	Segment    string
+	Source     string
+	Organism   string
+	Lineage    string
+	References []Reference
+	Comment    string
+
+	// Contig holds the CONTIG assembly
+	// description of entries that do
+	// not include a sequence.
+	Contig string
+
+	// Other holds unrecognised header
+	// fields in the order they were read.
+	Other []Field
+}
+
+// A Qualifier is a feature table qualifier. Quoted indicates that the value
+// is written as a quoted string. A qualifier with an empty unquoted value is
+// written without a value, for example /pseudo.
+type Qualifier struct {
+	Name   string
+	Value  string
+	Quoted bool
+}
+
+// Qualifiers is a list of feature qualifiers.
+type Qualifiers []Qualifier
+
+// Get returns the value of the first qualifier with the given name.
+func (q Qualifiers) Get(name string) string {
+	for _, e := range q {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// A Feature is a GenBank feature table entry.
+type Feature struct {
+	Key        string
+	Loc        Location
+	Qualifiers Qualifiers
+
+	// Parent is the feature location, usually
+	// the sequence of the entry holding the
+	// feature.
+	Parent feat.Feature
+}
+
+func (f *Feature) Start() int { s, _, _ := bounds(f.Loc); return s }
+func (f *Feature) End() int   { _, e, _ := bounds(f.Loc); return e }
+func (f *Feature) Len() int   { return f.End() - f.Start() }
+
+// Name returns the value of the gene, locus_tag or label qualifier of the
+// feature, or the feature key if none of these is present.
+func (f *Feature) Name() string {
+	for _, n := range []string{"gene", "locus_tag", "label"} {
+		if v := f.Qualifiers.Get(n); v != "" {
+			return v
+		}
+	}
+	return f.Key
+}
+
+func (f *Feature) Description() string           { return f.Key }
+func (f *Feature) Location() feat.Feature        { return f.Parent }
+func (f *Feature) Orientation() feat.Orientation { return orientation(f.Loc) }
+
+// A Record is a complete GenBank entry.
+type Record struct {
+	Metadata
+	Features []*Feature
+	Seq      *linear.Seq
+}
+
+// Reader is a GenBank format reader.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+
+	last   []byte
+	unread bool
+	err    error
+}
+
+// NewReader returns a new GenBank format reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read reads a single GenBank entry and returns its sequence as a *linear.Seq.
+// The feature table and header fields of the entry are discarded; use
+// ReadRecord to obtain them.
+func (r *Reader) Read() (seq.Sequence, error) {
+	rec, err := r.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+	return rec.Seq, nil
+}
+
+// ReadRecord reads and returns a single complete GenBank entry. The ID, Desc
+// and Conform fields of the returned sequence are set from the LOCUS name,
+// the DEFINITION and the LOCUS topology, and the Parent of each feature is
+// the returned sequence. Nucleic acid entries are given the DNAredundant
+// alphabet since GenBank presents RNA entries as their DNA sequence.
+func (r *Reader) ReadRecord() (*Record, error) {
+	var line []byte
+	for {
+		var err error
+		line, err = r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) != 0 {
+			break
+		}
+	}
+	if !bytes.HasPrefix(line, []byte("LOCUS")) {
+		return nil, r.errorf(ErrNoLocus)
+	}
+	rec := &Record{}
+	err := rec.Locus.parse(string(line))
+	if err != nil {
+		return nil, r.errorf(err)
+	}
+	rec.Seq = linear.NewSeq(rec.Locus.Name, nil, alphabet.DNAredundant)
+	rec.Seq.Conform = rec.Locus.Conform
+	if rec.Locus.Moltype == "" {
+		rec.Seq.Alpha = alphabet.Protein
+	}
+
+	for {
+		kw, lines, err := r.field()
+		if err != nil {
+			if err == io.EOF {
+				err = r.errorf(ErrUnterminated)
+			}
+			return nil, err
+		}
+		switch kw {
+		case "//":
+			if len(rec.Seq.Seq) != 0 && len(rec.Seq.Seq) != rec.Locus.Length {
+				return nil, r.errorf(ErrLengthMismatch)
+			}
+			rec.Seq.Desc = rec.Definition
+			return rec, nil
+		case "DEFINITION":
+			rec.Definition = strings.Join(lines, " ")
+		case "ACCESSION":
+			rec.Accession = strings.Join(lines, " ")
+		case "VERSION":
+			rec.Version = strings.Join(lines, " ")
+		case "DBLINK":
+			rec.DBLink = strings.Join(lines, "\n")
+		case "KEYWORDS":
+			rec.Keywords = strings.Join(lines, " ")
+		case "SEGMENT":
+			rec.Segment = strings.Join(lines, " ")
+		case "SOURCE":
+			rec.Source = strings.Join(lines, " ")
+		case "ORGANISM":
+			rec.Organism = lines[0]
+			rec.Lineage = strings.Join(lines[1:], " ")
+		case "REFERENCE":
+			var ref Reference
+			f := strings.SplitN(strings.Join(lines, " "), " ", 2)
+			ref.Number, err = strconv.Atoi(f[0])
+			if err != nil {
+				return nil, r.errorf(ErrBadLine)
+			}
+			if len(f) > 1 {
+				ref.Bases = strings.TrimSpace(f[1])
+			}
+			rec.References = append(rec.References, ref)
+		case "AUTHORS", "CONSRTM", "TITLE", "JOURNAL", "PUBMED", "REMARK":
+			if len(rec.References) == 0 {
+				return nil, r.errorf(ErrBadLine)
+			}
+			ref := &rec.References[len(rec.References)-1]
+			v := strings.Join(lines, " ")
+			switch kw {
+			case "AUTHORS":
+				ref.Authors = v
+			case "CONSRTM":
+				ref.Consortium = v
+			case "TITLE":
+				ref.Title = v
+			case "JOURNAL":
+				ref.Journal = v
+			case "PUBMED":
+				ref.PubMed = v
+			case "REMARK":
+				ref.Remark = v
+			}
+		case "COMMENT":
+			rec.Comment = strings.Join(lines, "\n")
+		case "CONTIG":
+			rec.Contig = strings.Join(lines, "")
+		case "BASE COUNT":
+			// Derived from the sequence.
+		case "FEATURES":
+			rec.Features, err = r.features(rec.Seq)
+			if err != nil {
+				return nil, err
+			}
+		case "ORIGIN":
+			err = r.origin(rec.Seq)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			rec.Other = append(rec.Other, Field{Keyword: kw, Value: strings.Join(lines, "\n")})
+		}
+	}
+}
+
+func (r *Reader) errorf(err error) error {
+	return &csv.ParseError{Line: r.line, Err: err}
+}
+
+func (r *Reader) readLine() ([]byte, error) {
+	if r.unread {
+		r.unread = false
+		return r.last, nil
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	line, err := r.r.ReadBytes('\n')
+	if err != nil {
+		if err != io.EOF || len(line) == 0 {
+			r.err = err
+			return nil, err
+		}
+	}
+	r.line++
+	r.last = bytes.TrimRight(line, "\r\n")
+	return r.last, nil
+}
+
+func (r *Reader) unreadLine() { r.unread = true }
+
+// field reads a header field and its continuation lines, returning the
+// keyword and the text of each line following the keyword column.
+func (r *Reader) field() (kw string, lines []string, err error) {
+	line, err := r.readLine()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(line) == 0 || line[0] == ' ' && isBlank(line[:minInt(len(line), headerIndent)]) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			return r.field()
+		}
+		return "", nil, r.errorf(ErrBadLine)
+	}
+	kw = strings.TrimSpace(string(line[:minInt(len(line), headerIndent)]))
+	switch kw {
+	case "//", "FEATURES", "ORIGIN":
+		return kw, nil, nil
+	}
+	lines = append(lines, value(line, headerIndent))
+	for {
+		line, err = r.readLine()
+		if err != nil {
+			if err == io.EOF {
+				return kw, lines, nil
+			}
+			return "", nil, err
+		}
+		if len(line) <= headerIndent || !isBlank(line[:headerIndent]) {
+			r.unreadLine()
+			return kw, lines, nil
+		}
+		lines = append(lines, value(line, headerIndent))
+	}
+}
+
+func value(line []byte, indent int) string {
+	if len(line) <= indent {
+		return ""
+	}
+	return string(bytes.TrimRight(line[indent:], " "))
+}
+
+func isBlank(b []byte) bool {
+	for _, c := range b {
+		if c != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// features reads the feature table.
+func (r *Reader) features(parent feat.Feature) ([]*Feature, error) {
+	var (
+		fs    []*Feature
+		key   string
+		lines []string
+	)
+	for {
+		line, err := r.readLine()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		end := err == io.EOF || len(line) == 0 || line[0] != ' '
+		if end || !isBlank(line[:minInt(len(line), featureIndent)]) {
+			if key != "" {
+				f, err := newFeature(key, lines, parent)
+				if err != nil {
+					return nil, r.errorf(err)
+				}
+				fs = append(fs, f)
+			}
+			if end {
+				if err == nil {
+					r.unreadLine()
+				}
+				return fs, nil
+			}
+			f := strings.Fields(string(line))
+			if len(f) == 0 {
+				return nil, r.errorf(ErrBadFeature)
+			}
+			key = f[0]
+			lines = []string{strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(line)), key))}
+			continue
+		}
+		lines = append(lines, value(line, featureIndent))
+	}
+}
+
+// newFeature returns a Feature from the key and the location and qualifier lines
+// of a feature table entry.
+func newFeature(key string, lines []string, parent feat.Feature) (*Feature, error) {
+	i := 1
+	for i < len(lines) && !strings.HasPrefix(lines[i], "/") {
+		i++
+	}
+	loc, err := ParseLocation(strings.Join(lines[:i], ""))
+	if err != nil {
+		return nil, err
+	}
+	q, err := ParseQualifiers(lines[i:])
+	if err != nil {
+		return nil, err
+	}
+	return &Feature{Key: key, Loc: loc, Qualifiers: q, Parent: parent}, nil
+}
+
+// ParseQualifiers parses the qualifier lines of a feature table entry. The
+// lines must have the feature table indent removed. Continuation lines are
+// joined with a space, except for /translation values which are joined
+// directly.
+func ParseQualifiers(lines []string) (Qualifiers, error) {
+	var (
+		raw    []string
+		quoted bool
+	)
+	for _, l := range lines {
+		if !quoted && strings.HasPrefix(l, "/") {
+			raw = append(raw, l)
+		} else {
+			if len(raw) == 0 {
+				return nil, ErrBadQualifier
+			}
+			sep := " "
+			if strings.HasPrefix(raw[len(raw)-1], "/translation=") {
+				sep = ""
+			}
+			raw[len(raw)-1] += sep + l
+		}
+		quoted = strings.Count(raw[len(raw)-1], `"`)%2 == 1
+	}
+	if quoted {
+		return nil, ErrBadQualifier
+	}
+	var q Qualifiers
+	for _, r := range raw {
+		q = append(q, parseQualifier(r))
+	}
+	return q, nil
+}
+
+func parseQualifier(q string) Qualifier {
+	q = q[1:]
+	i := strings.Index(q, "=")
+	if i < 0 {
+		return Qualifier{Name: q}
+	}
+	name, v := q[:i], q[i+1:]
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		return Qualifier{Name: name, Value: strings.Replace(v[1:len(v)-1], `""`, `"`, -1), Quoted: true}
+	}
+	return Qualifier{Name: name, Value: v}
+}
+
+// origin reads the sequence lines following an ORIGIN line.
+func (r *Reader) origin(s *linear.Seq) error {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			if err == io.EOF {
+				err = r.errorf(ErrUnterminated)
+			}
+			return err
+		}
+		if bytes.HasPrefix(line, []byte("//")) {
+			r.unreadLine()
+			return nil
+		}
+		for _, c := range line {
+			if c == ' ' || ('0' <= c && c <= '9') {
+				continue
+			}
+			s.Seq = append(s.Seq, alphabet.Letter(c))
+		}
+	}
+}
+
+// parse parses a LOCUS line.
+func (l *Locus) parse(line string) error {
+	f := strings.Fields(line)
+	if len(f) < 4 || f[0] != "LOCUS" {
+		return ErrBadLocus
+	}
+	var err error
+	l.Name = f[1]
+	l.Length, err = strconv.Atoi(f[2])
+	if err != nil {
+		return ErrBadLocus
+	}
+	unit := f[3]
+	if unit != "bp" && unit != "aa" {
+		return ErrBadLocus
+	}
+	f = f[4:]
+
+	l.Conform = feat.UndefinedConformation
+	if len(f) != 0 && strings.Count(f[len(f)-1], "-") == 2 {
+		l.Date = f[len(f)-1]
+		f = f[:len(f)-1]
+	}
+	if len(f) != 0 && unit == "bp" && f[0] != "linear" && f[0] != "circular" {
+		l.Moltype = f[0]
+		f = f[1:]
+	} else if unit == "bp" {
+		l.Moltype = "DNA"
+	}
+	if len(f) != 0 {
+		switch f[0] {
+		case "linear":
+			l.Conform = feat.Linear
+			f = f[1:]
+		case "circular":
+			l.Conform = feat.Circular
+			f = f[1:]
+		}
+	}
+	switch len(f) {
+	case 0:
+	case 1:
+		l.Division = f[0]
+	default:
+		return ErrBadLocus
+	}
+	return nil
+}
+
+// Writer is a GenBank format writer.
+type Writer struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+// NewWriter returns a new GenBank format writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes a single sequence as a GenBank entry with no features and
+// returns the number of bytes written and any error. The LOCUS name,
+// DEFINITION and topology are taken from the name, description and
+// conformation of the sequence.
+func (w *Writer) Write(s seq.Sequence) (n int, err error) {
+	m := Metadata{
+		Locus:      Locus{Name: s.Name(), Conform: feat.Linear},
+		Definition: s.Description(),
+	}
+	if c, ok := s.(seq.Conformationer); ok {
+		m.Locus.Conform = c.Conformation()
+	}
+	if a := s.Alphabet(); a != nil {
+		switch a.Moltype() {
+		case feat.DNA:
+			m.Locus.Moltype = "DNA"
+		case feat.RNA:
+			m.Locus.Moltype = "RNA"
+		}
+	}
+	return w.write(&m, nil, s)
+}
+
+// WriteRecord writes a complete GenBank entry and returns the number of bytes
+// written and any error. The LOCUS length is taken from the record's sequence
+// unless it is empty. Features are written with their qualifier values wrapped
+// at spaces, except for /translation which is wrapped at any position.
+func (w *Writer) WriteRecord(r *Record) (n int, err error) {
+	var s seq.Sequence
+	if r.Seq != nil {
+		s = r.Seq
+	}
+	return w.write(&r.Metadata, r.Features, s)
+}
+
+func (w *Writer) write(m *Metadata, fs []*Feature, s seq.Sequence) (int, error) {
+	b := &w.buf
+	b.Reset()
+
+	length := m.Locus.Length
+	unit := "bp"
+	if s != nil {
+		if s.Len() != 0 {
+			length = s.Len()
+		}
+		if s.Alphabet() != nil && s.Alphabet().Moltype() == feat.Protein {
+			unit = "aa"
+		}
+	}
+	b.WriteString(m.Locus.format(length, unit))
+	b.WriteByte('\n')
+
+	writeField(b, "DEFINITION", m.Definition)
+	writeField(b, "ACCESSION", m.Accession)
+	writeField(b, "VERSION", m.Version)
+	writeLines(b, "DBLINK", m.DBLink)
+	writeField(b, "KEYWORDS", m.Keywords)
+	writeField(b, "SEGMENT", m.Segment)
+	writeField(b, "SOURCE", m.Source)
+	if m.Organism != "" {
+		writeField(b, "  ORGANISM", m.Organism)
+		for _, l := range wrap(m.Lineage, lineWidth-headerIndent, false) {
+			b.WriteString(strings.Repeat(" ", headerIndent))
+			b.WriteString(l)
+			b.WriteByte('\n')
+		}
+	}
+	for _, ref := range m.References {
+		num := strconv.Itoa(ref.Number)
+		if ref.Bases != "" {
+			num += strings.Repeat(" ", maxInt(1, 3-len(num))) + ref.Bases
+		}
+		writeField(b, "REFERENCE", num)
+		writeField(b, "  AUTHORS", ref.Authors)
+		writeField(b, "  CONSRTM", ref.Consortium)
+		writeField(b, "  TITLE", ref.Title)
+		writeField(b, "  JOURNAL", ref.Journal)
+		writeField(b, "   PUBMED", ref.PubMed)
+		writeField(b, "  REMARK", ref.Remark)
+	}
+	writeLines(b, "COMMENT", m.Comment)
+	for _, f := range m.Other {
+		writeLines(b, f.Keyword, f.Value)
+	}
+
+	if len(fs) != 0 {
+		b.WriteString("FEATURES             Location/Qualifiers\n")
+		for _, f := range fs {
+			err := writeFeature(b, f)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if s != nil && s.Len() != 0 {
+		b.WriteString("ORIGIN\n")
+		for i := 0; i < s.Len(); i++ {
+			switch {
+			case i%60 == 0:
+				if i != 0 {
+					b.WriteByte('\n')
+				}
+				fmt.Fprintf(b, "%9d ", i+1)
+			case i%10 == 0:
+				b.WriteByte(' ')
+			}
+			b.WriteByte(byte(s.At(i).L))
+		}
+		b.WriteByte('\n')
+	} else if m.Contig != "" {
+		writeField(b, "CONTIG", m.Contig)
+	}
+	b.WriteString("//\n")
+
+	return w.w.Write(b.Bytes())
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// format returns the formatted LOCUS line.
+func (l *Locus) format(length int, unit string) string {
+	var strand, mol string
+	if unit == "bp" {
+		mol = l.Moltype
+		if mol == "" {
+			mol = "DNA"
+		}
+		if len(mol) > 3 && mol[2] == '-' {
+			strand, mol = mol[:3], mol[3:]
+		}
+	}
+	var topology string
+	switch l.Conform {
+	case feat.Linear:
+		topology = "linear"
+	case feat.Circular:
+		topology = "circular"
+	}
+	div := l.Division
+	if div == "" {
+		div = "UNK"
+	}
+	date := l.Date
+	if date == "" {
+		date = DefaultDate
+	}
+	size := strconv.Itoa(length)
+	return fmt.Sprintf("LOCUS       %s %*s %s %3s%-6s  %-8s %s %s",
+		l.Name, maxInt(len(size), 27-len(l.Name)), size, unit, strand, mol, topology, div, date)
+}
+
+// writeField writes a header field, wrapping the value at spaces.
+func writeField(b *bytes.Buffer, kw, v string) {
+	if v == "" {
+		return
+	}
+	for i, l := range wrap(v, lineWidth-headerIndent, false) {
+		if i == 0 {
+			fmt.Fprintf(b, "%-*s", headerIndent, kw)
+		} else {
+			b.WriteString(strings.Repeat(" ", headerIndent))
+		}
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+}
+
+// writeLines writes a header field retaining the line structure of the value.
+func writeLines(b *bytes.Buffer, kw, v string) {
+	if v == "" {
+		return
+	}
+	for i, l := range strings.Split(v, "\n") {
+		if i == 0 {
+			fmt.Fprintf(b, "%-*s", headerIndent, kw)
+		} else {
+			b.WriteString(strings.Repeat(" ", headerIndent))
+		}
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+}
+
+func writeFeature(b *bytes.Buffer, f *Feature) error {
+	if f.Loc == nil {
+		return ErrBadFeature
+	}
+	indent := strings.Repeat(" ", featureIndent)
+	width := lineWidth - featureIndent
+	for i, l := range wrapLocation(f.Loc.String(), width) {
+		if i == 0 {
+			fmt.Fprintf(b, "     %-15s ", f.Key)
+		} else {
+			b.WriteString(indent)
+		}
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	for _, q := range f.Qualifiers {
+		if q.Name == "" {
+			return ErrBadQualifier
+		}
+		text := "/" + q.Name
+		switch {
+		case q.Quoted:
+			text += `="` + strings.Replace(q.Value, `"`, `""`, -1) + `"`
+		case q.Value != "":
+			text += "=" + q.Value
+		}
+		for _, l := range wrap(text, width, q.Name == "translation") {
+			b.WriteString(indent)
+			b.WriteString(l)
+			b.WriteByte('\n')
+		}
+	}
+	return nil
+}
+
+// wrap splits text into lines no longer than width. Lines are broken at
+// spaces, which are removed, unless anywhere is true or a word is longer
+// than width.
+func wrap(text string, width int, anywhere bool) []string {
+	var lines []string
+	for len(text) > width {
+		i := width
+		if !anywhere {
+			if j := strings.LastIndex(text[:width+1], " "); j > 0 {
+				i = j
+			}
+		}
+		lines = append(lines, text[:i])
+		text = text[i:]
+		if !anywhere && len(text) != 0 && text[0] == ' ' {
+			text = text[1:]
+		}
+	}
+	return append(lines, text)
+}
+
+// wrapLocation splits a location into lines no longer than width, breaking
+// after commas where possible.
+func wrapLocation(loc string, width int) []string {
+	var lines []string
+	for len(loc) > width {
+		i := strings.LastIndex(loc[:width], ",") + 1
+		if i == 0 {
+			i = width
+		}
+		lines = append(lines, loc[:i])
+		loc = loc[i:]
+	}
+	return append(lines, loc)
+}
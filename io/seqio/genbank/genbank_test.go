@@ -0,0 +1,228 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package genbank
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const plasmid = `LOCUS       pTEST1                   180 bp    DNA     circular SYN 17-OCT-2016
+DEFINITION  Cloning vector pTEST1, complete sequence, with a definition long
+            enough to wrap onto a second line.
+ACCESSION   XX000001
+VERSION     XX000001.1
+DBLINK      BioProject: PRJNA000000
+            BioSample: SAMN00000000
+KEYWORDS    cloning vector.
+SOURCE      synthetic construct
+  ORGANISM  synthetic construct
+            other sequences; artificial sequences; vectors.
+REFERENCE   1  (bases 1 to 180)
+  AUTHORS   Doe,J. and Roe,R.
+  TITLE     Direct Submission
+  JOURNAL   Submitted (17-OCT-2016) Nowhere Institute, 1 Main Street,
+            Springfield 00000, USA
+   PUBMED   00000000
+COMMENT     This is a test entry.
+            It has two lines.
+FEATURES             Location/Qualifiers
+     source          1..180
+                     /organism="synthetic construct"
+                     /mol_type="other DNA"
+     gene            complement(join(10..40,60..90))
+                     /gene="tst"
+     CDS             complement(join(10..40,60..90))
+                     /gene="tst"
+                     /codon_start=1
+                     /note="a note that is long enough to be wrapped onto more
+                     than one line and contains a ""quoted"" word and a /slash"
+                     /translation="MSTVMSTVMSTVMSTVMSTVMSTVMSTVMSTVMSTVMSTVMSTV
+                     MSTVMSTVMSTVMSTV"
+     misc_feature    <1..>20
+                     /pseudo
+     misc_feature    order(100,XX000002.1:5..15,120^121)
+                     /label=site
+     rep_origin      180^1
+ORIGIN
+        1 gattacagcc gattacagcc gattacagcc gattacagcc gattacagcc gattacagcc
+       61 gattacagcc gattacagcc gattacagcc gattacagcc gattacagcc gattacagcc
+      121 gattacagcc gattacagcc gattacagcc gattacagcc gattacagcc gattacagcc
+//
+`
+
+func (s *S) TestReadRecord(c *check.C) {
+	r := NewReader(strings.NewReader(plasmid))
+	rec, err := r.ReadRecord()
+	c.Assert(err, check.Equals, nil)
+
+	c.Check(rec.Locus, check.Equals, Locus{
+		Name:     "pTEST1",
+		Length:   180,
+		Moltype:  "DNA",
+		Conform:  feat.Circular,
+		Division: "SYN",
+		Date:     "17-OCT-2016",
+	})
+	c.Check(rec.Definition, check.Equals, "Cloning vector pTEST1, complete sequence, with a definition long enough to wrap onto a second line.")
+	c.Check(rec.DBLink, check.Equals, "BioProject: PRJNA000000\nBioSample: SAMN00000000")
+	c.Check(rec.Organism, check.Equals, "synthetic construct")
+	c.Check(rec.Lineage, check.Equals, "other sequences; artificial sequences; vectors.")
+	c.Check(rec.References, check.DeepEquals, []Reference{{
+		Number:  1,
+		Bases:   "(bases 1 to 180)",
+		Authors: "Doe,J. and Roe,R.",
+		Title:   "Direct Submission",
+		Journal: "Submitted (17-OCT-2016) Nowhere Institute, 1 Main Street, Springfield 00000, USA",
+		PubMed:  "00000000",
+	}})
+	c.Check(rec.Comment, check.Equals, "This is a test entry.\nIt has two lines.")
+
+	c.Check(rec.Seq.Name(), check.Equals, "pTEST1")
+	c.Check(rec.Seq.Description(), check.Equals, rec.Definition)
+	c.Check(rec.Seq.Conformation(), check.Equals, feat.Circular)
+	c.Check(rec.Seq.Seq, check.DeepEquals, alphabet.Letters(strings.Repeat("gattacagcc", 18)))
+
+	c.Assert(len(rec.Features), check.Equals, 6)
+	for _, f := range rec.Features {
+		c.Check(f.Location(), check.Equals, rec.Seq)
+	}
+	for i, t := range []struct {
+		name, desc string
+		start, end int
+		ori        feat.Orientation
+	}{
+		{"source", "source", 0, 180, feat.Forward},
+		{"tst", "gene", 9, 90, feat.Reverse},
+		{"tst", "CDS", 9, 90, feat.Reverse},
+		{"misc_feature", "misc_feature", 0, 20, feat.Forward},
+		{"site", "misc_feature", 99, 120, feat.Forward},
+		{"rep_origin", "rep_origin", 180, 180, feat.Forward},
+	} {
+		f := rec.Features[i]
+		c.Check(f.Name(), check.Equals, t.name)
+		c.Check(f.Description(), check.Equals, t.desc)
+		c.Check(f.Start(), check.Equals, t.start)
+		c.Check(f.End(), check.Equals, t.end)
+		c.Check(f.Orientation(), check.Equals, t.ori)
+	}
+	c.Check(rec.Features[2].Loc, check.DeepEquals, Location(Complement{Join{Span{From: 9, To: 40}, Span{From: 59, To: 90}}}))
+	c.Check(rec.Features[2].Qualifiers, check.DeepEquals, Qualifiers{
+		{Name: "gene", Value: "tst", Quoted: true},
+		{Name: "codon_start", Value: "1"},
+		{Name: "note", Value: `a note that is long enough to be wrapped onto more than one line and contains a "quoted" word and a /slash`, Quoted: true},
+		{Name: "translation", Value: strings.Repeat("MSTV", 15), Quoted: true},
+	})
+	c.Check(rec.Features[3].Qualifiers, check.DeepEquals, Qualifiers{{Name: "pseudo"}})
+
+	_, err = r.ReadRecord()
+	c.Check(err, check.Equals, io.EOF)
+}
+
+func (s *S) TestRoundTrip(c *check.C) {
+	rec, err := NewReader(strings.NewReader(plasmid)).ReadRecord()
+	c.Assert(err, check.Equals, nil)
+	var buf bytes.Buffer
+	n, err := NewWriter(&buf).WriteRecord(rec)
+	c.Check(err, check.Equals, nil)
+	c.Check(n, check.Equals, buf.Len())
+	c.Check(buf.String(), check.Equals, plasmid)
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{"ID   X; SV 1; linear; DNA; STD; SYN; 10 BP.\n", ErrNoLocus},
+		{"LOCUS       X\n//\n", ErrBadLocus},
+		{"LOCUS       X  10 bp    DNA     linear   SYN 01-JAN-1980\nFEATURES             Location/Qualifiers\n     gene            join(1..5\n//\n", ErrBadLocation},
+		{"LOCUS       X  10 bp    DNA     linear   SYN 01-JAN-1980\nORIGIN\n        1 gattaca\n//\n", ErrLengthMismatch},
+		{"LOCUS       X  10 bp    DNA     linear   SYN 01-JAN-1980\nORIGIN\n        1 gattacagat\n", ErrUnterminated},
+	} {
+		_, err := NewReader(strings.NewReader(t.in)).ReadRecord()
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err)
+	}
+}
+
+func (s *S) TestWriteSeq(c *check.C) {
+	sq := linear.NewSeq("seq1", alphabet.BytesToLetters([]byte("ACGTACGTACGT")), alphabet.DNA)
+	sq.Desc = "a short sequence"
+	var buf bytes.Buffer
+	_, err := NewWriter(&buf).Write(sq)
+	c.Assert(err, check.Equals, nil)
+	c.Check(buf.String(), check.Equals, `LOCUS       seq1                      12 bp    DNA     linear   UNK 01-JAN-1980
+DEFINITION  a short sequence
+ORIGIN
+        1 ACGTACGTAC GT
+//
+`)
+
+	got, err := NewReader(&buf).Read()
+	c.Assert(err, check.Equals, nil)
+	c.Check(got.(*linear.Seq).Seq, check.DeepEquals, sq.Seq)
+	c.Check(got.Name(), check.Equals, sq.Name())
+	c.Check(got.Description(), check.Equals, sq.Description())
+}
+
+func (s *S) TestLocusProtein(c *check.C) {
+	const line = "LOCUS       AAA00001                 120 aa            linear   PLN 10-JUN-2016"
+	var l Locus
+	c.Assert(l.parse(line), check.Equals, nil)
+	c.Check(l, check.Equals, Locus{Name: "AAA00001", Length: 120, Conform: feat.Linear, Division: "PLN", Date: "10-JUN-2016"})
+	c.Check(l.format(120, "aa"), check.Equals, line)
+}
+
+func (s *S) TestParseLocation(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		loc Location
+		err error
+	}{
+		{in: "467", loc: Span{From: 466, To: 467}},
+		{in: "340..565", loc: Span{From: 339, To: 565}},
+		{in: "<345..500", loc: Span{From: 344, To: 500, PartialStart: true}},
+		{in: "<1..>888", loc: Span{From: 0, To: 888, PartialStart: true, PartialEnd: true}},
+		{in: "<1", loc: Span{From: 0, To: 1, PartialStart: true}},
+		{in: "123^124", loc: Between{From: 123, To: 123}},
+		{in: "J00194.1:100..202", loc: Span{Accession: "J00194.1", From: 99, To: 202}},
+		{in: "complement(34..126)", loc: Complement{Span{From: 33, To: 126}}},
+		{in: "join(12..78,134..202)", loc: Join{Span{From: 11, To: 78}, Span{From: 133, To: 202}}},
+		{in: "complement(join(2691..4571,4918..5163))", loc: Complement{Join{Span{From: 2690, To: 4571}, Span{From: 4917, To: 5163}}}},
+		{in: "join(complement(4918..5163),complement(2691..4571))", loc: Join{Complement{Span{From: 4917, To: 5163}}, Complement{Span{From: 2690, To: 4571}}}},
+		{in: "order(1..10, 20..30)", loc: Order{Span{From: 0, To: 10}, Span{From: 19, To: 30}}},
+		{in: "join(1..10", err: ErrBadLocation},
+		{in: "102.110", err: ErrBadLocation},
+		{in: "10..5", err: ErrBadLocation},
+		{in: "0..5", err: ErrBadLocation},
+		{in: "5^7", err: ErrBadLocation},
+		{in: "join(1..10))", err: ErrBadLocation},
+	} {
+		loc, err := ParseLocation(t.in)
+		c.Check(err, check.Equals, t.err, check.Commentf("%q", t.in))
+		c.Check(loc, check.DeepEquals, t.loc, check.Commentf("%q", t.in))
+		if err == nil {
+			c.Check(loc.String(), check.Equals, strings.Replace(t.in, " ", "", -1))
+		}
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package genbank
+
+import (
+	"github.com/biogo/biogo/feat"
+
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Location is a GenBank feature table location. Locations are held in zero-based
+// half-open coordinates; translation to and from the one-based closed coordinates
+// of the flat-file format is handled by ParseLocation and the String methods.
+//
+// Start and End return the bounds of the location on the entry holding the
+// feature. Spans on remote entries do not contribute to the bounds of a
+// compound location.
+type Location interface {
+	Start() int
+	End() int
+	String() string
+}
+
+var (
+	_ Location = Span{}
+	_ Location = Between{}
+	_ Location = Join(nil)
+	_ Location = Order(nil)
+	_ Location = Complement{}
+)
+
+// Span is a contiguous range of bases, written as 5..10 or, for a single base, 5.
+// PartialStart and PartialEnd indicate that the feature extends beyond the start
+// or end of the span, written as <5..>10.
+type Span struct {
+	// Accession is the accession of the remote
+	// entry holding the span, or empty for a
+	// span on the entry holding the feature.
+	Accession string
+
+	From, To int
+
+	PartialStart, PartialEnd bool
+}
+
+func (s Span) Start() int { return s.From }
+func (s Span) End() int   { return s.To }
+
+func (s Span) String() string {
+	var b bytes.Buffer
+	if s.Accession != "" {
+		b.WriteString(s.Accession)
+		b.WriteByte(':')
+	}
+	if s.PartialStart {
+		b.WriteByte('<')
+	}
+	if s.To-s.From == 1 && !(s.PartialStart && s.PartialEnd) {
+		if s.PartialEnd {
+			b.WriteByte('>')
+		}
+		b.WriteString(strconv.Itoa(s.To))
+		return b.String()
+	}
+	b.WriteString(strconv.Itoa(s.From + 1))
+	b.WriteString("..")
+	if s.PartialEnd {
+		b.WriteByte('>')
+	}
+	b.WriteString(strconv.Itoa(s.To))
+	return b.String()
+}
+
+// Between is a site between two adjacent bases, written as a^b. From is the
+// position following base a and To is the position preceding base b. From
+// and To are equal except for sites that span the origin of a circular entry.
+type Between struct {
+	// Accession is the accession of the remote
+	// entry holding the site, or empty for a
+	// site on the entry holding the feature.
+	Accession string
+
+	From, To int
+}
+
+func (s Between) Start() int { return s.From }
+func (s Between) End() int   { return s.From }
+
+func (s Between) String() string {
+	var acc string
+	if s.Accession != "" {
+		acc = s.Accession + ":"
+	}
+	return acc + strconv.Itoa(s.From) + "^" + strconv.Itoa(s.To+1)
+}
+
+// Join is a set of locations joined end to end to form a contiguous sequence,
+// written as join(a,b,...).
+type Join []Location
+
+func (j Join) Start() int     { s, _, _ := bounds(j); return s }
+func (j Join) End() int       { _, e, _ := bounds(j); return e }
+func (j Join) String() string { return list("join", j) }
+
+// Order is a set of locations in a specified order with no implication that
+// they are joined, written as order(a,b,...).
+type Order []Location
+
+func (o Order) Start() int     { s, _, _ := bounds(o); return s }
+func (o Order) End() int       { _, e, _ := bounds(o); return e }
+func (o Order) String() string { return list("order", o) }
+
+// Complement is a location on the strand complementary to the presented
+// sequence, written as complement(a).
+type Complement struct {
+	Location
+}
+
+func (c Complement) String() string { return "complement(" + c.Location.String() + ")" }
+
+func list(op string, l []Location) string {
+	var b bytes.Buffer
+	b.WriteString(op)
+	b.WriteByte('(')
+	for i, e := range l {
+		if i != 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(e.String())
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// bounds returns the bounds of the parts of l that are on the
+// entry holding the feature, and whether there are any such parts.
+func bounds(l Location) (start, end int, ok bool) {
+	switch l := l.(type) {
+	case Span:
+		return l.From, l.To, l.Accession == ""
+	case Between:
+		return l.From, l.From, l.Accession == ""
+	case Complement:
+		return bounds(l.Location)
+	case Join:
+		return listBounds(l)
+	case Order:
+		return listBounds(l)
+	}
+	return 0, 0, false
+}
+
+func listBounds(l []Location) (start, end int, ok bool) {
+	for _, e := range l {
+		s, t, eok := bounds(e)
+		if !eok {
+			continue
+		}
+		if !ok || s < start {
+			start = s
+		}
+		if !ok || t > end {
+			end = t
+		}
+		ok = true
+	}
+	return start, end, ok
+}
+
+// orientation returns the orientation of l relative to the presented strand.
+// Compound locations with parts on both strands are not oriented.
+func orientation(l Location) feat.Orientation {
+	switch l := l.(type) {
+	case Complement:
+		return -orientation(l.Location)
+	case Join:
+		return listOrientation(l)
+	case Order:
+		return listOrientation(l)
+	case nil:
+		return feat.NotOriented
+	}
+	return feat.Forward
+}
+
+func listOrientation(l []Location) feat.Orientation {
+	if len(l) == 0 {
+		return feat.NotOriented
+	}
+	o := orientation(l[0])
+	for _, e := range l[1:] {
+		if orientation(e) != o {
+			return feat.NotOriented
+		}
+	}
+	return o
+}
+
+// ParseLocation parses a GenBank feature table location. Whitespace within the
+// location is ignored. The obsolete single base within a range (a.b) and
+// one-of() location forms are not supported.
+func ParseLocation(s string) (Location, error) {
+	p := locParser{s: strings.Join(strings.Fields(s), "")}
+	l, err := p.location()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, ErrBadLocation
+	}
+	return l, nil
+}
+
+type locParser struct {
+	s   string
+	pos int
+}
+
+func (p *locParser) consume(prefix string) bool {
+	if strings.HasPrefix(p.s[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *locParser) location() (Location, error) {
+	switch {
+	case p.consume("complement("):
+		l, err := p.location()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, ErrBadLocation
+		}
+		return Complement{l}, nil
+	case p.consume("join("):
+		l, err := p.list()
+		return Join(l), err
+	case p.consume("order("):
+		l, err := p.list()
+		return Order(l), err
+	}
+	return p.base()
+}
+
+func (p *locParser) list() ([]Location, error) {
+	var l []Location
+	for {
+		e, err := p.location()
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, e)
+		if p.consume(")") {
+			return l, nil
+		}
+		if !p.consume(",") {
+			return nil, ErrBadLocation
+		}
+	}
+}
+
+func (p *locParser) base() (Location, error) {
+	var acc string
+	if i := strings.IndexAny(p.s[p.pos:], ":,()"); i > 0 && p.s[p.pos+i] == ':' {
+		acc = p.s[p.pos : p.pos+i]
+		p.pos += i + 1
+	}
+
+	startMark := p.mark()
+	from, err := p.int()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.consume(".."):
+		endMark := p.mark()
+		to, err := p.int()
+		if err != nil {
+			return nil, err
+		}
+		if startMark == '>' || endMark == '<' || to < from {
+			return nil, ErrBadLocation
+		}
+		return Span{
+			Accession:    acc,
+			From:         from - 1,
+			To:           to,
+			PartialStart: startMark == '<',
+			PartialEnd:   endMark == '>',
+		}, nil
+	case p.consume("^"):
+		if startMark != 0 {
+			return nil, ErrBadLocation
+		}
+		to, err := p.int()
+		if err != nil {
+			return nil, err
+		}
+		if to != from+1 && to != 1 {
+			return nil, ErrBadLocation
+		}
+		return Between{Accession: acc, From: from, To: to - 1}, nil
+	}
+	return Span{
+		Accession:    acc,
+		From:         from - 1,
+		To:           from,
+		PartialStart: startMark == '<',
+		PartialEnd:   startMark == '>',
+	}, nil
+}
+
+func (p *locParser) mark() byte {
+	if p.pos < len(p.s) && (p.s[p.pos] == '<' || p.s[p.pos] == '>') {
+		p.pos++
+		return p.s[p.pos-1]
+	}
+	return 0
+}
+
+func (p *locParser) int() (int, error) {
+	i := p.pos
+	for i < len(p.s) && '0' <= p.s[i] && p.s[i] <= '9' {
+		i++
+	}
+	if i == p.pos {
+		return 0, ErrBadLocation
+	}
+	v, err := strconv.Atoi(p.s[p.pos:i])
+	if err != nil || v < 1 {
+		return 0, ErrBadLocation
+	}
+	p.pos = i
+	return v, nil
+}
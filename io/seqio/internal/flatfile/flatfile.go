@@ -0,0 +1,71 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package flatfile provides helpers shared by the EMBL and UniProtKB
+// flat-file readers.
+package flatfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// Reader reads the lines of a flat file, counting the lines read.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader returns a new Reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// ReadLine returns the next line without its line ending.
+func (r *Reader) ReadLine() ([]byte, error) {
+	line, err := r.r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return nil, err
+	}
+	r.line++
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// Error returns err wrapped in a *csv.ParseError holding the number of
+// the last line read.
+func (r *Reader) Error(err error) error {
+	return &csv.ParseError{Line: r.line, Err: err}
+}
+
+// Value returns the content of line following the line code indent,
+// without trailing spaces.
+func Value(line []byte, indent int) string {
+	if len(line) <= indent {
+		return ""
+	}
+	return string(bytes.TrimRight(line[indent:], " "))
+}
+
+// Join returns v appended to dst separated by sep, or v if dst is empty.
+func Join(dst, v, sep string) string {
+	if dst == "" {
+		return v
+	}
+	return dst + sep + v
+}
+
+// Split returns the non-empty semicolon separated elements of v.
+func Split(v string) []string {
+	var f []string
+	for _, e := range strings.Split(v, ";") {
+		e = strings.TrimSpace(e)
+		if e != "" {
+			f = append(f, e)
+		}
+	}
+	return f
+}
@@ -0,0 +1,471 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package twobit provides types to read and write UCSC .2bit format files.
+//
+// The .2bit format stores each base in two bits, with runs of N and runs of
+// soft-masked (lowercase) bases held as separate block lists. Sequences are
+// read from a .2bit file with random access by name and range.
+package twobit
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/seqio"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+)
+
+var _ seqio.Writer = (*Writer)(nil)
+
+const (
+	signature        = 0x1a412743
+	swappedSignature = 0x4327411a
+)
+
+var (
+	ErrBadSignature = errors.New("twobit: bad signature")
+	ErrBadVersion   = errors.New("twobit: unsupported version")
+	ErrCorrupt      = errors.New("twobit: corrupt file")
+	ErrNotFound     = errors.New("twobit: sequence not found")
+	ErrOutOfRange   = errors.New("twobit: region out of range")
+	ErrDuplicate    = errors.New("twobit: duplicate sequence name")
+	ErrNameTooLong  = errors.New("twobit: sequence name too long")
+	ErrTooLarge     = errors.New("twobit: sequence too long")
+	ErrClosed       = errors.New("twobit: writer closed")
+)
+
+// Contig is the feat.Feature location of sequences returned by a Reader. It
+// describes a complete sequence held in a .2bit file.
+type Contig struct {
+	ID     string
+	Length int
+}
+
+func (c Contig) Start() int             { return 0 }
+func (c Contig) End() int               { return c.Length }
+func (c Contig) Len() int               { return c.Length }
+func (c Contig) Name() string           { return c.ID }
+func (c Contig) Description() string    { return "2bit sequence" }
+func (c Contig) Location() feat.Feature { return nil }
+
+// block is a half-open run of N or masked bases.
+type block struct {
+	start, end int
+}
+
+// record is the header of a sequence record.
+type record struct {
+	length int
+	nBlock []block
+	mask   []block
+
+	// dna is the file offset of the packed bases.
+	dna int64
+}
+
+// Reader provides random access to the sequences of a .2bit file.
+type Reader struct {
+	r     io.ReaderAt
+	order binary.ByteOrder
+
+	names   []string
+	offsets map[string]int64
+	records map[string]*record
+}
+
+// NewReader returns a Reader that reads the .2bit file provided by r. The
+// file header and sequence index are read by NewReader; sequence records
+// are read as they are required. Both version 0 files and version 1 files,
+// which have 64 bit offsets, are accepted.
+func NewReader(r io.ReaderAt) (*Reader, error) {
+	var h [16]byte
+	_, err := r.ReadAt(h[:], 0)
+	if err != nil {
+		if err == io.EOF {
+			err = ErrCorrupt
+		}
+		return nil, err
+	}
+	tr := &Reader{r: r, offsets: make(map[string]int64), records: make(map[string]*record)}
+	switch binary.LittleEndian.Uint32(h[:4]) {
+	case signature:
+		tr.order = binary.LittleEndian
+	case swappedSignature:
+		tr.order = binary.BigEndian
+	default:
+		return nil, ErrBadSignature
+	}
+	version := tr.order.Uint32(h[4:8])
+	if version > 1 {
+		return nil, ErrBadVersion
+	}
+	count := int(tr.order.Uint32(h[8:12]))
+
+	sr := &sectionReader{r: io.NewSectionReader(r, int64(len(h)), 1<<63-1-int64(len(h)))}
+	tr.names = make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		var n [1]byte
+		sr.read(n[:])
+		name := make([]byte, n[0])
+		sr.read(name)
+		var off int64
+		if version == 0 {
+			var b [4]byte
+			sr.read(b[:])
+			off = int64(tr.order.Uint32(b[:]))
+		} else {
+			var b [8]byte
+			sr.read(b[:])
+			off = int64(tr.order.Uint64(b[:]))
+		}
+		if sr.err != nil {
+			return nil, ErrCorrupt
+		}
+		if _, dup := tr.offsets[string(name)]; dup {
+			return nil, ErrDuplicate
+		}
+		tr.names = append(tr.names, string(name))
+		tr.offsets[string(name)] = off
+	}
+	return tr, nil
+}
+
+// sectionReader is an io.Reader that retains the first error it encounters.
+type sectionReader struct {
+	r   io.Reader
+	err error
+}
+
+func (sr *sectionReader) read(b []byte) {
+	if sr.err != nil {
+		return
+	}
+	_, sr.err = io.ReadFull(sr.r, b)
+}
+
+// Names returns the names of the sequences in the file in index order.
+func (r *Reader) Names() []string { return append([]string(nil), r.names...) }
+
+// Len returns the length of the named sequence.
+func (r *Reader) Len(name string) (int, error) {
+	rec, err := r.record(name)
+	if err != nil {
+		return 0, err
+	}
+	return rec.length, nil
+}
+
+// Seq returns the complete named sequence.
+func (r *Reader) Seq(name string) (*linear.Seq, error) {
+	rec, err := r.record(name)
+	if err != nil {
+		return nil, err
+	}
+	return r.seqRange(name, rec, 0, rec.length)
+}
+
+// SeqRange returns the half-open range [start, end) of the named sequence in
+// zero-based coordinates. N-blocks are restored as 'N' and soft-masked bases
+// are returned in lowercase. The Offset of the returned sequence is start and
+// its Loc is a Contig describing the named sequence.
+func (r *Reader) SeqRange(name string, start, end int) (*linear.Seq, error) {
+	rec, err := r.record(name)
+	if err != nil {
+		return nil, err
+	}
+	return r.seqRange(name, rec, start, end)
+}
+
+// Feature returns the sequence covered by f. If f has a location, the sequence
+// is taken from the sequence named by the location and the Loc of the returned
+// sequence is the location of f, otherwise f is taken to describe a complete
+// sequence, such as a *genome.Chromosome, and is used as the Loc.
+func (r *Reader) Feature(f feat.Feature) (*linear.Seq, error) {
+	loc := f.Location()
+	if loc == nil {
+		loc = f
+	}
+	s, err := r.SeqRange(loc.Name(), f.Start(), f.End())
+	if err != nil {
+		return nil, err
+	}
+	s.Loc = loc
+	return s, nil
+}
+
+func (r *Reader) seqRange(name string, rec *record, start, end int) (*linear.Seq, error) {
+	if start < 0 || end < start || rec.length < end {
+		return nil, ErrOutOfRange
+	}
+
+	b := make([]byte, end-start)
+	if start < end {
+		first := start / 4
+		packed := make([]byte, (end+3)/4-first)
+		n, err := r.r.ReadAt(packed, rec.dna+int64(first))
+		if n < len(packed) {
+			if err == nil || err == io.EOF {
+				err = ErrCorrupt
+			}
+			return nil, err
+		}
+		for i := start; i < end; i++ {
+			b[i-start] = bases[packed[i/4-first]>>uint(6-2*(i%4))&0x3]
+		}
+		for _, blk := range overlapping(rec.nBlock, start, end) {
+			for i := max(blk.start, start); i < min(blk.end, end); i++ {
+				b[i-start] = 'N'
+			}
+		}
+		for _, blk := range overlapping(rec.mask, start, end) {
+			for i := max(blk.start, start); i < min(blk.end, end); i++ {
+				b[i-start] |= 0x20
+			}
+		}
+	}
+
+	s := linear.NewSeq(name, alphabet.BytesToLetters(b), alphabet.DNA)
+	s.Offset = start
+	s.Loc = Contig{ID: name, Length: rec.length}
+	return s, nil
+}
+
+// bases maps 2 bit codes to bases.
+var bases = [4]byte{'T', 'C', 'A', 'G'}
+
+// overlapping returns the blocks in the sorted non-overlapping blocks list
+// that overlap [start, end).
+func overlapping(blocks []block, start, end int) []block {
+	i := sort.Search(len(blocks), func(i int) bool { return blocks[i].end > start })
+	j := i
+	for j < len(blocks) && blocks[j].start < end {
+		j++
+	}
+	return blocks[i:j]
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// record returns the header of the named sequence record, reading it if
+// necessary.
+func (r *Reader) record(name string) (*record, error) {
+	if rec, ok := r.records[name]; ok {
+		return rec, nil
+	}
+	off, ok := r.offsets[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	sr := &sectionReader{r: io.NewSectionReader(r.r, off, 1<<63-1-off)}
+	var b [4]byte
+	u32 := func() int {
+		sr.read(b[:])
+		return int(r.order.Uint32(b[:]))
+	}
+	blocks := func() []block {
+		n := u32()
+		if sr.err != nil || n < 0 || n > 1<<28 {
+			sr.err = ErrCorrupt
+			return nil
+		}
+		starts := make([]uint32, n)
+		sizes := make([]uint32, n)
+		if sr.err == nil {
+			sr.err = binary.Read(sr.r, r.order, starts)
+		}
+		if sr.err == nil {
+			sr.err = binary.Read(sr.r, r.order, sizes)
+		}
+		blk := make([]block, n)
+		for i := range blk {
+			blk[i] = block{start: int(starts[i]), end: int(starts[i] + sizes[i])}
+		}
+		return blk
+	}
+
+	rec := &record{length: u32()}
+	rec.nBlock = blocks()
+	rec.mask = blocks()
+	u32() // Reserved.
+	if sr.err != nil {
+		return nil, ErrCorrupt
+	}
+	rec.dna = off + int64(4*(4+2*len(rec.nBlock)+2*len(rec.mask)))
+	r.records[name] = rec
+	return rec, nil
+}
+
+// Writer packs sequences into the .2bit format. Since the .2bit index must
+// precede the sequence data, packed sequences are held by the Writer until
+// Close is called.
+type Writer struct {
+	w io.Writer
+
+	names   []string
+	seen    map[string]bool
+	records [][]byte
+
+	closed bool
+}
+
+// NewWriter returns a new .2bit format writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, seen: make(map[string]bool)}
+}
+
+// Write packs s into a .2bit sequence record, returning the size of the packed
+// record and any error. Letters other than A, C, G and T are stored as N, and
+// lowercase letters are stored as soft-masked. Sequence names must be unique
+// and no longer than 255 bytes.
+func (w *Writer) Write(s seq.Sequence) (n int, err error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+	name := s.Name()
+	if len(name) > 255 {
+		return 0, ErrNameTooLong
+	}
+	if w.seen[name] {
+		return 0, ErrDuplicate
+	}
+	if uint64(s.Len()) > 1<<32-1 {
+		return 0, ErrTooLarge
+	}
+
+	var (
+		nBlock, mask []block
+		packed       = make([]byte, (s.Len()+3)/4)
+	)
+	for i := 0; i < s.Len(); i++ {
+		l := byte(s.At(i).L)
+		var code byte
+		switch l | 0x20 {
+		case 't':
+			code = 0
+		case 'c':
+			code = 1
+		case 'a':
+			code = 2
+		case 'g':
+			code = 3
+		default:
+			nBlock = extend(nBlock, i)
+		}
+		if 'a' <= l && l <= 'z' {
+			mask = extend(mask, i)
+		}
+		packed[i/4] |= code << uint(6-2*(i%4))
+	}
+
+	rec := make([]byte, 0, 4*(4+2*len(nBlock)+2*len(mask))+len(packed))
+	rec = w.appendUint32(rec, s.Len())
+	rec = w.appendBlocks(rec, nBlock)
+	rec = w.appendBlocks(rec, mask)
+	rec = w.appendUint32(rec, 0)
+	rec = append(rec, packed...)
+
+	w.names = append(w.names, name)
+	w.seen[name] = true
+	w.records = append(w.records, rec)
+	return len(rec), nil
+}
+
+// extend adds position i to the final block in blocks, or starts a new block
+// if i is not adjacent to it.
+func extend(blocks []block, i int) []block {
+	if len(blocks) != 0 && blocks[len(blocks)-1].end == i {
+		blocks[len(blocks)-1].end++
+		return blocks
+	}
+	return append(blocks, block{start: i, end: i + 1})
+}
+
+func (w *Writer) appendUint32(b []byte, v int) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(v))
+	return append(b, buf[:]...)
+}
+
+func (w *Writer) appendBlocks(b []byte, blocks []block) []byte {
+	b = w.appendUint32(b, len(blocks))
+	for _, blk := range blocks {
+		b = w.appendUint32(b, blk.start)
+	}
+	for _, blk := range blocks {
+		b = w.appendUint32(b, blk.end-blk.start)
+	}
+	return b
+}
+
+// Close writes the .2bit header, index and the packed sequences to the
+// underlying io.Writer. A version 1 file with 64 bit offsets is written if
+// the file would be larger than 4GiB. Close does not close the underlying
+// io.Writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	index := 16
+	for _, n := range w.names {
+		index += 1 + len(n) + 4
+	}
+	size := int64(index)
+	for _, rec := range w.records {
+		size += int64(len(rec))
+	}
+	var version uint32
+	offWidth := 4
+	if size > 1<<32-1 {
+		version = 1
+		offWidth = 8
+		index += 4 * len(w.names)
+	}
+
+	b := make([]byte, 16, index)
+	binary.LittleEndian.PutUint32(b[0:4], signature)
+	binary.LittleEndian.PutUint32(b[4:8], version)
+	binary.LittleEndian.PutUint32(b[8:12], uint32(len(w.names)))
+	off := uint64(index)
+	for i, n := range w.names {
+		b = append(b, byte(len(n)))
+		b = append(b, n...)
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], off)
+		b = append(b, buf[:offWidth]...)
+		off += uint64(len(w.records[i]))
+	}
+	_, err := w.w.Write(b)
+	if err != nil {
+		return err
+	}
+	for _, rec := range w.records {
+		_, err = w.w.Write(rec)
+		if err != nil {
+			return err
+		}
+	}
+	w.records = nil
+	return nil
+}
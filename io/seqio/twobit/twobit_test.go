@@ -0,0 +1,154 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package twobit
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat/genome"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+// twoBitFile is a hand packed .2bit file holding the sequence
+// "ACGTNNacgT" named "chrT".
+var twoBitFile = func() []byte {
+	var b bytes.Buffer
+	w := func(v ...uint32) { binary.Write(&b, binary.LittleEndian, v) }
+	w(signature, 0, 1, 0)
+	b.WriteByte(4)
+	b.WriteString("chrT")
+	w(16 + 1 + 4 + 4)
+	w(10)      // dnaSize
+	w(1, 4, 2) // nBlockCount, nBlockStarts, nBlockSizes
+	w(1, 6, 3) // maskBlockCount, maskBlockStarts, maskBlockSizes
+	w(0)       // reserved
+	b.Write([]byte{
+		0x9c, // ACGT: 10 01 11 00
+		0x09, // NNac: 00 00 10 01 (N stored as T)
+		0xc0, // gT: 11 00 00 00
+	})
+	return b.Bytes()
+}()
+
+func (s *S) TestReadPacked(c *check.C) {
+	r, err := NewReader(bytes.NewReader(twoBitFile))
+	c.Assert(err, check.Equals, nil)
+	c.Check(r.Names(), check.DeepEquals, []string{"chrT"})
+	n, err := r.Len("chrT")
+	c.Check(err, check.Equals, nil)
+	c.Check(n, check.Equals, 10)
+
+	sq, err := r.Seq("chrT")
+	c.Assert(err, check.Equals, nil)
+	c.Check(sq.Seq, check.DeepEquals, alphabet.Letters("ACGTNNacgT"))
+	c.Check(sq.Loc, check.Equals, Contig{ID: "chrT", Length: 10})
+
+	_, err = r.Seq("chrU")
+	c.Check(err, check.Equals, ErrNotFound)
+	_, err = r.SeqRange("chrT", 5, 11)
+	c.Check(err, check.Equals, ErrOutOfRange)
+	_, err = NewReader(bytes.NewReader([]byte("not a 2bit file!")))
+	c.Check(err, check.Equals, ErrBadSignature)
+}
+
+func (s *S) TestBigEndian(c *check.C) {
+	var b bytes.Buffer
+	w := func(v ...uint32) { binary.Write(&b, binary.BigEndian, v) }
+	w(signature, 0, 1, 0)
+	b.WriteByte(1)
+	b.WriteString("x")
+	w(16 + 1 + 1 + 4)
+	w(5, 0, 0, 0)
+	b.Write([]byte{0x9c, 0x80})
+
+	r, err := NewReader(bytes.NewReader(b.Bytes()))
+	c.Assert(err, check.Equals, nil)
+	sq, err := r.Seq("x")
+	c.Assert(err, check.Equals, nil)
+	c.Check(sq.Seq, check.DeepEquals, alphabet.Letters("ACGTA"))
+}
+
+var testSeqs = []*linear.Seq{
+	linear.NewSeq("chr1", alphabet.Letters("NNNNacgtACGTnnnnACGTacgtNNNNAGCTTCGAacgtnNnN"), alphabet.DNA),
+	linear.NewSeq("chr2", alphabet.Letters("G"), alphabet.DNA),
+	linear.NewSeq("empty", nil, alphabet.DNA),
+	linear.NewSeq("chrM", alphabet.Letters("gattacaRYgattacaNNNNNNNNNNNNNNNNNNNNNNNNNNNNNNNNNNNNNgattaca"), alphabet.DNAredundant),
+}
+
+func (s *S) TestRoundTrip(c *check.C) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, sq := range testSeqs {
+		_, err := w.Write(sq)
+		c.Assert(err, check.Equals, nil)
+	}
+	_, err := w.Write(testSeqs[0])
+	c.Check(err, check.Equals, ErrDuplicate)
+	c.Assert(w.Close(), check.Equals, nil)
+	_, err = w.Write(testSeqs[0])
+	c.Check(err, check.Equals, ErrClosed)
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	c.Assert(err, check.Equals, nil)
+	c.Check(r.Names(), check.DeepEquals, []string{"chr1", "chr2", "empty", "chrM"})
+	for _, sq := range testSeqs {
+		want := make(alphabet.Letters, len(sq.Seq))
+		for i, l := range sq.Seq {
+			switch l | 0x20 {
+			case 'a', 'c', 'g', 't':
+				want[i] = l
+			default:
+				want[i] = 'N' | l&0x20
+			}
+		}
+
+		got, err := r.Seq(sq.Name())
+		c.Assert(err, check.Equals, nil)
+		c.Check(got.Seq.String(), check.Equals, want.String(), check.Commentf("%s", sq.Name()))
+		for start := 0; start <= len(want); start++ {
+			for end := start; end <= len(want); end++ {
+				got, err := r.SeqRange(sq.Name(), start, end)
+				c.Assert(err, check.Equals, nil)
+				c.Check(got.Offset, check.Equals, start)
+				c.Check(got.Seq.String(), check.Equals, want[start:end].String(), check.Commentf("%s [%d,%d)", sq.Name(), start, end))
+			}
+		}
+	}
+}
+
+func (s *S) TestFeature(c *check.C) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err := w.Write(testSeqs[0])
+	c.Assert(err, check.Equals, nil)
+	c.Assert(w.Close(), check.Equals, nil)
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	c.Assert(err, check.Equals, nil)
+
+	chr := &genome.Chromosome{Chr: "chr1", Length: len(testSeqs[0].Seq)}
+	sq, err := r.Feature(chr)
+	c.Assert(err, check.Equals, nil)
+	c.Check(sq.Seq, check.DeepEquals, testSeqs[0].Seq)
+	c.Check(sq.Loc, check.Equals, chr)
+
+	band := &genome.Band{Band: "p1", Chr: chr, StartPos: 4, EndPos: 12}
+	sq, err = r.Feature(band)
+	c.Assert(err, check.Equals, nil)
+	c.Check(sq.Seq, check.DeepEquals, alphabet.Letters("acgtACGT"))
+	c.Check(sq.Offset, check.Equals, 4)
+	c.Check(sq.Loc, check.Equals, chr)
+}
@@ -0,0 +1,461 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package uniprot provides types to read UniProtKB/Swiss-Prot text format files.
+package uniprot
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/seqio"
+	"github.com/biogo/biogo/io/seqio/genbank"
+	"github.com/biogo/biogo/io/seqio/internal/flatfile"
+	"github.com/biogo/biogo/seq"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	_ seqio.Reader = (*Reader)(nil)
+
+	_ feat.Feature = (*Feature)(nil)
+)
+
+var (
+	ErrNoID           = errors.New("uniprot: missing ID line")
+	ErrBadID          = errors.New("uniprot: malformed ID line")
+	ErrBadLine        = errors.New("uniprot: malformed line")
+	ErrBadFeature     = errors.New("uniprot: malformed feature")
+	ErrBadPosition    = errors.New("uniprot: malformed feature position")
+	ErrLengthMismatch = errors.New("uniprot: sequence length does not match ID length")
+	ErrUnterminated   = errors.New("uniprot: unterminated entry")
+)
+
+// Layout of the flat-file format.
+const (
+	valueIndent   = 5
+	featureIndent = 21
+)
+
+// Fuzz describes the certainty of a feature end point.
+type Fuzz byte
+
+const (
+	Exact   Fuzz = iota // The position is known.
+	Before              // The feature extends before the position, written <n.
+	After               // The feature extends after the position, written >n.
+	Unknown             // The position is uncertain, written ?n, or unknown, written ?.
+)
+
+func (f Fuzz) String() string {
+	switch f {
+	case Exact:
+		return ""
+	case Before:
+		return "<"
+	case After:
+		return ">"
+	case Unknown:
+		return "?"
+	}
+	panic("uniprot: illegal fuzz")
+}
+
+// A Feature is a UniProtKB FT line feature. FeatStart and FeatEnd are zero-based
+// half-open. An unknown start position is given as zero and an unknown end
+// position as the length of the entry.
+type Feature struct {
+	Key                string
+	FeatStart, FeatEnd int
+	StartFuzz, EndFuzz Fuzz
+	Qualifiers         genbank.Qualifiers
+
+	// Parent is the feature location, usually
+	// the sequence of the entry holding the
+	// feature.
+	Parent feat.Feature
+}
+
+func (f *Feature) Start() int { return f.FeatStart }
+func (f *Feature) End() int   { return f.FeatEnd }
+func (f *Feature) Len() int   { return f.FeatEnd - f.FeatStart }
+
+// Name returns the value of the note qualifier of the feature, or the feature
+// key if it has no note.
+func (f *Feature) Name() string {
+	if n := f.Qualifiers.Get("note"); n != "" {
+		return n
+	}
+	return f.Key
+}
+
+func (f *Feature) Description() string    { return f.Key }
+func (f *Feature) Location() feat.Feature { return f.Parent }
+
+// A Reference is a citation associated with a UniProtKB entry.
+type Reference struct {
+	Number    int      // RN
+	Positions string   // RP
+	Comment   string   // RC
+	CrossRefs []string // RX
+	Group     string   // RG
+	Authors   string   // RA
+	Title     string   // RT
+	Location  string   // RL
+}
+
+// Metadata holds the header fields of a UniProtKB entry. Multi-line fields
+// are joined with spaces, except the DE and CC fields which retain their
+// line structure.
+type Metadata struct {
+	// Fields of the ID line.
+	Name   string
+	Status string
+	Length int
+
+	Accessions     []string // AC
+	Dates          []string // DT
+	Description    string   // DE
+	Genes          string   // GN
+	Species        string   // OS
+	Organelle      string   // OG
+	Classification string   // OC
+	TaxonomyID     string   // OX
+	Hosts          []string // OH
+	References     []Reference
+	Comment        string   // CC
+	CrossRefs      []string // DR
+	Evidence       string   // PE
+	Keywords       []string // KW
+
+	// Fields of the SQ line.
+	Weight int
+	CRC64  string
+}
+
+// RecommendedName returns the full recommended name of the entry given in
+// the DE lines, or the full submitted name for unreviewed entries.
+func (m *Metadata) RecommendedName() string {
+	for _, l := range strings.Split(m.Description, "\n") {
+		for _, p := range []string{"RecName: Full=", "SubName: Full="} {
+			if strings.HasPrefix(l, p) {
+				n := strings.TrimSuffix(l[len(p):], ";")
+				if i := strings.Index(n, " {"); i >= 0 {
+					n = n[:i]
+				}
+				return n
+			}
+		}
+	}
+	return ""
+}
+
+// A Record is a complete UniProtKB entry.
+type Record struct {
+	Metadata
+	Features []*Feature
+	Seq      *linear.Seq
+}
+
+// Reader is a UniProtKB text format reader.
+type Reader struct {
+	r *flatfile.Reader
+}
+
+// NewReader returns a new UniProtKB text format reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: flatfile.NewReader(r)}
+}
+
+// Read reads a single UniProtKB entry and returns its sequence as a *linear.Seq.
+// The features and header fields of the entry are discarded; use ReadRecord
+// to obtain them.
+func (r *Reader) Read() (seq.Sequence, error) {
+	rec, err := r.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+	return rec.Seq, nil
+}
+
+// ReadRecord reads and returns a single complete UniProtKB entry. The ID
+// and Desc fields of the returned sequence are set from the entry name and
+// the recommended name, the alphabet is alphabet.Protein, and the Parent of
+// each feature is the returned sequence. Both the current FT line format and
+// the pre-2019 columnar format are accepted; descriptions in the columnar
+// format are returned as a note qualifier.
+func (r *Reader) ReadRecord() (*Record, error) {
+	var line []byte
+	for {
+		var err error
+		line, err = r.r.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) != 0 {
+			break
+		}
+	}
+	if !bytes.HasPrefix(line, []byte("ID   ")) {
+		return nil, r.r.Error(ErrNoID)
+	}
+	rec := &Record{}
+	err := rec.parseID(flatfile.Value(line, valueIndent))
+	if err != nil {
+		return nil, r.r.Error(err)
+	}
+	rec.Seq = linear.NewSeq(rec.Name, nil, alphabet.Protein)
+
+	var (
+		ref  *Reference
+		key  string
+		ft   []string
+		last string
+	)
+	for {
+		line, err = r.r.ReadLine()
+		if err != nil {
+			if err == io.EOF {
+				err = r.r.Error(ErrUnterminated)
+			}
+			return nil, err
+		}
+		if len(line) < 2 {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			return nil, r.r.Error(ErrBadLine)
+		}
+		code := string(line[:2])
+		if key != "" && code != "FT" {
+			err = rec.addFeature(key, ft)
+			if err != nil {
+				return nil, r.r.Error(err)
+			}
+			key = ""
+		}
+		v := flatfile.Value(line, valueIndent)
+		switch code {
+		case "//":
+			if len(rec.Seq.Seq) != rec.Length {
+				return nil, r.r.Error(ErrLengthMismatch)
+			}
+			rec.Seq.Desc = rec.RecommendedName()
+			return rec, nil
+		case "XX":
+		case "SQ":
+			err = rec.parseSQ(v)
+			if err != nil {
+				return nil, r.r.Error(err)
+			}
+		case "  ":
+			if last != "SQ" && last != "  " {
+				return nil, r.r.Error(ErrBadLine)
+			}
+			for _, c := range line {
+				if c != ' ' {
+					rec.Seq.Seq = append(rec.Seq.Seq, alphabet.Letter(c))
+				}
+			}
+		case "AC":
+			rec.Accessions = append(rec.Accessions, flatfile.Split(v)...)
+		case "DT":
+			rec.Dates = append(rec.Dates, v)
+		case "DE":
+			rec.Description = flatfile.Join(rec.Description, v, "\n")
+		case "GN":
+			rec.Genes = flatfile.Join(rec.Genes, v, " ")
+		case "OS":
+			rec.Species = flatfile.Join(rec.Species, v, " ")
+		case "OG":
+			rec.Organelle = flatfile.Join(rec.Organelle, v, " ")
+		case "OC":
+			rec.Classification = flatfile.Join(rec.Classification, v, " ")
+		case "OX":
+			rec.TaxonomyID = flatfile.Join(rec.TaxonomyID, v, " ")
+		case "OH":
+			rec.Hosts = append(rec.Hosts, v)
+		case "CC":
+			rec.Comment = flatfile.Join(rec.Comment, v, "\n")
+		case "DR":
+			rec.CrossRefs = append(rec.CrossRefs, v)
+		case "PE":
+			rec.Evidence = v
+		case "KW":
+			rec.Keywords = append(rec.Keywords, flatfile.Split(strings.TrimSuffix(v, "."))...)
+		case "RN":
+			f := strings.Fields(v)
+			if len(f) == 0 {
+				return nil, r.r.Error(ErrBadLine)
+			}
+			n, err := strconv.Atoi(strings.Trim(f[0], "[]"))
+			if err != nil {
+				return nil, r.r.Error(ErrBadLine)
+			}
+			rec.References = append(rec.References, Reference{Number: n})
+			ref = &rec.References[len(rec.References)-1]
+		case "RP", "RC", "RX", "RG", "RA", "RT", "RL":
+			if ref == nil {
+				return nil, r.r.Error(ErrBadLine)
+			}
+			switch code {
+			case "RP":
+				ref.Positions = flatfile.Join(ref.Positions, v, " ")
+			case "RC":
+				ref.Comment = flatfile.Join(ref.Comment, v, " ")
+			case "RX":
+				ref.CrossRefs = append(ref.CrossRefs, v)
+			case "RG":
+				ref.Group = flatfile.Join(ref.Group, v, " ")
+			case "RA":
+				ref.Authors = flatfile.Join(ref.Authors, v, " ")
+			case "RT":
+				ref.Title = flatfile.Join(ref.Title, v, " ")
+			case "RL":
+				ref.Location = flatfile.Join(ref.Location, v, " ")
+			}
+		case "FT":
+			if len(line) > valueIndent && line[valueIndent] != ' ' {
+				if key != "" {
+					err = rec.addFeature(key, ft)
+					if err != nil {
+						return nil, r.r.Error(err)
+					}
+				}
+				key = strings.Fields(v)[0]
+				ft = []string{strings.TrimSpace(strings.TrimPrefix(v, key))}
+			} else {
+				if key == "" {
+					return nil, r.r.Error(ErrBadFeature)
+				}
+				ft = append(ft, flatfile.Value(line, featureIndent))
+			}
+		}
+		last = code
+	}
+}
+
+// parseID parses the value of an ID line, for example
+//
+//	CYC_HUMAN               Reviewed;         105 AA.
+func (m *Metadata) parseID(v string) error {
+	f := strings.Fields(strings.Replace(strings.TrimSuffix(v, "."), ";", " ", -1))
+	if len(f) != 4 || f[3] != "AA" {
+		return ErrBadID
+	}
+	m.Name, m.Status = f[0], f[1]
+	var err error
+	m.Length, err = strconv.Atoi(f[2])
+	if err != nil {
+		return ErrBadID
+	}
+	return nil
+}
+
+// parseSQ parses the value of an SQ line, for example
+//
+//	SEQUENCE   105 AA;  11749 MW;  3B6C6B4EA9B5D8B0 CRC64;
+func (m *Metadata) parseSQ(v string) error {
+	f := strings.Fields(strings.Replace(v, ";", " ", -1))
+	if len(f) != 7 || f[0] != "SEQUENCE" || f[2] != "AA" || f[4] != "MW" || f[6] != "CRC64" {
+		return ErrBadLine
+	}
+	var err error
+	m.Weight, err = strconv.Atoi(f[3])
+	if err != nil {
+		return ErrBadLine
+	}
+	m.CRC64 = f[5]
+	return nil
+}
+
+// addFeature adds the feature with the given key and location and qualifier
+// lines to the record.
+func (rec *Record) addFeature(key string, lines []string) error {
+	f := &Feature{Key: key, Parent: rec.Seq}
+	loc := strings.Fields(lines[0])
+	var err error
+	switch {
+	case len(loc) == 1:
+		// Current format: FT   KEY             from..to
+		err = f.setPositions(rec.Length, strings.SplitN(loc[0], "..", 2)...)
+		if err != nil {
+			return err
+		}
+		f.Qualifiers, err = genbank.ParseQualifiers(lines[1:])
+		if err != nil {
+			return err
+		}
+	case len(loc) >= 2:
+		// Columnar format: FT   KEY        from    to       description
+		err = f.setPositions(rec.Length, loc[0], loc[1])
+		if err != nil {
+			return err
+		}
+		desc := strings.Join(loc[2:], " ")
+		for _, l := range lines[1:] {
+			desc = flatfile.Join(desc, strings.TrimSpace(l), " ")
+		}
+		if desc != "" {
+			f.Qualifiers = genbank.Qualifiers{{Name: "note", Value: desc, Quoted: true}}
+		}
+	default:
+		return ErrBadFeature
+	}
+	rec.Features = append(rec.Features, f)
+	return nil
+}
+
+// setPositions sets the start and end of f from the one-based closed
+// positions in pos. If pos holds a single position, the feature covers
+// that residue.
+func (f *Feature) setPositions(length int, pos ...string) error {
+	var err error
+	f.FeatStart, f.StartFuzz, err = position(pos[0], 1)
+	if err != nil {
+		return err
+	}
+	f.FeatStart--
+	end := pos[len(pos)-1]
+	f.FeatEnd, f.EndFuzz, err = position(end, length)
+	if err != nil {
+		return err
+	}
+	if f.FeatEnd < f.FeatStart {
+		return ErrBadPosition
+	}
+	return nil
+}
+
+// position returns the position and fuzz described by s, using unknown
+// for unknown positions.
+func position(s string, unknown int) (int, Fuzz, error) {
+	fuzz := Exact
+	if len(s) != 0 {
+		switch s[0] {
+		case '<':
+			fuzz = Before
+		case '>':
+			fuzz = After
+		case '?':
+			fuzz = Unknown
+		}
+	}
+	if fuzz != Exact {
+		s = s[1:]
+	}
+	if s == "" && fuzz == Unknown {
+		return unknown, fuzz, nil
+	}
+	p, err := strconv.Atoi(s)
+	if err != nil || p < 1 {
+		return 0, fuzz, ErrBadPosition
+	}
+	return p, fuzz, nil
+}
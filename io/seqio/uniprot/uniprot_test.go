@@ -0,0 +1,161 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package uniprot
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/feat"
+	"github.com/biogo/biogo/io/seqio/genbank"
+
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const entries = `ID   CYC_HUMAN               Reviewed;         105 AA.
+AC   P99999; B2R4P3; Q6NUR2;
+DT   21-JUL-1986, integrated into UniProtKB/Swiss-Prot.
+DE   RecName: Full=Cytochrome c {ECO:0000303|PubMed:00000000};
+DE   AltName: Full=Test name;
+GN   Name=CYCS; Synonyms=CYC;
+OS   Homo sapiens (Human).
+OC   Eukaryota; Metazoa; Chordata; Craniata; Vertebrata; Euteleostomi;
+OC   Mammalia; Eutheria; Euarchontoglires; Primates; Haplorrhini;
+OC   Catarrhini; Hominidae; Homo.
+OX   NCBI_TaxID=9606;
+RN   [1]
+RP   NUCLEOTIDE SEQUENCE [MRNA].
+RX   PubMed=00000000; DOI=10.0000/xxxx;
+RA   Doe J., Roe R.;
+RT   "A test title that is long enough to be wrapped across two
+RT   lines.";
+RL   J. Test. 1:1-2(2016).
+CC   -!- FUNCTION: Electron carrier protein. The reduced form of the
+CC       cytochrome c donates an electron.
+DR   EMBL; M22877; AAA35732.1; -; mRNA.
+PE   1: Evidence at protein level;
+KW   3D-structure; Acetylation; Apoptosis; Electron transport.
+FT   INIT_MET        1
+FT                   /note="Removed"
+FT   CHAIN           2..105
+FT                   /note="Cytochrome c"
+FT                   /id="PRO_0000108218"
+FT   BINDING         ?..18
+FT                   /ligand="heme c"
+FT   REGION          <50..>60
+SQ   SEQUENCE   105 AA;  11749 MW;  3B6C6B4EA9B5D8B0 CRC64;
+     MGDVEKGKKI FIMKCSQCHT VEKGGKHKTG PNLHGLFGRK TGQAPGYSYT AANKNKGIIW
+     GEDTLMEYLE NPKKYIPGTK MIFVGIKKKE ERADLIAYLK KATNE
+//
+ID   TEST_OLD                Unreviewed;        10 AA.
+AC   Q00000;
+DE   SubName: Full=Old format entry;
+FT   CHAIN         1     10       Test chain
+FT                                protein.
+FT   SITE          ?      4       Site.
+SQ   SEQUENCE   10 AA;  1000 MW;  0000000000000000 CRC64;
+     MSTVMSTVMS
+//
+`
+
+func (s *S) TestReadRecord(c *check.C) {
+	r := NewReader(strings.NewReader(entries))
+	rec, err := r.ReadRecord()
+	c.Assert(err, check.Equals, nil)
+
+	c.Check(rec.Name, check.Equals, "CYC_HUMAN")
+	c.Check(rec.Status, check.Equals, "Reviewed")
+	c.Check(rec.Length, check.Equals, 105)
+	c.Check(rec.Accessions, check.DeepEquals, []string{"P99999", "B2R4P3", "Q6NUR2"})
+	c.Check(rec.RecommendedName(), check.Equals, "Cytochrome c")
+	c.Check(rec.Genes, check.Equals, "Name=CYCS; Synonyms=CYC;")
+	c.Check(rec.Classification, check.Equals, "Eukaryota; Metazoa; Chordata; Craniata; Vertebrata; Euteleostomi; "+
+		"Mammalia; Eutheria; Euarchontoglires; Primates; Haplorrhini; Catarrhini; Hominidae; Homo.")
+	c.Check(rec.TaxonomyID, check.Equals, "NCBI_TaxID=9606;")
+	c.Check(rec.References, check.HasLen, 1)
+	c.Check(rec.References[0].Title, check.Equals, `"A test title that is long enough to be wrapped across two lines.";`)
+	c.Check(rec.References[0].CrossRefs, check.DeepEquals, []string{"PubMed=00000000; DOI=10.0000/xxxx;"})
+	c.Check(rec.Comment, check.Equals, "-!- FUNCTION: Electron carrier protein. The reduced form of the\n    cytochrome c donates an electron.")
+	c.Check(rec.CrossRefs, check.DeepEquals, []string{"EMBL; M22877; AAA35732.1; -; mRNA."})
+	c.Check(rec.Evidence, check.Equals, "1: Evidence at protein level;")
+	c.Check(rec.Keywords, check.DeepEquals, []string{"3D-structure", "Acetylation", "Apoptosis", "Electron transport"})
+	c.Check(rec.Weight, check.Equals, 11749)
+	c.Check(rec.CRC64, check.Equals, "3B6C6B4EA9B5D8B0")
+
+	c.Check(rec.Seq.Name(), check.Equals, "CYC_HUMAN")
+	c.Check(rec.Seq.Description(), check.Equals, "Cytochrome c")
+	c.Check(rec.Seq.Alphabet(), check.Equals, alphabet.Protein)
+	c.Check(rec.Seq.Moltype(), check.Equals, feat.Protein)
+	c.Check(rec.Seq.Len(), check.Equals, 105)
+	c.Check(rec.Seq.Seq[:10], check.DeepEquals, alphabet.Letters("MGDVEKGKKI"))
+
+	c.Assert(rec.Features, check.HasLen, 4)
+	for i, t := range []struct {
+		key, name  string
+		start, end int
+		sf, ef     Fuzz
+	}{
+		{"INIT_MET", "Removed", 0, 1, Exact, Exact},
+		{"CHAIN", "Cytochrome c", 1, 105, Exact, Exact},
+		{"BINDING", "BINDING", 0, 18, Unknown, Exact},
+		{"REGION", "REGION", 49, 60, Before, After},
+	} {
+		f := rec.Features[i]
+		c.Check(f.Key, check.Equals, t.key)
+		c.Check(f.Description(), check.Equals, t.key)
+		c.Check(f.Name(), check.Equals, t.name)
+		c.Check(f.Start(), check.Equals, t.start)
+		c.Check(f.End(), check.Equals, t.end)
+		c.Check(f.StartFuzz, check.Equals, t.sf)
+		c.Check(f.EndFuzz, check.Equals, t.ef)
+		c.Check(f.Location(), check.Equals, rec.Seq)
+	}
+	c.Check(rec.Features[1].Qualifiers, check.DeepEquals, genbank.Qualifiers{
+		{Name: "note", Value: "Cytochrome c", Quoted: true},
+		{Name: "id", Value: "PRO_0000108218", Quoted: true},
+	})
+
+	rec, err = r.ReadRecord()
+	c.Assert(err, check.Equals, nil)
+	c.Check(rec.Seq.Description(), check.Equals, "Old format entry")
+	c.Assert(rec.Features, check.HasLen, 2)
+	c.Check(rec.Features[0].Name(), check.Equals, "Test chain protein.")
+	c.Check(rec.Features[0].Start(), check.Equals, 0)
+	c.Check(rec.Features[0].End(), check.Equals, 10)
+	c.Check(rec.Features[1].StartFuzz, check.Equals, Unknown)
+	c.Check(rec.Features[1].End(), check.Equals, 4)
+
+	_, err = r.ReadRecord()
+	c.Check(err, check.Equals, io.EOF)
+}
+
+func (s *S) TestReadErrors(c *check.C) {
+	for _, t := range []struct {
+		in  string
+		err error
+	}{
+		{"ID   X; SV 1; linear; DNA; STD; SYN; 10 BP.\n", ErrBadID},
+		{"AC   P99999;\n", ErrNoID},
+		{"ID   X  Reviewed;  5 AA.\nSQ   SEQUENCE   5 AA;  500 MW;  0 CRC64;\n     MST\n//\n", ErrLengthMismatch},
+		{"ID   X  Reviewed;  5 AA.\nFT   CHAIN           4..2\nSQ   SEQUENCE   5 AA;  500 MW;  0 CRC64;\n     MSTVM\n//\n", ErrBadPosition},
+		{"ID   X  Reviewed;  5 AA.\nSQ   SEQUENCE   5 AA;\n     MSTVM\n//\n", ErrBadLine},
+		{"ID   X  Reviewed;  5 AA.\n", ErrUnterminated},
+	} {
+		_, err := NewReader(strings.NewReader(t.in)).ReadRecord()
+		pe, ok := err.(*csv.ParseError)
+		c.Assert(ok, check.Equals, true, check.Commentf("%q: %v", t.in, err))
+		c.Check(pe.Err, check.Equals, t.err, check.Commentf("%q", t.in))
+	}
+}
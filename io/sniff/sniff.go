@@ -0,0 +1,405 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sniff provides format detection for sequence and feature streams.
+//
+// The format of a stream is determined by peeking at its first bytes, after
+// transparently removing gzip or BGZF compression, and the matching seqio or
+// featio reader is constructed. This allows programs to accept any supported
+// file without requiring the user to name its format.
+package sniff
+
+import (
+	"github.com/biogo/biogo/io/bgzf"
+	"github.com/biogo/biogo/io/featio"
+	"github.com/biogo/biogo/io/featio/bam"
+	"github.com/biogo/biogo/io/featio/bed"
+	"github.com/biogo/biogo/io/featio/gff"
+	"github.com/biogo/biogo/io/featio/gff3"
+	"github.com/biogo/biogo/io/featio/gtf"
+	"github.com/biogo/biogo/io/featio/sam"
+	"github.com/biogo/biogo/io/featio/vcf"
+	"github.com/biogo/biogo/io/seqio"
+	"github.com/biogo/biogo/io/seqio/embl"
+	"github.com/biogo/biogo/io/seqio/fasta"
+	"github.com/biogo/biogo/io/seqio/fastq"
+	"github.com/biogo/biogo/io/seqio/genbank"
+	"github.com/biogo/biogo/io/seqio/uniprot"
+
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownFormat = errors.New("sniff: unknown format")
+	ErrNotSequence   = errors.New("sniff: not a sequence format")
+	ErrNotFeature    = errors.New("sniff: not a feature format")
+)
+
+// Format is a file format.
+type Format int
+
+const (
+	Unknown Format = iota
+
+	// Sequence formats.
+	FASTA
+	FASTQ
+	GenBank
+	EMBL
+	UniProt
+
+	// Feature formats.
+	BED
+	GFF
+	GFF3
+	GTF
+	VCF
+	SAM
+	BAM
+)
+
+var formatNames = [...]string{
+	Unknown: "unknown",
+	FASTA:   "FASTA",
+	FASTQ:   "FASTQ",
+	GenBank: "GenBank",
+	EMBL:    "EMBL",
+	UniProt: "UniProt",
+	BED:     "BED",
+	GFF:     "GFF",
+	GFF3:    "GFF3",
+	GTF:     "GTF",
+	VCF:     "VCF",
+	SAM:     "SAM",
+	BAM:     "BAM",
+}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+	return formatNames[f]
+}
+
+// IsSequence returns whether f is a sequence format.
+func (f Format) IsSequence() bool { return FASTA <= f && f <= UniProt }
+
+// IsFeature returns whether f is a feature format.
+func (f Format) IsFeature() bool { return BED <= f && f <= BAM }
+
+// Compression is a stream compression method.
+type Compression int
+
+const (
+	Uncompressed Compression = iota
+	Gzip
+	BGZF
+)
+
+func (c Compression) String() string {
+	switch c {
+	case Uncompressed:
+		return "uncompressed"
+	case Gzip:
+		return "gzip"
+	case BGZF:
+		return "BGZF"
+	}
+	return fmt.Sprintf("Compression(%d)", int(c))
+}
+
+// Info describes a detected stream.
+type Info struct {
+	Format      Format
+	Compression Compression
+
+	// BedType is the BED type to read for
+	// BED streams: 3, 4, 5, 6 or 12, or
+	// bed.BroadPeakType or bed.NarrowPeakType.
+	BedType int
+}
+
+// peekLen is the number of bytes examined for detection.
+const peekLen = 1 << 16
+
+// Sniff determines the format of the data in r. It returns the format
+// information and a reader from which the uncompressed data can be read from
+// its start. BAM data are returned still BGZF compressed, as expected by the
+// bam package. If the format cannot be determined, ErrUnknownFormat is
+// returned with the reader.
+func Sniff(r io.Reader) (Info, io.Reader, error) {
+	info, br, err := sniff(r)
+	if br == nil {
+		return info, nil, err
+	}
+	return info, br, err
+}
+
+func sniff(r io.Reader) (Info, *bufio.Reader, error) {
+	var info Info
+	br := bufio.NewReaderSize(r, bgzf.MaxBlockSize)
+	magic, _ := br.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		h, _ := br.Peek(bgzf.MaxBlockSize)
+		if bgzf.IsBGZF(h) {
+			info.Compression = BGZF
+			if isBAM(h) {
+				info.Format = BAM
+				return info, br, nil
+			}
+			dr, err := bgzf.NewReader(br)
+			if err != nil {
+				return info, nil, err
+			}
+			br = bufio.NewReaderSize(dr, peekLen)
+		} else {
+			info.Compression = Gzip
+			gz, err := gzip.NewReader(br)
+			if err != nil {
+				return info, nil, err
+			}
+			br = bufio.NewReaderSize(gz, peekLen)
+		}
+	}
+	b, err := br.Peek(peekLen)
+	if err != nil && err != io.EOF {
+		return info, nil, err
+	}
+	info.Format, info.BedType = detect(b, err == nil)
+	if info.Format == Unknown {
+		return info, br, ErrUnknownFormat
+	}
+	return info, br, nil
+}
+
+// isBAM returns whether the first BGZF block in b holds the BAM magic
+// number.
+func isBAM(b []byte) bool {
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return false
+	}
+	var magic [4]byte
+	_, err = io.ReadFull(gz, magic[:])
+	return err == nil && string(magic[:]) == "BAM\x01"
+}
+
+// detect returns the format of the text in b. If partial is true the last
+// line of b may be incomplete and is ignored unless it is the only line.
+func detect(b []byte, partial bool) (Format, int) {
+	lines := bytes.Split(b, []byte("\n"))
+	if partial && len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+	var gffVersion string
+	for _, l := range lines {
+		l = bytes.TrimRight(l, "\r")
+		if len(bytes.TrimSpace(l)) == 0 {
+			continue
+		}
+		switch {
+		case l[0] == '>':
+			return FASTA, 0
+		case l[0] == '@':
+			if isSAMHeader(l) {
+				return SAM, 0
+			}
+			return FASTQ, 0
+		case bytes.HasPrefix(l, []byte("LOCUS ")):
+			return GenBank, 0
+		case bytes.HasPrefix(l, []byte("ID   ")):
+			if bytes.HasSuffix(bytes.TrimSpace(l), []byte(" AA.")) {
+				return UniProt, 0
+			}
+			return EMBL, 0
+		case bytes.HasPrefix(l, []byte("##fileformat=VCF")):
+			return VCF, 0
+		case bytes.HasPrefix(l, []byte("##gff-version")):
+			gffVersion = strings.TrimSpace(string(l[len("##gff-version"):]))
+		case l[0] == '#', bytes.HasPrefix(l, []byte("track")), bytes.HasPrefix(l, []byte("browser")):
+		default:
+			return classify(string(l), gffVersion)
+		}
+	}
+	switch {
+	case strings.HasPrefix(gffVersion, "3"):
+		return GFF3, 0
+	case gffVersion != "":
+		return GFF, 0
+	}
+	return Unknown, 0
+}
+
+func isSAMHeader(l []byte) bool {
+	if len(l) < 4 || l[3] != '\t' {
+		return false
+	}
+	switch string(l[1:3]) {
+	case "HD", "SQ", "RG", "PG", "CO":
+		return true
+	}
+	return false
+}
+
+// classify returns the format of the tab-delimited data line l.
+func classify(l, gffVersion string) (Format, int) {
+	f := strings.Split(l, "\t")
+	if len(f) >= 11 && isInt(f[1]) && isInt(f[3]) && isInt(f[4]) {
+		if _, err := sam.ParseCigar(f[5]); err == nil {
+			return SAM, 0
+		}
+	}
+	if len(f) >= 8 && isInt(f[3]) && isInt(f[4]) && len(f[6]) == 1 && strings.Contains("+-.?", f[6]) {
+		switch {
+		case strings.HasPrefix(gffVersion, "3"):
+			return GFF3, 0
+		case len(f) >= 9 && strings.Contains(f[8], `gene_id "`):
+			return GTF, 0
+		case gffVersion == "" && len(f) >= 9 && strings.Contains(f[8], "=") && !strings.Contains(f[8], `"`):
+			return GFF3, 0
+		}
+		return GFF, 0
+	}
+	if len(f) >= 3 && isInt(f[1]) && isInt(f[2]) {
+		// Only column counts that can be read by the bed
+		// package are reported, so that no columns are
+		// silently discarded.
+		switch n := len(f); n {
+		case 3, 4, 5, 6, 12:
+			return BED, n
+		case bed.BroadPeakType:
+			if isFloat(f[6]) && isFloat(f[7]) && isFloat(f[8]) {
+				return BED, bed.BroadPeakType
+			}
+		case bed.NarrowPeakType:
+			if isFloat(f[6]) && isFloat(f[7]) && isFloat(f[8]) && isInt(f[9]) {
+				return BED, bed.NarrowPeakType
+			}
+		}
+	}
+	return Unknown, 0
+}
+
+func isInt(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func isFloat(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// NewSeqReader returns a seqio.Reader for the sequence data in r and the
+// detected stream information. The template is used to construct sequences
+// for FASTA and FASTQ streams.
+func NewSeqReader(r io.Reader, template seqio.SequenceAppender) (seqio.Reader, Info, error) {
+	info, br, err := sniff(r)
+	if err != nil {
+		return nil, info, err
+	}
+	switch info.Format {
+	case FASTA:
+		return fasta.NewReader(br, template), info, nil
+	case FASTQ:
+		return fastq.NewReader(br, template), info, nil
+	case GenBank:
+		return genbank.NewReader(br), info, nil
+	case EMBL:
+		return embl.NewReader(br), info, nil
+	case UniProt:
+		return uniprot.NewReader(br), info, nil
+	}
+	return nil, info, ErrNotSequence
+}
+
+// NewFeatReader returns a featio.Reader for the feature data in r and the
+// detected stream information. BED track, browser and comment lines at the
+// start of the stream are skipped.
+func NewFeatReader(r io.Reader) (featio.Reader, Info, error) {
+	info, br, err := sniff(r)
+	if err != nil {
+		return nil, info, err
+	}
+	var fr featio.Reader
+	switch info.Format {
+	case BED:
+		err = skipBedHeader(br)
+		if err == nil {
+			fr, err = bed.NewReader(br, info.BedType)
+		}
+	case GFF:
+		fr = gff.NewReader(br)
+	case GFF3:
+		fr = gff3.NewReader(br)
+	case GTF:
+		fr = gtf.NewReader(br)
+	case VCF:
+		fr, err = vcf.NewReader(br)
+	case SAM:
+		fr, err = sam.NewReader(br)
+	case BAM:
+		fr, err = bam.NewReader(br)
+	default:
+		err = ErrNotFeature
+	}
+	if err != nil {
+		return nil, info, err
+	}
+	return fr, info, nil
+}
+
+func skipBedHeader(br *bufio.Reader) error {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if b[0] != '#' && b[0] != 't' && b[0] != 'b' && b[0] != '\n' && b[0] != '\r' {
+			return nil
+		}
+		l, _ := br.Peek(len("browser"))
+		if b[0] == 't' && !bytes.HasPrefix(l, []byte("track")) ||
+			b[0] == 'b' && !bytes.HasPrefix(l, []byte("browser")) {
+			return nil
+		}
+		_, err = br.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// NewSeqScanner returns a seqio.Scanner for the sequence data in r. See
+// NewSeqReader for details.
+func NewSeqScanner(r io.Reader, template seqio.SequenceAppender) (*seqio.Scanner, Info, error) {
+	sr, info, err := NewSeqReader(r, template)
+	if err != nil {
+		return nil, info, err
+	}
+	return seqio.NewScanner(sr), info, nil
+}
+
+// NewFeatScanner returns a featio.Scanner for the feature data in r. See
+// NewFeatReader for details.
+func NewFeatScanner(r io.Reader) (*featio.Scanner, Info, error) {
+	fr, info, err := NewFeatReader(r)
+	if err != nil {
+		return nil, info, err
+	}
+	return featio.NewScanner(fr), info, nil
+}
@@ -0,0 +1,215 @@
+// Copyright ©2016 The bíogo Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sniff
+
+import (
+	"github.com/biogo/biogo/alphabet"
+	"github.com/biogo/biogo/io/bgzf"
+	"github.com/biogo/biogo/io/featio/bam"
+	"github.com/biogo/biogo/io/featio/bed"
+	"github.com/biogo/biogo/io/featio/sam"
+	"github.com/biogo/biogo/seq/linear"
+
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+var detectTests = []struct {
+	name   string
+	text   string
+	format Format
+	bed    int
+}{
+	{"fasta", ">seq1 description\nACGT\n", FASTA, 0},
+	{"fastq", "@read1\nACGT\n+\nIIII\n", FASTQ, 0},
+	{"fastq @SQ name", "@SQ1\nACGT\n+\nIIII\n", FASTQ, 0},
+	{"genbank", "LOCUS       SCU49845     5028 bp    DNA             PLN       21-JUN-1999\n", GenBank, 0},
+	{"embl", "ID   X56734; SV 1; linear; mRNA; STD; PLN; 1859 BP.\n", EMBL, 0},
+	{"uniprot", "ID   CYC_HUMAN               Reviewed;         105 AA.\n", UniProt, 0},
+	{"vcf", "##fileformat=VCFv4.2\n#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\n", VCF, 0},
+	{"sam header", "@HD\tVN:1.6\n@SQ\tSN:ref\tLN:45\n", SAM, 0},
+	{"sam headerless", "r001\t0\tref\t7\t30\t8M\t*\t0\t0\tTTAGATAA\t*\n", SAM, 0},
+	{"gff3 pragma", "##gff-version 3\nchr1\t.\tgene\t1\t100\t.\t+\t.\tID=g1\n", GFF3, 0},
+	{"gff3 pragma only", "##gff-version 3\n", GFF3, 0},
+	{"gff3 attributes", "chr1\t.\tgene\t1\t100\t.\t+\t.\tID=g1;Name=a\n", GFF3, 0},
+	{"gff", "##gff-version 2\nchr1\tsrc\texon\t1\t100\t.\t+\t.\tgroup1\n", GFF, 0},
+	{"gtf", "chr1\tsrc\texon\t1\t100\t.\t+\t.\tgene_id \"g1\"; transcript_id \"t1\";\n", GTF, 0},
+	{"gtf with gff2 pragma", "##gff-version 2\nchr1\tsrc\texon\t1\t100\t.\t+\t.\tgene_id \"g1\"; transcript_id \"t1\";\n", GTF, 0},
+	{"bed3", "chr1\t0\t100\n", BED, 3},
+	{"bed4", "track name=x\nchr1\t0\t100\tname\n", BED, 4},
+	{"bed5", "browser position chr1:1-100\n# comment\nchr1\t0\t100\tname\t0\n", BED, 5},
+	{"bed6", "chr1\t0\t100\tname\t0\t+\n", BED, 6},
+	{"bed7", "chr1\t0\t100\tname\t0\t+\t0\n", Unknown, 0},
+	{"bed9", "chr1\t0\t100\tname\t0\t+\t0\t100\t0,0,0\n", Unknown, 0},
+	{"bed12", "chr1\t0\t100\tname\t0\t+\t0\t100\t0\t2\t10,10\t0,90\n", BED, 12},
+	{"bed13", "chr1\t0\t100\tname\t0\t+\t0\t100\t0\t2\t10,10\t0,90\tx\n", Unknown, 0},
+	{"broadPeak", "chr1\t0\t100\tpeak1\t0\t.\t5.5\t-1\t3.2\n", BED, bed.BroadPeakType},
+	{"narrowPeak", "chr1\t0\t100\tpeak1\t0\t.\t5.5\t-1\t3.2\t50\n", BED, bed.NarrowPeakType},
+	{"narrowPeak bad peak", "chr1\t0\t100\tpeak1\t0\t.\t5.5\t-1\t3.2\tx\n", Unknown, 0},
+	{"unknown", "this is not a known format\n", Unknown, 0},
+	{"empty", "", Unknown, 0},
+}
+
+func gzipped(c *check.C, text string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := io.WriteString(w, text)
+	c.Assert(err, check.Equals, nil)
+	c.Assert(w.Close(), check.Equals, nil)
+	return buf.Bytes()
+}
+
+func bgzipped(c *check.C, text string) []byte {
+	var buf bytes.Buffer
+	w := bgzf.NewWriter(&buf)
+	_, err := io.WriteString(w, text)
+	c.Assert(err, check.Equals, nil)
+	c.Assert(w.Close(), check.Equals, nil)
+	return buf.Bytes()
+}
+
+func (s *S) TestSniff(c *check.C) {
+	for _, t := range detectTests {
+		for _, in := range []struct {
+			data []byte
+			comp Compression
+		}{
+			{[]byte(t.text), Uncompressed},
+			{gzipped(c, t.text), Gzip},
+			{bgzipped(c, t.text), BGZF},
+		} {
+			info, r, err := Sniff(bytes.NewReader(in.data))
+			if t.format == Unknown {
+				c.Check(err, check.Equals, ErrUnknownFormat, check.Commentf("%s %v", t.name, in.comp))
+			} else {
+				c.Check(err, check.Equals, nil, check.Commentf("%s %v", t.name, in.comp))
+			}
+			c.Check(info, check.Equals, Info{Format: t.format, Compression: in.comp, BedType: t.bed}, check.Commentf("%s %v", t.name, in.comp))
+			var buf bytes.Buffer
+			_, err = io.Copy(&buf, r)
+			c.Check(err, check.Equals, nil)
+			c.Check(buf.String(), check.Equals, t.text, check.Commentf("%s %v", t.name, in.comp))
+		}
+	}
+}
+
+func (s *S) TestNewSeqScanner(c *check.C) {
+	const text = ">a\nACGT\n>b\nGGCC\n"
+	for _, data := range [][]byte{[]byte(text), gzipped(c, text), bgzipped(c, text)} {
+		sc, info, err := NewSeqScanner(bytes.NewReader(data), linear.NewSeq("", nil, alphabet.DNA))
+		c.Assert(err, check.Equals, nil)
+		c.Check(info.Format, check.Equals, FASTA)
+		var names []string
+		for sc.Next() {
+			names = append(names, sc.Seq().Name())
+		}
+		c.Check(sc.Error(), check.Equals, nil)
+		c.Check(names, check.DeepEquals, []string{"a", "b"})
+	}
+
+	_, _, err := NewSeqReader(strings.NewReader("chr1\t0\t100\n"), nil)
+	c.Check(err, check.Equals, ErrNotSequence)
+}
+
+func (s *S) TestNewFeatScanner(c *check.C) {
+	const text = "track name=peaks\n# comment\nchr1\t0\t100\tp1\t10\t+\nchr1\t200\t300\tp2\t20\t-\n"
+	sc, info, err := NewFeatScanner(bytes.NewReader(gzipped(c, text)))
+	c.Assert(err, check.Equals, nil)
+	c.Check(info, check.Equals, Info{Format: BED, Compression: Gzip, BedType: 6})
+	var names []string
+	for sc.Next() {
+		f := sc.Feat()
+		_, ok := f.(*bed.Bed6)
+		c.Check(ok, check.Equals, true)
+		names = append(names, f.Name())
+	}
+	c.Check(sc.Error(), check.Equals, nil)
+	c.Check(names, check.DeepEquals, []string{"p1", "p2"})
+
+	_, _, err = NewFeatReader(strings.NewReader(">a\nACGT\n"))
+	c.Check(err, check.Equals, ErrNotFeature)
+
+	// Streams with columns that cannot be read are not
+	// read as a narrower BED type.
+	_, _, err = NewFeatReader(strings.NewReader("chr1\t0\t100\tname\t0\t+\t0\t100\t255,0,0\n"))
+	c.Check(err, check.Equals, ErrUnknownFormat)
+}
+
+func (s *S) TestNewFeatReaderPeaks(c *check.C) {
+	for _, t := range []struct {
+		text string
+		bed  int
+		want string
+	}{
+		{
+			text: "track type=broadPeak\nchr1\t0\t100\tpeak1\t0\t.\t5.5\t-1\t3.2\n",
+			bed:  bed.BroadPeakType,
+			want: "chr1\t0\t100\tpeak1\t0\t.\t5.5\t-1\t3.2",
+		},
+		{
+			text: "track type=narrowPeak\nchr1\t0\t100\tpeak1\t0\t.\t5.5\t-1\t3.2\t50\n",
+			bed:  bed.NarrowPeakType,
+			want: "chr1\t0\t100\tpeak1\t0\t.\t5.5\t-1\t3.2\t50",
+		},
+	} {
+		fr, info, err := NewFeatReader(strings.NewReader(t.text))
+		c.Assert(err, check.Equals, nil)
+		c.Check(info, check.Equals, Info{Format: BED, BedType: t.bed})
+		f, err := fr.Read()
+		c.Assert(err, check.Equals, nil)
+		switch t.bed {
+		case bed.BroadPeakType:
+			_, ok := f.(*bed.BroadPeak)
+			c.Check(ok, check.Equals, true)
+		case bed.NarrowPeakType:
+			_, ok := f.(*bed.NarrowPeak)
+			c.Check(ok, check.Equals, true)
+		}
+		c.Check(fmt.Sprint(f), check.Equals, t.want)
+		_, err = fr.Read()
+		c.Check(err, check.Equals, io.EOF)
+	}
+}
+
+func (s *S) TestBAM(c *check.C) {
+	const text = "@HD\tVN:1.6\tSO:coordinate\n@SQ\tSN:ref\tLN:45\nr001\t0\tref\t7\t30\t8M\t*\t0\t0\tTTAGATAA\t*\n"
+	sr, err := sam.NewReader(strings.NewReader(text))
+	c.Assert(err, check.Equals, nil)
+	rec, err := sr.Read()
+	c.Assert(err, check.Equals, nil)
+
+	var buf bytes.Buffer
+	w, err := bam.NewWriter(&buf, sr.Header)
+	c.Assert(err, check.Equals, nil)
+	_, err = w.Write(rec.(*sam.Record))
+	c.Assert(err, check.Equals, nil)
+	c.Assert(w.Close(), check.Equals, nil)
+
+	info, _, err := Sniff(bytes.NewReader(buf.Bytes()))
+	c.Check(err, check.Equals, nil)
+	c.Check(info, check.Equals, Info{Format: BAM, Compression: BGZF})
+
+	fr, info, err := NewFeatReader(bytes.NewReader(buf.Bytes()))
+	c.Assert(err, check.Equals, nil)
+	c.Check(info.Format, check.Equals, BAM)
+	f, err := fr.Read()
+	c.Assert(err, check.Equals, nil)
+	c.Check(f.Name(), check.Equals, "r001")
+	_, err = fr.Read()
+	c.Check(err, check.Equals, io.EOF)
+}